@@ -480,7 +480,9 @@ func (fb *filterBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscr
 	return fb.bc.SubscribeLogsEvent(ch)
 }
 
-func (fb *filterBackend) BloomStatus() (uint64, uint64) { return 4096, 0 }
+func (fb *filterBackend) BloomStatus() (uint64, uint64)    { return 4096, 0 }
+func (fb *filterBackend) LogIndexStatus() (uint64, uint64) { return 4096, 0 }
+func (fb *filterBackend) RPCLogCap() int                   { return 0 }
 func (fb *filterBackend) ServiceFilter(ctx context.Context, ms *bloombits.MatcherSession) {
 	panic("not supported")
 }
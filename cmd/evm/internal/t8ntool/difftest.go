@@ -0,0 +1,252 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of go-etherzero.
+//
+// go-etherzero is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-etherzero is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-etherzero. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"strings"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/common/math"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	// fuzzKey is the key of the account sending the fuzzer transactions.
+	fuzzKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+
+	// fuzzContract is the address the random programs are deployed to.
+	fuzzContract = common.HexToAddress("0x00000000000000000000000000000000000c0de0")
+)
+
+// Diff is the entry point of the difftest command, executing the same state
+// transition under two rulesets and comparing their opcode-by-opcode traces.
+// Without fuzzing, the transition is read the same way the t8n command does,
+// otherwise the given number of random programs are executed.
+func Diff(ctx *cli.Context) error {
+	setupLogger(ctx)
+
+	configA, err := forkConfig(ctx.String(ForkFlag.Name))
+	if err != nil {
+		return err
+	}
+	configB, err := forkConfig(ctx.String(DiffForkFlag.Name))
+	if err != nil {
+		return err
+	}
+	logConfig := &vm.LogConfig{
+		DisableMemory: ctx.Bool(TraceDisableMemoryFlag.Name),
+		DisableStack:  ctx.Bool(TraceDisableStackFlag.Name),
+	}
+	iterations := ctx.Int(FuzzIterationsFlag.Name)
+	if iterations <= 0 {
+		prestate, txs, err := readInput(ctx, configA)
+		if err != nil {
+			return err
+		}
+		return DiffTransition(prestate, txs, configA, configB, logConfig)
+	}
+	var (
+		rng      = rand.New(rand.NewSource(ctx.Int64(FuzzSeedFlag.Name)))
+		codeSize = ctx.Int(FuzzCodeSizeFlag.Name)
+	)
+	for i := 0; i < iterations; i++ {
+		code := RandomProgram(rng, codeSize)
+		prestate, txs, err := fuzzTransition(code)
+		if err != nil {
+			return err
+		}
+		if err := DiffTransition(prestate, txs, configA, configB, logConfig); err != nil {
+			log.Error("Found diverging program", "iteration", i, "code", hexutil.Encode(code))
+			return err
+		}
+		log.Debug("Executed random program", "iteration", i, "size", len(code))
+	}
+	log.Info("No divergence found", "iterations", iterations, "forks", ctx.String(ForkFlag.Name)+"/"+ctx.String(DiffForkFlag.Name))
+	return nil
+}
+
+// DiffTransition applies the transactions on top of the prestate under both
+// chain configurations, returning an error describing the first difference in
+// their traces or outcome.
+func DiffTransition(prestate *Prestate, txs types.Transactions, configA, configB *params.ChainConfig, logConfig *vm.LogConfig) error {
+	tracesA, resultA, err := traceTransition(prestate, txs, configA, logConfig)
+	if err != nil {
+		return NewError(ErrorEVM, err)
+	}
+	tracesB, resultB, err := traceTransition(prestate, txs, configB, logConfig)
+	if err != nil {
+		return NewError(ErrorEVM, err)
+	}
+	for i, tx := range txs {
+		if err := diffTraces(tracesA[i], tracesB[i]); err != nil {
+			return NewError(ErrorDiff, fmt.Errorf("tx %d (%x): %v", i, tx.Hash(), err))
+		}
+	}
+	if resultA.StateRoot != resultB.StateRoot {
+		return NewError(ErrorDiff, fmt.Errorf("state root mismatch: %x != %x", resultA.StateRoot, resultB.StateRoot))
+	}
+	if resultA.LogsHash != resultB.LogsHash {
+		return NewError(ErrorDiff, fmt.Errorf("logs hash mismatch: %x != %x", resultA.LogsHash, resultB.LogsHash))
+	}
+	return nil
+}
+
+// traceBuffer is an in-memory trace destination.
+type traceBuffer struct {
+	bytes.Buffer
+}
+
+func (b *traceBuffer) Close() error { return nil }
+
+// traceTransition applies a state transition, collecting the JSON trace of
+// every transaction.
+func traceTransition(prestate *Prestate, txs types.Transactions, config *params.ChainConfig, logConfig *vm.LogConfig) ([]*traceBuffer, *ExecutionResult, error) {
+	traces := make([]*traceBuffer, len(txs))
+	for i := range traces {
+		traces[i] = new(traceBuffer)
+	}
+	getTraceWriter := func(txIndex int, txHash common.Hash) (io.WriteCloser, error) {
+		return traces[txIndex], nil
+	}
+	_, result, err := prestate.Apply(config, logConfig, txs, getTraceWriter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return traces, result, nil
+}
+
+// diffTraces compares two JSON traces line by line, ignoring the execution
+// times, returning an error describing the first differing step.
+func diffTraces(a, b *traceBuffer) error {
+	var (
+		scanA = bufio.NewScanner(&a.Buffer)
+		scanB = bufio.NewScanner(&b.Buffer)
+	)
+	scanA.Buffer(nil, 16*1024*1024)
+	scanB.Buffer(nil, 16*1024*1024)
+
+	for step := 0; ; step++ {
+		okA, okB := scanA.Scan(), scanB.Scan()
+		if !okA && !okB {
+			break
+		}
+		lineA, err := normalizeTraceLine(scanA.Bytes())
+		if err != nil {
+			return err
+		}
+		lineB, err := normalizeTraceLine(scanB.Bytes())
+		if err != nil {
+			return err
+		}
+		if lineA != lineB {
+			return fmt.Errorf("traces diverge at step %d:\n  a: %s\n  b: %s", step, lineA, lineB)
+		}
+	}
+	if err := scanA.Err(); err != nil {
+		return err
+	}
+	return scanB.Err()
+}
+
+// normalizeTraceLine strips the non-deterministic fields of a JSON trace line,
+// returning it in canonical form. Missing lines are returned empty.
+func normalizeTraceLine(line []byte) (string, error) {
+	if len(strings.TrimSpace(string(line))) == 0 {
+		return "", nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return "", fmt.Errorf("invalid trace line %q: %v", line, err)
+	}
+	delete(fields, "time")
+
+	canonical, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(canonical), nil
+}
+
+// RandomProgram generates a random EVM program of at most the given size. Half
+// of the instructions are small pushes, keeping memory offsets and jump targets
+// within reach, the rest are random defined opcodes.
+func RandomProgram(rng *rand.Rand, size int) []byte {
+	code := make([]byte, 0, size)
+	for len(code) < size {
+		if rng.Intn(2) == 0 {
+			code = append(code, byte(vm.PUSH1), byte(rng.Intn(64)))
+			continue
+		}
+		op := vm.OpCode(rng.Intn(256))
+		if strings.HasPrefix(op.String(), "Missing opcode") {
+			continue
+		}
+		code = append(code, byte(op))
+		if op.IsPush() {
+			for i := 0; i < int(op-vm.PUSH1)+1; i++ {
+				code = append(code, byte(rng.Intn(256)))
+			}
+		}
+	}
+	return code[:size]
+}
+
+// fuzzTransition creates a state transition calling the given program, with
+// the sender having plenty of balance and power to pay for it.
+func fuzzTransition(code []byte) (*Prestate, types.Transactions, error) {
+	funds := new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether))
+
+	prestate := &Prestate{
+		Env: Env{
+			Coinbase:   common.HexToAddress("0x00000000000000000000000000000000000000c0"),
+			Difficulty: (*math.HexOrDecimal256)(big.NewInt(131072)),
+			GasLimit:   math.HexOrDecimal64(params.GenesisGasLimit * 10),
+			Number:     1,
+			Timestamp:  1000,
+		},
+		Pre: Alloc{
+			crypto.PubkeyToAddress(fuzzKey.PublicKey): {
+				Balance: (*math.HexOrDecimal256)(funds),
+				Power:   (*math.HexOrDecimal256)(funds),
+			},
+			fuzzContract: {
+				Code:    code,
+				Balance: (*math.HexOrDecimal256)(big.NewInt(params.Ether)),
+			},
+		},
+	}
+	// Sign with the homestead signer, valid under all the supported rulesets
+	tx := types.NewTransaction(0, fuzzContract, big.NewInt(1), 1000000, big.NewInt(1), nil)
+	signed, err := types.SignTx(tx, types.HomesteadSigner{}, fuzzKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return prestate, types.Transactions{signed}, nil
+}
@@ -0,0 +1,259 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of go-etherzero.
+//
+// go-etherzero is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-etherzero is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-etherzero. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/common/math"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/params"
+	"github.com/etherzero/go-etherzero/rlp"
+	"golang.org/x/crypto/sha3"
+)
+
+// Account is the state of a single account in the pre- or post-state alloc.
+// Unlike core.GenesisAccount, it also carries the power the account has to pay
+// for gas with at the current block.
+type Account struct {
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+	Balance *math.HexOrDecimal256       `json:"balance"`
+	Power   *math.HexOrDecimal256       `json:"power,omitempty"`
+	Nonce   math.HexOrDecimal64         `json:"nonce,omitempty"`
+}
+
+// Alloc is the set of accounts of a state, keyed by address.
+type Alloc map[common.Address]Account
+
+// Env is the block environment the transactions are executed in.
+type Env struct {
+	Coinbase    common.Address                      `json:"currentCoinbase"`
+	Difficulty  *math.HexOrDecimal256               `json:"currentDifficulty"`
+	GasLimit    math.HexOrDecimal64                 `json:"currentGasLimit"`
+	Number      math.HexOrDecimal64                 `json:"currentNumber"`
+	Timestamp   math.HexOrDecimal64                 `json:"currentTimestamp"`
+	BlockHashes map[math.HexOrDecimal64]common.Hash `json:"blockHashes,omitempty"` // Hashes for BLOCKHASH, missing ones are zero
+}
+
+// Prestate is the state and environment a state transition starts from.
+type Prestate struct {
+	Env Env   `json:"env"`
+	Pre Alloc `json:"pre"`
+}
+
+// ExecutionResult is the outcome of a state transition, apart from the
+// post-state itself.
+type ExecutionResult struct {
+	StateRoot   common.Hash         `json:"stateRoot"`
+	TxRoot      common.Hash         `json:"txRoot"`
+	ReceiptRoot common.Hash         `json:"receiptRoot"`
+	LogsHash    common.Hash         `json:"logsHash"`
+	Bloom       types.Bloom         `json:"logsBloom"`
+	Receipts    types.Receipts      `json:"receipts"`
+	Logs        []*types.Log        `json:"logs"`
+	Rejected    []int               `json:"rejected,omitempty"`
+	GasUsed     math.HexOrDecimal64 `json:"gasUsed"`
+}
+
+// TraceWriterFunc opens the destination of the JSON trace of a transaction, or
+// returns a nil writer if the transaction should not be traced.
+type TraceWriterFunc func(txIndex int, txHash common.Hash) (io.WriteCloser, error)
+
+// Apply executes the transactions on top of the prestate, returning the post
+// state and the execution result. Transactions failing validation (e.g. due to a
+// bad nonce or insufficient power) are rejected and skipped.
+//
+// Gas is paid out of the senders' power, the same way the devote consensus does.
+// As block rewards are handed out by the consensus engine, none are applied.
+func (pre *Prestate) Apply(chainConfig *params.ChainConfig, logConfig *vm.LogConfig, txs types.Transactions, getTraceWriter TraceWriterFunc) (*state.StateDB, *ExecutionResult, error) {
+	var (
+		number     = new(big.Int).SetUint64(uint64(pre.Env.Number))
+		statedb    = MakePreState(ethdb.NewMemDatabase(), pre.Pre, number)
+		signer     = types.MakeSigner(chainConfig, number)
+		gaspool    = new(core.GasPool).AddGas(uint64(pre.Env.GasLimit))
+		blockHash  = common.Hash{0x13, 0x37}
+		included   types.Transactions
+		rejected   []int
+		receipts   = make(types.Receipts, 0)
+		gasUsed    uint64
+		txIndex    int
+		difficulty = new(big.Int)
+	)
+	if pre.Env.Difficulty != nil {
+		difficulty = (*big.Int)(pre.Env.Difficulty)
+	}
+	getHash := func(num uint64) common.Hash {
+		return pre.Env.BlockHashes[math.HexOrDecimal64(num)]
+	}
+	for i, tx := range txs {
+		msg, err := tx.AsMessage(signer)
+		if err != nil {
+			log.Info("Rejected transaction", "index", i, "hash", tx.Hash(), "error", err)
+			rejected = append(rejected, i)
+			continue
+		}
+		var (
+			vmConfig    vm.Config
+			traceWriter io.WriteCloser
+		)
+		if getTraceWriter != nil {
+			if traceWriter, err = getTraceWriter(i, tx.Hash()); err != nil {
+				return nil, nil, err
+			}
+			if traceWriter != nil {
+				vmConfig.Debug, vmConfig.Tracer = true, vm.NewJSONLogger(logConfig, traceWriter)
+			}
+		}
+		context := vm.Context{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			GetHash:     getHash,
+			Origin:      msg.From(),
+			Coinbase:    pre.Env.Coinbase,
+			BlockNumber: number,
+			Time:        new(big.Int).SetUint64(uint64(pre.Env.Timestamp)),
+			Difficulty:  difficulty,
+			GasLimit:    uint64(pre.Env.GasLimit),
+			GasPrice:    new(big.Int).Set(msg.GasPrice()),
+		}
+		statedb.Prepare(tx.Hash(), blockHash, txIndex)
+		evm := vm.NewEVM(context, statedb, chainConfig, vmConfig)
+
+		snapshot := statedb.Snapshot()
+		_, gas, failed, err := core.ApplyMessage(evm, msg, gaspool)
+		if traceWriter != nil {
+			traceWriter.Close()
+		}
+		if err != nil {
+			statedb.RevertToSnapshot(snapshot)
+			log.Info("Rejected transaction", "index", i, "hash", tx.Hash(), "from", msg.From(), "error", err)
+			rejected = append(rejected, i)
+			continue
+		}
+		included = append(included, tx)
+
+		// Update the state with pending changes, same as core.ApplyTransaction
+		var root []byte
+		if chainConfig.IsByzantium(number) {
+			statedb.Finalise(true)
+		} else {
+			root = statedb.IntermediateRoot(chainConfig.IsEIP158(number)).Bytes()
+		}
+		gasUsed += gas
+
+		receipt := types.NewReceipt(root, failed, gasUsed)
+		receipt.TxHash = tx.Hash()
+		receipt.GasUsed = gas
+		if msg.To() == nil {
+			receipt.ContractAddress = crypto.CreateAddress(msg.From(), tx.Nonce())
+		}
+		receipt.Logs = statedb.GetLogs(tx.Hash())
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		receipt.Intxs = statedb.GetIntxs(tx.Hash())
+		receipts = append(receipts, receipt)
+
+		txIndex++
+	}
+	root, err := statedb.Commit(chainConfig.IsEIP158(number))
+	if err != nil {
+		return nil, nil, err
+	}
+	logs := statedb.Logs()
+	result := &ExecutionResult{
+		StateRoot:   root,
+		TxRoot:      types.DeriveSha(included),
+		ReceiptRoot: types.DeriveSha(receipts),
+		LogsHash:    rlpHash(logs),
+		Bloom:       types.CreateBloom(receipts),
+		Receipts:    receipts,
+		Logs:        logs,
+		Rejected:    rejected,
+		GasUsed:     math.HexOrDecimal64(gasUsed),
+	}
+	if result.Logs == nil {
+		result.Logs = []*types.Log{}
+	}
+	return statedb, result, nil
+}
+
+// MakePreState creates a state database containing the given accounts, with
+// their power pinned to the given block.
+func MakePreState(db ethdb.Database, accounts Alloc, number *big.Int) *state.StateDB {
+	sdb := state.NewDatabase(db)
+	statedb, _ := state.New(common.Hash{}, sdb)
+	for addr, a := range accounts {
+		statedb.SetCode(addr, a.Code)
+		statedb.SetNonce(addr, uint64(a.Nonce))
+		if a.Balance != nil {
+			statedb.SetBalance(addr, new(big.Int).Set((*big.Int)(a.Balance)), number)
+		}
+		if a.Power != nil {
+			statedb.SetPower(addr, new(big.Int).Set((*big.Int)(a.Power)))
+		}
+		for k, v := range a.Storage {
+			statedb.SetState(addr, k, v)
+		}
+	}
+	// Commit and re-open to start with a clean state.
+	root, _ := statedb.Commit(false)
+	statedb, _ = state.New(root, sdb)
+	return statedb
+}
+
+// DumpAlloc collects all the accounts of a state database into an alloc, with
+// the power each account has at the given block.
+func DumpAlloc(statedb *state.StateDB, number *big.Int) Alloc {
+	alloc := make(Alloc)
+	for hexAddr := range statedb.RawDump().Accounts {
+		addr := common.HexToAddress(hexAddr)
+		account := Account{
+			Code:    statedb.GetCode(addr),
+			Balance: (*math.HexOrDecimal256)(statedb.GetBalance(addr)),
+			Nonce:   math.HexOrDecimal64(statedb.GetNonce(addr)),
+		}
+		if power := statedb.GetPower(addr, number); power.Sign() > 0 {
+			account.Power = (*math.HexOrDecimal256)(power)
+		}
+		statedb.ForEachStorage(addr, func(key, _ common.Hash) bool {
+			// The iterated values are RLP encoded, look them up instead
+			if account.Storage == nil {
+				account.Storage = make(map[common.Hash]common.Hash)
+			}
+			account.Storage[key] = statedb.GetState(addr, key)
+			return true
+		})
+		alloc[addr] = account
+	}
+	return alloc
+}
+
+func rlpHash(x interface{}) (h common.Hash) {
+	hw := sha3.NewLegacyKeccak256()
+	rlp.Encode(hw, x)
+	hw.Sum(h[:0])
+	return h
+}
@@ -0,0 +1,63 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of go-etherzero.
+//
+// go-etherzero is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-etherzero is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-etherzero. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Forks is the set of rulesets transitions can be executed in. All of them run
+// on top of the Etherzero masternode and Devote forks, differing only in the
+// Ethereum forks enabled for the EVM.
+var Forks = map[string]*params.ChainConfig{
+	"Etherzero": {
+		ChainID:        big.NewInt(90),
+		EtherzeroBlock: big.NewInt(0),
+		HomesteadBlock: big.NewInt(0),
+		EIP150Block:    big.NewInt(0),
+		EIP155Block:    big.NewInt(0),
+		EIP158Block:    big.NewInt(0),
+		ByzantiumBlock: big.NewInt(0),
+		DevoteBlock:    big.NewInt(0),
+	},
+	"EtherzeroConstantinople": {
+		ChainID:             big.NewInt(90),
+		EtherzeroBlock:      big.NewInt(0),
+		HomesteadBlock:      big.NewInt(0),
+		EIP150Block:         big.NewInt(0),
+		EIP155Block:         big.NewInt(0),
+		EIP158Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: big.NewInt(0),
+		PetersburgBlock:     big.NewInt(10000000),
+		DevoteBlock:         big.NewInt(0),
+	},
+	"EtherzeroPetersburg": {
+		ChainID:             big.NewInt(90),
+		EtherzeroBlock:      big.NewInt(0),
+		HomesteadBlock:      big.NewInt(0),
+		EIP150Block:         big.NewInt(0),
+		EIP155Block:         big.NewInt(0),
+		EIP158Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: big.NewInt(0),
+		PetersburgBlock:     big.NewInt(0),
+		DevoteBlock:         big.NewInt(0),
+	},
+}
@@ -0,0 +1,304 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of go-etherzero.
+//
+// go-etherzero is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-etherzero is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-etherzero. If not, see <http://www.gnu.org/licenses/>.
+
+// Package t8ntool implements the state transition tool of the evm command,
+// applying transactions on top of a prestate and differentially executing them
+// across rulesets.
+package t8ntool
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const (
+	ErrorEVM      = 2
+	ErrorVMConfig = 3
+
+	ErrorJson = 10
+	ErrorIO   = 11
+	ErrorDiff = 12
+
+	stdinSelector = "stdin"
+)
+
+// NumberedError is an error carrying the exit code the process should end with.
+type NumberedError struct {
+	errorCode int
+	err       error
+}
+
+// NewError wraps an error with the given exit code.
+func NewError(errorCode int, err error) *NumberedError {
+	return &NumberedError{errorCode, err}
+}
+
+func (n *NumberedError) Error() string {
+	return fmt.Sprintf("ERROR(%d): %v", n.errorCode, n.err.Error())
+}
+
+// ExitCode implements cli.ExitCoder.
+func (n *NumberedError) ExitCode() int {
+	return n.errorCode
+}
+
+// input is the combined prestate and transactions, as read from stdin.
+type input struct {
+	Alloc Alloc        `json:"alloc,omitempty"`
+	Env   *Env         `json:"env,omitempty"`
+	Txs   []*txWithKey `json:"txs,omitempty"`
+}
+
+// txWithKey is a transaction which is signed with the given secret key before
+// execution, if one is given.
+type txWithKey struct {
+	key *ecdsa.PrivateKey
+	tx  *types.Transaction
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading the transaction and the
+// optional secretKey field it is to be signed with.
+func (t *txWithKey) UnmarshalJSON(input []byte) error {
+	var key struct {
+		Key *common.Hash `json:"secretKey"`
+	}
+	if err := json.Unmarshal(input, &key); err != nil {
+		return err
+	}
+	if key.Key != nil {
+		k, err := crypto.ToECDSA(key.Key.Bytes())
+		if err != nil {
+			return err
+		}
+		t.key = k
+	}
+	var tx types.Transaction
+	if err := json.Unmarshal(input, &tx); err != nil {
+		return err
+	}
+	t.tx = &tx
+	return nil
+}
+
+// signTransactions returns the transactions, signing the ones carrying a key.
+func signTransactions(txs []*txWithKey, signer types.Signer) (types.Transactions, error) {
+	signed := make(types.Transactions, len(txs))
+	for i, tx := range txs {
+		if tx.key == nil {
+			signed[i] = tx.tx
+			continue
+		}
+		var err error
+		if signed[i], err = types.SignTx(tx.tx, signer, tx.key); err != nil {
+			return nil, NewError(ErrorJson, fmt.Errorf("tx %d: failed to sign: %v", i, err))
+		}
+	}
+	return signed, nil
+}
+
+// Main is the entry point of the t8n command, applying the input transactions
+// on top of the input prestate and writing out the post-state alloc and the
+// execution result.
+func Main(ctx *cli.Context) error {
+	setupLogger(ctx)
+
+	var (
+		baseDir    = ctx.String(OutputBasedir.Name)
+		traceWrite TraceWriterFunc
+	)
+	if baseDir != "" {
+		if err := os.MkdirAll(baseDir, 0755); err != nil {
+			return NewError(ErrorIO, fmt.Errorf("failed creating output basedir: %v", err))
+		}
+	}
+	logConfig := &vm.LogConfig{
+		DisableMemory: ctx.Bool(TraceDisableMemoryFlag.Name),
+		DisableStack:  ctx.Bool(TraceDisableStackFlag.Name),
+	}
+	if ctx.Bool(TraceFlag.Name) {
+		traceWrite = func(txIndex int, txHash common.Hash) (io.WriteCloser, error) {
+			fname := filepath.Join(baseDir, fmt.Sprintf("trace-%d-%v.jsonl", txIndex, txHash.String()))
+			f, err := os.Create(fname)
+			if err != nil {
+				return nil, NewError(ErrorIO, fmt.Errorf("failed creating trace-file: %v", err))
+			}
+			return f, nil
+		}
+	}
+	chainConfig, err := forkConfig(ctx.String(ForkFlag.Name))
+	if err != nil {
+		return err
+	}
+	prestate, txs, err := readInput(ctx, chainConfig)
+	if err != nil {
+		return err
+	}
+	statedb, result, err := prestate.Apply(chainConfig, logConfig, txs, traceWrite)
+	if err != nil {
+		return NewError(ErrorEVM, fmt.Errorf("failed executing transition: %v", err))
+	}
+	number := new(big.Int).SetUint64(uint64(prestate.Env.Number))
+	return dispatchOutput(ctx, baseDir, result, DumpAlloc(statedb, number))
+}
+
+// setupLogger configures the go-etherzero logger from the verbosity flag.
+func setupLogger(ctx *cli.Context) {
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(ctx.Int(VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+}
+
+// forkConfig looks up the chain configuration of a named ruleset.
+func forkConfig(fork string) (*params.ChainConfig, error) {
+	config, ok := Forks[fork]
+	if !ok {
+		return nil, NewError(ErrorVMConfig, fmt.Errorf("unsupported fork %q, expected one of %v", fork, forkNames()))
+	}
+	return config, nil
+}
+
+// readInput reads the prestate and the transactions from the input flags,
+// signing any transactions carrying a secret key.
+func readInput(ctx *cli.Context, chainConfig *params.ChainConfig) (*Prestate, types.Transactions, error) {
+	var (
+		allocStr  = ctx.String(InputAllocFlag.Name)
+		envStr    = ctx.String(InputEnvFlag.Name)
+		txStr     = ctx.String(InputTxsFlag.Name)
+		inputData = new(input)
+	)
+	// Read the combined input from stdin if any part of it lives there
+	if allocStr == stdinSelector || envStr == stdinSelector || txStr == stdinSelector {
+		decoder := json.NewDecoder(os.Stdin)
+		if err := decoder.Decode(inputData); err != nil {
+			return nil, nil, NewError(ErrorJson, fmt.Errorf("failed unmarshaling stdin: %v", err))
+		}
+	}
+	if allocStr != stdinSelector {
+		if err := readFile(allocStr, "alloc", &inputData.Alloc); err != nil {
+			return nil, nil, err
+		}
+	}
+	if envStr != stdinSelector {
+		var env Env
+		if err := readFile(envStr, "env", &env); err != nil {
+			return nil, nil, err
+		}
+		inputData.Env = &env
+	}
+	if txStr != stdinSelector {
+		if err := readFile(txStr, "txs", &inputData.Txs); err != nil {
+			return nil, nil, err
+		}
+	}
+	if inputData.Env == nil {
+		return nil, nil, NewError(ErrorJson, errors.New("missing env"))
+	}
+	prestate := &Prestate{Env: *inputData.Env, Pre: inputData.Alloc}
+
+	signer := types.MakeSigner(chainConfig, new(big.Int).SetUint64(uint64(prestate.Env.Number)))
+	txs, err := signTransactions(inputData.Txs, signer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return prestate, txs, nil
+}
+
+// readFile decodes the JSON content of a file into the given value.
+func readFile(path, desc string, dest interface{}) error {
+	inFile, err := os.Open(path)
+	if err != nil {
+		return NewError(ErrorIO, fmt.Errorf("failed reading %s file: %v", desc, err))
+	}
+	defer inFile.Close()
+
+	decoder := json.NewDecoder(inFile)
+	if err := decoder.Decode(dest); err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed unmarshaling %s file: %v", desc, err))
+	}
+	return nil
+}
+
+// saveFile marshals the object to the given file.
+func saveFile(baseDir, filename string, data interface{}) error {
+	b, err := json.MarshalIndent(data, "", " ")
+	if err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed marshalling output: %v", err))
+	}
+	location := filepath.Join(baseDir, filename)
+	if err = ioutil.WriteFile(location, b, 0644); err != nil {
+		return NewError(ErrorIO, fmt.Errorf("failed writing output: %v", err))
+	}
+	log.Info("Wrote file", "file", location)
+	return nil
+}
+
+// dispatchOutput writes the output data to either stderr or stdout, or to the
+// specified files.
+func dispatchOutput(ctx *cli.Context, baseDir string, result *ExecutionResult, alloc Alloc) error {
+	stdOutObject := make(map[string]interface{})
+	stdErrObject := make(map[string]interface{})
+	dispatch := func(baseDir, fName, name string, obj interface{}) error {
+		switch fName {
+		case "stdout":
+			stdOutObject[name] = obj
+		case "stderr":
+			stdErrObject[name] = obj
+		case "":
+			// don't save
+		default:
+			return saveFile(baseDir, fName, obj)
+		}
+		return nil
+	}
+	if err := dispatch(baseDir, ctx.String(OutputAllocFlag.Name), "alloc", alloc); err != nil {
+		return err
+	}
+	if err := dispatch(baseDir, ctx.String(OutputResultFlag.Name), "result", result); err != nil {
+		return err
+	}
+	if len(stdOutObject) > 0 {
+		b, err := json.MarshalIndent(stdOutObject, "", " ")
+		if err != nil {
+			return NewError(ErrorJson, fmt.Errorf("failed marshalling output: %v", err))
+		}
+		os.Stdout.Write(b)
+		os.Stdout.Write([]byte("\n"))
+	}
+	if len(stdErrObject) > 0 {
+		b, err := json.MarshalIndent(stdErrObject, "", " ")
+		if err != nil {
+			return NewError(ErrorJson, fmt.Errorf("failed marshalling output: %v", err))
+		}
+		os.Stderr.Write(b)
+		os.Stderr.Write([]byte("\n"))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of go-etherzero.
+//
+// go-etherzero is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-etherzero is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-etherzero. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/vm"
+)
+
+// t8nFixture is the output of a state transition fixture.
+type t8nFixture struct {
+	Alloc  Alloc           `json:"alloc"`
+	Result ExecutionResult `json:"result"`
+}
+
+// runFixture executes the transition in the given fixture directory under the
+// given fork, returning the JSON encoded post-state and result.
+func runFixture(t *testing.T, dir, fork string) []byte {
+	config, err := forkConfig(fork)
+	if err != nil {
+		t.Fatalf("failed to look up fork: %v", err)
+	}
+	var (
+		alloc Alloc
+		env   Env
+		txs   []*txWithKey
+	)
+	if err := readFile(filepath.Join(dir, "alloc.json"), "alloc", &alloc); err != nil {
+		t.Fatalf("failed to read alloc: %v", err)
+	}
+	if err := readFile(filepath.Join(dir, "env.json"), "env", &env); err != nil {
+		t.Fatalf("failed to read env: %v", err)
+	}
+	if err := readFile(filepath.Join(dir, "txs.json"), "txs", &txs); err != nil {
+		t.Fatalf("failed to read txs: %v", err)
+	}
+	number := new(big.Int).SetUint64(uint64(env.Number))
+	signed, err := signTransactions(txs, types.MakeSigner(config, number))
+	if err != nil {
+		t.Fatalf("failed to sign txs: %v", err)
+	}
+	prestate := &Prestate{Env: env, Pre: alloc}
+	statedb, result, err := prestate.Apply(config, new(vm.LogConfig), signed, nil)
+	if err != nil {
+		t.Fatalf("failed to apply transition: %v", err)
+	}
+	out, err := json.Marshal(&t8nFixture{Alloc: DumpAlloc(statedb, number), Result: *result})
+	if err != nil {
+		t.Fatalf("failed to encode output: %v", err)
+	}
+	return out
+}
+
+// Tests that the state transition fixtures produce the expected post-state and
+// result, paying for gas out of the senders' power.
+func TestT8n(t *testing.T) {
+	tests := []struct {
+		dir  string
+		fork string
+	}{
+		{"testdata/1", "Etherzero"},
+		{"testdata/1", "EtherzeroPetersburg"},
+	}
+	for i, tt := range tests {
+		want, err := ioutil.ReadFile(filepath.Join(tt.dir, "exp.json"))
+		if err != nil {
+			t.Fatalf("test %d: failed to read expected output: %v", i, err)
+		}
+		have := runFixture(t, tt.dir, tt.fork)
+
+		var haveObj, wantObj interface{}
+		if err := json.Unmarshal(have, &haveObj); err != nil {
+			t.Fatalf("test %d: failed to decode output: %v", i, err)
+		}
+		if err := json.Unmarshal(want, &wantObj); err != nil {
+			t.Fatalf("test %d: failed to decode expected output: %v", i, err)
+		}
+		if !reflect.DeepEqual(haveObj, wantObj) {
+			t.Errorf("test %d (%s, %s): output mismatch:\nhave %s\nwant %s", i, tt.dir, tt.fork, have, want)
+		}
+	}
+}
+
+// Tests that transitions are only executed under the Etherzero rulesets.
+func TestForkConfig(t *testing.T) {
+	for name := range Forks {
+		config, err := forkConfig(name)
+		if err != nil {
+			t.Errorf("fork %s: failed to look up: %v", name, err)
+			continue
+		}
+		if !config.IsEtherzero(new(big.Int)) || !config.IsDevote(new(big.Int)) {
+			t.Errorf("fork %s: Etherzero rules not enabled", name)
+		}
+	}
+	if _, err := forkConfig("Frontier"); err == nil {
+		t.Errorf("unsupported fork accepted")
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"math/big"
 	"os"
 
+	"github.com/etherzero/go-etherzero/cmd/evm/internal/t8ntool"
 	"github.com/etherzero/go-etherzero/cmd/utils"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -112,6 +113,49 @@ var (
 	}
 )
 
+var stateTransitionCommand = cli.Command{
+	Name:    "transition",
+	Aliases: []string{"t8n"},
+	Usage:   "executes a full state transition",
+	Action:  t8ntool.Main,
+	Flags: []cli.Flag{
+		t8ntool.TraceFlag,
+		t8ntool.TraceDisableMemoryFlag,
+		t8ntool.TraceDisableStackFlag,
+		t8ntool.OutputBasedir,
+		t8ntool.OutputAllocFlag,
+		t8ntool.OutputResultFlag,
+		t8ntool.InputAllocFlag,
+		t8ntool.InputEnvFlag,
+		t8ntool.InputTxsFlag,
+		t8ntool.ForkFlag,
+		t8ntool.VerbosityFlag,
+	},
+}
+
+var diffTestCommand = cli.Command{
+	Name:  "difftest",
+	Usage: "compares the execution traces of a state transition under two rulesets",
+	Description: `The difftest command executes a state transition under the rulesets given
+by --state.fork and --diff.fork, reporting the first opcode where their JSON
+traces diverge. With --fuzz.iterations, random programs are executed instead of
+the input transition.`,
+	Action: t8ntool.Diff,
+	Flags: []cli.Flag{
+		t8ntool.TraceDisableMemoryFlag,
+		t8ntool.TraceDisableStackFlag,
+		t8ntool.InputAllocFlag,
+		t8ntool.InputEnvFlag,
+		t8ntool.InputTxsFlag,
+		t8ntool.ForkFlag,
+		t8ntool.DiffForkFlag,
+		t8ntool.FuzzIterationsFlag,
+		t8ntool.FuzzSeedFlag,
+		t8ntool.FuzzCodeSizeFlag,
+		t8ntool.VerbosityFlag,
+	},
+}
+
 func init() {
 	app.Flags = []cli.Flag{
 		CreateFlag,
@@ -139,6 +183,8 @@ func init() {
 		disasmCommand,
 		runCommand,
 		stateTestCommand,
+		stateTransitionCommand,
+		diffTestCommand,
 	}
 }
 
@@ -176,6 +176,13 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 		utils.RegisterShhService(stack, &cfg.Shh)
 	}
 
+	// Add the GraphQL query service on the HTTP endpoint if requested
+	if ctx.GlobalBool(utils.GraphQLEnabledFlag.Name) {
+		if cfg.Node.HTTPHost == "" {
+			utils.Fatalf("GraphQL requires the HTTP-RPC server to be enabled (--rpc)")
+		}
+		utils.RegisterGraphQLService(stack)
+	}
 	// Add the Ethereum Stats daemon if requested.
 	if cfg.Ethstats.URL != "" {
 		utils.RegisterEthStatsService(stack, cfg.Ethstats.URL)
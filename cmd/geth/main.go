@@ -81,13 +81,21 @@ var (
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
+		utils.TxPoolAccountPowerSlotsFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolHistoryFlag,
+		utils.TxPoolHistoryJournalFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
+		utils.LightPriceFlag,
+		utils.ULCServersFlag,
+		utils.ULCFractionFlag,
 		utils.LightKDFFlag,
 		utils.WhitelistFlag,
+		utils.SyncCheckpointFlag,
+		utils.LogIndexFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheTrieFlag,
@@ -127,6 +135,7 @@ var (
 		utils.ConstantinopleOverrideFlag,
 		utils.RPCCORSDomainFlag,
 		utils.RPCVirtualHostsFlag,
+		utils.GraphQLEnabledFlag,
 		utils.EthStatsURLFlag,
 		utils.MetricsEnabledFlag,
 		utils.FakePoWFlag,
@@ -153,6 +162,7 @@ var (
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 		utils.RPCGlobalGasCap,
+		utils.RPCGlobalLogCap,
 	}
 
 	whisperFlags = []cli.Flag{
@@ -81,8 +81,13 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IdentityFlag,
 			utils.LightServFlag,
 			utils.LightPeersFlag,
+			utils.LightPriceFlag,
+			utils.ULCServersFlag,
+			utils.ULCFractionFlag,
 			utils.LightKDFFlag,
 			utils.WhitelistFlag,
+			utils.SyncCheckpointFlag,
+			utils.LogIndexFlag,
 		},
 	},
 	{
@@ -126,7 +131,10 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolGlobalSlotsFlag,
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
+			utils.TxPoolAccountPowerSlotsFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolHistoryFlag,
+			utils.TxPoolHistoryJournalFlag,
 		},
 	},
 	{
@@ -154,6 +162,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RPCPortFlag,
 			utils.RPCApiFlag,
 			utils.RPCGlobalGasCap,
+			utils.RPCGlobalLogCap,
 			utils.WSEnabledFlag,
 			utils.WSListenAddrFlag,
 			utils.WSPortFlag,
@@ -163,6 +172,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IPCPathFlag,
 			utils.RPCCORSDomainFlag,
 			utils.RPCVirtualHostsFlag,
+			utils.GraphQLEnabledFlag,
 			utils.JSpathFlag,
 			utils.ExecFlag,
 			utils.PreloadJSFlag,
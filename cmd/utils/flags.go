@@ -19,6 +19,7 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -45,6 +46,7 @@ import (
 	"github.com/etherzero/go-etherzero/eth/gasprice"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/ethstats"
+	"github.com/etherzero/go-etherzero/graphql"
 	"github.com/etherzero/go-etherzero/les"
 	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/metrics"
@@ -168,7 +170,7 @@ var (
 	defaultSyncMode = eth.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "full", "light" or "snap")`,
 		Value: &defaultSyncMode,
 	}
 	GCModeFlag = cli.StringFlag{
@@ -186,6 +188,19 @@ var (
 		Usage: "Maximum number of LES client peers",
 		Value: eth.DefaultConfig.LightPeers,
 	}
+	LightPriceFlag = cli.Uint64Flag{
+		Name:  "lightprice",
+		Usage: "Price in wei of a unit of priority LES service paid by on-chain deposits to the etherbase (0 = disabled)",
+	}
+	ULCServersFlag = cli.StringFlag{
+		Name:  "ulc.servers",
+		Usage: "Comma separated enode URLs of trusted LES servers, enables ultra light client mode",
+	}
+	ULCFractionFlag = cli.IntFlag{
+		Name:  "ulc.fraction",
+		Usage: "Minimum percentage of trusted servers that must announce a new head in ultra light client mode",
+		Value: eth.DefaultConfig.ULCFraction,
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -194,6 +209,10 @@ var (
 		Name:  "whitelist",
 		Usage: "Comma separated block number-to-hash mappings to enforce (<number>=<hash>)",
 	}
+	SyncCheckpointFlag = cli.StringFlag{
+		Name:  "sync.checkpoint",
+		Usage: "Block any synced chain must contain, with the witnesses allowed to seal it (<number>=<hash>[:<witness>,...])",
+	}
 	// Dashboard settings
 	DashboardEnabledFlag = cli.BoolFlag{
 		Name:  metrics.DashboardEnabledFlag,
@@ -293,11 +312,26 @@ var (
 		Usage: "Maximum number of non-executable transaction slots for all accounts",
 		Value: eth.DefaultConfig.TxPool.GlobalQueue,
 	}
+	TxPoolAccountPowerSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.accountpowerslots",
+		Usage: "Maximum number of power funded transactions permitted per remote account",
+		Value: eth.DefaultConfig.TxPool.AccountPowerSlots,
+	}
 	TxPoolLifetimeFlag = cli.DurationFlag{
 		Name:  "txpool.lifetime",
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: eth.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolHistoryFlag = cli.DurationFlag{
+		Name:  "txpool.history",
+		Usage: "Amount of time the lifecycle of transactions that left the pool is retained",
+		Value: eth.DefaultConfig.TxPool.History,
+	}
+	TxPoolHistoryJournalFlag = cli.StringFlag{
+		Name:  "txpool.historyjournal",
+		Usage: "Disk journal for transaction lifecycle records to survive node restarts",
+		Value: core.DefaultTxPoolConfig.HistoryJournal,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -415,6 +449,15 @@ var (
 		Name:  "rpc.gascap",
 		Usage: "Sets a cap on gas that can be used in eth_call/estimateGas",
 	}
+	RPCGlobalLogCap = cli.IntFlag{
+		Name:  "rpc.logcap",
+		Usage: "Sets a cap on the logs returned by eth_getLogs, larger results must be paginated (0 = no cap)",
+		Value: eth.DefaultConfig.RPCLogCap,
+	}
+	LogIndexFlag = cli.BoolFlag{
+		Name:  "logindex",
+		Usage: "Maintain an index of the blocks containing the logs of each address and topic",
+	}
 	// Logging and debug settings
 	EthStatsURLFlag = cli.StringFlag{
 		Name:  "ethstats",
@@ -453,6 +496,10 @@ var (
 		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
 		Value: strings.Join(node.DefaultConfig.HTTPVirtualHosts, ","),
 	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable the GraphQL query service on the HTTP-RPC server's /graphql path",
+	}
 	RPCApiFlag = cli.StringFlag{
 		Name:  "rpcapi",
 		Usage: "API's offered over the HTTP-RPC interface",
@@ -1087,9 +1134,18 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolGlobalQueueFlag.Name) {
 		cfg.GlobalQueue = ctx.GlobalUint64(TxPoolGlobalQueueFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolAccountPowerSlotsFlag.Name) {
+		cfg.AccountPowerSlots = ctx.GlobalUint64(TxPoolAccountPowerSlotsFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolHistoryFlag.Name) {
+		cfg.History = ctx.GlobalDuration(TxPoolHistoryFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolHistoryJournalFlag.Name) {
+		cfg.HistoryJournal = ctx.GlobalString(TxPoolHistoryJournalFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *eth.Config) {
@@ -1136,6 +1192,39 @@ func setWhitelist(ctx *cli.Context, cfg *eth.Config) {
 	}
 }
 
+// setSyncCheckpoint creates the sync checkpoint from the set command line flag.
+func setSyncCheckpoint(ctx *cli.Context, cfg *eth.Config) {
+	checkpoint := ctx.GlobalString(SyncCheckpointFlag.Name)
+	if checkpoint == "" {
+		return
+	}
+	var witnesses []string
+	if parts := strings.SplitN(checkpoint, ":", 2); len(parts) == 2 {
+		checkpoint = parts[0]
+		witnesses = strings.Split(parts[1], ",")
+	}
+	parts := strings.Split(checkpoint, "=")
+	if len(parts) != 2 {
+		Fatalf("Invalid sync checkpoint: %s", checkpoint)
+	}
+	number, err := strconv.ParseUint(parts[0], 0, 64)
+	if err != nil {
+		Fatalf("Invalid sync checkpoint block number %s: %v", parts[0], err)
+	}
+	var hash common.Hash
+	if err = hash.UnmarshalText([]byte(parts[1])); err != nil {
+		Fatalf("Invalid sync checkpoint hash %s: %v", parts[1], err)
+	}
+	cfg.SyncCheckpoint = &params.SyncCheckpoint{
+		Number:    number,
+		Hash:      hash,
+		Witnesses: witnesses,
+	}
+	if err = cfg.SyncCheckpoint.Validate(); err != nil {
+		Fatalf("Invalid sync checkpoint: %v", err)
+	}
+}
+
 // checkExclusive verifies that only a single instance of the provided flags was
 // set by the user. Each flag might optionally be followed by a string type to
 // specialize it further.
@@ -1202,6 +1291,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	setTxPool(ctx, &cfg.TxPool)
 	setEthash(ctx, cfg)
 	setWhitelist(ctx, cfg)
+	setSyncCheckpoint(ctx, cfg)
 
 	if ctx.GlobalIsSet(SyncModeFlag.Name) {
 		cfg.SyncMode = *GlobalTextMarshaler(ctx, SyncModeFlag.Name).(*downloader.SyncMode)
@@ -1212,6 +1302,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(LightPeersFlag.Name) {
 		cfg.LightPeers = ctx.GlobalInt(LightPeersFlag.Name)
 	}
+	if ctx.GlobalIsSet(LightPriceFlag.Name) {
+		cfg.LightPrice = ctx.GlobalUint64(LightPriceFlag.Name)
+	}
+	if ctx.GlobalIsSet(ULCServersFlag.Name) {
+		if cfg.SyncMode != downloader.LightSync {
+			Fatalf("--%s can only be used with --%s=light", ULCServersFlag.Name, SyncModeFlag.Name)
+		}
+		cfg.ULCServers = strings.Split(ctx.GlobalString(ULCServersFlag.Name), ",")
+	}
+	if ctx.GlobalIsSet(ULCFractionFlag.Name) {
+		cfg.ULCFraction = ctx.GlobalInt(ULCFractionFlag.Name)
+	}
 	if ctx.GlobalIsSet(NetworkIdFlag.Name) {
 		cfg.NetworkId = ctx.GlobalUint64(NetworkIdFlag.Name)
 	}
@@ -1279,6 +1381,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(RPCGlobalGasCap.Name) {
 		cfg.RPCGasCap = new(big.Int).SetUint64(ctx.GlobalUint64(RPCGlobalGasCap.Name))
 	}
+	if ctx.GlobalIsSet(RPCGlobalLogCap.Name) {
+		cfg.RPCLogCap = ctx.GlobalInt(RPCGlobalLogCap.Name)
+	}
+	if ctx.GlobalIsSet(LogIndexFlag.Name) {
+		cfg.LogIndex = ctx.GlobalBool(LogIndexFlag.Name)
+	}
 
 	// Override any default configs for hard coded networks.
 	switch {
@@ -1375,6 +1483,24 @@ func RegisterShhService(stack *node.Node, cfg *whisper.Config) {
 	}
 }
 
+// RegisterGraphQLService adds the GraphQL query service to the given node,
+// resolving queries against either the full or the light Ethereum service.
+func RegisterGraphQLService(stack *node.Node) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		var ethServ *eth.Ethereum
+		if err := ctx.Service(&ethServ); err == nil {
+			return graphql.New(ethServ.APIBackend)
+		}
+		var lesServ *les.LightEthereum
+		if err := ctx.Service(&lesServ); err == nil {
+			return graphql.New(lesServ.ApiBackend)
+		}
+		return nil, errors.New("no Ethereum service")
+	}); err != nil {
+		Fatalf("Failed to register the GraphQL service: %v", err)
+	}
+}
+
 // RegisterEthStatsService configures the Ethereum Stats daemon and adds it to
 // the given node.
 func RegisterEthStatsService(stack *node.Node, url string) {
@@ -18,8 +18,14 @@
 package utils
 
 import (
+	"flag"
 	"reflect"
 	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/eth"
+	"github.com/etherzero/go-etherzero/params"
+	"gopkg.in/urfave/cli.v1"
 )
 
 func Test_SplitTagsFlag(t *testing.T) {
@@ -62,3 +68,35 @@ func Test_SplitTagsFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestSetSyncCheckpoint(t *testing.T) {
+	hash := common.HexToHash("0xdeadbeef")
+	tests := []struct {
+		name string
+		flag string
+		want *params.SyncCheckpoint
+	}{
+		{"unset", "", nil},
+		{"hash only", "100=" + hash.Hex(), &params.SyncCheckpoint{Number: 100, Hash: hash}},
+		{"hex number", "0x64=" + hash.Hex(), &params.SyncCheckpoint{Number: 100, Hash: hash}},
+		{
+			"witnesses",
+			"100=" + hash.Hex() + ":0x01,0x02",
+			&params.SyncCheckpoint{Number: 100, Hash: hash, Witnesses: []string{"0x01", "0x02"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := flag.NewFlagSet("test", flag.ContinueOnError)
+			set.String(SyncCheckpointFlag.Name, "", "")
+			if err := set.Parse([]string{"--" + SyncCheckpointFlag.Name, tt.flag}); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+			cfg := new(eth.Config)
+			setSyncCheckpoint(cli.NewContext(nil, set, nil), cfg)
+			if !reflect.DeepEqual(cfg.SyncCheckpoint, tt.want) {
+				t.Errorf("setSyncCheckpoint() = %+v, want %+v", cfg.SyncCheckpoint, tt.want)
+			}
+		})
+	}
+}
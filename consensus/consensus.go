@@ -114,3 +114,13 @@ type PoW interface {
 	// Hashrate returns the current mining hashrate of a PoW consensus engine.
 	Hashrate() float64
 }
+
+// CheckpointVerifier is a consensus engine able to verify the seal of a single
+// header against a known set of sealers, without access to its ancestors.
+type CheckpointVerifier interface {
+	Engine
+
+	// VerifyCheckpointSeal checks whether the header is sealed by one of the
+	// given witnesses, any witness being accepted if none are given.
+	VerifyCheckpointSeal(header *types.Header, witnesses []string) error
+}
@@ -510,6 +510,30 @@ func (d *Devote) verifyBlockSigner(witness string, header *types.Header) error {
 	return nil
 }
 
+// VerifyCheckpointSeal implements consensus.CheckpointVerifier, checking that the
+// header is signed by its witness and that the witness is one of the given ones.
+func (d *Devote) VerifyCheckpointSeal(header *types.Header, witnesses []string) error {
+	if header.Protocol == nil {
+		return ErrInvalidBlockWitness
+	}
+	signer, err := ecrecover(header, d.signatures)
+	if err != nil {
+		return err
+	}
+	if signer != header.Witness {
+		return ErrMismatchSignerAndWitness
+	}
+	if len(witnesses) == 0 {
+		return nil
+	}
+	for _, witness := range witnesses {
+		if witness == signer {
+			return nil
+		}
+	}
+	return errUnauthorizedSigner
+}
+
 func (d *Devote) checkTime(lastBlock *types.Block, now uint64) error {
 	prevSlot := PrevSlot(now)
 	nextSlot := NextSlot(now)
@@ -545,6 +569,79 @@ func (d *Devote) CheckWitness(lastBlock *types.Block, now int64) error {
 	return nil
 }
 
+// Schedule returns the witnesses scheduled to seal the given number of slots
+// following the given header, in slot order and without duplicates. Slots past
+// the end of the header's cycle are assumed to be sealed by its witnesses too,
+// as the next cycle is not elected yet.
+func (d *Devote) Schedule(header *types.Header, slots int) ([]string, error) {
+	period := params.Period
+	if isForked(params.Pre2ShardingBlockNumber, header.Number) {
+		period = params.Period1Second
+	}
+	devoteDB, err := devotedb.New(devotedb.NewDatabase(d.db), header.Protocol.CycleHash, header.Protocol.StatsHash)
+	if err != nil {
+		return nil, err
+	}
+	witnesses, err := devoteDB.GetWitnesses(header.Time / params.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	if len(witnesses) == 0 {
+		return nil, errors.New("empty witness list")
+	}
+	var (
+		schedule []string
+		seen     = make(map[string]struct{})
+		slot     = header.Time - header.Time%period
+	)
+	for i := 0; i < slots; i++ {
+		slot += period
+		witness := witnesses[(slot%params.Epoch/period)%uint64(len(witnesses))]
+		if _, ok := seen[witness]; !ok {
+			seen[witness] = struct{}{}
+			schedule = append(schedule, witness)
+		}
+	}
+	return schedule, nil
+}
+
+// Witnesses returns the witnesses of the cycle of the given header. If the header
+// is within the given number of seconds of the end of its cycle, the candidates
+// the witnesses of the next cycle will be elected from are included too, as the
+// election itself only happens once the next cycle begins.
+func (d *Devote) Witnesses(header *types.Header, window uint64) ([]string, error) {
+	devoteDB, err := devotedb.New(devotedb.NewDatabase(d.db), header.Protocol.CycleHash, header.Protocol.StatsHash)
+	if err != nil {
+		return nil, err
+	}
+	witnesses, err := devoteDB.GetWitnesses(header.Time / params.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	if params.Epoch-header.Time%params.Epoch > window {
+		return witnesses, nil
+	}
+	var candidates []string
+	if isForked(params.H0401BlockNumber, header.Number) {
+		candidates = params.StableMasternodes
+	} else if d.masternodeListFn != nil {
+		if candidates, err = d.masternodeListFn(header.Number); err != nil {
+			return nil, err
+		}
+	}
+	seen := make(map[string]struct{}, len(witnesses)+len(candidates))
+	for _, witness := range witnesses {
+		seen[witness] = struct{}{}
+	}
+	for _, candidate := range candidates {
+		if _, ok := seen[candidate]; !ok {
+			seen[candidate] = struct{}{}
+			witnesses = append(witnesses, candidate)
+		}
+	}
+	return witnesses, nil
+}
+
 // Seal generates a new block for the given input block with the local miner's
 // seal place on top.
 func (d *Devote) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
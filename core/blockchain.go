@@ -652,6 +652,15 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	return receipts
 }
 
+// HasTransaction checks if a transaction is included in the canonical chain.
+func (bc *BlockChain) HasTransaction(hash common.Hash) bool {
+	blockHash, number, _ := rawdb.ReadTxLookupEntry(bc.db, hash)
+	if blockHash == (common.Hash{}) {
+		return false
+	}
+	return rawdb.ReadCanonicalHash(bc.db, number) == blockHash
+}
+
 // GetBlocksFromHash returns the block corresponding to hash and up to n-1 ancestors.
 // [deprecated by eth/62]
 func (bc *BlockChain) GetBlocksFromHash(hash common.Hash, n int) (blocks []*types.Block) {
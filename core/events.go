@@ -24,6 +24,10 @@ import (
 // NewTxsEvent is posted when a batch of transactions enter the transaction pool.
 type NewTxsEvent struct{ Txs []*types.Transaction }
 
+// TxLifecycleEvent is posted when transactions move between the lifecycle stages
+// of the transaction pool.
+type TxLifecycleEvent struct{ Records []TxLifecycleRecord }
+
 // PendingLogsEvent is posted pre mining and notifies of pending logs.
 type PendingLogsEvent struct {
 	Logs []*types.Log
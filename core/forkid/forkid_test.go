@@ -0,0 +1,108 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package forkid
+
+import (
+	"testing"
+
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that the fork ID of the main network is calculated correctly across
+// the devote hard forks.
+func TestCreation(t *testing.T) {
+	tests := []struct {
+		head uint64
+		want ID
+	}{
+		{params.GenesisBlockNumber, ID{Hash: [4]byte{0xfc, 0xc7, 0xf5, 0x84}, Next: 35360000}}, // Genesis block
+		{35359999, ID{Hash: [4]byte{0xfc, 0xc7, 0xf5, 0x84}, Next: 35360000}},                  // Last pre-sharding block
+		{35360000, ID{Hash: [4]byte{0x31, 0x6b, 0xb0, 0x7b}, Next: 84656689}},                  // First sharding block
+		{84656688, ID{Hash: [4]byte{0x31, 0x6b, 0xb0, 0x7b}, Next: 84656689}},                  // Last pre-0401 block
+		{84656689, ID{Hash: [4]byte{0xd9, 0x86, 0xd9, 0x85}, Next: 0}},                         // First 0401 block
+		{100000000, ID{Hash: [4]byte{0xd9, 0x86, 0xd9, 0x85}, Next: 0}},                        // Future block
+	}
+	for i, tt := range tests {
+		if have := newID(params.DevoteChainConfig, params.MainnetGenesisHash, params.GenesisBlockNumber, tt.head); have != tt.want {
+			t.Errorf("test %d: fork ID mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that the latest fork passed by the main network is found across the
+// devote hard forks.
+func TestPassedFork(t *testing.T) {
+	tests := []struct {
+		head   uint64
+		fork   uint64
+		passed bool
+	}{
+		{params.GenesisBlockNumber, 0, false}, // Genesis block
+		{35359999, 0, false},                  // Last pre-sharding block
+		{35360000, 35360000, true},            // First sharding block
+		{84656688, 35360000, true},            // Last pre-0401 block
+		{84656689, 84656689, true},            // First 0401 block
+		{100000000, 84656689, true},           // Future block
+	}
+	for i, tt := range tests {
+		if fork, passed := passedFork(params.DevoteChainConfig, params.GenesisBlockNumber, tt.head); fork != tt.fork || passed != tt.passed {
+			t.Errorf("test %d: passed fork mismatch: have %d (%v), want %d (%v)", i, fork, passed, tt.fork, tt.passed)
+		}
+	}
+}
+
+// Tests that remote fork IDs are validated against the local chain state.
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		head uint64
+		id   ID
+		err  error
+	}{
+		// Local is mainnet past the 0401 fork, remote announces the same. No future
+		// fork is announced, connect.
+		{90000000, ID{Hash: [4]byte{0xd9, 0x86, 0xd9, 0x85}, Next: 0}, nil},
+
+		// Local is mainnet past the 0401 fork, remote announces the same along with
+		// a future fork at a block not yet reached, connect.
+		{90000000, ID{Hash: [4]byte{0xd9, 0x86, 0xd9, 0x85}, Next: 95000000}, nil},
+
+		// Local is mainnet past the 0401 fork, remote announces the same along with
+		// a fork at a block already passed locally, reject.
+		{90000000, ID{Hash: [4]byte{0xd9, 0x86, 0xd9, 0x85}, Next: 89000000}, ErrLocalIncompatibleOrStale},
+
+		// Local is mainnet past the 0401 fork, remote is still syncing before it and
+		// knows about it, connect.
+		{90000000, ID{Hash: [4]byte{0x31, 0x6b, 0xb0, 0x7b}, Next: 84656689}, nil},
+
+		// Local is mainnet past the 0401 fork, remote is before it and doesn't know
+		// about it: the remote missed the fork, reject.
+		{90000000, ID{Hash: [4]byte{0x31, 0x6b, 0xb0, 0x7b}, Next: 0}, ErrRemoteStale},
+
+		// Local is syncing before the sharding fork, remote is past the 0401 fork.
+		// The remote's checksum is a superset of ours, connect.
+		{30000000, ID{Hash: [4]byte{0xd9, 0x86, 0xd9, 0x85}, Next: 0}, nil},
+
+		// Remote is on a different chain altogether, reject.
+		{90000000, ID{Hash: [4]byte{0xaf, 0xec, 0x6b, 0x27}, Next: 0}, ErrLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		filter := newFilter(params.DevoteChainConfig, params.MainnetGenesisHash, params.GenesisBlockNumber, func() uint64 { return tt.head })
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
@@ -105,3 +105,17 @@ func WriteBloomBits(db DatabaseWriter, bit uint, section uint64, head common.Has
 		log.Crit("Failed to store bloom bits", "err", err)
 	}
 }
+
+// ReadLogIndex retrieves the compressed bit vector of the blocks within the given
+// section containing logs emitted by the given address or carrying the given topic.
+func ReadLogIndex(db DatabaseReader, item []byte, section uint64, head common.Hash) ([]byte, error) {
+	return db.Get(logIndexKey(item, section, head))
+}
+
+// WriteLogIndex stores the compressed bit vector of the blocks within the given
+// section containing logs of an address or topic.
+func WriteLogIndex(db DatabaseWriter, item []byte, section uint64, head common.Hash, bits []byte) {
+	if err := db.Put(logIndexKey(item, section, head), bits); err != nil {
+		log.Crit("Failed to store log index", "err", err)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"github.com/etherzero/go-etherzero/common"
+)
+
+// AccessTracker records the accounts and storage slots read and written through
+// a state database. A nil tracker records nothing.
+type AccessTracker struct {
+	Reads  map[common.Address]map[common.Hash]struct{} // Accounts read, along with the storage slots read
+	Writes map[common.Address]map[common.Hash]struct{} // Accounts written, along with the storage slots written
+}
+
+// NewAccessTracker creates an empty state access tracker.
+func NewAccessTracker() *AccessTracker {
+	return &AccessTracker{
+		Reads:  make(map[common.Address]map[common.Hash]struct{}),
+		Writes: make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+// readAccount records a read of an account field.
+func (t *AccessTracker) readAccount(addr common.Address) {
+	if t != nil {
+		touchAccount(t.Reads, addr)
+	}
+}
+
+// readSlot records a read of a storage slot.
+func (t *AccessTracker) readSlot(addr common.Address, key common.Hash) {
+	if t != nil {
+		touchAccount(t.Reads, addr)[key] = struct{}{}
+	}
+}
+
+// writeAccount records a write of an account field.
+func (t *AccessTracker) writeAccount(addr common.Address) {
+	if t != nil {
+		touchAccount(t.Writes, addr)
+	}
+}
+
+// writeSlot records a write of a storage slot.
+func (t *AccessTracker) writeSlot(addr common.Address, key common.Hash) {
+	if t != nil {
+		touchAccount(t.Writes, addr)[key] = struct{}{}
+	}
+}
+
+// touchAccount ensures an account is present in an access set, returning its
+// set of storage slots.
+func touchAccount(set map[common.Address]map[common.Hash]struct{}, addr common.Address) map[common.Hash]struct{} {
+	slots, ok := set[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		set[addr] = slots
+	}
+	return slots
+}
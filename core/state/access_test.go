@@ -0,0 +1,89 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/ethdb"
+)
+
+// Tests that the state accesses are recorded into the tracker, split into
+// reads and writes, and only while a tracker is set.
+func TestAccessTracker(t *testing.T) {
+	var (
+		addr1 = common.BytesToAddress([]byte{0x01})
+		addr2 = common.BytesToAddress([]byte{0x02})
+		addr3 = common.BytesToAddress([]byte{0x03})
+		addr4 = common.BytesToAddress([]byte{0x04})
+		key1  = common.BytesToHash([]byte{0x01})
+		key2  = common.BytesToHash([]byte{0x02})
+	)
+	statedb, _ := New(common.Hash{}, NewDatabase(ethdb.NewMemDatabase()))
+
+	// Accesses made before the tracker is set must not be recorded
+	statedb.SetBalance(addr4, big.NewInt(1), common.Big1)
+	statedb.GetState(addr4, key1)
+
+	tracker := NewAccessTracker()
+	statedb.SetAccessTracker(tracker)
+	if statedb.AccessTracker() != tracker {
+		t.Fatalf("tracker mismatch: have %p, want %p", statedb.AccessTracker(), tracker)
+	}
+	statedb.GetBalance(addr1)
+	statedb.GetPower(addr1, common.Big1)
+	statedb.GetState(addr1, key1)
+	statedb.GetCommittedState(addr2, key2)
+	statedb.SetNonce(addr2, 1)
+	statedb.SetState(addr2, key1, common.Hash{0x01})
+	statedb.SetCode(addr3, []byte{0x01})
+	statedb.GetCodeHash(addr3)
+
+	// Accesses made after the tracker is cleared must not be recorded either
+	statedb.SetAccessTracker(nil)
+	statedb.SetBalance(addr4, big.NewInt(2), common.Big1)
+	statedb.GetState(addr4, key2)
+
+	reads := map[common.Address]map[common.Hash]struct{}{
+		addr1: {key1: {}},
+		addr2: {key2: {}},
+		addr3: {},
+	}
+	writes := map[common.Address]map[common.Hash]struct{}{
+		addr2: {key1: {}},
+		addr3: {},
+	}
+	if !reflect.DeepEqual(tracker.Reads, reads) {
+		t.Errorf("reads mismatch: have %v, want %v", tracker.Reads, reads)
+	}
+	if !reflect.DeepEqual(tracker.Writes, writes) {
+		t.Errorf("writes mismatch: have %v, want %v", tracker.Writes, writes)
+	}
+}
+
+// Tests that a nil tracker can be used without recording anything.
+func TestNilAccessTracker(t *testing.T) {
+	var tracker *AccessTracker
+
+	tracker.readAccount(common.Address{})
+	tracker.readSlot(common.Address{}, common.Hash{})
+	tracker.writeAccount(common.Address{})
+	tracker.writeSlot(common.Address{}, common.Hash{})
+}
@@ -6,10 +6,13 @@ import (
 	"github.com/etherzero/go-etherzero/common"
 )
 
+// MinPowerBalance is the minimum balance an account needs to hold any power.
+var MinPowerBalance = big.NewInt(1e+16)
+
 // EXP(−1÷(etz×50)×10000)×10000000+200000
 // EXP(−1÷(etz×2)×1000)×200000+1000
 func CalculatePower(prevBlock, newBlock, prevPower, balance *big.Int) *big.Int {
-	if balance.Cmp(big.NewInt(1e+16)) < 0 {
+	if balance.Cmp(MinPowerBalance) < 0 {
 		return common.Big0
 	}
 	if prevBlock.Cmp(newBlock) >= 0 {
@@ -89,6 +89,9 @@ type StateDB struct {
 	journal        *journal
 	validRevisions []revision
 	nextRevisionId int
+
+	// Tracker of the accounts and storage slots accessed, if enabled.
+	access *AccessTracker
 }
 
 // Create a new state from a given trie.
@@ -211,18 +214,21 @@ func (self *StateDB) SubRefund(gas uint64) {
 // Exist reports whether the given account address exists in the state.
 // Notably this also returns true for suicided accounts.
 func (self *StateDB) Exist(addr common.Address) bool {
+	self.access.readAccount(addr)
 	return self.getStateObject(addr) != nil
 }
 
 // Empty returns whether the state object is either non-existent
 // or empty according to the EIP161 specification (balance = nonce = code = 0)
 func (self *StateDB) Empty(addr common.Address) bool {
+	self.access.readAccount(addr)
 	so := self.getStateObject(addr)
 	return so == nil || so.empty()
 }
 
 // Retrieve the balance from the given address or 0 if object not found
 func (self *StateDB) GetBalance(addr common.Address) *big.Int {
+	self.access.readAccount(addr)
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Balance()
@@ -231,6 +237,7 @@ func (self *StateDB) GetBalance(addr common.Address) *big.Int {
 }
 
 func (self *StateDB) GetPower(addr common.Address, blockNumber *big.Int) *big.Int {
+	self.access.readAccount(addr)
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
 		return CalculatePower(stateObject.BlockNumber(), blockNumber, stateObject.Power(), stateObject.Balance())
@@ -240,6 +247,7 @@ func (self *StateDB) GetPower(addr common.Address, blockNumber *big.Int) *big.In
 
 
 func (self *StateDB) GetNonce(addr common.Address) uint64 {
+	self.access.readAccount(addr)
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Nonce()
@@ -249,6 +257,7 @@ func (self *StateDB) GetNonce(addr common.Address) uint64 {
 }
 
 func (self *StateDB) GetCode(addr common.Address) []byte {
+	self.access.readAccount(addr)
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Code(self.db)
@@ -257,6 +266,7 @@ func (self *StateDB) GetCode(addr common.Address) []byte {
 }
 
 func (self *StateDB) GetCodeSize(addr common.Address) int {
+	self.access.readAccount(addr)
 	stateObject := self.getStateObject(addr)
 	if stateObject == nil {
 		return 0
@@ -272,6 +282,7 @@ func (self *StateDB) GetCodeSize(addr common.Address) int {
 }
 
 func (self *StateDB) GetCodeHash(addr common.Address) common.Hash {
+	self.access.readAccount(addr)
 	stateObject := self.getStateObject(addr)
 	if stateObject == nil {
 		return common.Hash{}
@@ -281,6 +292,7 @@ func (self *StateDB) GetCodeHash(addr common.Address) common.Hash {
 
 // GetState retrieves a value from the given account's storage trie.
 func (self *StateDB) GetState(addr common.Address, hash common.Hash) common.Hash {
+	self.access.readSlot(addr, hash)
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.GetState(self.db, hash)
@@ -308,6 +320,7 @@ func (self *StateDB) GetStorageProof(a common.Address, key common.Hash) ([][]byt
 
 // GetCommittedState retrieves a value from the given account's committed storage trie.
 func (self *StateDB) GetCommittedState(addr common.Address, hash common.Hash) common.Hash {
+	self.access.readSlot(addr, hash)
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.GetCommittedState(self.db, hash)
@@ -332,6 +345,7 @@ func (self *StateDB) StorageTrie(addr common.Address) Trie {
 }
 
 func (self *StateDB) HasSuicided(addr common.Address) bool {
+	self.access.readAccount(addr)
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.suicided
@@ -345,6 +359,7 @@ func (self *StateDB) HasSuicided(addr common.Address) bool {
 
 // AddBalance adds amount to the account associated with addr.
 func (self *StateDB) AddBalance(addr common.Address, amount *big.Int, blockNumber *big.Int) {
+	self.access.writeAccount(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.AddBalance(amount, blockNumber)
@@ -352,6 +367,7 @@ func (self *StateDB) AddBalance(addr common.Address, amount *big.Int, blockNumbe
 }
 
 func (self *StateDB) AddPower(addr common.Address, amount *big.Int) {
+	self.access.writeAccount(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.AddPower(amount)
@@ -360,6 +376,7 @@ func (self *StateDB) AddPower(addr common.Address, amount *big.Int) {
 
 // SubBalance subtracts amount from the account associated with addr.
 func (self *StateDB) SubBalance(addr common.Address, amount, blockNumber *big.Int) {
+	self.access.writeAccount(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SubBalance(amount, blockNumber)
@@ -367,6 +384,7 @@ func (self *StateDB) SubBalance(addr common.Address, amount, blockNumber *big.In
 }
 
 func (self *StateDB) SubPower(addr common.Address, amount, blockNumber *big.Int) {
+	self.access.writeAccount(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SubPower(amount, blockNumber)
@@ -374,6 +392,7 @@ func (self *StateDB) SubPower(addr common.Address, amount, blockNumber *big.Int)
 }
 
 func (self *StateDB) SetBalance(addr common.Address, amount, blockNumber *big.Int) {
+	self.access.writeAccount(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetBalance(amount, blockNumber)
@@ -381,6 +400,7 @@ func (self *StateDB) SetBalance(addr common.Address, amount, blockNumber *big.In
 }
 
 func (self *StateDB) SetPower(addr common.Address, amount *big.Int) {
+	self.access.writeAccount(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetPower(amount)
@@ -388,6 +408,7 @@ func (self *StateDB) SetPower(addr common.Address, amount *big.Int) {
 }
 
 func (self *StateDB) SetNonce(addr common.Address, nonce uint64) {
+	self.access.writeAccount(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetNonce(nonce)
@@ -395,6 +416,7 @@ func (self *StateDB) SetNonce(addr common.Address, nonce uint64) {
 }
 
 func (self *StateDB) SetCode(addr common.Address, code []byte) {
+	self.access.writeAccount(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetCode(crypto.Keccak256Hash(code), code)
@@ -402,6 +424,7 @@ func (self *StateDB) SetCode(addr common.Address, code []byte) {
 }
 
 func (self *StateDB) SetState(addr common.Address, key, value common.Hash) {
+	self.access.writeSlot(addr, key)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetState(self.db, key, value)
@@ -414,6 +437,7 @@ func (self *StateDB) SetState(addr common.Address, key, value common.Hash) {
 // The account's state object is still available until the state is committed,
 // getStateObject will return a non-nil account after Suicide.
 func (self *StateDB) Suicide(addr common.Address) bool {
+	self.access.writeAccount(addr)
 	stateObject := self.getStateObject(addr)
 	if stateObject == nil {
 		return false
@@ -516,6 +540,7 @@ func (self *StateDB) createObject(addr common.Address) (newobj, prev *stateObjec
 //
 // Carrying over the balance ensures that Ether doesn't disappear.
 func (self *StateDB) CreateAccount(addr common.Address) {
+	self.access.writeAccount(addr)
 	newObj, prev := self.createObject(addr)
 	if prev != nil {
 		newObj.setBalance(prev.data.Balance)
@@ -538,6 +563,17 @@ func (db *StateDB) ForEachStorage(addr common.Address, cb func(key, value common
 	}
 }
 
+// SetAccessTracker starts recording the accounts and storage slots accessed
+// into the given tracker, or stops recording if nil.
+func (self *StateDB) SetAccessTracker(tracker *AccessTracker) {
+	self.access = tracker
+}
+
+// AccessTracker returns the tracker recording the state accesses, if any.
+func (self *StateDB) AccessTracker() *AccessTracker {
+	return self.access
+}
+
 // Copy creates a deep, independent copy of the state.
 // Snapshots of the copied state cannot be applied to the copy.
 func (self *StateDB) Copy() *StateDB {
@@ -17,7 +17,6 @@
 package core
 
 import (
-	"errors"
 	"math"
 	"math/big"
 
@@ -27,10 +26,6 @@ import (
 	"github.com/etherzero/go-etherzero/params"
 )
 
-var (
-	errInsufficientBalanceForGas = errors.New("insufficient balance to pay for gas")
-)
-
 /*
 The State Transitioning Model
 
@@ -58,6 +53,7 @@ type StateTransition struct {
 	data       []byte
 	state      vm.StateDB
 	evm        *vm.EVM
+	payment    *vm.GasPayment
 }
 
 // Message represents a message sent to a contract.
@@ -151,8 +147,12 @@ func (st *StateTransition) useGas(amount uint64) error {
 
 func (st *StateTransition) buyGas() error {
 	mgval := new(big.Int).Mul(new(big.Int).SetUint64(st.msg.Gas()), st.gasPrice)
-	if st.state.GetPower(st.msg.From(), st.evm.BlockNumber).Cmp(mgval) < 0 {
-		return errInsufficientBalanceForGas
+
+	power := new(big.Int).Set(st.state.GetPower(st.msg.From(), st.evm.BlockNumber))
+	st.payment = &vm.GasPayment{Cost: mgval, PowerBefore: power}
+	if power.Cmp(mgval) < 0 {
+		st.evm.CapturePower(st.payment)
+		return ErrInsufficientPower
 	}
 
 	if err := st.gp.SubGas(st.msg.Gas()); err != nil {
@@ -240,6 +240,9 @@ func (st *StateTransition) refundGas() {
 	//st.state.AddBalance(st.msg.From(), remaining)
 	st.state.AddPower(st.msg.From(), remaining)
 
+	st.payment.PowerAfter = new(big.Int).Set(st.state.GetPower(st.msg.From(), st.evm.BlockNumber))
+	st.evm.CapturePower(st.payment)
+
 	// Also return remaining gas to the block gas counter so it is
 	// available for the next transaction.
 	st.gp.AddGas(st.gas)
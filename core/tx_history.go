@@ -0,0 +1,262 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/rlp"
+)
+
+var (
+	// errTxExpired is the drop reason of a transaction queued for longer than
+	// the configured lifetime.
+	errTxExpired = errors.New("transaction lifetime expired")
+
+	// errTxRateLimited is the drop reason of a transaction evicted to keep the
+	// pool within its account or global slot limits.
+	errTxRateLimited = errors.New("transaction pool slots exceeded")
+)
+
+// txHistoryLimit is the maximum number of lifecycle records of transactions no
+// longer in the pool that are retained, regardless of the retention window.
+const txHistoryLimit = 65536
+
+// TxLifecycle is the lifecycle stage of a transaction as seen by the pool.
+type TxLifecycle uint
+
+const (
+	TxLifecycleQueued   TxLifecycle = iota // Waiting in the future queue for a nonce gap
+	TxLifecyclePending                     // Executable and waiting for inclusion
+	TxLifecycleReplaced                    // Superseded by another transaction with the same nonce
+	TxLifecycleDropped                     // Removed from the pool, see the reason
+	TxLifecycleIncluded                    // Included in the canonical chain
+)
+
+// String implements the stringer interface.
+func (s TxLifecycle) String() string {
+	switch s {
+	case TxLifecycleQueued:
+		return "queued"
+	case TxLifecyclePending:
+		return "pending"
+	case TxLifecycleReplaced:
+		return "replaced"
+	case TxLifecycleDropped:
+		return "dropped"
+	case TxLifecycleIncluded:
+		return "included"
+	default:
+		return "unknown"
+	}
+}
+
+// TxLifecycleRecord is the last known lifecycle stage of a transaction.
+type TxLifecycleRecord struct {
+	Hash       common.Hash // Hash of the transaction
+	Status     TxLifecycle // Lifecycle stage of the transaction
+	ReplacedBy common.Hash // Hash of the replacing transaction, if replaced
+	Reason     string      // Reason of the removal, if dropped
+	Time       time.Time   // Time the transaction entered its current stage
+}
+
+// storedTxLifecycleRecord is the RLP encoding of a lifecycle record persisted
+// into the history journal.
+type storedTxLifecycleRecord struct {
+	Hash       common.Hash
+	Status     uint
+	ReplacedBy common.Hash
+	Reason     string
+	Time       uint64 // Unix time of the stage change in nanoseconds
+}
+
+// txHistory tracks the lifecycle of all the transactions that entered the pool,
+// retaining the records of the ones that left it for a limited amount of time.
+type txHistory struct {
+	window  time.Duration                      // Retention time of records of transactions that left the pool
+	records map[common.Hash]*TxLifecycleRecord // Last known lifecycle stage of each transaction
+	done    []*TxLifecycleRecord               // Records of transactions that left the pool, oldest first
+	updates []TxLifecycleRecord                // Lifecycle changes not yet announced
+}
+
+// newTxHistory creates a lifecycle tracker retaining the records of transactions
+// that left the pool for the given amount of time.
+func newTxHistory(window time.Duration) *txHistory {
+	return &txHistory{
+		window:  window,
+		records: make(map[common.Hash]*TxLifecycleRecord),
+	}
+}
+
+// queued marks a transaction as waiting in the future queue.
+func (h *txHistory) queued(hash common.Hash) {
+	h.update(&TxLifecycleRecord{Hash: hash, Status: TxLifecycleQueued})
+}
+
+// pending marks a transaction as executable.
+func (h *txHistory) pending(hash common.Hash) {
+	h.update(&TxLifecycleRecord{Hash: hash, Status: TxLifecyclePending})
+}
+
+// replaced marks a transaction as superseded by another one.
+func (h *txHistory) replaced(hash common.Hash, by common.Hash) {
+	h.update(&TxLifecycleRecord{Hash: hash, Status: TxLifecycleReplaced, ReplacedBy: by})
+}
+
+// dropped marks a transaction as removed from the pool for the given reason.
+// Transactions already known to be included are not marked, as they are only
+// dropped due to their nonce becoming stale.
+func (h *txHistory) dropped(hash common.Hash, reason error) {
+	if old := h.records[hash]; old != nil && old.Status == TxLifecycleIncluded {
+		return
+	}
+	h.update(&TxLifecycleRecord{Hash: hash, Status: TxLifecycleDropped, Reason: reason.Error()})
+}
+
+// included marks a transaction as included in the canonical chain. Only the
+// transactions that went through the pool are tracked.
+func (h *txHistory) included(hash common.Hash) {
+	if h.records[hash] != nil {
+		h.update(&TxLifecycleRecord{Hash: hash, Status: TxLifecycleIncluded})
+	}
+}
+
+// update stores a new lifecycle stage of a transaction, unless it is already in
+// the same stage, and queues it up for announcement.
+func (h *txHistory) update(record *TxLifecycleRecord) {
+	if old := h.records[record.Hash]; old != nil && old.Status == record.Status {
+		return
+	}
+	record.Time = time.Now()
+	h.records[record.Hash] = record
+	h.updates = append(h.updates, *record)
+
+	if record.Status != TxLifecycleQueued && record.Status != TxLifecyclePending {
+		h.done = append(h.done, record)
+	}
+}
+
+// get retrieves the last known lifecycle stage of a transaction.
+func (h *txHistory) get(hash common.Hash) *TxLifecycleRecord {
+	if record := h.records[hash]; record != nil {
+		cpy := *record
+		return &cpy
+	}
+	return nil
+}
+
+// flush returns all the lifecycle changes since the last flush.
+func (h *txHistory) flush() []TxLifecycleRecord {
+	updates := h.updates
+	h.updates = nil
+	return updates
+}
+
+// expire removes the records of transactions that left the pool longer ago than
+// the retention window, or above the retention limit.
+func (h *txHistory) expire() {
+	var i int
+	for ; i < len(h.done); i++ {
+		if len(h.done)-i <= txHistoryLimit && time.Since(h.done[i].Time) <= h.window {
+			break
+		}
+		// Only delete the record if the transaction didn't reenter the pool since
+		if h.records[h.done[i].Hash] == h.done[i] {
+			delete(h.records, h.done[i].Hash)
+		}
+	}
+	h.done = h.done[i:]
+}
+
+// load parses a history journal dump from disk, restoring the records of the
+// transactions that left the pool before the node was restarted.
+func (h *txHistory) load(path string) error {
+	// Skip the parsing if the journal file doesn't exist at all
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	input, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	stream := rlp.NewStream(input, 0)
+	for {
+		var stored storedTxLifecycleRecord
+		if err = stream.Decode(&stored); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			break
+		}
+		record := &TxLifecycleRecord{
+			Hash:       stored.Hash,
+			Status:     TxLifecycle(stored.Status),
+			ReplacedBy: stored.ReplacedBy,
+			Reason:     stored.Reason,
+			Time:       time.Unix(0, int64(stored.Time)),
+		}
+		h.records[record.Hash] = record
+		h.done = append(h.done, record)
+	}
+	h.expire()
+	log.Info("Loaded transaction history journal", "records", len(h.done))
+
+	return err
+}
+
+// save regenerates the history journal with the records of the transactions
+// that left the pool.
+func (h *txHistory) save(path string) error {
+	replacement, err := os.OpenFile(path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	saved := 0
+	for _, record := range h.done {
+		// Skip records superseded since, the transaction reentered the pool
+		if h.records[record.Hash] != record {
+			continue
+		}
+		stored := &storedTxLifecycleRecord{
+			Hash:       record.Hash,
+			Status:     uint(record.Status),
+			ReplacedBy: record.ReplacedBy,
+			Reason:     record.Reason,
+			Time:       uint64(record.Time.UnixNano()),
+		}
+		if err = rlp.Encode(replacement, stored); err != nil {
+			replacement.Close()
+			return err
+		}
+		saved++
+	}
+	replacement.Close()
+
+	// Replace the live journal with the newly generated one
+	if err = os.Rename(path+".new", path); err != nil {
+		return err
+	}
+	log.Debug("Regenerated transaction history journal", "records", saved)
+	return nil
+}
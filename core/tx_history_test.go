@@ -0,0 +1,117 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+)
+
+// Tests that lifecycle changes are tracked and announced, and that included
+// transactions are not reported as dropped due to their stale nonces.
+func TestTxHistoryLifecycle(t *testing.T) {
+	history := newTxHistory(time.Hour)
+
+	a, b := common.HexToHash("0x01"), common.HexToHash("0x02")
+	history.queued(a)
+	history.pending(a)
+	history.replaced(a, b)
+	history.pending(b)
+	history.included(b)
+	history.dropped(b, ErrNonceTooLow)
+
+	if record := history.get(a); record == nil || record.Status != TxLifecycleReplaced || record.ReplacedBy != b {
+		t.Errorf("replaced record mismatch: have %+v", record)
+	}
+	if record := history.get(b); record == nil || record.Status != TxLifecycleIncluded {
+		t.Errorf("included record mismatch: have %+v", record)
+	}
+	if updates := history.flush(); len(updates) != 5 {
+		t.Errorf("announced update count mismatch: have %d, want %d", len(updates), 5)
+	}
+	if updates := history.flush(); len(updates) != 0 {
+		t.Errorf("reannounced updates: have %d, want %d", len(updates), 0)
+	}
+	// Unknown transactions should not be marked as included
+	history.included(common.HexToHash("0x03"))
+	if record := history.get(common.HexToHash("0x03")); record != nil {
+		t.Errorf("untracked transaction recorded: %+v", record)
+	}
+}
+
+// Tests that the records of transactions that left the pool expire, but not the
+// ones of still pooled transactions.
+func TestTxHistoryExpiration(t *testing.T) {
+	history := newTxHistory(time.Hour)
+
+	a, b := common.HexToHash("0x01"), common.HexToHash("0x02")
+	history.pending(a)
+	history.dropped(b, ErrUnderpriced)
+
+	history.records[b].Time = time.Now().Add(-2 * time.Hour)
+	history.expire()
+
+	if history.get(a) == nil {
+		t.Errorf("pooled transaction record expired")
+	}
+	if history.get(b) != nil {
+		t.Errorf("dropped transaction record not expired")
+	}
+}
+
+// Tests that the records of transactions that left the pool survive restarts
+// through the history journal, while the ones of pooled transactions don't.
+func TestTxHistoryJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txhistory")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "txhistory.rlp")
+
+	history := newTxHistory(time.Hour)
+
+	a, b, c, d := common.HexToHash("0x01"), common.HexToHash("0x02"), common.HexToHash("0x03"), common.HexToHash("0x04")
+	history.pending(a)
+	history.replaced(a, b)
+	history.dropped(c, ErrUnderpriced)
+	history.pending(d)
+	history.included(d)
+	history.pending(common.HexToHash("0x05"))
+
+	if err := history.save(path); err != nil {
+		t.Fatalf("failed to save history: %v", err)
+	}
+	restored := newTxHistory(time.Hour)
+	if err := restored.load(path); err != nil {
+		t.Fatalf("failed to load history: %v", err)
+	}
+	for _, hash := range []common.Hash{a, c, d} {
+		want, have := history.get(hash), restored.get(hash)
+		if have == nil || have.Status != want.Status || have.ReplacedBy != want.ReplacedBy || have.Reason != want.Reason || !have.Time.Equal(want.Time) {
+			t.Errorf("record %x mismatch: have %+v, want %+v", hash, have, want)
+		}
+	}
+	if record := restored.get(common.HexToHash("0x05")); record != nil {
+		t.Errorf("pooled transaction record restored: %+v", record)
+	}
+}
@@ -249,19 +249,18 @@ func (l *txList) Overlaps(tx *types.Transaction) bool {
 // Add tries to insert a new transaction into the list, returning whether the
 // transaction was accepted, and if yes, any previous transaction it replaced.
 //
+// A transaction may replace an older one either by bumping the gas price by the
+// required percentage, or, keeping the same gas price, by lowering the power it
+// consumes (gas price * gas limit) by the same percentage. Fee-free transactions
+// consume no power, they are replaced by lowering the gas limit instead.
+//
 // If the new transaction is accepted into the list, the lists' cost and gas
 // thresholds are also potentially updated.
 func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Transaction) {
 	// If there's an older better transaction, abort
 	old := l.txs.Get(tx.Nonce())
-	if old != nil {
-		threshold := new(big.Int).Div(new(big.Int).Mul(old.GasPrice(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
-		// Have to ensure that the new gas price is higher than the old gas
-		// price as well as checking the percentage threshold to ensure that
-		// this is accurate for low (Wei-level) gas price replacements
-		if old.GasPrice().Cmp(tx.GasPrice()) >= 0 || threshold.Cmp(tx.GasPrice()) > 0 {
-			return false, nil
-		}
+	if old != nil && !replaceable(old, tx, priceBump) {
+		return false, nil
 	}
 	// Otherwise overwrite the old transaction with the current one
 	l.txs.Put(tx)
@@ -277,6 +276,29 @@ func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Tran
 	return true, old
 }
 
+// replaceable checks whether the new transaction is allowed to replace the old
+// one with the same nonce, either paying a higher gas price or consuming less
+// power at the same gas price.
+func replaceable(old, tx *types.Transaction, priceBump uint64) bool {
+	bump := big.NewInt(100 + int64(priceBump))
+
+	if old.GasPrice().Cmp(tx.GasPrice()) == 0 {
+		// Same price, the power cost must drop by the bump percentage. Zero cost
+		// transactions can't be made any cheaper, their gas limit must drop instead.
+		oldCost, cost := old.Cost(), tx.Cost()
+		if oldCost.Sign() == 0 {
+			oldCost, cost = new(big.Int).SetUint64(old.Gas()), new(big.Int).SetUint64(tx.Gas())
+		}
+		cost.Mul(cost, bump)
+		return oldCost.Sign() > 0 && cost.Cmp(new(big.Int).Mul(oldCost, big.NewInt(100))) <= 0
+	}
+	threshold := new(big.Int).Div(new(big.Int).Mul(old.GasPrice(), bump), big.NewInt(100))
+	// Have to ensure that the new gas price is higher than the old gas
+	// price as well as checking the percentage threshold to ensure that
+	// this is accurate for low (Wei-level) gas price replacements
+	return old.GasPrice().Cmp(tx.GasPrice()) < 0 && threshold.Cmp(tx.GasPrice()) <= 0
+}
+
 // Forward removes all transactions from the list with a nonce lower than the
 // provided threshold. Every removed transaction is returned for any post-removal
 // maintenance.
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"math/big"
 	"math/rand"
 	"testing"
 
@@ -49,3 +50,34 @@ func TestStrictTxListAdd(t *testing.T) {
 		}
 	}
 }
+
+// Tests that transactions are replaced either by bumping the gas price, or at
+// the same price by consuming less power, fee-free ones by lowering their gas.
+func TestTxListReplacement(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	tests := []struct {
+		oldGas, oldPrice uint64
+		newGas, newPrice uint64
+		replaced         bool
+	}{
+		{100000, 100, 100000, 109, false}, // price bump too low
+		{100000, 100, 100000, 110, true},  // price bumped
+		{100000, 100, 90910, 100, false},  // power drop too low
+		{100000, 100, 90909, 100, true},   // power dropped
+		{100000, 0, 100000, 0, false},     // fee-free duplicate
+		{100000, 0, 90910, 0, false},      // fee-free gas drop too low
+		{100000, 0, 90909, 0, true},       // fee-free gas dropped
+		{100000, 0, 100000, 1, true},      // fee-free replaced by paying one
+		{100000, 1, 50000, 0, false},      // paying one not replaced by fee-free
+	}
+	for i, tt := range tests {
+		list := newTxList(true)
+		list.Add(pricedTransaction(0, tt.oldGas, new(big.Int).SetUint64(tt.oldPrice), key), DefaultTxPoolConfig.PriceBump)
+
+		tx := pricedTransaction(0, tt.newGas, new(big.Int).SetUint64(tt.newPrice), key)
+		if inserted, _ := list.Add(tx, DefaultTxPoolConfig.PriceBump); inserted != tt.replaced {
+			t.Errorf("test %d: replacement mismatch: have %v, want %v", i, inserted, tt.replaced)
+		}
+	}
+}
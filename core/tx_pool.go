@@ -62,6 +62,10 @@ var (
 	ErrInsufficientMinFunds = errors.New("insufficient funds for 0.01 etz")
 	ErrInsufficientPower = errors.New("insufficient power for gas * price")
 
+	// ErrPowerSlotsExceeded is returned if a remote account attempts to pool more
+	// power funded transactions than the per account allowance.
+	ErrPowerSlotsExceeded = errors.New("exceeds account power slots")
+
 	// ErrIntrinsicGas is returned if the transaction is specified to use less gas
 	// than required to start the invocation.
 	ErrIntrinsicGas = errors.New("intrinsic gas too low")
@@ -101,6 +105,10 @@ var (
 	// General tx metrics
 	invalidTxCounter     = metrics.NewRegisteredCounter("txpool/invalid", nil)
 	underpricedTxCounter = metrics.NewRegisteredCounter("txpool/underpriced", nil)
+
+	// Metrics for power funded transactions
+	powerRejectedTxCounter = metrics.NewRegisteredCounter("txpool/power/rejected", nil) // Rejected due to insufficient power
+	powerLimitedTxCounter  = metrics.NewRegisteredCounter("txpool/power/limited", nil)  // Rejected due to the account power slots
 )
 
 // TxStatus is the current status of a transaction as seen by the pool.
@@ -119,6 +127,7 @@ type blockChain interface {
 	CurrentBlock() *types.Block
 	GetBlock(hash common.Hash, number uint64) *types.Block
 	StateAt(root common.Hash) (*state.StateDB, error)
+	HasTransaction(hash common.Hash) bool
 
 	SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription
 }
@@ -138,7 +147,12 @@ type TxPoolConfig struct {
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
+	AccountPowerSlots uint64 // Maximum number of transactions (power funded or fee-free) permitted per remote account
+
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+	History  time.Duration // Amount of time the lifecycle of transactions that left the pool is retained
+
+	HistoryJournal string // Journal of transaction lifecycle records to survive node restarts
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -155,7 +169,12 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	AccountQueue: 64,
 	GlobalQueue:  1024,
 
+	AccountPowerSlots: 32,
+
 	Lifetime: 30 * time.Minute,
+	History:  time.Hour,
+
+	HistoryJournal: "txhistory.rlp",
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -190,10 +209,18 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool global queue", "provided", conf.GlobalQueue, "updated", DefaultTxPoolConfig.GlobalQueue)
 		conf.GlobalQueue = DefaultTxPoolConfig.GlobalQueue
 	}
+	if conf.AccountPowerSlots < 1 {
+		log.Warn("Sanitizing invalid txpool account power slots", "provided", conf.AccountPowerSlots, "updated", DefaultTxPoolConfig.AccountPowerSlots)
+		conf.AccountPowerSlots = DefaultTxPoolConfig.AccountPowerSlots
+	}
 	if conf.Lifetime < 1 {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultTxPoolConfig.Lifetime)
 		conf.Lifetime = DefaultTxPoolConfig.Lifetime
 	}
+	if conf.History < 1 {
+		log.Warn("Sanitizing invalid txpool history", "provided", conf.History, "updated", DefaultTxPoolConfig.History)
+		conf.History = DefaultTxPoolConfig.History
+	}
 	return conf
 }
 
@@ -208,13 +235,14 @@ type TxPool struct {
 	config       TxPoolConfig
 	chainconfig  *params.ChainConfig
 	chain        blockChain
-	gasPrice     *big.Int
-	txFeed       event.Feed
-	scope        event.SubscriptionScope
-	chainHeadCh  chan ChainHeadEvent
-	chainHeadSub event.Subscription
-	signer       types.Signer
-	mu           sync.RWMutex
+	gasPrice      *big.Int
+	txFeed        event.Feed
+	lifecycleFeed event.Feed
+	scope         event.SubscriptionScope
+	chainHeadCh   chan ChainHeadEvent
+	chainHeadSub  event.Subscription
+	signer        types.Signer
+	mu            sync.RWMutex
 
 	currentState  *state.StateDB      // Current state in the blockchain head
 	pendingState  *state.ManagedState // Pending state tracking virtual nonces
@@ -228,8 +256,14 @@ type TxPool struct {
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
 	all     *txLookup                    // All transactions to allow lookups
 	priced  *txPricedList                // All transactions sorted by price
+	history *txHistory                   // Lifecycle of all transactions that went through the pool
+
+	lifecycleQueue []TxLifecycleEvent // Lifecycle announcements not yet delivered, oldest first
+	lifecycleLock  sync.Mutex         // Protects the lifecycle announcement queue
+	lifecycleWake  chan struct{}      // Notification channel of queued lifecycle announcements
 
-	wg sync.WaitGroup // for shutdown sync
+	wg   sync.WaitGroup // for shutdown sync
+	quit chan struct{}  // Quit channel of the lifecycle announcer
 
 	homestead bool
 }
@@ -242,16 +276,19 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 
 	// Create the transaction pool with its initial settings
 	pool := &TxPool{
-		config:      config,
-		chainconfig: chainconfig,
-		chain:       chain,
-		signer:      types.NewEIP155Signer(chainconfig.ChainID),
-		pending:     make(map[common.Address]*txList),
-		queue:       make(map[common.Address]*txList),
-		beats:       make(map[common.Address]time.Time),
-		all:         newTxLookup(),
-		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
-		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
+		config:        config,
+		chainconfig:   chainconfig,
+		chain:         chain,
+		signer:        types.NewEIP155Signer(chainconfig.ChainID),
+		pending:       make(map[common.Address]*txList),
+		queue:         make(map[common.Address]*txList),
+		beats:         make(map[common.Address]time.Time),
+		all:           newTxLookup(),
+		history:       newTxHistory(config.History),
+		lifecycleWake: make(chan struct{}, 1),
+		quit:          make(chan struct{}),
+		chainHeadCh:   make(chan ChainHeadEvent, chainHeadChanSize),
+		gasPrice:      new(big.Int).SetUint64(config.PriceLimit),
 	}
 	pool.locals = newAccountSet(pool.signer)
 	for _, addr := range config.Locals {
@@ -259,6 +296,13 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		pool.locals.add(addr)
 	}
 	pool.priced = newTxPricedList(pool.all)
+
+	// If lifecycle journaling is enabled, restore the history before any events
+	if config.HistoryJournal != "" {
+		if err := pool.history.load(config.HistoryJournal); err != nil {
+			log.Warn("Failed to load transaction history journal", "err", err)
+		}
+	}
 	pool.reset(nil, chain.CurrentBlock().Header())
 
 	// If local transactions and journaling is enabled, load from disk
@@ -276,8 +320,9 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
 	// Start the event loop and return
-	pool.wg.Add(1)
+	pool.wg.Add(2)
 	go pool.loop()
+	go pool.lifecycleLoop()
 
 	return pool
 }
@@ -314,6 +359,7 @@ func (pool *TxPool) loop() {
 					pool.homestead = true
 				}
 				pool.reset(head.Header(), ev.Block.Header())
+				pool.announceLifecycle()
 				head = ev.Block
 
 				pool.mu.Unlock()
@@ -345,10 +391,13 @@ func (pool *TxPool) loop() {
 				// Any non-locals old enough should be removed
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					for _, tx := range pool.queue[addr].Flatten() {
+						pool.history.dropped(tx.Hash(), errTxExpired)
 						pool.removeTx(tx.Hash(), true)
 					}
 				}
 			}
+			pool.history.expire()
+			pool.announceLifecycle()
 			pool.mu.Unlock()
 
 		// Handle local transaction journal rotation
@@ -360,6 +409,35 @@ func (pool *TxPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+			if pool.config.HistoryJournal != "" {
+				pool.mu.RLock()
+				if err := pool.history.save(pool.config.HistoryJournal); err != nil {
+					log.Warn("Failed to save tx history journal", "err", err)
+				}
+				pool.mu.RUnlock()
+			}
+		}
+	}
+}
+
+// lifecycleLoop delivers the queued lifecycle announcements to the subscribers
+// one after the other, retaining the order in which the changes happened.
+func (pool *TxPool) lifecycleLoop() {
+	defer pool.wg.Done()
+
+	for {
+		select {
+		case <-pool.lifecycleWake:
+			pool.lifecycleLock.Lock()
+			events := pool.lifecycleQueue
+			pool.lifecycleQueue = nil
+			pool.lifecycleLock.Unlock()
+
+			for _, ev := range events {
+				pool.lifecycleFeed.Send(ev)
+			}
+		case <-pool.quit:
+			return
 		}
 	}
 }
@@ -371,14 +449,21 @@ func (pool *TxPool) lockedReset(oldHead, newHead *types.Header) {
 	defer pool.mu.Unlock()
 
 	pool.reset(oldHead, newHead)
+	pool.announceLifecycle()
 }
 
 // reset retrieves the current state of the blockchain and ensures the content
 // of the transaction pool is valid with regard to the chain state.
 func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// If we're reorging an old state, reinject all dropped transactions
-	var reinject types.Transactions
+	var reinject, included types.Transactions
 
+	if oldHead != nil && oldHead.Hash() == newHead.ParentHash {
+		// Head simply advanced, track the transactions included in the new block
+		if block := pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64()); block != nil {
+			included = block.Transactions()
+		}
+	}
 	if oldHead != nil && oldHead.Hash() != newHead.ParentHash {
 		// If the reorg is too deep, avoid doing it (will happen during fast sync)
 		oldNum := oldHead.Number.Uint64()
@@ -388,7 +473,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 			log.Debug("Skipping deep transaction reorg", "depth", depth)
 		} else {
 			// Reorg seems shallow enough to pull in all transactions into memory
-			var discarded, included types.Transactions
+			var discarded types.Transactions
 
 			var (
 				rem = pool.chain.GetBlock(oldHead.Hash(), oldHead.Number.Uint64())
@@ -436,6 +521,10 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.pendingState = state.ManageState(statedb)
 	pool.currentMaxGas = newHead.GasLimit
 
+	// Track the lifecycle of any pooled transactions that made it into the chain
+	for _, tx := range included {
+		pool.history.included(tx.Hash())
+	}
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	senderCacher.recover(pool.signer, reinject)
@@ -464,11 +553,19 @@ func (pool *TxPool) Stop() {
 
 	// Unsubscribe subscriptions registered from blockchain
 	pool.chainHeadSub.Unsubscribe()
+	close(pool.quit)
 	pool.wg.Wait()
 
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	if pool.config.HistoryJournal != "" {
+		pool.mu.RLock()
+		if err := pool.history.save(pool.config.HistoryJournal); err != nil {
+			log.Warn("Failed to save tx history journal", "err", err)
+		}
+		pool.mu.RUnlock()
+	}
 	log.Info("Transaction pool stopped")
 }
 
@@ -494,8 +591,10 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 
 	pool.gasPrice = price
 	for _, tx := range pool.priced.Cap(price, pool.locals) {
+		pool.history.dropped(tx.Hash(), ErrUnderpriced)
 		pool.removeTx(tx.Hash(), false)
 	}
+	pool.announceLifecycle()
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
 
@@ -637,6 +736,43 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	return nil
 }
 
+// validatePower checks whether the sender of a remote transaction can fund it
+// with power alongside all its other pooled transactions, and whether it stays
+// within the power funded transaction slots allowed per account. Fees are never
+// paid from the balance, so every transaction takes a slot, fee-free ones too.
+// Transactions replacing an already pooled one are only accounted for once.
+//
+// Local transactions are exempt, similarly to how they are from pricing limits.
+func (pool *TxPool) validatePower(tx *types.Transaction, local bool) error {
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	if local || pool.locals.contains(from) {
+		return nil
+	}
+	var (
+		spent = new(big.Int).Set(tx.Cost())
+		slots = uint64(1)
+	)
+	for _, list := range []*txList{pool.pending[from], pool.queue[from]} {
+		if list == nil {
+			continue
+		}
+		for nonce, pooled := range list.txs.items {
+			if nonce == tx.Nonce() {
+				continue
+			}
+			spent.Add(spent, pooled.Cost())
+			slots++
+		}
+	}
+	if slots > pool.config.AccountPowerSlots {
+		return ErrPowerSlotsExceeded
+	}
+	if pool.currentState.GetPower(from, pool.chain.CurrentBlock().Number()).Cmp(spent) < 0 {
+		return ErrInsufficientPower
+	}
+	return nil
+}
+
 // add validates a transaction and inserts it into the non-executable queue for
 // later pending promotion and execution. If the transaction is a replacement for
 // an already pending or queued one, it overwrites the previous and returns this
@@ -656,6 +792,19 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	if err := pool.validateTx(tx, local); err != nil {
 		log.Trace("Discarding invalid transaction", "hash", hash, "err", err)
 		invalidTxCounter.Inc(1)
+		if err == ErrInsufficientPower {
+			powerRejectedTxCounter.Inc(1)
+		}
+		return false, err
+	}
+	// If the sender can't fund all its pooled transactions with power, discard it
+	if err := pool.validatePower(tx, local); err != nil {
+		log.Trace("Discarding power exceeding transaction", "hash", hash, "err", err)
+		if err == ErrPowerSlotsExceeded {
+			powerLimitedTxCounter.Inc(1)
+		} else {
+			powerRejectedTxCounter.Inc(1)
+		}
 		return false, err
 	}
 	// If the transaction pool is full, discard underpriced transactions
@@ -671,6 +820,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
+			pool.history.dropped(tx.Hash(), ErrUnderpriced)
 			pool.removeTx(tx.Hash(), false)
 		}
 	}
@@ -687,10 +837,12 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		if old != nil {
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed()
+			pool.history.replaced(old.Hash(), hash)
 			pendingReplaceCounter.Inc(1)
 		}
 		pool.all.Add(tx)
 		pool.priced.Put(tx)
+		pool.history.pending(hash)
 		pool.journalTx(from, tx)
 
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
@@ -737,12 +889,14 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed()
+		pool.history.replaced(old.Hash(), hash)
 		queuedReplaceCounter.Inc(1)
 	}
 	if pool.all.Get(hash) == nil {
 		pool.all.Add(tx)
 		pool.priced.Put(tx)
 	}
+	pool.history.queued(hash)
 	return old != nil, nil
 }
 
@@ -774,6 +928,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		// An older transaction was better, discard this
 		pool.all.Remove(hash)
 		pool.priced.Removed()
+		pool.history.dropped(hash, ErrReplaceUnderpriced)
 
 		pendingDiscardCounter.Inc(1)
 		return false
@@ -782,6 +937,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed()
+		pool.history.replaced(old.Hash(), hash)
 
 		pendingReplaceCounter.Inc(1)
 	}
@@ -790,6 +946,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.all.Add(tx)
 		pool.priced.Put(tx)
 	}
+	pool.history.pending(hash)
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.beats[addr] = time.Now()
 	pool.pendingState.SetNonce(addr, tx.Nonce()+1)
@@ -831,6 +988,7 @@ func (pool *TxPool) AddRemotes(txs []*types.Transaction) []error {
 func (pool *TxPool) addTx(tx *types.Transaction, local bool) error {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
+	defer pool.announceLifecycle()
 
 	// Try to inject the transaction and update any state
 	replace, err := pool.add(tx, local)
@@ -849,6 +1007,7 @@ func (pool *TxPool) addTx(tx *types.Transaction, local bool) error {
 func (pool *TxPool) addTxs(txs []*types.Transaction, local bool) []error {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
+	defer pool.announceLifecycle()
 
 	return pool.addTxsLocked(txs, local)
 }
@@ -898,6 +1057,80 @@ func (pool *TxPool) Status(hashes []common.Hash) []TxStatus {
 	return status
 }
 
+// RemoveTx removes a single transaction from the pool, moving all subsequent
+// transactions back to the future queue, and records the reason of the removal
+// in the lifecycle of the transaction.
+func (pool *TxPool) RemoveTx(hash common.Hash, reason error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.all.Get(hash) == nil {
+		return
+	}
+	pool.history.dropped(hash, reason)
+	pool.removeTx(hash, true)
+	pool.announceLifecycle()
+}
+
+// Lifecycle returns the last known lifecycle stage of a transaction that went
+// through the pool, or nil if it is unknown or its record already expired.
+func (pool *TxPool) Lifecycle(hash common.Hash) *TxLifecycleRecord {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.history.get(hash)
+}
+
+// SubscribeTxLifecycleEvent registers a subscription of TxLifecycleEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeTxLifecycleEvent(ch chan<- TxLifecycleEvent) event.Subscription {
+	return pool.scope.Track(pool.lifecycleFeed.Subscribe(ch))
+}
+
+// announceLifecycle notifies subsystems of the lifecycle changes of transactions
+// since the last announcement. The announcements are queued up for delivery in
+// order by the lifecycle loop, so slow subscribers don't block the pool.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) announceLifecycle() {
+	if records := pool.history.flush(); len(records) > 0 {
+		pool.lifecycleLock.Lock()
+		pool.lifecycleQueue = append(pool.lifecycleQueue, TxLifecycleEvent{records})
+		pool.lifecycleLock.Unlock()
+
+		select {
+		case pool.lifecycleWake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// staleNonce records the lifecycle of a transaction removed due to its nonce
+// becoming stale. Reorgs too deep to be tracked don't report the transactions
+// included, so the chain is consulted whether it's the one that made it in.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) staleNonce(hash common.Hash) {
+	if record := pool.history.records[hash]; record != nil && record.Status != TxLifecycleIncluded && pool.chain.HasTransaction(hash) {
+		pool.history.included(hash)
+		return
+	}
+	pool.history.dropped(hash, ErrNonceTooLow)
+}
+
+// unpayableReason returns the reason a transaction was filtered out of the pool
+// due to the sender's funds or the block gas limit.
+func (pool *TxPool) unpayableReason(addr common.Address, tx *types.Transaction) error {
+	switch {
+	case pool.currentState.GetPower(addr, pool.chain.CurrentBlock().Number()).Cmp(tx.Cost()) < 0:
+		return ErrInsufficientPower
+	case pool.currentState.GetBalance(addr).Cmp(tx.Value()) < 0:
+		return ErrInsufficientFunds
+	default:
+		return ErrGasLimit
+	}
+}
+
 // Get returns a transaction if it is contained in the pool
 // and nil otherwise.
 func (pool *TxPool) Get(hash common.Hash) *types.Transaction {
@@ -973,6 +1206,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			log.Trace("Removed old queued transaction", "hash", hash)
 			pool.all.Remove(hash)
 			pool.priced.Removed()
+			pool.staleNonce(hash)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentState.GetPower(addr, pool.chain.CurrentBlock().Number()), pool.currentMaxGas)
@@ -982,6 +1216,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			log.Trace("Removed unpayable queued transaction", "hash", hash)
 			pool.all.Remove(hash)
 			pool.priced.Removed()
+			pool.history.dropped(hash, pool.unpayableReason(addr, tx))
 			queuedNofundsCounter.Inc(1)
 		}
 		// Gather all executable transactions and promote them
@@ -998,6 +1233,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 				hash := tx.Hash()
 				pool.all.Remove(hash)
 				pool.priced.Removed()
+				pool.history.dropped(hash, errTxRateLimited)
 				queuedRateLimitCounter.Inc(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
@@ -1047,6 +1283,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 							hash := tx.Hash()
 							pool.all.Remove(hash)
 							pool.priced.Removed()
+							pool.history.dropped(hash, errTxRateLimited)
 
 							// Update the account nonce to the dropped transaction
 							if nonce := tx.Nonce(); pool.pendingState.GetNonce(offenders[i]) > nonce {
@@ -1069,6 +1306,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 						hash := tx.Hash()
 						pool.all.Remove(hash)
 						pool.priced.Removed()
+						pool.history.dropped(hash, errTxRateLimited)
 
 						// Update the account nonce to the dropped transaction
 						if nonce := tx.Nonce(); pool.pendingState.GetNonce(addr) > nonce {
@@ -1107,6 +1345,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			// Drop all transactions if they are less than the overflow
 			if size := uint64(list.Len()); size <= drop {
 				for _, tx := range list.Flatten() {
+					pool.history.dropped(tx.Hash(), errTxRateLimited)
 					pool.removeTx(tx.Hash(), true)
 				}
 				drop -= size
@@ -1116,6 +1355,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			// Otherwise drop only last few transactions
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
+				pool.history.dropped(txs[i].Hash(), errTxRateLimited)
 				pool.removeTx(txs[i].Hash(), true)
 				drop--
 				queuedRateLimitCounter.Inc(1)
@@ -1138,6 +1378,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed old pending transaction", "hash", hash)
 			pool.all.Remove(hash)
 			pool.priced.Removed()
+			pool.staleNonce(hash)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr),pool.currentState.GetPower(addr, pool.chain.CurrentBlock().Number()), pool.currentMaxGas)
@@ -1146,6 +1387,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			pool.all.Remove(hash)
 			pool.priced.Removed()
+			pool.history.dropped(hash, pool.unpayableReason(addr, tx))
 			pendingNofundsCounter.Inc(1)
 		}
 		for _, tx := range invalids {
@@ -42,6 +42,7 @@ var testTxPoolConfig TxPoolConfig
 func init() {
 	testTxPoolConfig = DefaultTxPoolConfig
 	testTxPoolConfig.Journal = ""
+	testTxPoolConfig.HistoryJournal = ""
 }
 
 type testBlockChain struct {
@@ -52,6 +53,7 @@ type testBlockChain struct {
 
 func (bc *testBlockChain) CurrentBlock() *types.Block {
 	return types.NewBlock(&types.Header{
+		Number:   new(big.Int),
 		GasLimit: bc.gasLimit,
 	}, nil, nil, nil)
 }
@@ -64,6 +66,10 @@ func (bc *testBlockChain) StateAt(common.Hash) (*state.StateDB, error) {
 	return bc.statedb, nil
 }
 
+func (bc *testBlockChain) HasTransaction(hash common.Hash) bool {
+	return false
+}
+
 func (bc *testBlockChain) SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription {
 	return bc.chainHeadFeed.Subscribe(ch)
 }
@@ -1567,6 +1573,159 @@ func TestTransactionReplacement(t *testing.T) {
 	}
 }
 
+// setupPowerTxPool creates a pool accepting any non-zero gas price, along with
+// an account funded with the given amount of power.
+func setupPowerTxPool(slots uint64, power int64) (*TxPool, *ecdsa.PrivateKey) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.PriceLimit = 1
+	config.AccountPowerSlots = slots
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(addr, big.NewInt(1e18), common.Big0)
+	pool.currentState.SetPower(addr, big.NewInt(power))
+
+	return pool, key
+}
+
+// Tests that remote accounts can't pool more transactions than their power slots
+// allow, fee-free ones included, while replacements and local transactions are
+// not limited.
+func TestTransactionPowerSlots(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPowerTxPool(4, 1e9)
+	defer pool.Stop()
+
+	for i := uint64(0); i < 4; i++ {
+		if err := pool.AddRemote(pricedTransaction(i, 21000, big.NewInt(1), key)); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	if err := pool.AddRemote(pricedTransaction(4, 21000, big.NewInt(1), key)); err != ErrPowerSlotsExceeded {
+		t.Fatalf("slot exhausting transaction error mismatch: have %v, want %v", err, ErrPowerSlotsExceeded)
+	}
+	if err := pool.AddRemote(pricedTransaction(10, 21000, big.NewInt(1), key)); err != ErrPowerSlotsExceeded {
+		t.Fatalf("slot exhausting queued transaction error mismatch: have %v, want %v", err, ErrPowerSlotsExceeded)
+	}
+	// Replacing a pooled transaction doesn't take another slot
+	if err := pool.AddRemote(pricedTransaction(3, 21000, big.NewInt(2), key)); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	// Fee-free transactions take slots too, though locals are exempt
+	if err := pool.addTx(pricedTransaction(4, 21000, big.NewInt(0), key), true); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if pending, _ := pool.Stats(); pending != 5 {
+		t.Fatalf("pending transactions mismatch: have %d, want %d", pending, 5)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// A fresh remote account is limited by fee-free transactions too
+	other, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(other.PublicKey), big.NewInt(1e18), common.Big0)
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	for i := uint64(0); i < 4; i++ {
+		tx := pricedTransaction(i, 21000, big.NewInt(0), other)
+		if err := pool.validatePower(tx, false); err != nil {
+			t.Fatalf("fee-free tx %d: failed to validate power: %v", i, err)
+		}
+		if _, err := pool.enqueueTx(tx.Hash(), tx); err != nil {
+			t.Fatalf("fee-free tx %d: failed to enqueue: %v", i, err)
+		}
+	}
+	if err := pool.validatePower(pricedTransaction(4, 21000, big.NewInt(0), other), false); err != ErrPowerSlotsExceeded {
+		t.Fatalf("fee-free slot exhausting transaction error mismatch: have %v, want %v", err, ErrPowerSlotsExceeded)
+	}
+}
+
+// Tests that remote transactions are rejected once the power of the sender can't
+// fund all of its pooled transactions.
+func TestTransactionPowerRejection(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPowerTxPool(16, 50000)
+	defer pool.Stop()
+
+	if err := pool.AddRemote(pricedTransaction(0, 60000, big.NewInt(1), key)); err != ErrInsufficientPower {
+		t.Fatalf("unfunded transaction error mismatch: have %v, want %v", err, ErrInsufficientPower)
+	}
+	if err := pool.AddRemote(pricedTransaction(0, 30000, big.NewInt(1), key)); err != nil {
+		t.Fatalf("failed to add funded transaction: %v", err)
+	}
+	if err := pool.AddRemote(pricedTransaction(1, 30000, big.NewInt(1), key)); err != ErrInsufficientPower {
+		t.Fatalf("jointly unfunded transaction error mismatch: have %v, want %v", err, ErrInsufficientPower)
+	}
+	// A replacement is only accounted for once
+	if err := pool.AddRemote(pricedTransaction(0, 25000, big.NewInt(1), key)); err != nil {
+		t.Fatalf("failed to replace funded transaction: %v", err)
+	}
+	if err := pool.AddRemote(pricedTransaction(1, 25000, big.NewInt(1), key)); err != nil {
+		t.Fatalf("failed to add jointly funded transaction: %v", err)
+	}
+	// Local transactions are only checked against their own cost
+	if err := pool.addTx(pricedTransaction(2, 25000, big.NewInt(1), key), true); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that pooled transactions can be replaced at the same gas price by ones
+// consuming less power, and fee-free ones by lowering their gas limit.
+func TestTransactionPowerReplacement(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPowerTxPool(16, 1e9)
+	defer pool.Stop()
+
+	bump := int64(testTxPoolConfig.PriceBump)
+	gas := uint64(100000)
+	cheaper := gas * 100 / uint64(100+bump)
+
+	if err := pool.AddRemote(pricedTransaction(0, gas, big.NewInt(10), key)); err != nil {
+		t.Fatalf("failed to add original transaction: %v", err)
+	}
+	if err := pool.AddRemote(pricedTransaction(0, cheaper+1, big.NewInt(10), key)); err != ErrReplaceUnderpriced {
+		t.Fatalf("insufficient power drop error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	if err := pool.AddRemote(pricedTransaction(0, cheaper, big.NewInt(10), key)); err != nil {
+		t.Fatalf("failed to replace with less power consuming transaction: %v", err)
+	}
+	// Fee-free transactions are replaced by dropping the gas limit
+	if err := pool.addTx(pricedTransaction(1, gas, big.NewInt(0), key), true); err != nil {
+		t.Fatalf("failed to add fee-free transaction: %v", err)
+	}
+	if err := pool.addTx(pricedTransaction(1, gas, big.NewInt(0), key), true); err == nil {
+		t.Fatalf("identical fee-free transaction accepted")
+	}
+	if err := pool.addTx(pricedTransaction(1, cheaper+1, big.NewInt(0), key), true); err != ErrReplaceUnderpriced {
+		t.Fatalf("insufficient gas drop error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	replacement := pricedTransaction(1, cheaper, big.NewInt(0), key)
+	if err := pool.addTx(replacement, true); err != nil {
+		t.Fatalf("failed to replace fee-free transaction: %v", err)
+	}
+	if pool.Get(replacement.Hash()) == nil {
+		t.Fatalf("fee-free replacement not pooled")
+	}
+	// A fee-free transaction is replaced by a paying one as well
+	if err := pool.addTx(pricedTransaction(1, gas, big.NewInt(1), key), true); err != nil {
+		t.Fatalf("failed to replace fee-free transaction with paying one: %v", err)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that local transactions are journaled to disk, but remote transactions
 // get discarded between restarts.
 func TestTransactionJournaling(t *testing.T)         { testTransactionJournaling(t, false) }
@@ -1831,4 +1990,99 @@ func benchmarkPoolBatchInsert(b *testing.B, size int) {
 	for _, batch := range batches {
 		pool.AddRemotes(batch)
 	}
-}
\ No newline at end of file
+}
+// includedTestBlockChain is a test chain reporting a configurable set of
+// transactions as included in the canonical chain.
+type includedTestBlockChain struct {
+	*testBlockChain
+	included map[common.Hash]bool
+}
+
+func (bc *includedTestBlockChain) HasTransaction(hash common.Hash) bool {
+	return bc.included[hash]
+}
+
+// Tests that the lifecycle of a transaction is tracked and announced in order
+// as it's added, replaced, dropped and included, even if the inclusion is only
+// noticed through the stale nonce of the transaction.
+func TestTransactionLifecycle(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &includedTestBlockChain{&testBlockChain{statedb, 1000000, new(event.Feed)}, make(map[common.Hash]bool)}
+
+	config := testTxPoolConfig
+	config.PriceLimit = 1
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	events := make(chan TxLifecycleEvent, 32)
+	sub := pool.SubscribeTxLifecycleEvent(events)
+	defer sub.Unsubscribe()
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(addr, big.NewInt(1e18), common.Big0)
+	pool.currentState.SetPower(addr, big.NewInt(1e9))
+
+	var (
+		original = pricedTransaction(0, 100000, big.NewInt(10), key)
+		replaced = pricedTransaction(0, 100000, big.NewInt(20), key)
+		dropped  = pricedTransaction(1, 100000, big.NewInt(2), key)
+	)
+	if err := pool.AddRemote(original); err != nil {
+		t.Fatalf("failed to add original transaction: %v", err)
+	}
+	if err := pool.AddRemote(replaced); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	if err := pool.AddRemote(dropped); err != nil {
+		t.Fatalf("failed to add underpriced transaction: %v", err)
+	}
+	pool.SetGasPrice(big.NewInt(5))
+
+	// Include the replacement without the pool seeing the block, as during deep reorgs
+	blockchain.included[replaced.Hash()] = true
+	pool.currentState.SetNonce(addr, 1)
+	pool.lockedReset(nil, nil)
+
+	want := []struct {
+		hash   common.Hash
+		status TxLifecycle
+	}{
+		{original.Hash(), TxLifecycleReplaced},
+		{dropped.Hash(), TxLifecycleDropped},
+		{replaced.Hash(), TxLifecycleIncluded},
+	}
+	for _, w := range want {
+		if record := pool.Lifecycle(w.hash); record == nil || record.Status != w.status {
+			t.Errorf("transaction %x: lifecycle mismatch: have %v, want %v", w.hash, record, w.status)
+		}
+	}
+	if record := pool.Lifecycle(original.Hash()); record != nil && record.ReplacedBy != replaced.Hash() {
+		t.Errorf("replacement mismatch: have %x, want %x", record.ReplacedBy, replaced.Hash())
+	}
+	if record := pool.Lifecycle(dropped.Hash()); record != nil && record.Reason != ErrUnderpriced.Error() {
+		t.Errorf("drop reason mismatch: have %q, want %q", record.Reason, ErrUnderpriced.Error())
+	}
+	// Ensure the changes were announced in the order they happened
+	var announced []TxLifecycleRecord
+	timeout := time.After(time.Second)
+	for len(announced) < len(want) || announced[len(announced)-1].Status != TxLifecycleIncluded {
+		select {
+		case ev := <-events:
+			announced = append(announced, ev.Records...)
+		case <-timeout:
+			t.Fatalf("lifecycle announcements timed out, have %d records", len(announced))
+		}
+	}
+	next := 0
+	for _, record := range announced {
+		if next < len(want) && record.Hash == want[next].hash && record.Status == want[next].status {
+			next++
+		}
+	}
+	if next != len(want) {
+		t.Errorf("lifecycle announcements out of order: %v", announced)
+	}
+}
@@ -319,12 +319,62 @@ func (s *TxByPrice) Pop() interface{} {
 	return x
 }
 
+// txByPriority implements the heap interface over the head transactions of a
+// set of accounts, ordering them by gas price and breaking ties by the power
+// headroom left to the sender after paying for the transaction. Accounts that
+// would drain less of their power are preferred, so that zero or equally priced
+// power-funded transactions are interleaved fairly between accounts.
+type txByPriority struct {
+	txs     Transactions
+	powers  map[common.Address]*big.Int // Remaining power of each sender, nil for price only ordering
+	senders []common.Address            // Sender of each head transaction
+}
+
+func (s txByPriority) Len() int { return len(s.txs) }
+func (s txByPriority) Less(i, j int) bool {
+	switch s.txs[i].data.Price.Cmp(s.txs[j].data.Price) {
+	case 1:
+		return true
+	case -1:
+		return false
+	}
+	if s.powers == nil {
+		return false
+	}
+	return s.headroom(i).Cmp(s.headroom(j)) > 0
+}
+func (s txByPriority) Swap(i, j int) {
+	s.txs[i], s.txs[j] = s.txs[j], s.txs[i]
+	s.senders[i], s.senders[j] = s.senders[j], s.senders[i]
+}
+
+// headroom returns the power the sender of the i-th head transaction retains
+// after paying for it. Senders with unknown power are treated as having none.
+func (s txByPriority) headroom(i int) *big.Int {
+	power, ok := s.powers[s.senders[i]]
+	if !ok {
+		power = new(big.Int)
+	}
+	return new(big.Int).Sub(power, s.txs[i].Cost())
+}
+
+func (s *txByPriority) Push(x interface{}) {
+	panic("not supported, heads are only fixed or popped")
+}
+
+func (s *txByPriority) Pop() interface{} {
+	n := len(s.txs)
+	x := s.txs[n-1]
+	s.txs, s.senders = s.txs[:n-1], s.senders[:n-1]
+	return x
+}
+
 // TransactionsByPriceAndNonce represents a set of transactions that can return
 // transactions in a profit-maximizing sorted order, while supporting removing
 // entire batches of transactions for non-executable accounts.
 type TransactionsByPriceAndNonce struct {
 	txs    map[common.Address]Transactions // Per account nonce-sorted list of transactions
-	heads  TxByPrice                       // Next transaction for each unique account (price heap)
+	heads  txByPriority                    // Next transaction for each unique account (priority heap)
 	signer Signer                          // Signer for the set of transactions
 }
 
@@ -334,12 +384,29 @@ type TransactionsByPriceAndNonce struct {
 // Note, the input map is reowned so the caller should not interact any more with
 // if after providing it to the constructor.
 func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transactions) *TransactionsByPriceAndNonce {
-	// Initialize a price based heap with the head transactions
-	heads := make(TxByPrice, 0, len(txs))
+	return NewTransactionsByPriorityAndNonce(signer, txs, nil)
+}
+
+// NewTransactionsByPriorityAndNonce creates a transaction set that can retrieve
+// price sorted transactions in a nonce-honouring way, preferring the senders with
+// the most power left after paying for their next transaction amongst equally
+// priced ones. As transactions are shifted out of the set, the power of their
+// senders is consumed accordingly.
+//
+// Note, both input maps are reowned so the caller should not interact any more
+// with them after providing them to the constructor.
+func NewTransactionsByPriorityAndNonce(signer Signer, txs map[common.Address]Transactions, powers map[common.Address]*big.Int) *TransactionsByPriceAndNonce {
+	// Initialize a priority based heap with the head transactions
+	heads := txByPriority{
+		txs:     make(Transactions, 0, len(txs)),
+		powers:  powers,
+		senders: make([]common.Address, 0, len(txs)),
+	}
 	for from, accTxs := range txs {
-		heads = append(heads, accTxs[0])
 		// Ensure the sender address is from the signer
 		acc, _ := Sender(signer, accTxs[0])
+		heads.txs = append(heads.txs, accTxs[0])
+		heads.senders = append(heads.senders, acc)
 		txs[acc] = accTxs[1:]
 		if from != acc {
 			delete(txs, from)
@@ -357,17 +424,20 @@ func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transa
 
 // Peek returns the next transaction by price.
 func (t *TransactionsByPriceAndNonce) Peek() *Transaction {
-	if len(t.heads) == 0 {
+	if len(t.heads.txs) == 0 {
 		return nil
 	}
-	return t.heads[0]
+	return t.heads.txs[0]
 }
 
 // Shift replaces the current best head with the next one from the same account.
 func (t *TransactionsByPriceAndNonce) Shift() {
-	acc, _ := Sender(t.signer, t.heads[0])
+	acc := t.heads.senders[0]
+	if power, ok := t.heads.powers[acc]; ok {
+		t.heads.powers[acc] = new(big.Int).Sub(power, t.heads.txs[0].Cost())
+	}
 	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
-		t.heads[0], t.txs[acc] = txs[0], txs[1:]
+		t.heads.txs[0], t.txs[acc] = txs[0], txs[1:]
 		heap.Fix(&t.heads, 0)
 	} else {
 		heap.Pop(&t.heads)
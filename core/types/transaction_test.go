@@ -177,6 +177,48 @@ func TestTransactionPriceNonceSort(t *testing.T) {
 	}
 }
 
+// Tests that equally priced transactions are ordered by the power left to their
+// senders, consuming the power of a sender as its transactions are retrieved.
+func TestTransactionPriorityNonceSort(t *testing.T) {
+	// Generate a pair of accounts with differing power
+	keys := make([]*ecdsa.PrivateKey, 2)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	signer := HomesteadSigner{}
+
+	groups := map[common.Address]Transactions{}
+	powers := map[common.Address]*big.Int{}
+	for i, key := range keys {
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		for nonce := uint64(0); nonce < 3; nonce++ {
+			tx, _ := SignTx(NewTransaction(nonce, common.Address{}, big.NewInt(100), 100, big.NewInt(1), nil), signer, key)
+			groups[addr] = append(groups[addr], tx)
+		}
+		powers[addr] = big.NewInt(int64(250 + 100*i))
+	}
+	rich := crypto.PubkeyToAddress(keys[1].PublicKey)
+
+	// The richer account should be drained until both have equal headroom
+	txset := NewTransactionsByPriorityAndNonce(signer, groups, powers)
+
+	var senders []common.Address
+	for tx := txset.Peek(); tx != nil; tx = txset.Peek() {
+		from, _ := Sender(signer, tx)
+		senders = append(senders, from)
+		txset.Shift()
+	}
+	if len(senders) != 6 {
+		t.Fatalf("expected %d transactions, found %d", 6, len(senders))
+	}
+	if senders[0] != rich {
+		t.Errorf("first transaction sender mismatch: have %x, want %x", senders[0], rich)
+	}
+	if senders[1] == senders[2] && senders[2] == senders[3] {
+		t.Errorf("transactions of equal headroom not interleaved")
+	}
+}
+
 // TestTransactionJSON tests serializing/de-serializing to/from JSON.
 func TestTransactionJSON(t *testing.T) {
 	key, err := crypto.GenerateKey()
@@ -169,6 +169,17 @@ func (evm *EVM) Cancel() {
 	atomic.StoreInt32(&evm.abort, 1)
 }
 
+// CapturePower reports how the executing message paid for its gas to the tracer,
+// if tracing is enabled and the tracer is interested in it.
+func (evm *EVM) CapturePower(payment *GasPayment) {
+	if !evm.vmConfig.Debug {
+		return
+	}
+	if tracer, ok := evm.vmConfig.Tracer.(PowerTracer); ok {
+		tracer.CapturePower(payment)
+	}
+}
+
 // Interpreter returns the current interpreter
 func (evm *EVM) Interpreter() Interpreter {
 	return evm.interpreter
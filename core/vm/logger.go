@@ -104,6 +104,20 @@ type Tracer interface {
 	CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error
 }
 
+// GasPayment describes how the traced message paid for its gas. Messages always
+// pay for their gas out of the sender's regenerating power, never its balance.
+type GasPayment struct {
+	Cost        *big.Int // Upfront cost of the gas, gas limit times price
+	PowerBefore *big.Int // Power of the sender before buying the gas
+	PowerAfter  *big.Int // Power of the sender after the refund, nil if the gas couldn't be bought
+}
+
+// PowerTracer is implemented by the tracers interested in how the traced message
+// paid for its gas.
+type PowerTracer interface {
+	CapturePower(payment *GasPayment) error
+}
+
 // StructLogger is an EVM state logger and implements Tracer.
 //
 // StructLogger can capture state based on the given Log configuration and also keeps
@@ -114,6 +128,7 @@ type StructLogger struct {
 
 	logs          []StructLog
 	changedValues map[common.Address]Storage
+	payment       *GasPayment
 	output        []byte
 	err           error
 }
@@ -203,9 +218,20 @@ func (l *StructLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration
 	return nil
 }
 
+// CapturePower implements the PowerTracer interface to record how the message
+// paid for its gas.
+func (l *StructLogger) CapturePower(payment *GasPayment) error {
+	cpy := *payment
+	l.payment = &cpy
+	return nil
+}
+
 // StructLogs returns the captured log entries.
 func (l *StructLogger) StructLogs() []StructLog { return l.logs }
 
+// Payment returns how the traced message paid for its gas, if known.
+func (l *StructLogger) Payment() *GasPayment { return l.payment }
+
 // Error returns the VM error captured by the trace.
 func (l *StructLogger) Error() error { return l.err }
 
@@ -85,3 +85,17 @@ func (l *JSONLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration,
 	}
 	return l.encoder.Encode(endLog{common.Bytes2Hex(output), math.HexOrDecimal64(gasUsed), t, ""})
 }
+
+// CapturePower outputs how the message paid for its gas.
+func (l *JSONLogger) CapturePower(payment *GasPayment) error {
+	type powerLog struct {
+		Cost        *math.HexOrDecimal256 `json:"gasCost"`
+		PowerBefore *math.HexOrDecimal256 `json:"powerBefore"`
+		PowerAfter  *math.HexOrDecimal256 `json:"powerAfter,omitempty"`
+	}
+	return l.encoder.Encode(powerLog{
+		Cost:        (*math.HexOrDecimal256)(payment.Cost),
+		PowerBefore: (*math.HexOrDecimal256)(payment.PowerBefore),
+		PowerAfter:  (*math.HexOrDecimal256)(payment.PowerAfter),
+	})
+}
@@ -68,3 +68,19 @@ func TestStoreCapture(t *testing.T) {
 		t.Errorf("expected %x, got %x", exp, logger.changedValues[contract.Address()][index])
 	}
 }
+
+// Tests that gas payments are only reported to power aware tracers when tracing
+// is enabled.
+func TestPowerCapture(t *testing.T) {
+	payment := &GasPayment{Cost: big.NewInt(21000), PowerBefore: big.NewInt(50000), PowerAfter: big.NewInt(29000)}
+
+	logger := NewStructLogger(nil)
+	NewEVM(Context{}, &dummyStatedb{}, params.TestChainConfig, Config{Tracer: logger}).CapturePower(payment)
+	if logger.Payment() != nil {
+		t.Fatalf("payment captured with tracing disabled: %+v", logger.Payment())
+	}
+	NewEVM(Context{}, &dummyStatedb{}, params.TestChainConfig, Config{Debug: true, Tracer: logger}).CapturePower(payment)
+	if have := logger.Payment(); have == nil || have.PowerBefore.Cmp(payment.PowerBefore) != 0 || have.PowerAfter.Cmp(payment.PowerAfter) != 0 {
+		t.Fatalf("payment mismatch: have %+v, want %+v", have, payment)
+	}
+}
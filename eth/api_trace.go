@@ -0,0 +1,594 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/rawdb"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/eth/tracers"
+	"github.com/etherzero/go-etherzero/rpc"
+)
+
+// maxTraceFilterBlocks is the maximum number of blocks a single trace_filter
+// request is allowed to scan.
+const maxTraceFilterBlocks = 1024
+
+var (
+	errTraceFilterRange   = errors.New("invalid block range")
+	errTraceFilterTooWide = fmt.Errorf("block range exceeds %d blocks", maxTraceFilterBlocks)
+	errVMTraceUnsupported = errors.New("vmTrace not supported")
+)
+
+// callTraceFrame is a single call of the call tracer's output.
+type callTraceFrame struct {
+	Type    string            `json:"type"`
+	From    *common.Address   `json:"from"`
+	To      *common.Address   `json:"to"`
+	Value   *hexutil.Big      `json:"value"`
+	Gas     *hexutil.Uint64   `json:"gas"`
+	GasUsed *hexutil.Uint64   `json:"gasUsed"`
+	Input   hexutil.Bytes     `json:"input"`
+	Output  hexutil.Bytes     `json:"output"`
+	Error   string            `json:"error"`
+	Calls   []*callTraceFrame `json:"calls"`
+}
+
+// ParityTraceAction is the invocation of a single flattened trace.
+type ParityTraceAction struct {
+	CallType      string          `json:"callType,omitempty"`
+	From          *common.Address `json:"from,omitempty"`
+	To            *common.Address `json:"to,omitempty"`
+	Gas           *hexutil.Uint64 `json:"gas,omitempty"`
+	Input         *hexutil.Bytes  `json:"input,omitempty"`
+	Init          *hexutil.Bytes  `json:"init,omitempty"`
+	Value         *hexutil.Big    `json:"value,omitempty"`
+	Address       *common.Address `json:"address,omitempty"`
+	RefundAddress *common.Address `json:"refundAddress,omitempty"`
+	Balance       *hexutil.Big    `json:"balance,omitempty"`
+}
+
+// ParityTraceResult is the outcome of a single successful flattened trace.
+type ParityTraceResult struct {
+	Address *common.Address `json:"address,omitempty"`
+	Code    *hexutil.Bytes  `json:"code,omitempty"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Output  *hexutil.Bytes  `json:"output,omitempty"`
+}
+
+// ParityTrace is a single call of a transaction, flattened out of the call tree
+// and positioned within it by its trace address.
+type ParityTrace struct {
+	Action              ParityTraceAction  `json:"action"`
+	BlockHash           *common.Hash       `json:"blockHash,omitempty"`
+	BlockNumber         *uint64            `json:"blockNumber,omitempty"`
+	Error               string             `json:"error,omitempty"`
+	Result              *ParityTraceResult `json:"result,omitempty"`
+	Subtraces           int                `json:"subtraces"`
+	TraceAddress        []int              `json:"traceAddress"`
+	TransactionHash     *common.Hash       `json:"transactionHash,omitempty"`
+	TransactionPosition *uint64            `json:"transactionPosition,omitempty"`
+	Type                string             `json:"type"`
+}
+
+// TraceFilterArgs are the criteria of the traces to retrieve with trace_filter.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+// AccountDiff is the change of an account's state made by a transaction. Each
+// field is either "=" if unchanged, {"+": value} if created, {"-": value} if
+// deleted or {"*": {"from": old, "to": new}} if modified.
+type AccountDiff struct {
+	Balance interface{}                 `json:"balance"`
+	Power   interface{}                 `json:"power"`
+	Code    interface{}                 `json:"code"`
+	Nonce   interface{}                 `json:"nonce"`
+	Storage map[common.Hash]interface{} `json:"storage"`
+}
+
+// TraceResults is the replayed execution of a single transaction.
+type TraceResults struct {
+	Output          hexutil.Bytes                   `json:"output"`
+	StateDiff       map[common.Address]*AccountDiff `json:"stateDiff"`
+	Trace           []*ParityTrace                  `json:"trace"`
+	VMTrace         interface{}                     `json:"vmTrace"`
+	TransactionHash common.Hash                     `json:"transactionHash"`
+}
+
+// PrivateTraceAPI is the collection of Parity compatible tracing APIs, exposing
+// the call trees of transactions as flat lists of traces.
+type PrivateTraceAPI struct {
+	debug *PrivateDebugAPI
+}
+
+// NewPrivateTraceAPI creates a new API definition for the Parity compatible
+// tracing methods, built on top of the debug tracers.
+func NewPrivateTraceAPI(debug *PrivateDebugAPI) *PrivateTraceAPI {
+	return &PrivateTraceAPI{debug: debug}
+}
+
+// Block returns the flattened call traces of all the transactions in a block.
+func (api *PrivateTraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]*ParityTrace, error) {
+	block, err := api.blockByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	return api.traceBlock(ctx, block)
+}
+
+// Transaction returns the flattened call traces of a single transaction.
+func (api *PrivateTraceAPI) Transaction(ctx context.Context, hash common.Hash) ([]*ParityTrace, error) {
+	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(api.debug.eth.ChainDb(), hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %#x not found", hash)
+	}
+	tracer := "callTracer"
+	res, err := api.debug.TraceTransaction(ctx, hash, &TraceConfig{Tracer: &tracer})
+	if err != nil {
+		return nil, err
+	}
+	frame, err := decodeCallTrace(res)
+	if err != nil {
+		return nil, err
+	}
+	traces := flattenCallTrace(frame, nil, nil)
+	for _, trace := range traces {
+		trace.BlockHash, trace.BlockNumber = &blockHash, &blockNumber
+		trace.TransactionHash, trace.TransactionPosition = &hash, &index
+	}
+	return traces, nil
+}
+
+// Filter returns the flattened call traces of a block range matching the given
+// sender and recipient addresses.
+func (api *PrivateTraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]*ParityTrace, error) {
+	head := api.debug.eth.blockchain.CurrentBlock().NumberU64()
+
+	from, to := uint64(0), head
+	if args.FromBlock != nil && *args.FromBlock >= 0 {
+		from = uint64(*args.FromBlock)
+	}
+	if args.ToBlock != nil && *args.ToBlock >= 0 {
+		to = uint64(*args.ToBlock)
+	}
+	if from > to || to > head {
+		return nil, errTraceFilterRange
+	}
+	if to-from >= maxTraceFilterBlocks {
+		return nil, errTraceFilterTooWide
+	}
+	if args.Count != nil && *args.Count == 0 {
+		return []*ParityTrace{}, nil
+	}
+	var (
+		senders    = make(map[common.Address]bool)
+		recipients = make(map[common.Address]bool)
+	)
+	for _, addr := range args.FromAddress {
+		senders[addr] = true
+	}
+	for _, addr := range args.ToAddress {
+		recipients[addr] = true
+	}
+	// Trace the requested blocks one by one, keeping the matching traces
+	var (
+		matches []*ParityTrace
+		skipped uint64
+	)
+	for number := from; number <= to; number++ {
+		block := api.debug.eth.blockchain.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		if len(block.Transactions()) == 0 {
+			continue
+		}
+		traces, err := api.traceBlock(ctx, block)
+		if err != nil {
+			return nil, err
+		}
+		for _, trace := range traces {
+			if len(senders) > 0 && !senders[traceSender(trace)] {
+				continue
+			}
+			if len(recipients) > 0 && !recipients[traceRecipient(trace)] {
+				continue
+			}
+			if args.After != nil && skipped < *args.After {
+				skipped++
+				continue
+			}
+			matches = append(matches, trace)
+			if args.Count != nil && uint64(len(matches)) >= *args.Count {
+				return matches, nil
+			}
+		}
+	}
+	return matches, nil
+}
+
+// ReplayBlockTransactions reexecutes all the transactions in a block, returning
+// the requested trace types of each: "trace" for the flattened call traces and
+// "stateDiff" for the changes made to the state.
+func (api *PrivateTraceAPI) ReplayBlockTransactions(ctx context.Context, number rpc.BlockNumber, traceTypes []string) ([]*TraceResults, error) {
+	var wantTrace, wantDiff bool
+	for _, typ := range traceTypes {
+		switch typ {
+		case "trace":
+			wantTrace = true
+		case "stateDiff":
+			wantDiff = true
+		case "vmTrace":
+			return nil, errVMTraceUnsupported
+		default:
+			return nil, fmt.Errorf("unknown trace type %q", typ)
+		}
+	}
+	block, err := api.blockByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	parent := api.debug.eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	statedb, err := api.debug.computeStateDB(parent, defaultTraceReexec)
+	if err != nil {
+		return nil, err
+	}
+	// Execute all the transactions sequentially, diffing the state after each
+	var (
+		signer  = types.MakeSigner(api.debug.config, block.Number())
+		results = make([]*TraceResults, len(block.Transactions()))
+	)
+	for i, tx := range block.Transactions() {
+		msg, _ := tx.AsMessage(signer)
+		vmctx := core.NewEVMContext(msg, block.Header(), api.debug.eth.blockchain, nil)
+
+		var prestate *state.StateDB
+		if wantDiff {
+			prestate = statedb.Copy()
+		}
+		calls, _ := tracers.NewTracer("callTracer")
+		touched, _ := tracers.NewTracer("prestateTracer")
+
+		output, err := api.replayTx(ctx, msg, vmctx, statedb, teeTracer{calls, touched})
+		if err != nil {
+			return nil, fmt.Errorf("transaction %#x failed: %v", tx.Hash(), err)
+		}
+		statedb.Finalise(api.debug.config.IsEIP158(block.Number()))
+
+		results[i] = &TraceResults{Output: output, TransactionHash: tx.Hash()}
+		if wantTrace {
+			res, err := calls.GetResult()
+			if err != nil {
+				return nil, err
+			}
+			frame, err := decodeCallTrace(res)
+			if err != nil {
+				return nil, err
+			}
+			results[i].Trace = flattenCallTrace(frame, nil, nil)
+		}
+		if wantDiff {
+			res, err := touched.GetResult()
+			if err != nil {
+				return nil, err
+			}
+			if results[i].StateDiff, err = diffState(res, msg, block.Number(), prestate, statedb); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return results, nil
+}
+
+// blockByNumber retrieves a block by number, resolving the special pending and
+// latest block numbers.
+func (api *PrivateTraceAPI) blockByNumber(number rpc.BlockNumber) (*types.Block, error) {
+	var block *types.Block
+
+	switch number {
+	case rpc.PendingBlockNumber:
+		block = api.debug.eth.miner.PendingBlock()
+	case rpc.LatestBlockNumber:
+		block = api.debug.eth.blockchain.CurrentBlock()
+	default:
+		block = api.debug.eth.blockchain.GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	return block, nil
+}
+
+// traceBlock runs the call tracer over all the transactions in a block, and
+// flattens the resulting call trees.
+func (api *PrivateTraceAPI) traceBlock(ctx context.Context, block *types.Block) ([]*ParityTrace, error) {
+	tracer := "callTracer"
+	results, err := api.debug.traceBlock(ctx, block, &TraceConfig{Tracer: &tracer})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		traces    []*ParityTrace
+		blockHash = block.Hash()
+		number    = block.NumberU64()
+	)
+	for i, tx := range block.Transactions() {
+		if results[i].Error != "" {
+			return nil, fmt.Errorf("transaction %#x trace failed: %v", tx.Hash(), results[i].Error)
+		}
+		frame, err := decodeCallTrace(results[i].Result)
+		if err != nil {
+			return nil, err
+		}
+		hash, index := tx.Hash(), uint64(i)
+		for _, trace := range flattenCallTrace(frame, nil, nil) {
+			trace.BlockHash, trace.BlockNumber = &blockHash, &number
+			trace.TransactionHash, trace.TransactionPosition = &hash, &index
+			traces = append(traces, trace)
+		}
+	}
+	return traces, nil
+}
+
+// replayTx executes a message with the given tracer attached, aborting it if the
+// request is cancelled or the default trace timeout is reached.
+func (api *PrivateTraceAPI) replayTx(ctx context.Context, msg core.Message, vmctx vm.Context, statedb *state.StateDB, tracer teeTracer) (hexutil.Bytes, error) {
+	deadlineCtx, cancel := context.WithTimeout(ctx, defaultTraceTimeout)
+	go func() {
+		<-deadlineCtx.Done()
+		tracer.Stop(errors.New("execution timeout"))
+	}()
+	defer cancel()
+
+	vmenv := vm.NewEVM(vmctx, statedb, api.debug.config, vm.Config{Debug: true, Tracer: tracer})
+	ret, _, _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()))
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// decodeCallTrace parses the result of a call tracer run.
+func decodeCallTrace(result interface{}) (*callTraceFrame, error) {
+	blob, ok := result.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected call trace type %T", result)
+	}
+	frame := new(callTraceFrame)
+	if err := json.Unmarshal(blob, frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// flattenCallTrace converts a call tree into a list of traces, depth first, each
+// positioned by the indices of the calls leading to it from the root.
+func flattenCallTrace(frame *callTraceFrame, address []int, traces []*ParityTrace) []*ParityTrace {
+	trace := &ParityTrace{
+		Error:        frame.Error,
+		Subtraces:    len(frame.Calls),
+		TraceAddress: append(make([]int, 0, len(address)), address...),
+	}
+	switch frame.Type {
+	case "CREATE", "CREATE2":
+		trace.Type = "create"
+		trace.Action = ParityTraceAction{From: frame.From, Gas: frame.Gas, Init: &frame.Input, Value: frame.Value}
+		if frame.Error == "" {
+			trace.Result = &ParityTraceResult{Address: frame.To, Code: &frame.Output, GasUsed: frame.GasUsed}
+		}
+	default:
+		trace.Type = "call"
+		trace.Action = ParityTraceAction{CallType: strings.ToLower(frame.Type), From: frame.From, To: frame.To, Gas: frame.Gas, Input: &frame.Input, Value: frame.Value}
+		if frame.Value == nil {
+			trace.Action.Value = new(hexutil.Big)
+		}
+		if frame.Error == "" {
+			trace.Result = &ParityTraceResult{GasUsed: frame.GasUsed, Output: &frame.Output}
+		}
+	}
+	if trace.Action.Gas == nil {
+		trace.Action.Gas = new(hexutil.Uint64)
+	}
+	traces = append(traces, trace)
+
+	for i, call := range frame.Calls {
+		if call.Type == "SELFDESTRUCT" {
+			contract := call.From
+			if contract == nil {
+				contract = frame.To
+			}
+			traces = append(traces, &ParityTrace{
+				Action:       ParityTraceAction{Address: contract, RefundAddress: call.To, Balance: call.Value},
+				TraceAddress: append(append(make([]int, 0, len(address)+1), address...), i),
+				Type:         "suicide",
+			})
+			continue
+		}
+		traces = flattenCallTrace(call, append(address, i), traces)
+	}
+	return traces
+}
+
+// traceSender returns the account originating a trace's call, which is the
+// destructed contract for self-destructs.
+func traceSender(trace *ParityTrace) common.Address {
+	switch {
+	case trace.Action.From != nil:
+		return *trace.Action.From
+	case trace.Action.Address != nil:
+		return *trace.Action.Address
+	}
+	return common.Address{}
+}
+
+// traceRecipient returns the account receiving a trace's call, which is the new
+// contract for creations and the refunded account for self-destructs.
+func traceRecipient(trace *ParityTrace) common.Address {
+	switch {
+	case trace.Action.To != nil:
+		return *trace.Action.To
+	case trace.Result != nil && trace.Result.Address != nil:
+		return *trace.Result.Address
+	case trace.Action.RefundAddress != nil:
+		return *trace.Action.RefundAddress
+	}
+	return common.Address{}
+}
+
+// touchedAccount is an account reported by the prestate tracer, along with the
+// storage slots accessed.
+type touchedAccount struct {
+	Storage map[common.Hash]json.RawMessage `json:"storage"`
+}
+
+// diffState computes the changes a transaction made to the accounts and storage
+// slots it touched, as reported by the prestate tracer. Power is evaluated at the
+// given block number, so only the gas paid is reflected, not the regeneration.
+func diffState(touched json.RawMessage, msg core.Message, number *big.Int, pre, post *state.StateDB) (map[common.Address]*AccountDiff, error) {
+	var accounts map[common.Address]*touchedAccount
+	if err := json.Unmarshal(touched, &accounts); err != nil {
+		return nil, err
+	}
+	// The prestate tracer omits the contract created by the transaction, add it back
+	if msg.To() == nil {
+		addr := crypto.CreateAddress(msg.From(), msg.Nonce())
+		if _, ok := accounts[addr]; !ok {
+			accounts[addr] = new(touchedAccount)
+		}
+	}
+	diffs := make(map[common.Address]*AccountDiff)
+	for addr, account := range accounts {
+		var (
+			existed = pre.Exist(addr)
+			exists  = post.Exist(addr)
+		)
+		if !existed && !exists {
+			continue
+		}
+		diff := &AccountDiff{
+			Balance: diffValue(existed, exists, (*hexutil.Big)(pre.GetBalance(addr)), (*hexutil.Big)(post.GetBalance(addr))),
+			Power:   diffValue(existed, exists, (*hexutil.Big)(pre.GetPower(addr, number)), (*hexutil.Big)(post.GetPower(addr, number))),
+			Code:    diffValue(existed, exists, hexutil.Bytes(pre.GetCode(addr)), hexutil.Bytes(post.GetCode(addr))),
+			Nonce:   diffValue(existed, exists, hexutil.Uint64(pre.GetNonce(addr)), hexutil.Uint64(post.GetNonce(addr))),
+			Storage: make(map[common.Hash]interface{}),
+		}
+		for key := range account.Storage {
+			before, after := pre.GetState(addr, key), post.GetState(addr, key)
+			if existed && exists && before == after {
+				continue
+			}
+			diff.Storage[key] = diffValue(existed, exists, before, after)
+		}
+		if diff.Balance == "=" && diff.Power == "=" && diff.Code == "=" && diff.Nonce == "=" && len(diff.Storage) == 0 {
+			continue
+		}
+		diffs[addr] = diff
+	}
+	return diffs, nil
+}
+
+// diffValue formats the change of a single state field.
+func diffValue(existed, exists bool, before, after interface{}) interface{} {
+	switch {
+	case !existed:
+		return map[string]interface{}{"+": after}
+	case !exists:
+		return map[string]interface{}{"-": before}
+	}
+	prev, _ := json.Marshal(before)
+	next, _ := json.Marshal(after)
+	if string(prev) == string(next) {
+		return "="
+	}
+	return map[string]interface{}{"*": map[string]interface{}{"from": before, "to": after}}
+}
+
+// teeTracer feeds the same execution to multiple tracers.
+type teeTracer []tracers.ResultTracer
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t teeTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	for _, tracer := range t {
+		tracer.CaptureStart(from, to, create, input, gas, value)
+	}
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t teeTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	for _, tracer := range t {
+		tracer.CaptureState(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t teeTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	for _, tracer := range t {
+		tracer.CaptureFault(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+	}
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t teeTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	for _, tracer := range t {
+		tracer.CaptureEnd(output, gasUsed, d, err)
+	}
+	return nil
+}
+
+// CapturePower implements the PowerTracer interface to forward how the message
+// paid for its gas to the tracers interested in it.
+func (t teeTracer) CapturePower(payment *vm.GasPayment) error {
+	for _, tracer := range t {
+		if tracer, ok := tracer.(vm.PowerTracer); ok {
+			tracer.CapturePower(payment)
+		}
+	}
+	return nil
+}
+
+// Stop terminates execution of all the tracers at the first opportune moment.
+func (t teeTracer) Stop(err error) {
+	for _, tracer := range t {
+		tracer.Stop(err)
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus/ethash"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+	"github.com/etherzero/go-etherzero/rpc"
+)
+
+// Tests that call trees are flattened depth first, with each trace positioned
+// by its trace address.
+func TestFlattenCallTrace(t *testing.T) {
+	blob := json.RawMessage(`{
+		"type": "CALL", "from": "0x0000000000000000000000000000000000000001", "to": "0x0000000000000000000000000000000000000002",
+		"value": "0x0", "gas": "0x100", "gasUsed": "0x50", "input": "0x", "output": "0x",
+		"calls": [
+			{"type": "CALL", "from": "0x0000000000000000000000000000000000000002", "to": "0x0000000000000000000000000000000000000003",
+			 "value": "0x1", "gas": "0x10", "gasUsed": "0x5", "input": "0x", "output": "0x",
+			 "calls": [{"type": "SELFDESTRUCT", "from": "0x0000000000000000000000000000000000000003", "to": "0x0000000000000000000000000000000000000006", "value": "0x7"}]},
+			{"type": "CREATE", "from": "0x0000000000000000000000000000000000000002", "to": "0x0000000000000000000000000000000000000004",
+			 "value": "0x0", "gas": "0x20", "gasUsed": "0x10", "input": "0x00", "output": "0x01"},
+			{"type": "STATICCALL", "from": "0x0000000000000000000000000000000000000002", "to": "0x0000000000000000000000000000000000000005",
+			 "gas": "0x10", "gasUsed": "0x10", "input": "0x", "error": "out of gas"}
+		]
+	}`)
+	frame, err := decodeCallTrace(blob)
+	if err != nil {
+		t.Fatalf("failed to decode call trace: %v", err)
+	}
+	traces := flattenCallTrace(frame, nil, nil)
+
+	want := []struct {
+		typ       string
+		address   []int
+		subtraces int
+		to        common.Address
+		result    bool
+	}{
+		{"call", []int{}, 3, common.HexToAddress("0x02"), true},
+		{"call", []int{0}, 1, common.HexToAddress("0x03"), true},
+		{"suicide", []int{0, 0}, 0, common.HexToAddress("0x06"), false},
+		{"create", []int{1}, 0, common.HexToAddress("0x04"), true},
+		{"call", []int{2}, 0, common.HexToAddress("0x05"), false},
+	}
+	if len(traces) != len(want) {
+		t.Fatalf("trace count mismatch: have %d, want %d", len(traces), len(want))
+	}
+	for i, trace := range traces {
+		if trace.Type != want[i].typ {
+			t.Errorf("trace %d: type mismatch: have %s, want %s", i, trace.Type, want[i].typ)
+		}
+		if !reflect.DeepEqual(trace.TraceAddress, want[i].address) {
+			t.Errorf("trace %d: address mismatch: have %v, want %v", i, trace.TraceAddress, want[i].address)
+		}
+		if trace.Subtraces != want[i].subtraces {
+			t.Errorf("trace %d: subtraces mismatch: have %d, want %d", i, trace.Subtraces, want[i].subtraces)
+		}
+		if to := traceRecipient(trace); to != want[i].to {
+			t.Errorf("trace %d: recipient mismatch: have %x, want %x", i, to, want[i].to)
+		}
+		if result := trace.Result != nil; result != want[i].result {
+			t.Errorf("trace %d: result mismatch: have %v, want %v", i, result, want[i].result)
+		}
+	}
+	// Self-destructs report the destructed contract and the refunded balance
+	suicide := traces[2].Action
+	if suicide.Address == nil || *suicide.Address != common.HexToAddress("0x03") {
+		t.Errorf("self-destruct address mismatch: have %v, want %x", suicide.Address, common.HexToAddress("0x03"))
+	}
+	if suicide.Balance == nil || suicide.Balance.ToInt().Int64() != 7 {
+		t.Errorf("self-destruct balance mismatch: have %v, want %d", suicide.Balance, 7)
+	}
+	if sender := traceSender(traces[2]); sender != common.HexToAddress("0x03") {
+		t.Errorf("self-destruct sender mismatch: have %x, want %x", sender, common.HexToAddress("0x03"))
+	}
+}
+
+var (
+	traceTestKey, _  = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	traceTestAddr    = crypto.PubkeyToAddress(traceTestKey.PublicKey)
+	traceTestPayee   = common.HexToAddress("0x0000000000000000000000000000000000000aaa")
+	traceTestRefunds = common.HexToAddress("0x0000000000000000000000000000000000000bbb")
+)
+
+// newTestTraceAPI creates a trace API over a chain of three blocks: a transfer,
+// the creation of a contract destructing itself on deployment, and another
+// transfer.
+func newTestTraceAPI(t *testing.T) (*PrivateTraceAPI, *core.BlockChain) {
+	var (
+		db     = ethdb.NewMemDatabase()
+		engine = ethash.NewFaker()
+		gspec  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Number: params.GenesisBlockNumber,
+			Alloc:  core.GenesisAlloc{traceTestAddr: {Balance: big.NewInt(1e18)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.HomesteadSigner{}
+	)
+	// Init code: PUSH20 <refunds> SELFDESTRUCT
+	initcode := append(append([]byte{byte(vm.PUSH20)}, traceTestRefunds.Bytes()...), byte(vm.SELFDESTRUCT))
+
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, engine, db, 3, func(i int, b *core.BlockGen) {
+		var tx *types.Transaction
+		switch i {
+		case 0, 2:
+			tx = types.NewTransaction(b.TxNonce(traceTestAddr), traceTestPayee, big.NewInt(1000), 21000, big.NewInt(1), nil)
+		case 1:
+			tx = types.NewContractCreation(b.TxNonce(traceTestAddr), big.NewInt(500), 100000, big.NewInt(1), initcode)
+		}
+		tx, _ = types.SignTx(tx, signer, traceTestKey)
+		b.AddTx(tx)
+	})
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, engine, vm.Config{}, nil)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	eth := &Ethereum{blockchain: chain, chainDb: db, engine: engine}
+	return NewPrivateTraceAPI(NewPrivateDebugAPI(gspec.Config, eth)), chain
+}
+
+// Tests that trace_filter selects the traces by sender, recipient and position.
+func TestTraceFilter(t *testing.T) {
+	api, chain := newTestTraceAPI(t)
+
+	var (
+		first   = rpc.BlockNumber(chain.CurrentBlock().NumberU64() - 2)
+		last    = rpc.BlockNumber(chain.CurrentBlock().NumberU64())
+		created = crypto.CreateAddress(traceTestAddr, 1)
+	)
+	count := func(n uint64) *uint64 { return &n }
+
+	tests := []struct {
+		args  TraceFilterArgs
+		types []string
+	}{
+		{TraceFilterArgs{}, []string{"call", "create", "suicide", "call"}},
+		{TraceFilterArgs{FromAddress: []common.Address{traceTestAddr}}, []string{"call", "create", "call"}},
+		{TraceFilterArgs{FromAddress: []common.Address{created}}, []string{"suicide"}},
+		{TraceFilterArgs{ToAddress: []common.Address{traceTestPayee}}, []string{"call", "call"}},
+		{TraceFilterArgs{ToAddress: []common.Address{traceTestRefunds}}, []string{"suicide"}},
+		{TraceFilterArgs{ToAddress: []common.Address{created}}, []string{"create"}},
+		{TraceFilterArgs{After: count(1), Count: count(2)}, []string{"create", "suicide"}},
+		{TraceFilterArgs{Count: count(0)}, []string{}},
+	}
+	for i, tt := range tests {
+		tt.args.FromBlock, tt.args.ToBlock = &first, &last
+
+		traces, err := api.Filter(context.Background(), tt.args)
+		if err != nil {
+			t.Fatalf("test %d: failed to filter traces: %v", i, err)
+		}
+		types := make([]string, len(traces))
+		for j, trace := range traces {
+			types[j] = trace.Type
+		}
+		if !reflect.DeepEqual(types, tt.types) {
+			t.Errorf("test %d: traces mismatch: have %v, want %v", i, types, tt.types)
+		}
+	}
+	// Ranges beyond the head or too wide should be rejected
+	beyond := last + 1
+	if _, err := api.Filter(context.Background(), TraceFilterArgs{FromBlock: &first, ToBlock: &beyond}); err != errTraceFilterRange {
+		t.Errorf("beyond head range error mismatch: have %v, want %v", err, errTraceFilterRange)
+	}
+}
+
+// Tests that replaying a block reports the self-destruct trace along with the
+// state changes, power included.
+func TestTraceReplayBlockTransactions(t *testing.T) {
+	api, chain := newTestTraceAPI(t)
+
+	number := rpc.BlockNumber(chain.CurrentBlock().NumberU64() - 1)
+	results, err := api.ReplayBlockTransactions(context.Background(), number, []string{"trace", "stateDiff"})
+	if err != nil {
+		t.Fatalf("failed to replay block: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("result count mismatch: have %d, want %d", len(results), 1)
+	}
+	result := results[0]
+	if len(result.Trace) != 2 || result.Trace[1].Type != "suicide" {
+		t.Fatalf("trace mismatch: have %v", result.Trace)
+	}
+	suicide := result.Trace[1].Action
+	if suicide.RefundAddress == nil || *suicide.RefundAddress != traceTestRefunds {
+		t.Errorf("refund address mismatch: have %v, want %x", suicide.RefundAddress, traceTestRefunds)
+	}
+	if suicide.Balance == nil || suicide.Balance.ToInt().Int64() != 500 {
+		t.Errorf("refunded balance mismatch: have %v, want %d", suicide.Balance, 500)
+	}
+	// The sender paid for gas with power and the refunded account got created
+	sender := result.StateDiff[traceTestAddr]
+	if sender == nil {
+		t.Fatalf("sender missing from state diff")
+	}
+	if _, ok := sender.Power.(map[string]interface{}); !ok {
+		t.Errorf("sender power not changed: have %v", sender.Power)
+	}
+	refunds := result.StateDiff[traceTestRefunds]
+	if refunds == nil {
+		t.Fatalf("refunded account missing from state diff")
+	}
+	if diff, ok := refunds.Balance.(map[string]interface{}); !ok || diff["+"] == nil {
+		t.Errorf("refunded account balance mismatch: have %v", refunds.Balance)
+	}
+	// Unknown and unsupported trace types should be rejected
+	if _, err := api.ReplayBlockTransactions(context.Background(), number, []string{"vmTrace"}); err != errVMTraceUnsupported {
+		t.Errorf("vmTrace error mismatch: have %v, want %v", err, errVMTraceUnsupported)
+	}
+}
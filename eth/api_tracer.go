@@ -714,7 +714,7 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, hash common.Ha
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
 func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, vmctx vm.Context, statedb *state.StateDB, config *TraceConfig) (interface{}, error) {
-	// Assemble the structured logger or the JavaScript tracer
+	// Assemble the structured logger or the native or JavaScript tracer
 	var (
 		tracer vm.Tracer
 		err    error
@@ -728,15 +728,15 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 				return nil, err
 			}
 		}
-		// Constuct the JavaScript tracer to execute with
-		if tracer, err = tracers.New(*config.Tracer); err != nil {
+		// Constuct the native or JavaScript tracer to execute with
+		if tracer, err = tracers.NewTracer(*config.Tracer); err != nil {
 			return nil, err
 		}
 		// Handle timeouts and RPC cancellations
 		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 		go func() {
 			<-deadlineCtx.Done()
-			tracer.(*tracers.Tracer).Stop(errors.New("execution timeout"))
+			tracer.(tracers.ResultTracer).Stop(errors.New("execution timeout"))
 		}()
 		defer cancel()
 
@@ -751,19 +751,30 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 
 	ret, gas, failed, err := core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.Gas()))
 	if err != nil {
+		// Explain failures to buy gas with the sender's power
+		if logger, ok := tracer.(*vm.StructLogger); ok {
+			if payment := logger.Payment(); payment != nil && payment.PowerAfter == nil {
+				return nil, fmt.Errorf("tracing failed: %v: power %v, cost %v", err, payment.PowerBefore, payment.Cost)
+			}
+		}
 		return nil, fmt.Errorf("tracing failed: %v", err)
 	}
 	// Depending on the tracer type, format and return the output
 	switch tracer := tracer.(type) {
 	case *vm.StructLogger:
-		return &ethapi.ExecutionResult{
+		result := &ethapi.ExecutionResult{
 			Gas:         gas,
 			Failed:      failed,
 			ReturnValue: fmt.Sprintf("%x", ret),
 			StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
-		}, nil
+		}
+		if payment := tracer.Payment(); payment != nil {
+			result.PowerBefore = (*hexutil.Big)(payment.PowerBefore)
+			result.PowerAfter = (*hexutil.Big)(payment.PowerAfter)
+		}
+		return result, nil
 
-	case *tracers.Tracer:
+	case tracers.ResultTracer:
 		return tracer.GetResult()
 
 	default:
@@ -0,0 +1,118 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+)
+
+// maxCompactBlocks is the number of recently propagated blocks kept around to
+// serve the transactions missing from the compact blocks sent to peers, as they
+// are propagated before being imported.
+const maxCompactBlocks = 32
+
+// shortTxIndex indexes the pooled transactions by their short ids within the
+// blocks built on a given parent. New transactions are added as they arrive,
+// and the index is only rebuilt from the pool once the parent changes.
+type shortTxIndex struct {
+	salt common.Hash                      // Parent hash the short ids are salted with
+	txs  map[shortTxID]*types.Transaction // Pooled transactions by salted short id
+	lock sync.Mutex
+}
+
+// newShortTxIndex creates an empty index of pooled transactions.
+func newShortTxIndex() *shortTxIndex {
+	return &shortTxIndex{
+		txs: make(map[shortTxID]*types.Transaction),
+	}
+}
+
+// add indexes a batch of newly pooled transactions.
+func (idx *shortTxIndex) add(txs []*types.Transaction) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	for _, tx := range txs {
+		idx.txs[newShortTxID(idx.salt, tx.Hash())] = tx
+	}
+}
+
+// fill looks up the missing transactions of a block with the given parent,
+// reindexing the pool if the block is built on a different parent than the
+// previous one.
+func (idx *shortTxIndex) fill(salt common.Hash, ids []shortTxID, txs []*types.Transaction, pool txPool) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if salt != idx.salt {
+		pending, _ := pool.Pending()
+
+		idx.salt = salt
+		idx.txs = make(map[shortTxID]*types.Transaction)
+		for _, batch := range pending {
+			for _, tx := range batch {
+				idx.txs[newShortTxID(salt, tx.Hash())] = tx
+			}
+		}
+	}
+	for i, id := range ids {
+		if txs[i] == nil {
+			txs[i] = idx.txs[id]
+		}
+	}
+}
+
+// compactTxs reassembles the transactions of a compact block from the ones sent
+// in full and the local pool. Transactions not found are left nil. If the block
+// is already known or queued for import, no transactions are returned.
+func (pm *ProtocolManager) compactTxs(request *compactBlockData) ([]*types.Transaction, error) {
+	txs := make([]*types.Transaction, len(request.TxIDs))
+	for _, prefilled := range request.Prefilled {
+		if prefilled == nil || prefilled.Tx == nil {
+			return nil, fmt.Errorf("prefilled transaction is nil")
+		}
+		if prefilled.Index >= uint64(len(txs)) {
+			return nil, fmt.Errorf("prefilled transaction index %d out of range (%d transactions)", prefilled.Index, len(txs))
+		}
+		txs[prefilled.Index] = prefilled.Tx
+	}
+	hash := request.Header.Hash()
+	if pm.compactBlocks.Contains(hash) || pm.blockchain.HasBlock(hash, request.Header.Number.Uint64()) {
+		return nil, nil
+	}
+	for _, tx := range txs {
+		if tx == nil {
+			// Some transactions weren't sent, look them up in the pool
+			pm.shortTxs.fill(request.Header.ParentHash, request.TxIDs, txs, pm.txpool)
+			break
+		}
+	}
+	return txs, nil
+}
+
+// compactBlock retrieves a block whose transactions a peer may request after
+// receiving it in compact form, either recently propagated or from the chain.
+func (pm *ProtocolManager) compactBlock(hash common.Hash) *types.Block {
+	if block, ok := pm.compactBlocks.Get(hash); ok {
+		return block.(*types.Block)
+	}
+	return pm.blockchain.GetBlockByHash(hash)
+}
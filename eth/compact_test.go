@@ -0,0 +1,107 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/eth/downloader"
+)
+
+// Tests that short transaction ids are salted with the parent of the block.
+func TestShortTxIDSalt(t *testing.T) {
+	hash := common.HexToHash("0x01")
+	if newShortTxID(common.Hash{1}, hash) == newShortTxID(common.Hash{2}, hash) {
+		t.Errorf("short ids not salted per block")
+	}
+	if newShortTxID(common.Hash{1}, hash) != newShortTxID(common.Hash{1}, hash) {
+		t.Errorf("short ids not deterministic")
+	}
+}
+
+// Tests that the short id index picks up newly pooled transactions and is only
+// rebuilt from the pool when the parent changes.
+func TestShortTxIndex(t *testing.T) {
+	var (
+		pool   = new(testTxPool)
+		index  = newShortTxIndex()
+		pooled = newTestTransaction(testAccount, 0, 0)
+		added  = newTestTransaction(testAccount, 1, 0)
+	)
+	pool.AddRemotes([]*types.Transaction{pooled})
+
+	// Reassembling on a new parent should index the pool
+	parent := common.Hash{1}
+	ids := []shortTxID{newShortTxID(parent, pooled.Hash()), newShortTxID(parent, added.Hash())}
+
+	txs := make([]*types.Transaction, len(ids))
+	index.fill(parent, ids, txs, pool)
+	if txs[0] != pooled || txs[1] != nil {
+		t.Fatalf("transactions mismatch: have %v, want [%x <nil>]", txs, pooled.Hash())
+	}
+	// Transactions added afterwards should be found without going to the pool
+	index.add([]*types.Transaction{added})
+
+	txs = make([]*types.Transaction, len(ids))
+	index.fill(parent, ids, txs, new(testTxPool))
+	if txs[0] != pooled || txs[1] != added {
+		t.Fatalf("transactions mismatch: have %v, want [%x %x]", txs, pooled.Hash(), added.Hash())
+	}
+	// A different parent should drop everything not in the pool
+	parent = common.Hash{2}
+	ids = []shortTxID{newShortTxID(parent, pooled.Hash()), newShortTxID(parent, added.Hash())}
+
+	txs = make([]*types.Transaction, len(ids))
+	index.fill(parent, ids, txs, pool)
+	if txs[0] != pooled || txs[1] != nil {
+		t.Fatalf("transactions mismatch: have %v, want [%x <nil>]", txs, pooled.Hash())
+	}
+}
+
+// Tests that compact blocks already known locally are not reassembled.
+func TestCompactTxsKnownBlock(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 1, nil, nil)
+	defer pm.Stop()
+
+	tx := newTestTransaction(testAccount, 0, 0)
+	pm.txpool.AddRemotes([]*types.Transaction{tx})
+
+	head := pm.blockchain.CurrentBlock()
+	request := &compactBlockData{
+		Header: head.Header(),
+		TxIDs:  []shortTxID{newShortTxID(head.ParentHash(), tx.Hash())},
+		TD:     new(big.Int),
+	}
+	if txs, err := pm.compactTxs(request); err != nil || txs != nil {
+		t.Errorf("known block reassembled: have %v, %v", txs, err)
+	}
+	// An unknown block should be reassembled from the pool
+	header := head.Header()
+	header.Extra = []byte("unknown")
+	request.Header = header
+
+	txs, err := pm.compactTxs(request)
+	if err != nil {
+		t.Fatalf("failed to reassemble block: %v", err)
+	}
+	if len(txs) != 1 || txs[0] != tx {
+		t.Errorf("transactions mismatch: have %v, want [%x]", txs, tx.Hash())
+	}
+}
@@ -45,6 +45,7 @@ var DefaultConfig = Config{
 	},
 	NetworkId:      90,
 	LightPeers:     100,
+	ULCFraction:    75,
 	DatabaseCache:  512,
 	TrieCleanCache: 256,
 	TrieDirtyCache: 256,
@@ -59,6 +60,7 @@ var DefaultConfig = Config{
 		Blocks:     20,
 		Percentile: 60,
 	},
+	RPCLogCap: 10000,
 }
 
 func init() {
@@ -90,9 +92,18 @@ type Config struct {
 	// Whitelist of required block number -> hash values to accept
 	Whitelist map[uint64]common.Hash `toml:"-"`
 
+	// Block (and witness set) any synchronised chain must contain. If nil, the
+	// known checkpoint of the network is used, if any.
+	SyncCheckpoint *params.SyncCheckpoint `toml:"-"`
+
 	// Light client options
-	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
-	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
+	LightServ  int    `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
+	LightPeers int    `toml:",omitempty"` // Maximum number of LES client peers
+	LightPrice uint64 `toml:",omitempty"` // Price in wei of a unit of priority LES service, 0 disables on-chain deposits
+
+	// Ultra light client options
+	ULCServers  []string `toml:",omitempty"` // Enode URLs of the trusted LES servers, enables ultra light mode
+	ULCFraction int      `toml:",omitempty"` // Minimum percentage of trusted servers announcing a head to accept it
 
 	// Database options
 	SkipBcVersionCheck bool `toml:"-"`
@@ -139,6 +150,14 @@ type Config struct {
 
 	// RPCGasCap is the global gas cap for eth-call variants.
 	RPCGasCap *big.Int `toml:",omitempty"`
+
+	// RPCLogCap is the global cap on the logs returned by a single log query,
+	// larger results have to be paginated. Zero means unlimited.
+	RPCLogCap int `toml:",omitempty"`
+
+	// LogIndex enables maintaining an index of the blocks containing the logs
+	// of each address and topic, serving sparse log queries without scanning.
+	LogIndex bool `toml:",omitempty"`
 }
 
 type configMarshaling struct {
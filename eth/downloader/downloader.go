@@ -27,6 +27,7 @@ import (
 
 	ethereum "github.com/etherzero/go-etherzero"
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus"
 	"github.com/etherzero/go-etherzero/core/rawdb"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/core/types/devotedb"
@@ -34,6 +35,7 @@ import (
 	"github.com/etherzero/go-etherzero/event"
 	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/metrics"
+	"github.com/etherzero/go-etherzero/p2p"
 	"github.com/etherzero/go-etherzero/params"
 )
 
@@ -45,6 +47,7 @@ var (
 	MaxBodyFetch    = 128 // Amount of block bodies to be fetched per retrieval request
 	MaxReceiptFetch = 256 // Amount of transaction receipts to allow fetching per request
 	MaxStateFetch   = 384 // Amount of node state values to allow fetching per request
+	MaxStorageFetch = 128 // Amount of storage tries to allow fetching per request
 
 	MaxForkAncestry  = 3 * params.EpochDuration // Maximum chain reorganisation
 	rttMinEstimate   = 2 * time.Second          // Minimum round-trip time to target for download requests
@@ -98,14 +101,17 @@ var (
 )
 
 type Downloader struct {
-	mode SyncMode       // Synchronisation mode defining the strategy used (per sync cycle)
-	mux  *event.TypeMux // Event multiplexer to announce sync operation events
-
-	checkpoint uint64   // Checkpoint block number to enforce head against (e.g. fast sync)
-	genesis    uint64   // Genesis block number to limit sync to (e.g. light client CHT)
-	queue      *queue   // Scheduler for selecting the hashes to download
-	peers      *peerSet // Set of active peers from which download can proceed
-	stateDB    ethdb.Database
+	mode     SyncMode       // Synchronisation mode defining the strategy used (per sync cycle)
+	snapSync bool           // Whether the fast sync pivot state is assembled from ranges (per sync cycle)
+	mux      *event.TypeMux // Event multiplexer to announce sync operation events
+
+	checkpoint     uint64                 // Checkpoint block number to enforce head against (e.g. fast sync)
+	syncCheckpoint *params.SyncCheckpoint // Block any synchronised chain must contain (weak subjectivity)
+	engine         consensus.Engine       // Consensus engine to verify the seal of the sync checkpoint with
+	genesis        uint64                 // Genesis block number to limit sync to (e.g. light client CHT)
+	queue          *queue                 // Scheduler for selecting the hashes to download
+	peers          *peerSet               // Set of active peers from which download can proceed
+	stateDB        ethdb.Database
 
 	rttEstimate   uint64 // Round trip time to target for download requests
 	rttConfidence uint64 // Confidence in the estimated RTT (unit: millionths to allow atomic ops)
@@ -140,6 +146,11 @@ type Downloader struct {
 	stateSyncStart chan *stateSync
 	trackStateReq  chan *stateReq
 	stateCh        chan dataPack // [eth/63] Channel receiving inbound node state data
+	snapCh         chan dataPack // [etz/65] Channel receiving inbound state ranges
+
+	// for snapshot state sync
+	snapProgress *snapProgress // Resumable progress of the state range retrieval
+	snapReqID    uint64        // Last range request id used (atomic access)
 
 	// Cancellation and termination
 	cancelPeer string         // Identifier of the peer currently being used as the master (cancel on drop)
@@ -208,7 +219,7 @@ type BlockChain interface {
 }
 
 // New creates a new downloader to fetch hashes and blocks from remote peers.
-func New(mode SyncMode, checkpoint uint64, stateDb ethdb.Database, mux *event.TypeMux, chain BlockChain, lightchain LightChain, dropPeer peerDropFn) *Downloader {
+func New(mode SyncMode, checkpoint uint64, syncCheckpoint *params.SyncCheckpoint, engine consensus.Engine, stateDb ethdb.Database, mux *event.TypeMux, chain BlockChain, lightchain LightChain, dropPeer peerDropFn) *Downloader {
 	if lightchain == nil {
 		lightchain = chain
 	}
@@ -217,6 +228,8 @@ func New(mode SyncMode, checkpoint uint64, stateDb ethdb.Database, mux *event.Ty
 		stateDB:        stateDb,
 		mux:            mux,
 		checkpoint:     checkpoint,
+		syncCheckpoint: syncCheckpoint,
+		engine:         engine,
 		queue:          newQueue(),
 		peers:          newPeerSet(),
 		rttEstimate:    uint64(rttMaxEstimate),
@@ -232,6 +245,7 @@ func New(mode SyncMode, checkpoint uint64, stateDb ethdb.Database, mux *event.Ty
 		headerProcCh:   make(chan []*types.Header, 1),
 		quitCh:         make(chan struct{}),
 		stateCh:        make(chan dataPack),
+		snapCh:         make(chan dataPack),
 		stateSyncStart: make(chan *stateSync),
 		syncStatsState: stateSyncStats{
 			processed: rawdb.ReadFastTrieProgress(stateDb),
@@ -333,6 +347,14 @@ func (d *Downloader) Synchronise(id string, head common.Hash, td *big.Int, mode
 		errEmptyHeaderSet, errPeersUnavailable, errTooOld,
 		errInvalidAncestor, errInvalidChain:
 		log.Warn("Synchronisation failed, dropping peer", "peer", id, "err", err)
+		if p := d.peers.Peer(id); p != nil {
+			switch err {
+			case errTimeout, errStallingPeer:
+				p.AdjustScore(p2p.ScoreTimeout, err.Error())
+			case errBadPeer, errEmptyHeaderSet, errInvalidAncestor, errInvalidChain:
+				p.AdjustScore(p2p.ScoreInvalid, err.Error())
+			}
+		}
 		if d.dropPeer == nil {
 			// The dropPeer method is nil when `--copydb` is used for a local copy.
 			// Timeouts can occur if e.g. compaction hits at the wrong time, and can be ignored
@@ -398,8 +420,12 @@ func (d *Downloader) synchronise(id string, hash common.Hash, td *big.Int, mode
 
 	defer d.Cancel() // No matter what, we can't leave the cancel channel open
 
-	// Set the requested sync mode, unless it's forbidden
-	d.mode = mode
+	// Set the requested sync mode, unless it's forbidden. Snapshot sync only
+	// differs from fast sync in how the pivot state is retrieved.
+	d.mode, d.snapSync = mode, mode == SnapSync
+	if d.snapSync {
+		d.mode = FastSync
+	}
 
 	// Retrieve the origin peer and initiate the downloading process
 	p := d.peers.Peer(id)
@@ -451,8 +477,8 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 	// Ensure our origin point is below any fast sync pivot point
 	pivot := uint64(0)
 	if d.mode == FastSync {
-		if height <= uint64(fsMinFullBlocks) {
-			origin = 0
+		if height <= params.GenesisBlockNumber+uint64(fsMinFullBlocks) {
+			origin = params.GenesisBlockNumber
 		} else {
 			pivot = height - uint64(fsMinFullBlocks)
 			if pivot <= origin {
@@ -584,6 +610,10 @@ func (d *Downloader) fetchHeight(p *peerConnection) (*types.Header, error) {
 				p.log.Warn("Remote head below checkpoint", "number", head.Number, "hash", head.Hash())
 				return nil, errUnsyncedPeer
 			}
+			if sc := d.syncCheckpoint; sc != nil && head.Number.Uint64() < sc.Number {
+				p.log.Warn("Remote head below sync checkpoint", "number", head.Number, "hash", head.Hash(), "checkpoint", sc.Number)
+				return nil, errUnsyncedPeer
+			}
 			p.log.Debug("Remote head header identified", "number", head.Number, "hash", head.Hash())
 			return head, nil
 
@@ -695,6 +725,10 @@ func (d *Downloader) findAncestor(p *peerConnection, remoteHeader *types.Header)
 			}
 		}
 	}
+	// If we're already past the sync checkpoint, the remote chain must contain it
+	if sc := d.syncCheckpoint; sc != nil && localHeight >= sc.Number && floor < int64(sc.Number)-1 {
+		floor = int64(sc.Number) - 1
+	}
 	from, count, skip, max := calculateRequestSpan(remoteHeight, localHeight)
 
 	p.log.Trace("Span searching for common ancestor", "count", count, "from", from, "skip", skip)
@@ -1002,6 +1036,7 @@ func (d *Downloader) fetchHeaders(p *peerConnection, from uint64, pivot uint64)
 			// Header retrieval timed out, consider the peer bad and drop
 			p.log.Debug("Header request timed out", "elapsed", ttl)
 			headerTimeoutMeter.Mark(1)
+			p.AdjustScore(p2p.ScoreTimeout, "header request timed out")
 			d.dropPeer(p.id)
 
 			// Finish the sync gracefully instead of dumping the gathered data though
@@ -1169,6 +1204,9 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 					peer.log.Trace("Requested data not delivered", "type", kind)
 				case err == nil:
 					peer.log.Trace("Delivered new batch of data", "type", kind, "count", packet.Stats())
+					if accepted > 0 {
+						peer.AdjustScore(p2p.ScoreUseful, "delivered "+kind)
+					}
 				default:
 					peer.log.Trace("Failed to deliver retrieved data", "type", kind, "err", err)
 				}
@@ -1217,6 +1255,7 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 						setIdle(peer, 0)
 					} else {
 						peer.log.Debug("Stalling delivery, dropping", "type", kind)
+						peer.AdjustScore(p2p.ScoreTimeout, "stalling "+kind+" delivery")
 						if d.dropPeer == nil {
 							// The dropPeer method is nil when `--copydb` is used for a local copy.
 							// Timeouts can occur if e.g. compaction hits at the wrong time, and can be ignored
@@ -1391,6 +1430,10 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 				}
 				chunk := headers[:limit]
 
+				// Reject the chain outright if it doesn't contain the sync checkpoint
+				if err := d.verifySyncCheckpoint(chunk); err != nil {
+					return err
+				}
 				// In case of header only syncing, validate the chunk immediately
 				if d.mode == FastSync || d.mode == LightSync {
 					// Collect the yet unknown headers to mark them as uncertain
@@ -1524,7 +1567,7 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 	// Figure out the ideal pivot block. Note, that this goalpost may move if the
 	// sync takes long enough for the chain head to move significantly.
 	pivot := uint64(0)
-	if height := latest.Number.Uint64(); height > uint64(fsMinFullBlocks) {
+	if height := latest.Number.Uint64(); height > params.GenesisBlockNumber+uint64(fsMinFullBlocks) {
 		pivot = height - uint64(fsMinFullBlocks)
 	}
 	// To cater for moving pivot points, track the pivot block and subsequently
@@ -1573,6 +1616,16 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 					return err
 				}
 			}
+			// The pre-pivot syncs displaced the running state retrieval, resume it
+			if P == nil {
+				stateSync = d.syncState(latest.Root)
+				defer stateSync.Cancel()
+				go func() {
+					if err := stateSync.Wait(); err != nil && err != errCancelStateFetch {
+						d.queue.Close() // wake up Results
+					}
+				}()
+			}
 		}
 		if P != nil {
 			// If new pivot block found, cancel old state retrieval and restart
@@ -1613,6 +1666,41 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 	}
 }
 
+// verifySyncCheckpoint checks the header at the sync checkpoint height, if the
+// given batch contains it, against the pinned block hash and witness set.
+func (d *Downloader) verifySyncCheckpoint(headers []*types.Header) error {
+	sc := d.syncCheckpoint
+	if sc == nil || len(headers) == 0 {
+		return nil
+	}
+	first, last := headers[0].Number.Uint64(), headers[len(headers)-1].Number.Uint64()
+	if sc.Number < first || sc.Number > last {
+		return nil
+	}
+	header := headers[sc.Number-first]
+	if err := VerifyCheckpointSeal(d.engine, sc, header); err != nil {
+		log.Warn("Sync checkpoint seal invalid", "number", sc.Number, "hash", header.Hash(), "witness", header.Witness, "err", err)
+		return errInvalidChain
+	}
+	if header.Hash() != sc.Hash {
+		log.Warn("Sync checkpoint hash mismatch", "number", sc.Number, "hash", header.Hash(), "want", sc.Hash)
+		return errInvalidChain
+	}
+	log.Debug("Sync checkpoint verified", "number", sc.Number, "hash", header.Hash())
+	return nil
+}
+
+// VerifyCheckpointSeal checks that the sync checkpoint header is sealed by one
+// of the checkpoint witnesses, if the consensus engine can verify the seal of a
+// header without its ancestors. Other engines rely on the pinned hash alone.
+func VerifyCheckpointSeal(engine consensus.Engine, sc *params.SyncCheckpoint, header *types.Header) error {
+	verifier, ok := engine.(consensus.CheckpointVerifier)
+	if !ok {
+		return nil
+	}
+	return verifier.VerifyCheckpointSeal(header, sc.Witnesses)
+}
+
 func splitAroundPivot(pivot uint64, results []*fetchResult) (p *fetchResult, prep []*fetchResult, before, after []*fetchResult) {
 	prePivot := pivot - 22
 	if prePivot < 0 {
@@ -1714,6 +1802,18 @@ func (d *Downloader) DeliverNodeData(id string, data [][]byte) (err error) {
 	return d.deliver(id, d.stateCh, &statePack{id, data}, stateInMeter, stateDropMeter)
 }
 
+// DeliverAccountRange injects a new batch of account trie leaves received from
+// a remote node.
+func (d *Downloader) DeliverAccountRange(id string, reqID uint64, hashes []common.Hash, accounts [][]byte, proof [][]byte) (err error) {
+	return d.deliver(id, d.snapCh, &accountRangePack{id, reqID, hashes, accounts, proof}, snapInMeter, snapDropMeter)
+}
+
+// DeliverStorageRanges injects a new batch of storage trie leaves received from
+// a remote node.
+func (d *Downloader) DeliverStorageRanges(id string, reqID uint64, hashes [][]common.Hash, slots [][][]byte, proof [][]byte) (err error) {
+	return d.deliver(id, d.snapCh, &storageRangesPack{id, reqID, hashes, slots, proof}, snapInMeter, snapDropMeter)
+}
+
 // deliver injects a new batch of data received from a remote node.
 func (d *Downloader) deliver(id string, destCh chan dataPack, packet dataPack, inMeter, dropMeter metrics.Meter) (err error) {
 	// Update the delivery metrics for both good and failed deliveries
@@ -28,9 +28,11 @@ import (
 
 	"github.com/etherzero/go-etherzero"
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/event"
+	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/trie"
 )
 
@@ -74,7 +76,7 @@ func newTester() *downloadTester {
 	tester.stateDb = ethdb.NewMemDatabase()
 	tester.stateDb.Put(testGenesis.Root().Bytes(), []byte{0x00})
 
-	tester.downloader = New(FullSync, 0, tester.stateDb, new(event.TypeMux), tester, nil, tester.dropPeer)
+	tester.downloader = New(FullSync, 0, nil, nil, tester.stateDb, new(event.TypeMux), tester, nil, tester.dropPeer)
 	return tester
 }
 
@@ -944,8 +946,8 @@ func testInvalidHeaderRollback(t *testing.T, protocol int, mode SyncMode) {
 	if err := tester.sync("fast-attack", nil, mode); err == nil {
 		t.Fatalf("succeeded fast attacker synchronisation")
 	}
-	if head := tester.CurrentHeader().Number.Int64(); int(head) > MaxHeaderFetch {
-		t.Errorf("rollback head mismatch: have %v, want at most %v", head, MaxHeaderFetch)
+	if head := tester.CurrentHeader().Number.Uint64(); head > testNumber(MaxHeaderFetch) {
+		t.Errorf("rollback head mismatch: have %v, want at most %v", head, testNumber(MaxHeaderFetch))
 	}
 
 	// Attempt to sync with an attacker that feeds junk during the block import phase.
@@ -960,11 +962,11 @@ func testInvalidHeaderRollback(t *testing.T, protocol int, mode SyncMode) {
 	if err := tester.sync("block-attack", nil, mode); err == nil {
 		t.Fatalf("succeeded block attacker synchronisation")
 	}
-	if head := tester.CurrentHeader().Number.Int64(); int(head) > 2*fsHeaderSafetyNet+MaxHeaderFetch {
-		t.Errorf("rollback head mismatch: have %v, want at most %v", head, 2*fsHeaderSafetyNet+MaxHeaderFetch)
+	if head := tester.CurrentHeader().Number.Uint64(); head > testNumber(2*fsHeaderSafetyNet+MaxHeaderFetch) {
+		t.Errorf("rollback head mismatch: have %v, want at most %v", head, testNumber(2*fsHeaderSafetyNet+MaxHeaderFetch))
 	}
 	if mode == FastSync {
-		if head := tester.CurrentBlock().NumberU64(); head != 0 {
+		if head := tester.CurrentBlock().NumberU64(); head != testNumber(0) {
 			t.Errorf("fast sync pivot block #%d not rolled back", head)
 		}
 	}
@@ -983,11 +985,11 @@ func testInvalidHeaderRollback(t *testing.T, protocol int, mode SyncMode) {
 	if err := tester.sync("withhold-attack", nil, mode); err == nil {
 		t.Fatalf("succeeded withholding attacker synchronisation")
 	}
-	if head := tester.CurrentHeader().Number.Int64(); int(head) > 2*fsHeaderSafetyNet+MaxHeaderFetch {
-		t.Errorf("rollback head mismatch: have %v, want at most %v", head, 2*fsHeaderSafetyNet+MaxHeaderFetch)
+	if head := tester.CurrentHeader().Number.Uint64(); head > testNumber(2*fsHeaderSafetyNet+MaxHeaderFetch) {
+		t.Errorf("rollback head mismatch: have %v, want at most %v", head, testNumber(2*fsHeaderSafetyNet+MaxHeaderFetch))
 	}
 	if mode == FastSync {
-		if head := tester.CurrentBlock().NumberU64(); head != 0 {
+		if head := tester.CurrentBlock().NumberU64(); head != testNumber(0) {
 			t.Errorf("fast sync pivot block #%d not rolled back", head)
 		}
 	}
@@ -1115,7 +1117,9 @@ func testSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 		starting <- struct{}{}
 		<-progress
 	}
-	checkProgress(t, tester.downloader, "pristine", ethereum.SyncProgress{})
+	checkProgress(t, tester.downloader, "pristine", ethereum.SyncProgress{
+		CurrentBlock: testNumber(0),
+	})
 
 	// Synchronise half the blocks and check initial progress
 	tester.newPeer("peer-half", protocol, chain.shorten(chain.len()/2))
@@ -1130,7 +1134,9 @@ func testSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 	}()
 	<-starting
 	checkProgress(t, tester.downloader, "initial", ethereum.SyncProgress{
-		HighestBlock: uint64(chain.len()/2 - 1),
+		StartingBlock: testNumber(0),
+		CurrentBlock:  testNumber(0),
+		HighestBlock:  testNumber(chain.len()/2 - 1),
 	})
 	progress <- struct{}{}
 	pending.Wait()
@@ -1146,18 +1152,18 @@ func testSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 	}()
 	<-starting
 	checkProgress(t, tester.downloader, "completing", ethereum.SyncProgress{
-		StartingBlock: uint64(chain.len()/2 - 1),
-		CurrentBlock:  uint64(chain.len()/2 - 1),
-		HighestBlock:  uint64(chain.len() - 1),
+		StartingBlock: testNumber(chain.len()/2 - 1),
+		CurrentBlock:  testNumber(chain.len()/2 - 1),
+		HighestBlock:  testNumber(chain.len() - 1),
 	})
 
 	// Check final progress after successful sync
 	progress <- struct{}{}
 	pending.Wait()
 	checkProgress(t, tester.downloader, "final", ethereum.SyncProgress{
-		StartingBlock: uint64(chain.len()/2 - 1),
-		CurrentBlock:  uint64(chain.len() - 1),
-		HighestBlock:  uint64(chain.len() - 1),
+		StartingBlock: testNumber(chain.len()/2 - 1),
+		CurrentBlock:  testNumber(chain.len() - 1),
+		HighestBlock:  testNumber(chain.len() - 1),
 	})
 }
 
@@ -1199,7 +1205,9 @@ func testForkedSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 		starting <- struct{}{}
 		<-progress
 	}
-	checkProgress(t, tester.downloader, "pristine", ethereum.SyncProgress{})
+	checkProgress(t, tester.downloader, "pristine", ethereum.SyncProgress{
+		CurrentBlock: testNumber(0),
+	})
 
 	// Synchronise with one of the forks and check progress
 	tester.newPeer("fork A", protocol, chainA)
@@ -1214,7 +1222,9 @@ func testForkedSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 	<-starting
 
 	checkProgress(t, tester.downloader, "initial", ethereum.SyncProgress{
-		HighestBlock: uint64(chainA.len() - 1),
+		StartingBlock: testNumber(0),
+		CurrentBlock:  testNumber(0),
+		HighestBlock:  testNumber(chainA.len() - 1),
 	})
 	progress <- struct{}{}
 	pending.Wait()
@@ -1233,18 +1243,18 @@ func testForkedSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 	}()
 	<-starting
 	checkProgress(t, tester.downloader, "forking", ethereum.SyncProgress{
-		StartingBlock: uint64(testChainBase.len()) - 1,
-		CurrentBlock:  uint64(chainA.len() - 1),
-		HighestBlock:  uint64(chainB.len() - 1),
+		StartingBlock: testNumber(testChainBase.len() - 1),
+		CurrentBlock:  testNumber(chainA.len() - 1),
+		HighestBlock:  testNumber(chainB.len() - 1),
 	})
 
 	// Check final progress after successful sync
 	progress <- struct{}{}
 	pending.Wait()
 	checkProgress(t, tester.downloader, "final", ethereum.SyncProgress{
-		StartingBlock: uint64(testChainBase.len()) - 1,
-		CurrentBlock:  uint64(chainB.len() - 1),
-		HighestBlock:  uint64(chainB.len() - 1),
+		StartingBlock: testNumber(testChainBase.len() - 1),
+		CurrentBlock:  testNumber(chainB.len() - 1),
+		HighestBlock:  testNumber(chainB.len() - 1),
 	})
 }
 
@@ -1273,7 +1283,9 @@ func testFailedSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 		starting <- struct{}{}
 		<-progress
 	}
-	checkProgress(t, tester.downloader, "pristine", ethereum.SyncProgress{})
+	checkProgress(t, tester.downloader, "pristine", ethereum.SyncProgress{
+		CurrentBlock: testNumber(0),
+	})
 
 	// Attempt a full sync with a faulty peer
 	brokenChain := chain.shorten(chain.len())
@@ -1293,7 +1305,9 @@ func testFailedSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 	}()
 	<-starting
 	checkProgress(t, tester.downloader, "initial", ethereum.SyncProgress{
-		HighestBlock: uint64(brokenChain.len() - 1),
+		StartingBlock: testNumber(0),
+		CurrentBlock:  testNumber(0),
+		HighestBlock:  testNumber(brokenChain.len() - 1),
 	})
 	progress <- struct{}{}
 	pending.Wait()
@@ -1316,8 +1330,9 @@ func testFailedSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 	progress <- struct{}{}
 	pending.Wait()
 	checkProgress(t, tester.downloader, "final", ethereum.SyncProgress{
-		CurrentBlock: uint64(chain.len() - 1),
-		HighestBlock: uint64(chain.len() - 1),
+		StartingBlock: testNumber(0),
+		CurrentBlock:  testNumber(chain.len() - 1),
+		HighestBlock:  testNumber(chain.len() - 1),
 	})
 }
 
@@ -1344,7 +1359,9 @@ func testFakedSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 		starting <- struct{}{}
 		<-progress
 	}
-	checkProgress(t, tester.downloader, "pristine", ethereum.SyncProgress{})
+	checkProgress(t, tester.downloader, "pristine", ethereum.SyncProgress{
+		CurrentBlock: testNumber(0),
+	})
 
 	// Create and sync with an attacker that promises a higher chain than available.
 	brokenChain := chain.shorten(chain.len())
@@ -1364,7 +1381,9 @@ func testFakedSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 	}()
 	<-starting
 	checkProgress(t, tester.downloader, "initial", ethereum.SyncProgress{
-		HighestBlock: uint64(brokenChain.len() - 1),
+		StartingBlock: testNumber(0),
+		CurrentBlock:  testNumber(0),
+		HighestBlock:  testNumber(brokenChain.len() - 1),
 	})
 	progress <- struct{}{}
 	pending.Wait()
@@ -1384,16 +1403,18 @@ func testFakedSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 	}()
 	<-starting
 	checkProgress(t, tester.downloader, "completing", ethereum.SyncProgress{
-		CurrentBlock: afterFailedSync.CurrentBlock,
-		HighestBlock: uint64(validChain.len() - 1),
+		StartingBlock: testNumber(0),
+		CurrentBlock:  afterFailedSync.CurrentBlock,
+		HighestBlock:  testNumber(validChain.len() - 1),
 	})
 
 	// Check final progress after successful sync.
 	progress <- struct{}{}
 	pending.Wait()
 	checkProgress(t, tester.downloader, "final", ethereum.SyncProgress{
-		CurrentBlock: uint64(validChain.len() - 1),
-		HighestBlock: uint64(validChain.len() - 1),
+		StartingBlock: testNumber(0),
+		CurrentBlock:  testNumber(validChain.len() - 1),
+		HighestBlock:  testNumber(validChain.len() - 1),
 	})
 }
 
@@ -1541,11 +1562,13 @@ func TestRemoteHeaderRequestSpan(t *testing.T) {
 		}
 		return r
 	}
+	// The heights are relative to the genesis block, requests never go below it
+	genesis := int(params.GenesisBlockNumber)
 	for i, tt := range testCases {
-		from, count, span, max := calculateRequestSpan(tt.remoteHeight, tt.localHeight)
-		data := reqs(int(from), count, span)
+		from, count, span, max := calculateRequestSpan(uint64(genesis)+tt.remoteHeight, uint64(genesis)+tt.localHeight)
+		data := reqs(int(from)-genesis, count, span)
 
-		if max != uint64(data[len(data)-1]) {
+		if max != uint64(genesis+data[len(data)-1]) {
 			t.Errorf("test %d: wrong last value %d != %d", i, data[len(data)-1], max)
 		}
 		failed := false
@@ -1586,8 +1609,8 @@ func testCheckpointEnforcement(t *testing.T, protocol int, mode SyncMode) {
 	tester := newTester()
 	defer tester.terminate()
 
-	tester.downloader.checkpoint = uint64(fsMinFullBlocks) + 256
-	chain := testChainBase.shorten(int(tester.downloader.checkpoint) - 1)
+	tester.downloader.checkpoint = testNumber(fsMinFullBlocks + 256)
+	chain := testChainBase.shorten(fsMinFullBlocks + 256 - 1)
 
 	// Attempt to sync with the peer and validate the result
 	tester.newPeer("peer", protocol, chain)
@@ -1605,3 +1628,56 @@ func testCheckpointEnforcement(t *testing.T, protocol int, mode SyncMode) {
 		assertOwnChain(t, tester, chain.len())
 	}
 }
+
+// witnessEngine is a consensus engine accepting the seal of a checkpoint header
+// if its claimed witness is within the given witness set.
+type witnessEngine struct {
+	consensus.Engine
+}
+
+func (witnessEngine) VerifyCheckpointSeal(header *types.Header, witnesses []string) error {
+	for _, witness := range witnesses {
+		if header.Witness == witness {
+			return nil
+		}
+	}
+	return errors.New("unauthorized witness")
+}
+
+// Tests that header batches crossing the sync checkpoint are only accepted from
+// peers serving the pinned block, sealed by one of the pinned witnesses.
+func TestSyncCheckpointVerification(t *testing.T) {
+	t.Parallel()
+
+	// Pick a checkpoint within the fork, so the two chains differ at its height
+	var (
+		base   = testChainBase.headers()
+		fork   = testChainForkLightA.headers()
+		number = fork[testChainBase.len()+1].Number.Uint64()
+		index  = testChainBase.len() - 1
+		hash   = fork[testChainBase.len()+1].Hash()
+	)
+	tests := []struct {
+		engine     consensus.Engine
+		checkpoint *params.SyncCheckpoint
+		headers    []*types.Header
+		err        error
+	}{
+		// Batches not containing the checkpoint height are accepted
+		{nil, &params.SyncCheckpoint{Number: number, Hash: common.Hash{0x01}}, base[:index], nil},
+		// The pinned block is accepted, any other at its height rejected
+		{nil, &params.SyncCheckpoint{Number: number, Hash: hash}, fork, nil},
+		{nil, &params.SyncCheckpoint{Number: number, Hash: common.Hash{0x01}}, fork, errInvalidChain},
+		// The checkpoint block must be sealed by a pinned witness, if the engine can tell
+		{witnessEngine{}, &params.SyncCheckpoint{Number: number, Hash: hash, Witnesses: []string{""}}, fork, nil},
+		{witnessEngine{}, &params.SyncCheckpoint{Number: number, Hash: hash, Witnesses: []string{"witness"}}, fork, errInvalidChain},
+		{witnessEngine{}, &params.SyncCheckpoint{Number: number, Hash: common.Hash{0x01}, Witnesses: []string{""}}, fork, errInvalidChain},
+		{nil, &params.SyncCheckpoint{Number: number, Hash: hash, Witnesses: []string{"witness"}}, fork, nil},
+	}
+	for i, tt := range tests {
+		d := &Downloader{syncCheckpoint: tt.checkpoint, engine: tt.engine}
+		if err := d.verifySyncCheckpoint(tt.headers); err != tt.err {
+			t.Errorf("test %d: verification error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
@@ -40,4 +40,8 @@ var (
 
 	stateInMeter   = metrics.NewRegisteredMeter("eth/downloader/states/in", nil)
 	stateDropMeter = metrics.NewRegisteredMeter("eth/downloader/states/drop", nil)
+
+	snapInMeter      = metrics.NewRegisteredMeter("eth/downloader/snap/in", nil)
+	snapDropMeter    = metrics.NewRegisteredMeter("eth/downloader/snap/drop", nil)
+	snapTimeoutMeter = metrics.NewRegisteredMeter("eth/downloader/snap/timeout", nil)
 )
@@ -90,6 +90,19 @@ type Peer interface {
 	RequestNodeData([]common.Hash) error
 }
 
+// SnapPeer encapsulates the methods required to retrieve verifiable state ranges
+// from a remote etz/65 peer.
+type SnapPeer interface {
+	RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error
+	RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error
+}
+
+// scoredPeer is implemented by the peers whose reputation is tracked by the
+// networking layer.
+type scoredPeer interface {
+	AdjustScore(delta int, reason string)
+}
+
 // lightPeerWrapper wraps a LightPeer struct, stubbing out the Peer-only methods.
 type lightPeerWrapper struct {
 	peer LightPeer
@@ -125,6 +138,18 @@ func newPeerConnection(id string, version int, peer Peer, logger log.Logger) *pe
 	}
 }
 
+// AdjustScore changes the reputation of the peer by the given amount, if it is
+// tracked by the networking layer.
+func (p *peerConnection) AdjustScore(delta int, reason string) {
+	peer := interface{}(p.peer)
+	if w, ok := peer.(*lightPeerWrapper); ok {
+		peer = w.peer
+	}
+	if sp, ok := peer.(scoredPeer); ok {
+		sp.AdjustScore(delta, reason)
+	}
+}
+
 // Reset clears the internal state of a peer entity.
 func (p *peerConnection) Reset() {
 	p.lock.Lock()
@@ -222,6 +247,44 @@ func (p *peerConnection) FetchNodeData(hashes []common.Hash) error {
 	return nil
 }
 
+// FetchAccountRange sends an account trie range retrieval request to the remote
+// peer. Range requests share the idle flag of node data retrievals.
+func (p *peerConnection) FetchAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	// Sanity check the protocol version
+	peer, ok := p.peer.(SnapPeer)
+	if p.version < 65 || !ok {
+		panic(fmt.Sprintf("account range fetch [etz/65+] requested on eth/%d", p.version))
+	}
+	// Short circuit if the peer is already fetching
+	if !atomic.CompareAndSwapInt32(&p.stateIdle, 0, 1) {
+		return errAlreadyFetching
+	}
+	p.stateStarted = time.Now()
+
+	go peer.RequestAccountRange(id, root, origin, limit, bytes)
+
+	return nil
+}
+
+// FetchStorageRanges sends a storage trie range retrieval request to the remote
+// peer. Range requests share the idle flag of node data retrievals.
+func (p *peerConnection) FetchStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error {
+	// Sanity check the protocol version
+	peer, ok := p.peer.(SnapPeer)
+	if p.version < 65 || !ok {
+		panic(fmt.Sprintf("storage range fetch [etz/65+] requested on eth/%d", p.version))
+	}
+	// Short circuit if the peer is already fetching
+	if !atomic.CompareAndSwapInt32(&p.stateIdle, 0, 1) {
+		return errAlreadyFetching
+	}
+	p.stateStarted = time.Now()
+
+	go peer.RequestStorageRanges(id, root, accounts, origin, limit, bytes)
+
+	return nil
+}
+
 // SetHeadersIdle sets the peer to idle, allowing it to execute new header retrieval
 // requests. Its estimated header retrieval throughput is updated with that measured
 // just now.
@@ -470,7 +533,7 @@ func (ps *peerSet) HeaderIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.headerThroughput
 	}
-	return ps.idlePeers(62, 64, idle, throughput)
+	return ps.idlePeers(62, 65, idle, throughput)
 }
 
 // BodyIdlePeers retrieves a flat list of all the currently body-idle peers within
@@ -484,7 +547,7 @@ func (ps *peerSet) BodyIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.blockThroughput
 	}
-	return ps.idlePeers(62, 64, idle, throughput)
+	return ps.idlePeers(62, 65, idle, throughput)
 }
 
 // ReceiptIdlePeers retrieves a flat list of all the currently receipt-idle peers
@@ -498,7 +561,7 @@ func (ps *peerSet) ReceiptIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.receiptThroughput
 	}
-	return ps.idlePeers(63, 64, idle, throughput)
+	return ps.idlePeers(63, 65, idle, throughput)
 }
 
 // NodeDataIdlePeers retrieves a flat list of all the currently node-data-idle
@@ -512,7 +575,24 @@ func (ps *peerSet) NodeDataIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.stateThroughput
 	}
-	return ps.idlePeers(63, 64, idle, throughput)
+	return ps.idlePeers(63, 65, idle, throughput)
+}
+
+// SnapIdlePeers retrieves a flat list of all the currently node-data-idle peers
+// capable of serving state ranges, ordered by their reputation.
+func (ps *peerSet) SnapIdlePeers() ([]*peerConnection, int) {
+	idle := func(p *peerConnection) bool {
+		if _, ok := p.peer.(SnapPeer); !ok {
+			return false
+		}
+		return atomic.LoadInt32(&p.stateIdle) == 0
+	}
+	throughput := func(p *peerConnection) float64 {
+		p.lock.RLock()
+		defer p.lock.RUnlock()
+		return p.stateThroughput
+	}
+	return ps.idlePeers(65, 65, idle, throughput)
 }
 
 // idlePeers retrieves a flat list of all currently idle peers satisfying the
@@ -0,0 +1,637 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/p2p"
+	"github.com/etherzero/go-etherzero/rlp"
+	"github.com/etherzero/go-etherzero/trie"
+)
+
+const (
+	snapAccountChunks = 16         // Number of account ranges the keyspace is split into
+	snapResponseBytes = 512 * 1024 // Soft size limit of the requested range responses
+	snapStorageBatch  = 64         // Maximum number of storage tries requested at once
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+
+	errInvalidRange = errors.New("invalid state range delivered")
+)
+
+// snapProgress is the resumable state of the range retrieval phase, carried over
+// across pivot moves and sync cycles. Every trie node persisted by the range
+// retrieval roots a complete subtrie (including any storage tries and code of
+// the accounts within), so the progress stays valid for any later state root:
+// whatever changed in between is fixed up by the heal.
+type snapProgress struct {
+	tasks []*accountTask           // Partitions of the account keyspace
+	codes map[common.Hash]struct{} // Contract codes left for the heal to retrieve
+	done  bool                     // Whether the range retrieval phase finished
+}
+
+// newSnapProgress splits the account keyspace into evenly sized ranges.
+func newSnapProgress() *snapProgress {
+	var (
+		step  = new(big.Int).Div(new(big.Int).Lsh(common.Big1, 256), big.NewInt(snapAccountChunks))
+		next  = new(big.Int)
+		tasks = make([]*accountTask, 0, snapAccountChunks)
+	)
+	for i := 0; i < snapAccountChunks; i++ {
+		last := new(big.Int).Sub(new(big.Int).Add(next, step), common.Big1)
+		if i == snapAccountChunks-1 {
+			last = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+		}
+		tasks = append(tasks, &accountTask{
+			next: common.BigToHash(next),
+			last: common.BigToHash(last),
+		})
+		next = new(big.Int).Add(last, common.Big1)
+	}
+	return &snapProgress{
+		tasks: tasks,
+		codes: make(map[common.Hash]struct{}),
+	}
+}
+
+// accountTask is a contiguous range of the account keyspace to retrieve.
+type accountTask struct {
+	next common.Hash // Next account to retrieve
+	last common.Hash // Last account belonging to this range
+	done bool        // Whether the entire range was retrieved
+
+	req     *snapRequest    // Pending request retrieving this range
+	batches []*accountBatch // Verified ranges waiting on storage to be persisted
+}
+
+// accountBatch is a verified account range waiting for the storage tries of
+// its accounts to complete before its trie nodes are persisted.
+type accountBatch struct {
+	task    *accountTask       // Account range the batch belongs to
+	origin  common.Hash        // First account hash covered by the batch
+	nodes   *ethdb.MemDatabase // Verified trie nodes spanned by the range
+	pending int                // Number of storage tries still being retrieved
+}
+
+// storageTask is a storage trie to retrieve.
+type storageTask struct {
+	account common.Hash // Hash of the account owning the storage trie
+	root    common.Hash // Storage root the ranges are verified against
+	next    common.Hash // Next slot to retrieve (zero for untouched tries)
+
+	req     *snapRequest           // Pending request retrieving this trie
+	bounds  map[common.Hash][]byte // Range boundary nodes to persist on completion
+	batches []*accountBatch        // Account ranges waiting for this trie
+}
+
+// snapRequest is an account or storage range request sent to a remote peer.
+type snapRequest struct {
+	id      uint64          // Request id to match up responses with
+	peer    *peerConnection // Peer that we're requesting from
+	timer   *time.Timer     // Timer to fire when the RTT timeout expires
+	account *accountTask    // Account range being retrieved (nil for storage)
+	storage []*storageTask  // Storage tries being retrieved (nil for accounts)
+}
+
+// snapSync retrieves the state of a pivot block as verified trie ranges from
+// etz/65 peers. Trie nodes on the range boundaries are not persisted by it, they
+// are left for the node data based heal that follows.
+type snapSync struct {
+	d        *Downloader
+	root     common.Hash
+	progress *snapProgress
+
+	storage   []*storageTask               // Storage tries waiting for retrieval
+	pending   map[common.Hash]*storageTask // Unfinished storage tries by account hash
+	active    map[uint64]*snapRequest      // Currently in-flight range requests
+	stateless map[string]struct{}          // Peers not having the state to sync
+
+	written  int               // Number of trie nodes persisted since the last log
+	deliver  chan dataPack     // Range responses pushed by the state fetcher
+	timeout  chan *snapRequest // Timed out active requests
+	finished chan struct{}     // Closed when the range retrieval terminates
+}
+
+// newSnapSync creates a range retrieval for the given state root, resuming the
+// progress of any previously interrupted one.
+func newSnapSync(d *Downloader, root common.Hash) *snapSync {
+	if d.snapProgress == nil {
+		d.snapProgress = newSnapProgress()
+	}
+	return &snapSync{
+		d:         d,
+		root:      root,
+		progress:  d.snapProgress,
+		pending:   make(map[common.Hash]*storageTask),
+		active:    make(map[uint64]*snapRequest),
+		stateless: make(map[string]struct{}),
+		deliver:   make(chan dataPack),
+		timeout:   make(chan *snapRequest),
+		finished:  make(chan struct{}),
+	}
+}
+
+// run retrieves account and storage ranges until the entire keyspace is covered,
+// the sync is cancelled, or no peers are left able to serve the state.
+func (s *snapSync) run(cancel chan struct{}) error {
+	defer close(s.finished)
+
+	// Listen for peer arrival and departure events to (re)assign tasks
+	newPeer := make(chan *peerConnection, 1024)
+	newSub := s.d.peers.SubscribeNewPeers(newPeer)
+	defer newSub.Unsubscribe()
+
+	peerDrop := make(chan *peerConnection, 1024)
+	dropSub := s.d.peers.SubscribePeerDrops(peerDrop)
+	defer dropSub.Unsubscribe()
+
+	defer func() {
+		// Cancel active request timers on exit. Also set peers to idle so they're
+		// available for the heal.
+		for _, req := range s.active {
+			req.timer.Stop()
+			req.peer.SetNodeDataIdle(0)
+		}
+	}()
+	for !s.complete() {
+		if !s.assignTasks() && len(s.active) == 0 {
+			log.Info("No peers serving state ranges, healing state", "root", s.root)
+			break
+		}
+		select {
+		case <-newPeer:
+			// New peer arrived, try to assign it download tasks
+
+		case <-cancel:
+			s.suspend()
+			return errCancelStateFetch
+
+		case <-s.d.cancelCh:
+			s.suspend()
+			return errCancelStateFetch
+
+		case p := <-peerDrop:
+			for id, req := range s.active {
+				if req.peer.id == p.id {
+					req.timer.Stop()
+					delete(s.active, id)
+					s.revert(req)
+				}
+			}
+
+		case req := <-s.timeout:
+			// Ignore stale timeouts of already delivered requests
+			if s.active[req.id] != req {
+				continue
+			}
+			snapTimeoutMeter.Mark(1)
+			delete(s.active, req.id)
+			s.revert(req)
+			req.peer.SetNodeDataIdle(0)
+
+		case pack := <-s.deliver:
+			var id uint64
+			switch pack := pack.(type) {
+			case *accountRangePack:
+				id = pack.id
+			case *storageRangesPack:
+				id = pack.id
+			}
+			req := s.active[id]
+			if req == nil || req.peer.id != pack.PeerId() {
+				log.Debug("Unrequested state range", "peer", pack.PeerId(), "id", id)
+				continue
+			}
+			req.timer.Stop()
+			delete(s.active, id)
+
+			var err error
+			switch pack := pack.(type) {
+			case *accountRangePack:
+				err = s.processAccounts(req, pack)
+			case *storageRangesPack:
+				err = s.processStorage(req, pack)
+			}
+			if err == errInvalidRange {
+				log.Warn("Invalid state range delivered, dropping peer", "peer", req.peer.id)
+				req.peer.AdjustScore(p2p.ScoreInvalid, "invalid state range")
+				s.revert(req)
+				s.d.dropPeer(req.peer.id)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			req.peer.SetNodeDataIdle(pack.Items())
+		}
+	}
+	if s.written > 0 {
+		s.updateStats(0)
+	}
+	s.progress.done = true
+	return nil
+}
+
+// complete returns whether the entire account keyspace has been persisted.
+func (s *snapSync) complete() bool {
+	for _, task := range s.progress.tasks {
+		if !task.done || len(task.batches) > 0 {
+			return false
+		}
+	}
+	return len(s.active) == 0
+}
+
+// heal schedules everything the range retrieval left behind into a trie node
+// sync of the state root.
+func (s *snapSync) heal(sched *trie.Sync) {
+	for hash := range s.progress.codes {
+		sched.AddRawEntry(hash, 64, common.Hash{})
+	}
+	s.progress.codes = make(map[common.Hash]struct{})
+}
+
+// suspend stashes the progress of an interrupted range retrieval. Any account
+// ranges not yet persisted (and the storage tries they wait on) are rewound, to
+// be retrieved again from whatever the state root is when resuming.
+func (s *snapSync) suspend() {
+	for _, task := range s.progress.tasks {
+		for _, batch := range task.batches {
+			if bytes.Compare(batch.origin[:], task.next[:]) < 0 {
+				task.next = batch.origin
+			}
+			task.done = false
+		}
+		task.batches = nil
+		task.req = nil
+	}
+}
+
+// revert returns the tasks of a failed request into the retrieval queue.
+func (s *snapSync) revert(req *snapRequest) {
+	if req.account != nil {
+		req.account.req = nil
+	}
+	for i := len(req.storage) - 1; i >= 0; i-- {
+		req.storage[i].req = nil
+		s.storage = append([]*storageTask{req.storage[i]}, s.storage...)
+	}
+}
+
+// assignTasks assigns range retrievals to all idle peers able to serve them,
+// storage first to keep the amount of unfinished tries bounded. It returns
+// whether any connected peer is able to serve the state at all.
+func (s *snapSync) assignTasks() bool {
+	capable := false
+	for _, p := range s.d.peers.AllPeers() {
+		if _, ok := s.stateless[p.id]; !ok && p.version >= 65 {
+			capable = true
+		}
+	}
+	peers, _ := s.d.peers.SnapIdlePeers()
+	for _, p := range peers {
+		if _, ok := s.stateless[p.id]; ok {
+			continue
+		}
+		req := &snapRequest{id: atomic.AddUint64(&s.d.snapReqID, 1), peer: p}
+		if len(s.storage) > 0 {
+			s.fillStorage(req)
+
+			origin := req.storage[0].next
+			accounts := make([]common.Hash, len(req.storage))
+			for i, task := range req.storage {
+				accounts[i] = task.account
+			}
+			if err := p.FetchStorageRanges(req.id, s.root, accounts, origin[:], nil, snapResponseBytes); err != nil {
+				s.revert(req)
+				continue
+			}
+			req.peer.log.Trace("Requesting storage ranges", "count", len(accounts), "origin", origin)
+		} else {
+			for _, task := range s.progress.tasks {
+				if !task.done && task.req == nil {
+					req.account = task
+					break
+				}
+			}
+			if req.account == nil {
+				break
+			}
+			task := req.account
+			if err := p.FetchAccountRange(req.id, s.root, task.next, task.last, snapResponseBytes); err != nil {
+				continue
+			}
+			task.req = req
+			req.peer.log.Trace("Requesting account range", "origin", task.next, "limit", task.last)
+		}
+		req.timer = time.AfterFunc(s.d.requestTTL(), func() {
+			select {
+			case s.timeout <- req:
+			case <-s.finished:
+			}
+		})
+		s.active[req.id] = req
+	}
+	return capable
+}
+
+// fillStorage moves storage tasks from the queue into a request. A partially
+// retrieved trie is always requested on its own, since the requested origin
+// applies to the first trie only.
+func (s *snapSync) fillStorage(req *snapRequest) {
+	for len(s.storage) > 0 && len(req.storage) < snapStorageBatch {
+		task := s.storage[0]
+		if len(req.storage) > 0 && task.next != (common.Hash{}) {
+			break
+		}
+		s.storage = s.storage[1:]
+		task.req = req
+		req.storage = append(req.storage, task)
+
+		if task.next != (common.Hash{}) {
+			break
+		}
+	}
+}
+
+// processAccounts verifies an account range response, schedules the storage
+// tries and code of the delivered accounts and persists the range once all its
+// storage is available.
+func (s *snapSync) processAccounts(req *snapRequest, res *accountRangePack) error {
+	task := req.account
+	task.req = nil
+
+	// An empty response without proofs signals the peer not having the state
+	if len(res.hashes) == 0 && len(res.proof) == 0 {
+		s.stateless[req.peer.id] = struct{}{}
+		return nil
+	}
+	if len(res.hashes) != len(res.accounts) {
+		return errInvalidRange
+	}
+	keys := make([][]byte, len(res.hashes))
+	for i, hash := range res.hashes {
+		keys[i] = common.CopyBytes(hash[:])
+	}
+	last := task.next
+	if len(res.hashes) > 0 {
+		last = res.hashes[len(res.hashes)-1]
+	}
+	nodes, more, err := trie.VerifyRangeProof(s.root, task.next[:], last[:], keys, res.accounts, proofDatabase(res.proof))
+	if err != nil {
+		log.Debug("Account range verification failed", "peer", req.peer.id, "err", err)
+		return errInvalidRange
+	}
+	accounts := make([]state.Account, len(res.accounts))
+	for i, blob := range res.accounts {
+		if err := rlp.DecodeBytes(blob, &accounts[i]); err != nil {
+			return errInvalidRange
+		}
+	}
+	batch := &accountBatch{task: task, origin: task.next, nodes: nodes}
+	for i, hash := range res.hashes {
+		account := accounts[i]
+		if account.Root != emptyRoot {
+			s.scheduleStorage(batch, hash, account.Root)
+		}
+		if hash := common.BytesToHash(account.CodeHash); hash != emptyCode {
+			if ok, _ := s.d.stateDB.Has(hash[:]); !ok {
+				s.progress.codes[hash] = struct{}{}
+			}
+		}
+	}
+	if !more || bytes.Compare(last[:], task.last[:]) >= 0 {
+		task.done = true
+	} else {
+		task.next = incHash(last)
+	}
+	if batch.pending == 0 {
+		return s.persist(batch.nodes)
+	}
+	task.batches = append(task.batches, batch)
+	return nil
+}
+
+// scheduleStorage queues a storage trie for retrieval unless it's already
+// available locally, making the account batch wait for its completion.
+func (s *snapSync) scheduleStorage(batch *accountBatch, account common.Hash, root common.Hash) {
+	if task, ok := s.pending[account]; ok && task.root == root {
+		task.batches = append(task.batches, batch)
+		batch.pending++
+		return
+	}
+	if ok, _ := s.d.stateDB.Has(root[:]); ok {
+		return
+	}
+	task := &storageTask{
+		account: account,
+		root:    root,
+		bounds:  make(map[common.Hash][]byte),
+		batches: []*accountBatch{batch},
+	}
+	s.pending[account] = task
+	s.storage = append(s.storage, task)
+	batch.pending++
+}
+
+// processStorage verifies a storage ranges response, persisting the delivered
+// tries and finishing any that were retrieved completely.
+func (s *snapSync) processStorage(req *snapRequest, res *storageRangesPack) error {
+	for _, task := range req.storage {
+		task.req = nil
+	}
+	// An empty response signals the peer not having the state
+	if len(res.hashes) == 0 {
+		s.stateless[req.peer.id] = struct{}{}
+		s.revert(req)
+		return nil
+	}
+	if len(res.hashes) > len(req.storage) || len(res.hashes) != len(res.slots) {
+		return errInvalidRange
+	}
+	// Verify all the delivered tries before touching any of the tasks
+	var (
+		nodes = make([]*ethdb.MemDatabase, len(res.hashes))
+		more  bool
+		last  common.Hash
+	)
+	for i, hashes := range res.hashes {
+		task := req.storage[i]
+		if len(hashes) != len(res.slots[i]) {
+			return errInvalidRange
+		}
+		keys := make([][]byte, len(hashes))
+		for j, hash := range hashes {
+			keys[j] = common.CopyBytes(hash[:])
+		}
+		last = task.next
+		if len(hashes) > 0 {
+			last = hashes[len(hashes)-1]
+		}
+		// Only the last delivered trie may be partial and thus carry a proof
+		var proof trie.DatabaseReader
+		if i == len(res.hashes)-1 {
+			proof = proofDatabase(res.proof)
+		}
+		var err error
+		if nodes[i], more, err = trie.VerifyRangeProof(task.root, task.next[:], last[:], keys, res.slots[i], proof); err != nil {
+			log.Debug("Storage range verification failed", "peer", req.peer.id, "err", err)
+			return errInvalidRange
+		}
+	}
+	// Persist the delivered ranges and finish all the completed tries
+	for i := range res.hashes {
+		task := req.storage[i]
+		if err := s.persist(nodes[i]); err != nil {
+			return err
+		}
+		if i < len(res.hashes)-1 || len(res.proof) == 0 {
+			if err := s.finishStorage(task); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, blob := range res.proof {
+			task.bounds[crypto.Keccak256Hash(blob)] = blob
+		}
+		if !more {
+			if err := s.finishStorage(task); err != nil {
+				return err
+			}
+			continue
+		}
+		task.next = incHash(last)
+		s.storage = append([]*storageTask{task}, s.storage...)
+	}
+	// Requeue any tries the peer didn't deliver
+	for i := len(req.storage) - 1; i >= len(res.hashes); i-- {
+		s.storage = append([]*storageTask{req.storage[i]}, s.storage...)
+	}
+	return nil
+}
+
+// finishStorage persists the boundary nodes of a completely retrieved storage
+// trie, along with any account ranges that were waiting only on it.
+func (s *snapSync) finishStorage(task *storageTask) error {
+	if len(task.bounds) > 0 {
+		batch := s.d.stateDB.NewBatch()
+		for hash, blob := range task.bounds {
+			if err := batch.Put(hash[:], blob); err != nil {
+				return err
+			}
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		s.written += len(task.bounds)
+	}
+	delete(s.pending, task.account)
+
+	for _, batch := range task.batches {
+		if batch.pending--; batch.pending > 0 {
+			continue
+		}
+		if err := s.persist(batch.nodes); err != nil {
+			return err
+		}
+		batches := batch.task.batches
+		for i, b := range batches {
+			if b == batch {
+				batch.task.batches = append(batches[:i], batches[i+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// persist writes a set of verified trie nodes into the state database.
+func (s *snapSync) persist(nodes *ethdb.MemDatabase) error {
+	start := time.Now()
+	batch := s.d.stateDB.NewBatch()
+	for _, key := range nodes.Keys() {
+		value, _ := nodes.Get(key)
+		if err := batch.Put(key, value); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	s.written += nodes.Len()
+	if s.written >= 4096 {
+		s.updateStats(time.Since(start))
+	}
+	return nil
+}
+
+// updateStats bumps the state sync progress counters with the nodes persisted
+// from ranges and displays a log message for the user to see.
+func (s *snapSync) updateStats(duration time.Duration) {
+	s.d.syncStatsLock.Lock()
+	defer s.d.syncStatsLock.Unlock()
+
+	s.d.syncStatsState.processed += uint64(s.written)
+	log.Info("Imported new state ranges", "count", s.written, "elapsed", common.PrettyDuration(duration), "processed", s.d.syncStatsState.processed, "storage", len(s.pending))
+	s.written = 0
+}
+
+// proofDatabase collects the nodes of a range proof into a database keyed by
+// their hashes, returning nil if there is no proof.
+func proofDatabase(proof [][]byte) trie.DatabaseReader {
+	if len(proof) == 0 {
+		return nil
+	}
+	db := ethdb.NewMemDatabase()
+	for _, blob := range proof {
+		db.Put(crypto.Keccak256(blob), blob)
+	}
+	return db
+}
+
+// incHash returns the hash following the given one in the keyspace.
+func incHash(h common.Hash) common.Hash {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i]++
+		if h[i] != 0 {
+			break
+		}
+	}
+	return h
+}
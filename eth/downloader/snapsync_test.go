@@ -0,0 +1,285 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/rlp"
+	"github.com/etherzero/go-etherzero/trie"
+)
+
+// Tests that the account keyspace is split into contiguous ranges covering all
+// possible account hashes.
+func TestSnapProgressSplit(t *testing.T) {
+	progress := newSnapProgress()
+	if len(progress.tasks) != snapAccountChunks {
+		t.Fatalf("task count mismatch: have %d, want %d", len(progress.tasks), snapAccountChunks)
+	}
+	if first := progress.tasks[0].next; first != (common.Hash{}) {
+		t.Errorf("first range origin mismatch: have %x, want %x", first, common.Hash{})
+	}
+	for i := 1; i < len(progress.tasks); i++ {
+		if have, want := progress.tasks[i].next, incHash(progress.tasks[i-1].last); have != want {
+			t.Errorf("range %d: origin mismatch: have %x, want %x", i, have, want)
+		}
+	}
+	last := progress.tasks[len(progress.tasks)-1].last
+	if incHash(last) != (common.Hash{}) {
+		t.Errorf("last range limit mismatch: have %x, want all ones", last)
+	}
+}
+
+// Tests that hashes are incremented with carry propagation.
+func TestIncHash(t *testing.T) {
+	tests := []struct {
+		hash common.Hash
+		want common.Hash
+	}{
+		{common.Hash{}, common.HexToHash("0x01")},
+		{common.HexToHash("0xff"), common.HexToHash("0x0100")},
+		{common.HexToHash("0x01ffff"), common.HexToHash("0x020000")},
+	}
+	for i, tt := range tests {
+		if have := incHash(tt.hash); have != tt.want {
+			t.Errorf("test %d: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
+
+// snapTesterPeer is a download tester peer serving state ranges of the tester's
+// peer database, mimicking the etz/65 range handlers.
+type snapTesterPeer struct {
+	*downloadTesterPeer
+	state state.Database
+}
+
+// RequestAccountRange serves a range of consecutive accounts along with the
+// proofs of its edges.
+func (dlp *snapTesterPeer) RequestAccountRange(id uint64, root, origin, limit common.Hash, bytesLimit uint64) error {
+	tr, err := dlp.state.OpenTrie(root)
+	if err != nil {
+		go dlp.dl.downloader.DeliverAccountRange(dlp.id, id, nil, nil, nil)
+		return nil
+	}
+	var (
+		hashes   []common.Hash
+		accounts [][]byte
+		size     uint64
+	)
+	it := trie.NewIterator(tr.NodeIterator(origin[:]))
+	for it.Next() {
+		hash := common.BytesToHash(it.Key)
+		hashes, accounts = append(hashes, hash), append(accounts, common.CopyBytes(it.Value))
+
+		size += uint64(common.HashLength + len(it.Value))
+		if bytes.Compare(hash[:], limit[:]) >= 0 || size >= bytesLimit {
+			break
+		}
+	}
+	proof := ethdb.NewMemDatabase()
+	tr.Prove(origin[:], 0, proof)
+	if len(hashes) > 0 {
+		tr.Prove(hashes[len(hashes)-1][:], 0, proof)
+	}
+	go dlp.dl.downloader.DeliverAccountRange(dlp.id, id, hashes, accounts, proofList(proof))
+	return nil
+}
+
+// RequestStorageRanges serves the storage tries of the requested accounts, the
+// last one possibly cut short and proven.
+func (dlp *snapTesterPeer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytesLimit uint64) error {
+	tr, err := dlp.state.OpenTrie(root)
+	if err != nil {
+		go dlp.dl.downloader.DeliverStorageRanges(dlp.id, id, nil, nil, nil)
+		return nil
+	}
+	var (
+		hashes [][]common.Hash
+		slots  [][][]byte
+		size   uint64
+	)
+	for i, account := range accounts {
+		if size >= bytesLimit {
+			break
+		}
+		blob, err := tr.TryGet(account[:])
+		if err != nil || blob == nil {
+			break
+		}
+		var acc state.Account
+		if err := rlp.DecodeBytes(blob, &acc); err != nil {
+			break
+		}
+		st, err := dlp.state.OpenStorageTrie(account, acc.Root)
+		if err != nil {
+			break
+		}
+		var start common.Hash
+		if i == 0 && len(origin) > 0 {
+			start = common.BytesToHash(origin)
+		}
+		var (
+			keys    []common.Hash
+			values  [][]byte
+			partial = start != (common.Hash{})
+		)
+		it := trie.NewIterator(st.NodeIterator(start[:]))
+		for it.Next() {
+			keys, values = append(keys, common.BytesToHash(it.Key)), append(values, common.CopyBytes(it.Value))
+
+			if size += uint64(common.HashLength + len(it.Value)); size >= bytesLimit {
+				partial = true
+				break
+			}
+		}
+		hashes, slots = append(hashes, keys), append(slots, values)
+
+		if partial {
+			proof := ethdb.NewMemDatabase()
+			st.Prove(start[:], 0, proof)
+			if len(keys) > 0 {
+				st.Prove(keys[len(keys)-1][:], 0, proof)
+			}
+			go dlp.dl.downloader.DeliverStorageRanges(dlp.id, id, hashes, slots, proofList(proof))
+			return nil
+		}
+	}
+	go dlp.dl.downloader.DeliverStorageRanges(dlp.id, id, hashes, slots, nil)
+	return nil
+}
+
+// proofList flattens a proof database into the list of its nodes.
+func proofList(proof *ethdb.MemDatabase) [][]byte {
+	var nodes [][]byte
+	for _, key := range proof.Keys() {
+		node, _ := proof.Get(key)
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// makeSnapState creates a state with plain accounts, contracts and a few large
+// storage tries that can't be delivered in a single range response.
+func makeSnapState(db ethdb.Database) common.Hash {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	for i := 0; i < 1000; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		statedb.SetBalance(addr, big.NewInt(int64(i+1)), common.Big0)
+		statedb.SetNonce(addr, uint64(i))
+
+		switch {
+		case i%250 == 0:
+			statedb.SetCode(addr, []byte{byte(i / 250), 0x60, 0x00})
+			for j := 0; j < 10000; j++ {
+				statedb.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BytesToHash(bytes.Repeat([]byte{0xff}, 32)))
+			}
+		case i%10 == 0:
+			statedb.SetCode(addr, []byte{byte(i), 0x60, 0x01})
+			for j := 0; j < 10; j++ {
+				statedb.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(i+j+1))))
+			}
+		}
+	}
+	root, _ := statedb.Commit(false)
+	statedb.Database().TrieDB().Commit(root, false)
+	return root
+}
+
+// Tests that a snapshot sync assembles a complete copy of a remote state from
+// ranges, including storage tries split over multiple responses and contract
+// code retrieved by the heal.
+func TestSnapSyncState(t *testing.T) {
+	t.Parallel()
+
+	source := ethdb.NewMemDatabase()
+	root := makeSnapState(source)
+
+	tester := newTester()
+	defer tester.terminate()
+
+	tester.peerDb = source
+	peer := &snapTesterPeer{
+		downloadTesterPeer: &downloadTesterPeer{dl: tester, id: "peer", chain: testChainBase},
+		state:              state.NewDatabase(source),
+	}
+	tester.peers["peer"] = peer.downloadTesterPeer
+	if err := tester.downloader.RegisterPeer("peer", 65, peer); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+	// Run a state sync outside of a full sync cycle
+	d := tester.downloader
+	d.snapSync = true
+	d.cancelLock.Lock()
+	d.cancelCh = make(chan struct{})
+	d.cancelLock.Unlock()
+
+	if err := d.syncState(root).Wait(); err != nil {
+		t.Fatalf("state sync failed: %v", err)
+	}
+	if d.snapProgress == nil || !d.snapProgress.done {
+		t.Fatalf("range retrieval not finished")
+	}
+	// Verify that every account, storage slot and code blob is available locally
+	want, _ := state.New(root, state.NewDatabase(source))
+	have, err := state.New(root, state.NewDatabase(tester.stateDb))
+	if err != nil {
+		t.Fatalf("synced state root missing: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		if have.GetBalance(addr).Cmp(want.GetBalance(addr)) != 0 {
+			t.Fatalf("account %d: balance mismatch: have %v, want %v", i, have.GetBalance(addr), want.GetBalance(addr))
+		}
+		if !bytes.Equal(have.GetCode(addr), want.GetCode(addr)) {
+			t.Fatalf("account %d: code mismatch", i)
+		}
+		if have.StorageTrie(addr) == nil && want.StorageTrie(addr) != nil {
+			t.Fatalf("account %d: storage trie missing", i)
+		}
+		for j := 0; j < 10000 && i%10 == 0; j++ {
+			key := common.BigToHash(big.NewInt(int64(j)))
+			if have.GetState(addr, key) != want.GetState(addr, key) {
+				t.Fatalf("account %d: slot %d mismatch: have %x, want %x", i, j, have.GetState(addr, key), want.GetState(addr, key))
+			}
+		}
+	}
+	it := trie.NewIterator(mustOpenTrie(t, state.NewDatabase(tester.stateDb), root).NodeIterator(nil))
+	accounts := 0
+	for it.Next() {
+		accounts++
+	}
+	if it.Err != nil {
+		t.Fatalf("failed to iterate synced state: %v", it.Err)
+	}
+	if accounts != 1000 {
+		t.Fatalf("account count mismatch: have %d, want %d", accounts, 1000)
+	}
+}
+
+func mustOpenTrie(t *testing.T, db state.Database, root common.Hash) state.Trie {
+	tr, err := db.OpenTrie(root)
+	if err != nil {
+		t.Fatalf("failed to open trie: %v", err)
+	}
+	return tr
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/p2p"
 	"github.com/etherzero/go-etherzero/trie"
 	"github.com/etherzero/go-etherzero/crypto/sha3"
 )
@@ -57,9 +58,13 @@ type stateSyncStats struct {
 	pending    uint64 // Number of still pending state entries
 }
 
-// syncState starts downloading state with the given root hash.
+// syncState starts downloading state with the given root hash. During snapshot
+// sync the state is assembled from ranges first, until that phase completes.
 func (d *Downloader) syncState(root common.Hash) *stateSync {
 	s := newStateSync(d, root)
+	if d.snapSync && (d.snapProgress == nil || !d.snapProgress.done) {
+		s.snap = newSnapSync(d, root)
+	}
 	select {
 	case d.stateSyncStart <- s:
 	case <-d.quitCh:
@@ -80,6 +85,8 @@ func (d *Downloader) stateFetcher() {
 			}
 		case <-d.stateCh:
 			// Ignore state responses while no sync is running.
+		case <-d.snapCh:
+			// Ignore state ranges while no sync is running.
 		case <-d.quitCh:
 			return
 		}
@@ -93,6 +100,7 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 		active   = make(map[string]*stateReq) // Currently in-flight requests
 		finished []*stateReq                  // Completed or failed requests
 		timeout  = make(chan *stateReq)       // Timed out active requests
+		ranges   []dataPack                   // Delivered state ranges
 	)
 	defer func() {
 		// Cancel active request timers on exit. Also set peers to idle so they're
@@ -121,6 +129,14 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 			deliverReq = finished[0]
 			deliverReqCh = s.deliver
 		}
+		var (
+			deliverRange   dataPack
+			deliverRangeCh chan dataPack
+		)
+		if len(ranges) > 0 {
+			deliverRange = ranges[0]
+			deliverRangeCh = s.snap.deliver
+		}
 
 		select {
 		// The stateSync lifecycle:
@@ -137,6 +153,24 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 			finished[len(finished)-1] = nil
 			finished = finished[:len(finished)-1]
 
+		// Send the next state range to the current sync:
+		case deliverRangeCh <- deliverRange:
+			ranges[0] = nil
+			ranges = ranges[1:]
+
+		// Handle incoming state range packs:
+		case pack := <-d.snapCh:
+			// Discard any ranges not requested by a running range retrieval
+			if s.snap == nil {
+				continue
+			}
+			select {
+			case <-s.snap.finished:
+				continue
+			default:
+			}
+			ranges = append(ranges, pack)
+
 		// Handle incoming state packs:
 		case pack := <-d.stateCh:
 			// Discard any data not requested (or previously timed out)
@@ -215,6 +249,7 @@ type stateSync struct {
 	d *Downloader // Downloader instance to access and manage current peerset
 
 	sched  *trie.Sync                 // State trie sync scheduler defining the tasks
+	snap   *snapSync                  // State range retrieval preceding the trie sync (snapshot sync)
 	keccak hash.Hash                  // Keccak256 hasher to verify deliveries with
 	tasks  map[common.Hash]*stateTask // Set of tasks currently queued for retrieval
 
@@ -275,6 +310,14 @@ func (s *stateSync) Cancel() error {
 // pushed here async. The reason is to decouple processing from data receipt
 // and timeouts.
 func (s *stateSync) loop() (err error) {
+	// Assemble as much of the state as possible from verified ranges, leaving
+	// only the range boundaries and contract code to the trie sync
+	if s.snap != nil {
+		if err := s.snap.run(s.cancel); err != nil {
+			return err
+		}
+		s.snap.heal(s.sched)
+	}
 	// Listen for new peer events to assign tasks to them
 	newPeer := make(chan *peerConnection, 1024)
 	peerSub := s.d.peers.SubscribeNewPeers(newPeer)
@@ -310,6 +353,7 @@ func (s *stateSync) loop() (err error) {
 				// 2 items are the minimum requested, if even that times out, we've no use of
 				// this peer at the moment.
 				log.Warn("Stalling state sync, dropping peer", "peer", req.peer.id)
+				req.peer.AdjustScore(p2p.ScoreTimeout, "stalling state sync")
 				s.d.dropPeer(req.peer.id)
 			}
 			// Process all the received blobs and check for stale delivery
@@ -38,6 +38,12 @@ var (
 	testGenesis = core.GenesisBlockForTesting(testDB, testAddress, big.NewInt(1000000000))
 )
 
+// testNumber returns the number of the block at the given position in the test
+// chains, which start at the genesis block.
+func testNumber(i int) uint64 {
+	return testGenesis.NumberU64() + uint64(i)
+}
+
 // The common prefix of all test chains:
 var testChainBase = newTestChain(blockCacheItems+200, testGenesis)
 
@@ -179,7 +185,10 @@ func (tc *testChain) headersByHash(origin common.Hash, amount int, skip int) []*
 // headersByNumber returns headers in ascending order from the given number.
 func (tc *testChain) headersByNumber(origin uint64, amount int, skip int) []*types.Header {
 	result := make([]*types.Header, 0, amount)
-	for num := origin; num < uint64(len(tc.chain)) && len(result) < amount; num += uint64(skip) + 1 {
+	if origin < tc.genesis.NumberU64() {
+		return result
+	}
+	for num := origin - tc.genesis.NumberU64(); num < uint64(len(tc.chain)) && len(result) < amount; num += uint64(skip) + 1 {
 		if header, ok := tc.headerm[tc.chain[int(num)]]; ok {
 			result = append(result, header)
 		}
@@ -187,6 +196,15 @@ func (tc *testChain) headersByNumber(origin uint64, amount int, skip int) []*typ
 	return result
 }
 
+// headers returns all headers of the chain in ascending order.
+func (tc *testChain) headers() []*types.Header {
+	headers := make([]*types.Header, 0, len(tc.chain))
+	for _, hash := range tc.chain {
+		headers = append(headers, tc.headerm[hash])
+	}
+	return headers
+}
+
 // receipts returns the receipts of the given block hashes.
 func (tc *testChain) receipts(hashes []common.Hash) [][]*types.Receipt {
 	results := make([][]*types.Receipt, 0, len(hashes))
@@ -214,7 +232,7 @@ func (tc *testChain) bodies(hashes []common.Hash) ([][]*types.Transaction, [][]*
 func (tc *testChain) hashToNumber(target common.Hash) (uint64, bool) {
 	for num, hash := range tc.chain {
 		if hash == target {
-			return uint64(num), true
+			return tc.genesis.NumberU64() + uint64(num), true
 		}
 	}
 	return 0, false
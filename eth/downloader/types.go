@@ -19,6 +19,7 @@ package downloader
 import (
 	"fmt"
 
+	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/core/types"
 )
 
@@ -77,3 +78,35 @@ type statePack struct {
 func (p *statePack) PeerId() string { return p.peerID }
 func (p *statePack) Items() int     { return len(p.states) }
 func (p *statePack) Stats() string  { return fmt.Sprintf("%d", len(p.states)) }
+
+// accountRangePack is a batch of account trie leaves returned by a peer.
+type accountRangePack struct {
+	peerID   string
+	id       uint64
+	hashes   []common.Hash
+	accounts [][]byte
+	proof    [][]byte
+}
+
+func (p *accountRangePack) PeerId() string { return p.peerID }
+func (p *accountRangePack) Items() int     { return len(p.hashes) }
+func (p *accountRangePack) Stats() string  { return fmt.Sprintf("%d", len(p.hashes)) }
+
+// storageRangesPack is a batch of storage trie leaves returned by a peer.
+type storageRangesPack struct {
+	peerID string
+	id     uint64
+	hashes [][]common.Hash
+	slots  [][][]byte
+	proof  [][]byte
+}
+
+func (p *storageRangesPack) PeerId() string { return p.peerID }
+func (p *storageRangesPack) Items() int {
+	items := 0
+	for _, hashes := range p.hashes {
+		items += len(hashes)
+	}
+	return items
+}
+func (p *storageRangesPack) Stats() string { return fmt.Sprintf("%d", p.Items()) }
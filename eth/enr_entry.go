@@ -0,0 +1,230 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"sync/atomic"
+	"time"
+
+	"github.com/etherzero/go-etherzero/accounts/abi/bind"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/forkid"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/rlp"
+)
+
+const (
+	etzEntryVersion = 2 // Layout version of the "etz" node record entry
+
+	maxMasternodeChecks   = 1024             // Number of contract lookups of advertised masternodes kept around
+	maxPendingMasternodes = 64               // Number of advertised masternodes queued for a contract lookup
+	masternodeCheckTTL    = 10 * time.Minute // Time after which an advertised masternode is looked up again
+)
+
+// masternodeCheck is the cached contract lookup of an advertised masternode.
+type masternodeCheck struct {
+	key     *ecdsa.PublicKey // Key the masternode is registered with, nil if unregistered
+	expires time.Time        // Time after which the contract is asked again
+}
+
+// etzEntry is the "etz" entry of the node record, advertising the chain a node
+// serves and, if it is a registered masternode, the masternode id it runs as.
+type etzEntry struct {
+	Version    uint // Layout version, entries of other versions are ignored
+	NetworkID  uint64
+	ForkID     forkid.ID // Fork identifier of the chain at the node's head
+	Masternode []byte    // Masternode id, empty if the node isn't a masternode
+	Signature  []byte    // Signature of the network and masternode id by the masternode key
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (e etzEntry) ENRKey() string {
+	return "etz"
+}
+
+// newEtzEntry creates the node record entry of the given chain. If a masternode
+// id is given, the entry is signed with the masternode key.
+func newEtzEntry(networkID uint64, forkID forkid.ID, id string, key *ecdsa.PrivateKey) *etzEntry {
+	entry := &etzEntry{Version: etzEntryVersion, NetworkID: networkID, ForkID: forkID}
+
+	if id == "" || key == nil {
+		return entry
+	}
+	blob, err := hex.DecodeString(id)
+	if err != nil {
+		return entry
+	}
+	sig, err := crypto.Sign(entry.sigHash(blob), key)
+	if err != nil {
+		log.Warn("Failed to sign masternode entry", "id", id, "err", err)
+		return entry
+	}
+	entry.Masternode, entry.Signature = blob, sig
+	return entry
+}
+
+// sigHash returns the hash signed by a masternode advertising the given id. The
+// fork id is left out, so the signature remains valid as the chain passes forks.
+func (e *etzEntry) sigHash(id []byte) []byte {
+	blob, _ := rlp.EncodeToBytes([]interface{}{e.NetworkID, id})
+	return crypto.Keccak256(blob)
+}
+
+// loadEtzEntry retrieves the entry from the record of the given node, unless it
+// is missing or of a different layout version.
+func loadEtzEntry(n *enode.Node) (*etzEntry, bool) {
+	var entry etzEntry
+	if err := n.Load(&entry); err != nil || entry.Version != etzEntryVersion {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// masternode returns the masternode id advertised in the entry, and the key
+// which signed it. Anyone can sign an entry, the key must still be checked
+// against the one the contract registers the masternode with.
+func (e *etzEntry) masternode() ([8]byte, *ecdsa.PublicKey, bool) {
+	var id [8]byte
+	if len(e.Masternode) != len(id) {
+		return id, nil, false
+	}
+	copy(id[:], e.Masternode)
+
+	pub, err := crypto.SigToPub(e.sigHash(e.Masternode), e.Signature)
+	if err != nil {
+		return id, nil, false
+	}
+	return id, pub, true
+}
+
+// currentEntry returns the node record entry advertising the chain of the
+// protocol manager at its current head, and the masternode id of the local
+// node if it is an active masternode.
+func (pm *ProtocolManager) currentEntry() *etzEntry {
+	var (
+		id  string
+		key *ecdsa.PrivateKey
+	)
+	if pm.mm != nil && atomic.LoadUint32(&pm.mm.IsMasternode) == 1 {
+		id, key = pm.mm.ID, pm.mm.PrivateKey
+	}
+	return newEtzEntry(pm.networkID, forkid.NewID(pm.blockchain), id, key)
+}
+
+// enrUpdateLoop keeps the entry of the local node record up to date as the
+// chain passes forks.
+func (pm *ProtocolManager) enrUpdateLoop(ln *enode.LocalNode) {
+	headCh := make(chan core.ChainHeadEvent, 10)
+	headSub := pm.blockchain.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+
+	current := forkid.NewID(pm.blockchain)
+	for {
+		select {
+		case <-headCh:
+			if next := forkid.NewID(pm.blockchain); next != current {
+				current = next
+				ln.Set(pm.currentEntry())
+			}
+		case <-headSub.Err():
+			return
+		case <-pm.quitSync:
+			return
+		}
+	}
+}
+
+// dialFilter rejects the discovered nodes advertising a different network, or
+// a fork id incompatible with the local chain. Nodes without an entry of the
+// known version are dialed and checked in the handshake.
+func (pm *ProtocolManager) dialFilter(n *enode.Node) bool {
+	entry, ok := loadEtzEntry(n)
+	if !ok {
+		return true
+	}
+	return entry.NetworkID == pm.networkID && pm.forkFilter(entry.ForkID) == nil
+}
+
+// dialPriority reports whether a discovered node advertises itself as a
+// masternode, signed by the key the contract registers that masternode with.
+// Dial candidates are never held up by the contract: unknown or expired
+// masternodes are queued for a lookup and reported by later discoveries.
+func (pm *ProtocolManager) dialPriority(n *enode.Node) bool {
+	entry, ok := loadEtzEntry(n)
+	if !ok {
+		return false
+	}
+	id, signer, ok := entry.masternode()
+	if !ok || pm.mm == nil || pm.mm.contract == nil {
+		return false
+	}
+	cached, ok := pm.masternodeChecks.Get(id)
+	if !ok || time.Now().After(cached.(*masternodeCheck).expires) {
+		select {
+		case pm.masternodeCheckCh <- id:
+		default:
+		}
+	}
+	if !ok {
+		return false
+	}
+	key := cached.(*masternodeCheck).key
+	if key == nil || !bytes.Equal(crypto.FromECDSAPub(key), crypto.FromECDSAPub(signer)) {
+		return false
+	}
+	return n.Pubkey() != nil && bytes.Equal(crypto.FromECDSAPub(key), crypto.FromECDSAPub(n.Pubkey()))
+}
+
+// masternodeCheckLoop looks up the masternodes queued by dialPriority in the
+// contract, caching the keys they are registered with.
+func (pm *ProtocolManager) masternodeCheckLoop() {
+	for {
+		select {
+		case id := <-pm.masternodeCheckCh:
+			if cached, ok := pm.masternodeChecks.Get(id); ok && time.Now().Before(cached.(*masternodeCheck).expires) {
+				continue
+			}
+			ctx, err := masternode.GetMasternodeContext(new(bind.CallOpts), pm.mm.contract, id)
+			if err != nil {
+				log.Trace("Failed to look up advertised masternode", "id", id, "err", err)
+				continue
+			}
+			check := &masternodeCheck{expires: time.Now().Add(masternodeCheckTTL)}
+			if ctx.Node.ENode != nil {
+				check.key = ctx.Node.ENode.Pubkey()
+			}
+			pm.masternodeChecks.Add(id, check)
+
+		case <-pm.quitSync:
+			return
+		}
+	}
+}
+
+// advertise updates the local node record with the masternode status.
+func (self *MasternodeManager) advertise() {
+	self.srvr.LocalNode().Set(self.eth.protocolManager.currentEntry())
+}
@@ -0,0 +1,209 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/etherzero/go-etherzero/contracts/masternode/contract"
+	"github.com/etherzero/go-etherzero/core/forkid"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/p2p/enr"
+	"github.com/hashicorp/golang-lru"
+)
+
+// signedNode creates a node record carrying the given entry, signed by key.
+func signedNode(t *testing.T, key *ecdsa.PrivateKey, entry *etzEntry) *enode.Node {
+	var r enr.Record
+	r.Set(entry)
+	if err := enode.SignV4(&r, key); err != nil {
+		t.Fatalf("failed to sign record: %v", err)
+	}
+	n, err := enode.New(enode.ValidSchemes, &r)
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	return n
+}
+
+// Tests that the masternode id advertised in a node record is returned along
+// with the key which signed it.
+func TestEtzEntryMasternode(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		other, _ = crypto.GenerateKey()
+		fork     = forkid.ID{Hash: [4]byte{0xd9, 0x86, 0xd9, 0x85}}
+	)
+	x8 := enode.NewV4(&key.PublicKey, nil, 0, 0).X8()
+	id := fmt.Sprintf("%x", x8[:])
+
+	// A masternode advertising its id reports its own key
+	n := signedNode(t, key, newEtzEntry(90, fork, id, key))
+
+	entry, ok := loadEtzEntry(n)
+	if !ok {
+		t.Fatalf("failed to load entry")
+	}
+	have, signer, ok := entry.masternode()
+	if !ok || have != x8 {
+		t.Fatalf("masternode mismatch: have %x (%v), want %x", have, ok, x8)
+	}
+	if !bytes.Equal(crypto.FromECDSAPub(signer), crypto.FromECDSAPub(&key.PublicKey)) {
+		t.Fatalf("signer mismatch: have %x, want %x", crypto.FromECDSAPub(signer), crypto.FromECDSAPub(&key.PublicKey))
+	}
+	if entry.NetworkID != 90 || entry.ForkID != fork {
+		t.Fatalf("chain mismatch: have %d/%x", entry.NetworkID, entry.ForkID)
+	}
+	// Entries without an id or key are not signed
+	for _, empty := range []*etzEntry{newEtzEntry(90, fork, "", key), newEtzEntry(90, fork, id, nil)} {
+		if len(empty.Masternode) != 0 || len(empty.Signature) != 0 {
+			t.Fatalf("masternode advertised without an id or key: %x", empty.Masternode)
+		}
+		if _, _, ok := empty.masternode(); ok {
+			t.Errorf("entry without an id accepted")
+		}
+	}
+	// An entry signed for another network reports another key
+	forged := newEtzEntry(90, fork, id, key)
+	forged.NetworkID = 91
+	if _, signer, ok := forged.masternode(); ok && bytes.Equal(crypto.FromECDSAPub(signer), crypto.FromECDSAPub(&key.PublicKey)) {
+		t.Errorf("entry of another network attributed to the masternode key")
+	}
+	// An entry signed by another key reports that key
+	if _, signer, _ := newEtzEntry(90, fork, id, other).masternode(); !bytes.Equal(crypto.FromECDSAPub(signer), crypto.FromECDSAPub(&other.PublicKey)) {
+		t.Errorf("signer mismatch of foreign entry")
+	}
+}
+
+// Tests that discovered nodes are only prioritised if they advertise an entry
+// signed by the key the contract registers the masternode with, and that the
+// contract lookups are queued rather than done while dialing.
+func TestDialPriority(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		other, _ = crypto.GenerateKey()
+	)
+	x8 := enode.NewV4(&key.PublicKey, nil, 0, 0).X8()
+	id := fmt.Sprintf("%x", x8[:])
+
+	pm := &ProtocolManager{
+		networkID:         90,
+		mm:                &MasternodeManager{contract: new(contract.Contract)},
+		masternodeCheckCh: make(chan [8]byte, 1),
+	}
+	pm.masternodeChecks, _ = lru.New(maxMasternodeChecks)
+
+	var (
+		masternode = signedNode(t, key, newEtzEntry(90, forkid.ID{}, id, key))
+		forged     = signedNode(t, other, newEtzEntry(90, forkid.ID{}, id, other))
+		copied     = signedNode(t, other, newEtzEntry(90, forkid.ID{}, id, key))
+	)
+	// Unknown masternodes are queued for a lookup, but not prioritised yet
+	if pm.dialPriority(masternode) {
+		t.Fatalf("unchecked masternode prioritised")
+	}
+	select {
+	case queued := <-pm.masternodeCheckCh:
+		if queued != x8 {
+			t.Fatalf("queued masternode mismatch: have %x, want %x", queued, x8)
+		}
+	default:
+		t.Fatalf("masternode not queued for a lookup")
+	}
+	// A full queue doesn't block dialing
+	pm.masternodeCheckCh <- [8]byte{}
+	if pm.dialPriority(masternode) {
+		t.Fatalf("unchecked masternode prioritised")
+	}
+	<-pm.masternodeCheckCh
+
+	// Once looked up, only the registered key is accepted
+	pm.masternodeChecks.Add(x8, &masternodeCheck{key: &key.PublicKey, expires: time.Now().Add(time.Minute)})
+	if !pm.dialPriority(masternode) {
+		t.Errorf("registered masternode not prioritised")
+	}
+	if pm.dialPriority(forged) {
+		t.Errorf("masternode signed by an unregistered key prioritised")
+	}
+	if pm.dialPriority(copied) {
+		t.Errorf("masternode entry copied into another record prioritised")
+	}
+	if len(pm.masternodeCheckCh) != 0 {
+		t.Errorf("fresh lookup queued again")
+	}
+	// Unregistered masternodes are not prioritised
+	pm.masternodeChecks.Add(x8, &masternodeCheck{expires: time.Now().Add(time.Minute)})
+	if pm.dialPriority(masternode) {
+		t.Errorf("unregistered masternode prioritised")
+	}
+	// Expired lookups are queued again, the previous result is used meanwhile
+	pm.masternodeChecks.Add(x8, &masternodeCheck{key: &key.PublicKey, expires: time.Now().Add(-time.Minute)})
+	if !pm.dialPriority(masternode) {
+		t.Errorf("expired masternode lookup not used")
+	}
+	if len(pm.masternodeCheckCh) != 1 {
+		t.Errorf("expired lookup not queued")
+	}
+}
+
+// legacyEtzEntry is the "etz" entry layout used before it was versioned.
+type legacyEtzEntry struct {
+	NetworkID  uint64
+	Masternode []byte
+	Signature  []byte
+}
+
+func (e legacyEtzEntry) ENRKey() string {
+	return "etz"
+}
+
+// Tests that only entries of the known layout version are loaded from records.
+func TestEtzEntryVersion(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	future := newEtzEntry(90, forkid.ID{}, "", nil)
+	future.Version = etzEntryVersion + 1
+
+	tests := []struct {
+		entry enr.Entry
+		ok    bool
+	}{
+		{newEtzEntry(90, forkid.ID{}, "", nil), true},
+		{future, false},
+		{&legacyEtzEntry{NetworkID: 1, Masternode: []byte{0x01}}, false},
+		{&legacyEtzEntry{NetworkID: 90}, false},
+	}
+	for i, tt := range tests {
+		var r enr.Record
+		r.Set(tt.entry)
+		if err := enode.SignV4(&r, key); err != nil {
+			t.Fatalf("test %d: failed to sign record: %v", i, err)
+		}
+		n, err := enode.New(enode.ValidSchemes, &r)
+		if err != nil {
+			t.Fatalf("test %d: failed to create node: %v", i, err)
+		}
+		if _, ok := loadEtzEntry(n); ok != tt.ok {
+			t.Errorf("test %d: load mismatch: have %v, want %v", i, ok, tt.ok)
+		}
+	}
+}
@@ -52,6 +52,10 @@ type headerRequesterFn func(common.Hash) error
 // bodyRequesterFn is a callback type for sending a body retrieval request.
 type bodyRequesterFn func([]common.Hash) error
 
+// blockTxsRequesterFn is a callback type for retrieving the transactions of a
+// compact block missing from the local pool.
+type blockTxsRequesterFn func(common.Hash, []uint64) error
+
 // headerVerifierFn is a callback type to verify a block's header for fast propagation.
 type headerVerifierFn func(header *types.Header) error
 
@@ -81,6 +85,41 @@ type announce struct {
 	fetchBodies bodyRequesterFn   // Fetcher function to retrieve the body of an announced block
 }
 
+// compact is a compact block propagation, being reassembled from the local
+// transaction pool.
+type compact struct {
+	header *types.Header        // Header of the propagated block
+	uncles []*types.Header      // Uncles of the propagated block
+	txs    []*types.Transaction // Transactions of the block, nil where missing from the pool
+	time   time.Time            // Arrival time of the compact block
+
+	origin string // Identifier of the peer propagating the block
+
+	fetchTxs    blockTxsRequesterFn // Fetcher function to retrieve the missing transactions
+	fetchHeader headerRequesterFn   // Fetcher function to retrieve the header if reassembly fails
+	fetchBodies bodyRequesterFn     // Fetcher function to retrieve the body if reassembly fails
+}
+
+// missing returns the indexes of the transactions of the block missing from the
+// local pool.
+func (c *compact) missing() []uint64 {
+	var missing []uint64
+	for i, tx := range c.txs {
+		if tx == nil {
+			missing = append(missing, uint64(i))
+		}
+	}
+	return missing
+}
+
+// blockTxsTask represents the delivery of the missing transactions of a compact
+// block.
+type blockTxsTask struct {
+	peer string               // The source peer of the transactions
+	hash common.Hash          // Hash of the compact block the transactions belong to
+	txs  []*types.Transaction // Transactions missing from the compact block, in order
+}
+
 // headerFilterTask represents a batch of headers needing fetcher filtering.
 type headerFilterTask struct {
 	peer    string          // The source peer of block headers
@@ -107,8 +146,10 @@ type inject struct {
 // and scheduling them for retrieval.
 type Fetcher struct {
 	// Various event channels
-	notify chan *announce
-	inject chan *inject
+	notify  chan *announce
+	inject  chan *inject
+	compact chan *compact
+	fill    chan *blockTxsTask
 
 	blockFilter  chan chan []*types.Block
 	headerFilter chan chan *headerFilterTask
@@ -123,6 +164,7 @@ type Fetcher struct {
 	fetching   map[common.Hash]*announce   // Announced blocks, currently fetching
 	fetched    map[common.Hash][]*announce // Blocks with headers fetched, scheduled for body retrieval
 	completing map[common.Hash]*announce   // Blocks with headers, currently body-completing
+	compacting map[common.Hash]*compact    // Compact blocks, currently retrieving missing transactions
 
 	// Block cache
 	queue  *prque.Prque            // Queue containing the import operations (block number sorted)
@@ -150,6 +192,8 @@ func New(getBlock blockRetrievalFn, verifyHeader headerVerifierFn, broadcastBloc
 	return &Fetcher{
 		notify:         make(chan *announce),
 		inject:         make(chan *inject),
+		compact:        make(chan *compact),
+		fill:           make(chan *blockTxsTask),
 		blockFilter:    make(chan chan []*types.Block),
 		headerFilter:   make(chan chan *headerFilterTask),
 		bodyFilter:     make(chan chan *bodyFilterTask),
@@ -160,6 +204,7 @@ func New(getBlock blockRetrievalFn, verifyHeader headerVerifierFn, broadcastBloc
 		fetching:       make(map[common.Hash]*announce),
 		fetched:        make(map[common.Hash][]*announce),
 		completing:     make(map[common.Hash]*announce),
+		compacting:     make(map[common.Hash]*compact),
 		queue:          prque.New(nil),
 		queues:         make(map[string]int),
 		queued:         make(map[common.Hash]*inject),
@@ -218,6 +263,41 @@ func (f *Fetcher) Enqueue(peer string, block *types.Block) error {
 	}
 }
 
+// EnqueueCompact schedules a compact block for import, reassembled from the
+// given transactions found in the local pool. Missing transactions are retrieved
+// from the propagating peer, and should that fail, the block body is fetched in
+// full as if the block was announced.
+func (f *Fetcher) EnqueueCompact(peer string, header *types.Header, uncles []*types.Header, txs []*types.Transaction, time time.Time,
+	txsFetcher blockTxsRequesterFn, headerFetcher headerRequesterFn, bodyFetcher bodyRequesterFn) error {
+	block := &compact{
+		header:      header,
+		uncles:      uncles,
+		txs:         txs,
+		time:        time,
+		origin:      peer,
+		fetchTxs:    txsFetcher,
+		fetchHeader: headerFetcher,
+		fetchBodies: bodyFetcher,
+	}
+	select {
+	case f.compact <- block:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// DeliverBlockTxs injects the transactions of a compact block retrieved from a
+// remote peer.
+func (f *Fetcher) DeliverBlockTxs(peer string, hash common.Hash, txs []*types.Transaction) error {
+	select {
+	case f.fill <- &blockTxsTask{peer: peer, hash: hash, txs: txs}:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
 // FilterHeaders extracts all the headers that were explicitly requested by the fetcher,
 // returning those that should be handled differently.
 func (f *Fetcher) FilterHeaders(peer string, headers []*types.Header, time time.Time) []*types.Header {
@@ -288,6 +368,13 @@ func (f *Fetcher) loop() {
 				f.forgetHash(hash)
 			}
 		}
+		// Fall back to full retrieval of compact blocks not completed in time
+		for hash, block := range f.compacting {
+			if time.Since(block.time) > fetchTimeout {
+				delete(f.compacting, hash)
+				f.fallbackCompact(block, completeTimer)
+			}
+		}
 		// Import any queued blocks that could potentially fit
 		height := f.chainHeight()
 		for !f.queue.Empty() {
@@ -357,6 +444,56 @@ func (f *Fetcher) loop() {
 			propBroadcastInMeter.Mark(1)
 			f.enqueue(op.origin, op.block)
 
+		case block := <-f.compact:
+			// A compact block was propagated, reassemble it if not yet known
+			propCompactInMeter.Mark(1)
+
+			hash := block.header.Hash()
+			if f.queued[hash] != nil || f.compacting[hash] != nil || f.getBlock(hash) != nil {
+				break
+			}
+			missing := block.missing()
+			if len(missing) == 0 {
+				f.completeCompact(block, completeTimer)
+				break
+			}
+			// Transactions are missing from the pool, make sure the peer isn't DOSing us
+			count := 0
+			for _, pending := range f.compacting {
+				if pending.origin == block.origin {
+					count++
+				}
+			}
+			if count >= blockLimit {
+				log.Debug("Discarded compact block, exceeded allowance", "peer", block.origin, "number", block.header.Number, "hash", hash, "limit", blockLimit)
+				propCompactDOSMeter.Mark(1)
+				break
+			}
+			log.Trace("Fetching missing compact block transactions", "peer", block.origin, "number", block.header.Number, "hash", hash, "missing", len(missing))
+			f.compacting[hash] = block
+
+			compactFetchMeter.Mark(int64(len(missing)))
+			go block.fetchTxs(hash, missing)
+
+		case task := <-f.fill:
+			// Missing transactions of a compact block arrived, reassemble it
+			block := f.compacting[task.hash]
+			if block == nil || block.origin != task.peer {
+				break
+			}
+			delete(f.compacting, task.hash)
+
+			missing := block.missing()
+			if len(task.txs) != len(missing) {
+				log.Debug("Invalid compact block transactions", "peer", task.peer, "hash", task.hash, "have", len(task.txs), "want", len(missing))
+				f.fallbackCompact(block, completeTimer)
+				break
+			}
+			for i, index := range missing {
+				block.txs[index] = task.txs[i]
+			}
+			f.completeCompact(block, completeTimer)
+
 		case hash := <-f.done:
 			// A pending import finished, remove all traces of the notification
 			f.forgetHash(hash)
@@ -564,6 +701,52 @@ func (f *Fetcher) loop() {
 	}
 }
 
+// completeCompact assembles a compact block with all its transactions and
+// schedules it for import. If the transactions don't match the header, e.g.
+// because a short id matched the wrong pool transaction, the body is retrieved
+// in full instead.
+func (f *Fetcher) completeCompact(block *compact, complete *time.Timer) {
+	if types.DeriveSha(types.Transactions(block.txs)) != block.header.TxHash || types.CalcUncleHash(block.uncles) != block.header.UncleHash {
+		log.Debug("Compact block reassembly failed", "peer", block.origin, "number", block.header.Number, "hash", block.header.Hash())
+		f.fallbackCompact(block, complete)
+		return
+	}
+	assembled := types.NewBlockWithHeader(block.header).WithBody(block.txs, block.uncles)
+	assembled.ReceivedAt = block.time
+
+	f.enqueue(block.origin, assembled)
+}
+
+// fallbackCompact schedules the body of a compact block that couldn't be
+// reassembled for retrieval in full from its origin.
+func (f *Fetcher) fallbackCompact(block *compact, complete *time.Timer) {
+	hash := block.header.Hash()
+	if f.queued[hash] != nil || f.fetching[hash] != nil || f.completing[hash] != nil || f.getBlock(hash) != nil {
+		return
+	}
+	count := f.announces[block.origin] + 1
+	if count > hashLimit {
+		log.Debug("Peer exceeded outstanding announces", "peer", block.origin, "limit", hashLimit)
+		propAnnounceDOSMeter.Mark(1)
+		return
+	}
+	propCompactFallbackMeter.Mark(1)
+
+	f.announces[block.origin] = count
+	f.fetched[hash] = append(f.fetched[hash], &announce{
+		hash:        hash,
+		number:      block.header.Number.Uint64(),
+		header:      block.header,
+		time:        time.Now(),
+		origin:      block.origin,
+		fetchHeader: block.fetchHeader,
+		fetchBodies: block.fetchBodies,
+	})
+	if len(f.fetched) == 1 {
+		f.rescheduleComplete(complete)
+	}
+}
+
 // rescheduleFetch resets the specified fetch timer to the next announce timeout.
 func (f *Fetcher) rescheduleFetch(fetch *time.Timer) {
 	// Short circuit if no blocks are announced
@@ -733,4 +916,5 @@ func (f *Fetcher) forgetBlock(hash common.Hash) {
 		}
 		delete(f.queued, hash)
 	}
+	delete(f.compacting, hash)
 }
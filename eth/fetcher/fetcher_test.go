@@ -38,7 +38,7 @@ var (
 	testKey, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 	testAddress  = crypto.PubkeyToAddress(testKey.PublicKey)
 	genesis      = core.GenesisBlockForTesting(testdb, testAddress, big.NewInt(1000000000))
-	unknownBlock = types.NewBlock(&types.Header{GasLimit: params.GenesisGasLimit}, nil, nil, nil)
+	unknownBlock = types.NewBlock(&types.Header{GasLimit: params.GenesisGasLimit, Number: genesis.Number()}, nil, nil, nil)
 )
 
 // makeChain creates a chain of n blocks starting at and including parent.
@@ -60,7 +60,7 @@ func makeChain(n int, seed byte, parent *types.Block) ([]common.Hash, map[common
 		}
 		// If the block number is a multiple of 5, add a bonus uncle to the block
 		if i%5 == 0 {
-			block.AddUncle(&types.Header{ParentHash: block.PrevBlock(i - 1).Hash(), Number: big.NewInt(int64(i - 1))})
+			block.AddUncle(&types.Header{ParentHash: block.PrevBlock(i - 1).Hash(), Number: block.PrevBlock(i - 1).Number()})
 		}
 	})
 	hashes := make([]common.Hash, n+1)
@@ -291,7 +291,7 @@ func testSequentialAnnouncements(t *testing.T, protocol int) {
 	tester.fetcher.importedHook = func(block *types.Block) { imported <- block }
 
 	for i := len(hashes) - 2; i >= 0; i-- {
-		tester.fetcher.Notify("valid", hashes[i], uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
+		tester.fetcher.Notify("valid", hashes[i], genesis.NumberU64()+uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
 		verifyImportEvent(t, imported, true)
 	}
 	verifyImportDone(t, imported)
@@ -329,9 +329,9 @@ func testConcurrentAnnouncements(t *testing.T, protocol int) {
 	tester.fetcher.importedHook = func(block *types.Block) { imported <- block }
 
 	for i := len(hashes) - 2; i >= 0; i-- {
-		tester.fetcher.Notify("first", hashes[i], uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), firstHeaderWrapper, firstBodyFetcher)
-		tester.fetcher.Notify("second", hashes[i], uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout+time.Millisecond), secondHeaderWrapper, secondBodyFetcher)
-		tester.fetcher.Notify("second", hashes[i], uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout-time.Millisecond), secondHeaderWrapper, secondBodyFetcher)
+		tester.fetcher.Notify("first", hashes[i], genesis.NumberU64()+uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), firstHeaderWrapper, firstBodyFetcher)
+		tester.fetcher.Notify("second", hashes[i], genesis.NumberU64()+uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout+time.Millisecond), secondHeaderWrapper, secondBodyFetcher)
+		tester.fetcher.Notify("second", hashes[i], genesis.NumberU64()+uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout-time.Millisecond), secondHeaderWrapper, secondBodyFetcher)
 		verifyImportEvent(t, imported, true)
 	}
 	verifyImportDone(t, imported)
@@ -366,7 +366,7 @@ func testOverlappingAnnouncements(t *testing.T, protocol int) {
 	tester.fetcher.importedHook = func(block *types.Block) { imported <- block }
 
 	for i := len(hashes) - 2; i >= 0; i-- {
-		tester.fetcher.Notify("valid", hashes[i], uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
+		tester.fetcher.Notify("valid", hashes[i], genesis.NumberU64()+uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
 		select {
 		case <-imported:
 		case <-time.After(time.Second):
@@ -405,7 +405,7 @@ func testPendingDeduplication(t *testing.T, protocol int) {
 	}
 	// Announce the same block many times until it's fetched (wait for any pending ops)
 	for tester.getBlock(hashes[0]) == nil {
-		tester.fetcher.Notify("repeater", hashes[0], 1, time.Now().Add(-arriveTimeout), headerWrapper, bodyFetcher)
+		tester.fetcher.Notify("repeater", hashes[0], genesis.NumberU64()+1, time.Now().Add(-arriveTimeout), headerWrapper, bodyFetcher)
 		time.Sleep(time.Millisecond)
 	}
 	time.Sleep(delay)
@@ -441,12 +441,12 @@ func testRandomArrivalImport(t *testing.T, protocol int) {
 
 	for i := len(hashes) - 1; i >= 0; i-- {
 		if i != skip {
-			tester.fetcher.Notify("valid", hashes[i], uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
+			tester.fetcher.Notify("valid", hashes[i], genesis.NumberU64()+uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
 			time.Sleep(time.Millisecond)
 		}
 	}
 	// Finally announce the skipped entry and check full import
-	tester.fetcher.Notify("valid", hashes[skip], uint64(len(hashes)-skip-1), time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
+	tester.fetcher.Notify("valid", hashes[skip], genesis.NumberU64()+uint64(len(hashes)-skip-1), time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
 	verifyImportCount(t, imported, len(hashes)-1)
 }
 
@@ -472,7 +472,7 @@ func testQueueGapFill(t *testing.T, protocol int) {
 
 	for i := len(hashes) - 1; i >= 0; i-- {
 		if i != skip {
-			tester.fetcher.Notify("valid", hashes[i], uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
+			tester.fetcher.Notify("valid", hashes[i], genesis.NumberU64()+uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
 			time.Sleep(time.Millisecond)
 		}
 	}
@@ -508,7 +508,7 @@ func testImportDeduplication(t *testing.T, protocol int) {
 	tester.fetcher.importedHook = func(block *types.Block) { imported <- block }
 
 	// Announce the duplicating block, wait for retrieval, and also propagate directly
-	tester.fetcher.Notify("valid", hashes[0], 1, time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
+	tester.fetcher.Notify("valid", hashes[0], genesis.NumberU64()+1, time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
 	<-fetching
 
 	tester.fetcher.Enqueue("valid", blocks[hashes[0]])
@@ -617,7 +617,7 @@ func testInvalidNumberAnnouncement(t *testing.T, protocol int) {
 	tester.fetcher.importedHook = func(block *types.Block) { imported <- block }
 
 	// Announce a block with a bad number, check for immediate drop
-	tester.fetcher.Notify("bad", hashes[0], 2, time.Now().Add(-arriveTimeout), badHeaderFetcher, badBodyFetcher)
+	tester.fetcher.Notify("bad", hashes[0], genesis.NumberU64()+2, time.Now().Add(-arriveTimeout), badHeaderFetcher, badBodyFetcher)
 	verifyImportEvent(t, imported, false)
 
 	tester.lock.RLock()
@@ -631,7 +631,7 @@ func testInvalidNumberAnnouncement(t *testing.T, protocol int) {
 	goodHeaderFetcher := tester.makeHeaderFetcher("good", blocks, -gatherSlack)
 	goodBodyFetcher := tester.makeBodyFetcher("good", blocks, 0)
 	// Make sure a good announcement passes without a drop
-	tester.fetcher.Notify("good", hashes[0], 1, time.Now().Add(-arriveTimeout), goodHeaderFetcher, goodBodyFetcher)
+	tester.fetcher.Notify("good", hashes[0], genesis.NumberU64()+1, time.Now().Add(-arriveTimeout), goodHeaderFetcher, goodBodyFetcher)
 	verifyImportEvent(t, imported, true)
 
 	tester.lock.RLock()
@@ -670,7 +670,7 @@ func testEmptyBlockShortCircuit(t *testing.T, protocol int) {
 
 	// Iteratively announce blocks until all are imported
 	for i := len(hashes) - 2; i >= 0; i-- {
-		tester.fetcher.Notify("valid", hashes[i], uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
+		tester.fetcher.Notify("valid", hashes[i], genesis.NumberU64()+uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
 
 		// All announces should fetch the header
 		verifyFetchingEvent(t, fetching, true)
@@ -717,9 +717,9 @@ func testHashMemoryExhaustionAttack(t *testing.T, protocol int) {
 	// Feed the tester a huge hashset from the attacker, and a limited from the valid peer
 	for i := 0; i < len(attack); i++ {
 		if i < maxQueueDist {
-			tester.fetcher.Notify("valid", hashes[len(hashes)-2-i], uint64(i+1), time.Now(), validHeaderFetcher, validBodyFetcher)
+			tester.fetcher.Notify("valid", hashes[len(hashes)-2-i], genesis.NumberU64()+uint64(i+1), time.Now(), validHeaderFetcher, validBodyFetcher)
 		}
-		tester.fetcher.Notify("attacker", attack[i], 1 /* don't distance drop */, time.Now(), attackerHeaderFetcher, attackerBodyFetcher)
+		tester.fetcher.Notify("attacker", attack[i], genesis.NumberU64()+1 /* don't distance drop */, time.Now(), attackerHeaderFetcher, attackerBodyFetcher)
 	}
 	if count := atomic.LoadInt32(&announces); count != hashLimit+maxQueueDist {
 		t.Fatalf("queued announce count mismatch: have %d, want %d", count, hashLimit+maxQueueDist)
@@ -729,7 +729,7 @@ func testHashMemoryExhaustionAttack(t *testing.T, protocol int) {
 
 	// Feed the remaining valid hashes to ensure DOS protection state remains clean
 	for i := len(hashes) - maxQueueDist - 2; i >= 0; i-- {
-		tester.fetcher.Notify("valid", hashes[i], uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), validHeaderFetcher, validBodyFetcher)
+		tester.fetcher.Notify("valid", hashes[i], genesis.NumberU64()+uint64(len(hashes)-i-1), time.Now().Add(-arriveTimeout), validHeaderFetcher, validBodyFetcher)
 		verifyImportEvent(t, imported, true)
 	}
 	verifyImportDone(t, imported)
@@ -788,3 +788,115 @@ func TestBlockMemoryExhaustionAttack(t *testing.T) {
 	}
 	verifyImportDone(t, imported)
 }
+
+// newCompactTester creates a fetcher importing the children of the given parent
+// block, reporting imported blocks on the returned channel.
+func newCompactTester(parent *types.Block) (*Fetcher, chan *types.Block) {
+	imported := make(chan *types.Block, 1)
+	fetcher := New(
+		func(hash common.Hash) *types.Block {
+			if hash == parent.Hash() {
+				return parent
+			}
+			return nil
+		},
+		func(*types.Header) error { return nil },
+		func(*types.Block, bool) {},
+		func() uint64 { return parent.NumberU64() },
+		func(blocks types.Blocks) (int, error) {
+			imported <- blocks[0]
+			return 0, nil
+		},
+		func(string) {},
+	)
+	fetcher.Start()
+	return fetcher, imported
+}
+
+// makeCompactBlock creates a child of the given parent with a few transactions.
+func makeCompactBlock(parent *types.Block) *types.Block {
+	var txs []*types.Transaction
+	for i := uint64(0); i < 3; i++ {
+		txs = append(txs, types.NewTransaction(i, common.Address{}, big.NewInt(1), params.TxGas, nil, nil))
+	}
+	header := &types.Header{ParentHash: parent.Hash(), Number: new(big.Int).Add(parent.Number(), common.Big1)}
+	return types.NewBlock(header, txs, nil, nil)
+}
+
+// Tests that compact blocks are reassembled with the transactions retrieved from
+// their origin and imported.
+func TestCompactBlockReassembly(t *testing.T) {
+	parent := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})
+	block := makeCompactBlock(parent)
+
+	fetcher, imported := newCompactTester(parent)
+	defer fetcher.Stop()
+
+	requested := make(chan []uint64, 1)
+	txsFetcher := func(hash common.Hash, indexes []uint64) error {
+		requested <- indexes
+		return nil
+	}
+	// Enqueue the block with a transaction missing from the pool
+	txs := append([]*types.Transaction{}, block.Transactions()...)
+	txs[1] = nil
+	fetcher.EnqueueCompact("peer", block.Header(), nil, txs, time.Now(), txsFetcher, nil, nil)
+
+	select {
+	case indexes := <-requested:
+		if len(indexes) != 1 || indexes[0] != 1 {
+			t.Fatalf("requested indexes mismatch: have %v, want [1]", indexes)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("missing transactions not requested")
+	}
+	fetcher.DeliverBlockTxs("peer", block.Hash(), []*types.Transaction{block.Transactions()[1]})
+
+	select {
+	case have := <-imported:
+		if have.Hash() != block.Hash() {
+			t.Fatalf("imported block mismatch: have %x, want %x", have.Hash(), block.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("reassembled block not imported")
+	}
+}
+
+// Tests that compact blocks failing to reassemble are retrieved in full from
+// their origin.
+func TestCompactBlockFallback(t *testing.T) {
+	parent := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})
+	block := makeCompactBlock(parent)
+
+	fetcher, imported := newCompactTester(parent)
+	defer fetcher.Stop()
+
+	requested := make(chan []common.Hash, 1)
+	bodyFetcher := func(hashes []common.Hash) error {
+		requested <- hashes
+		return nil
+	}
+	// Enqueue the block with a wrong transaction in place of one from the pool
+	txs := append([]*types.Transaction{}, block.Transactions()...)
+	txs[2] = types.NewTransaction(9, common.Address{}, big.NewInt(1), params.TxGas, nil, nil)
+	fetcher.EnqueueCompact("peer", block.Header(), nil, txs, time.Now(), nil, nil, bodyFetcher)
+
+	select {
+	case hashes := <-requested:
+		if len(hashes) != 1 || hashes[0] != block.Hash() {
+			t.Fatalf("requested bodies mismatch: have %v, want %x", hashes, block.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("block body not requested")
+	}
+	fetcher.FilterBodies("peer", [][]*types.Transaction{block.Transactions()}, [][]*types.Header{nil}, time.Now())
+
+	select {
+	case have := <-imported:
+		if have.Hash() != block.Hash() {
+			t.Fatalf("imported block mismatch: have %x, want %x", have.Hash(), block.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("retrieved block not imported")
+	}
+}
@@ -33,11 +33,25 @@ var (
 	propBroadcastDropMeter = metrics.NewRegisteredMeter("eth/fetcher/prop/broadcasts/drop", nil)
 	propBroadcastDOSMeter  = metrics.NewRegisteredMeter("eth/fetcher/prop/broadcasts/dos", nil)
 
-	headerFetchMeter = metrics.NewRegisteredMeter("eth/fetcher/fetch/headers", nil)
-	bodyFetchMeter   = metrics.NewRegisteredMeter("eth/fetcher/fetch/bodies", nil)
+	propCompactInMeter       = metrics.NewRegisteredMeter("eth/fetcher/prop/compacts/in", nil)
+	propCompactFallbackMeter = metrics.NewRegisteredMeter("eth/fetcher/prop/compacts/fallback", nil)
+	propCompactDOSMeter      = metrics.NewRegisteredMeter("eth/fetcher/prop/compacts/dos", nil)
+
+	headerFetchMeter  = metrics.NewRegisteredMeter("eth/fetcher/fetch/headers", nil)
+	bodyFetchMeter    = metrics.NewRegisteredMeter("eth/fetcher/fetch/bodies", nil)
+	compactFetchMeter = metrics.NewRegisteredMeter("eth/fetcher/fetch/compacttxs", nil)
 
 	headerFilterInMeter  = metrics.NewRegisteredMeter("eth/fetcher/filter/headers/in", nil)
 	headerFilterOutMeter = metrics.NewRegisteredMeter("eth/fetcher/filter/headers/out", nil)
 	bodyFilterInMeter    = metrics.NewRegisteredMeter("eth/fetcher/filter/bodies/in", nil)
 	bodyFilterOutMeter   = metrics.NewRegisteredMeter("eth/fetcher/filter/bodies/out", nil)
+
+	txAnnounceInMeter    = metrics.NewRegisteredMeter("eth/fetcher/tx/announces/in", nil)
+	txAnnounceKnownMeter = metrics.NewRegisteredMeter("eth/fetcher/tx/announces/known", nil)
+	txAnnounceDOSMeter   = metrics.NewRegisteredMeter("eth/fetcher/tx/announces/dos", nil)
+
+	txBroadcastInMeter  = metrics.NewRegisteredMeter("eth/fetcher/tx/broadcasts/in", nil)
+	txReplyInMeter      = metrics.NewRegisteredMeter("eth/fetcher/tx/replies/in", nil)
+	txRequestOutMeter   = metrics.NewRegisteredMeter("eth/fetcher/tx/requests/out", nil)
+	txFetchTimeoutMeter = metrics.NewRegisteredMeter("eth/fetcher/tx/requests/timeout", nil)
 )
@@ -0,0 +1,393 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/mclock"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/log"
+)
+
+const (
+	txArriveTimeout = 500 * time.Millisecond // Time allowance before an announced transaction is explicitly requested
+	txFetchTimeout  = 5 * time.Second        // Maximum allotted time to return an explicitly requested transaction
+	maxTxAnnounces  = 4096                   // Maximum number of unique transactions a peer may have announced
+	MaxTxRetrievals = 256                    // Maximum number of transactions to request or serve at once
+)
+
+// txPoolLookupFn is a callback type for checking whether a transaction is
+// already known to the local pool.
+type txPoolLookupFn func(common.Hash) bool
+
+// txPoolAddFn is a callback type for adding a batch of transactions to the
+// local pool.
+type txPoolAddFn func([]*types.Transaction) []error
+
+// txRequesterFn is a callback type for sending a transaction retrieval request.
+type txRequesterFn func(peer string, hashes []common.Hash) error
+
+// txAnnounce is the notification of the availability of a batch of new
+// transactions in the network.
+type txAnnounce struct {
+	origin string        // Identifier of the peer originating the notification
+	hashes []common.Hash // Hashes of the transactions being announced
+}
+
+// txDelivery is the notification that a batch of transactions has arrived,
+// either broadcast by a peer or in reply to a retrieval request.
+type txDelivery struct {
+	origin string        // Identifier of the peer delivering the transactions
+	hashes []common.Hash // Hashes of the transactions delivered
+	direct bool          // Whether the transactions are the reply to a request
+}
+
+// txRequest is a transaction retrieval request in flight.
+type txRequest struct {
+	hashes []common.Hash  // Transactions requested from the peer
+	time   mclock.AbsTime // Timestamp of the request
+}
+
+// TxFetcher is responsible for retrieving transactions announced by hash. The
+// announced transactions are given a short time to arrive through a regular
+// broadcast, after which they are requested from one of the peers announcing
+// them. Transactions not delivered in time are requested from the next peer.
+type TxFetcher struct {
+	// Various event channels
+	notify  chan *txAnnounce
+	cleanup chan *txDelivery
+	drop    chan string
+	quit    chan struct{}
+
+	// Announce states
+	waiting   map[common.Hash]mclock.AbsTime      // Announced transactions waiting for a broadcast, with the time of the first announcement
+	announces map[string]map[common.Hash]struct{} // Transactions announced by each peer and not yet delivered
+	announced map[common.Hash]map[string]struct{} // Peers announcing each transaction, eligible to retrieve it from
+	fetching  map[common.Hash]string              // Transactions being retrieved, with the peer queried
+	requests  map[string]*txRequest               // Retrieval request in flight to each peer
+
+	// Callbacks
+	hasTx    txPoolLookupFn // Checks whether a transaction is already in the pool
+	addTxs   txPoolAddFn    // Injects a batch of transactions into the pool
+	fetchTxs txRequesterFn  // Retrieves a batch of transactions from a peer
+
+	clock mclock.Clock // Time source, replaceable for testing
+}
+
+// NewTxFetcher creates a transaction fetcher to retrieve transactions based on
+// hash announcements.
+func NewTxFetcher(hasTx txPoolLookupFn, addTxs txPoolAddFn, fetchTxs txRequesterFn) *TxFetcher {
+	return newTxFetcher(hasTx, addTxs, fetchTxs, mclock.System{})
+}
+
+// newTxFetcher is the internal version of NewTxFetcher, running on the given
+// clock.
+func newTxFetcher(hasTx txPoolLookupFn, addTxs txPoolAddFn, fetchTxs txRequesterFn, clock mclock.Clock) *TxFetcher {
+	return &TxFetcher{
+		notify:    make(chan *txAnnounce),
+		cleanup:   make(chan *txDelivery),
+		drop:      make(chan string),
+		quit:      make(chan struct{}),
+		waiting:   make(map[common.Hash]mclock.AbsTime),
+		announces: make(map[string]map[common.Hash]struct{}),
+		announced: make(map[common.Hash]map[string]struct{}),
+		fetching:  make(map[common.Hash]string),
+		requests:  make(map[string]*txRequest),
+		hasTx:     hasTx,
+		addTxs:    addTxs,
+		fetchTxs:  fetchTxs,
+		clock:     clock,
+	}
+}
+
+// Start boots up the announcement based transaction retrieval, processing
+// announcements and deliveries until termination requested.
+func (f *TxFetcher) Start() {
+	go f.loop()
+}
+
+// Stop terminates the announcement based transaction retrieval, canceling all
+// pending operations.
+func (f *TxFetcher) Stop() {
+	close(f.quit)
+}
+
+// Notify announces the fetcher of the potential availability of a batch of new
+// transactions in the network.
+func (f *TxFetcher) Notify(peer string, hashes []common.Hash) error {
+	// Skip the transactions already in the pool, no need to bother the loop
+	unknown := make([]common.Hash, 0, len(hashes))
+	for _, hash := range hashes {
+		if !f.hasTx(hash) {
+			unknown = append(unknown, hash)
+		}
+	}
+	txAnnounceInMeter.Mark(int64(len(hashes)))
+	txAnnounceKnownMeter.Mark(int64(len(hashes) - len(unknown)))
+
+	if len(unknown) == 0 {
+		return nil
+	}
+	select {
+	case f.notify <- &txAnnounce{origin: peer, hashes: unknown}:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Enqueue imports a batch of transactions into the pool and marks them as no
+// longer needing retrieval. Direct deliveries are replies to a request of the
+// fetcher, any transaction requested but missing from them is retrieved from
+// another peer.
+func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool) error {
+	if direct {
+		txReplyInMeter.Mark(int64(len(txs)))
+	} else {
+		txBroadcastInMeter.Mark(int64(len(txs)))
+	}
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	f.addTxs(txs)
+
+	select {
+	case f.cleanup <- &txDelivery{origin: peer, hashes: hashes, direct: direct}:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Drop removes all the announcements of a disconnected peer, retrieving the
+// transactions requested from it from other peers.
+func (f *TxFetcher) Drop(peer string) error {
+	select {
+	case f.drop <- peer:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// loop is the main fetcher loop, checking and processing various notification
+// events.
+func (f *TxFetcher) loop() {
+	var (
+		timeout  <-chan time.Time // Timer firing at the next announce or request expiry
+		deadline mclock.AbsTime   // Time at which the active timer fires
+	)
+	for {
+		// (Re)arm the timer if some announce or request expires before it fires
+		if next, ok := f.nextDeadline(); ok && (timeout == nil || next < deadline) {
+			timeout, deadline = f.clock.After(time.Duration(next-f.clock.Now())), next
+		}
+		select {
+		case <-f.quit:
+			// Fetcher terminating, abort all operations
+			return
+
+		case ann := <-f.notify:
+			// Transactions were announced, make sure the peer isn't DOSing us
+			announces := f.announces[ann.origin]
+			if announces == nil {
+				announces = make(map[common.Hash]struct{})
+				f.announces[ann.origin] = announces
+			}
+			now := f.clock.Now()
+			for i, hash := range ann.hashes {
+				if len(announces) >= maxTxAnnounces {
+					log.Debug("Peer exceeded outstanding transaction announces", "peer", ann.origin, "limit", maxTxAnnounces)
+					txAnnounceDOSMeter.Mark(int64(len(ann.hashes) - i))
+					break
+				}
+				announces[hash] = struct{}{}
+
+				if f.announced[hash] == nil {
+					f.announced[hash] = make(map[string]struct{})
+					f.waiting[hash] = now
+				}
+				f.announced[hash][ann.origin] = struct{}{}
+			}
+			// Announcements of transactions already waited for may be fetched
+			// from the peer right away
+			f.schedule()
+
+		case delivery := <-f.cleanup:
+			// Transactions arrived, stop tracking them
+			for _, hash := range delivery.hashes {
+				f.forgetTx(hash)
+			}
+			if req := f.requests[delivery.origin]; req != nil && delivery.direct {
+				// The peer answered our request, requeue the transactions it
+				// didn't have for retrieval from the other announcers
+				delete(f.requests, delivery.origin)
+				for _, hash := range req.hashes {
+					if f.fetching[hash] == delivery.origin {
+						f.unfetch(hash, delivery.origin)
+					}
+				}
+			}
+			f.schedule()
+
+		case peer := <-f.drop:
+			// A peer disconnected, retrieve its pending transactions elsewhere
+			if req := f.requests[peer]; req != nil {
+				delete(f.requests, peer)
+				for _, hash := range req.hashes {
+					if f.fetching[hash] == peer {
+						delete(f.fetching, hash)
+					}
+				}
+			}
+			for hash := range f.announces[peer] {
+				f.forgetAnnounce(hash, peer)
+			}
+			delete(f.announces, peer)
+			f.schedule()
+
+		case <-timeout:
+			timeout = nil
+
+			// Move the announces waited for long enough into the retrieval queue
+			now := f.clock.Now()
+			for hash, announced := range f.waiting {
+				if time.Duration(now-announced) >= txArriveTimeout {
+					delete(f.waiting, hash)
+				}
+			}
+			// Requeue the transactions of timed out requests, disregarding the
+			// peer for their retrieval
+			for peer, req := range f.requests {
+				if time.Duration(now-req.time) < txFetchTimeout {
+					continue
+				}
+				log.Trace("Transaction retrieval timed out", "peer", peer, "count", len(req.hashes))
+				txFetchTimeoutMeter.Mark(int64(len(req.hashes)))
+
+				delete(f.requests, peer)
+				for _, hash := range req.hashes {
+					if f.fetching[hash] == peer {
+						f.unfetch(hash, peer)
+					}
+				}
+			}
+			f.schedule()
+		}
+	}
+}
+
+// nextDeadline returns the time at which the next waiting announce or request
+// in flight expires, if any.
+func (f *TxFetcher) nextDeadline() (mclock.AbsTime, bool) {
+	var (
+		next  mclock.AbsTime
+		found bool
+	)
+	for _, announced := range f.waiting {
+		if at := announced.Add(txArriveTimeout); !found || at < next {
+			next, found = at, true
+		}
+	}
+	for _, req := range f.requests {
+		if at := req.time.Add(txFetchTimeout); !found || at < next {
+			next, found = at, true
+		}
+	}
+	return next, found
+}
+
+// schedule requests the transactions which waited long enough for a broadcast
+// from the idle peers announcing them, at most one request per peer at a time.
+func (f *TxFetcher) schedule() {
+	now := f.clock.Now()
+	for peer, announces := range f.announces {
+		if f.requests[peer] != nil {
+			continue
+		}
+		var hashes []common.Hash
+		for hash := range announces {
+			if _, ok := f.waiting[hash]; ok {
+				continue
+			}
+			if _, ok := f.fetching[hash]; ok {
+				continue
+			}
+			f.fetching[hash] = peer
+			if hashes = append(hashes, hash); len(hashes) >= MaxTxRetrievals {
+				break
+			}
+		}
+		if len(hashes) == 0 {
+			continue
+		}
+		f.requests[peer] = &txRequest{hashes: hashes, time: now}
+		txRequestOutMeter.Mark(int64(len(hashes)))
+
+		log.Trace("Fetching scheduled transactions", "peer", peer, "count", len(hashes))
+		go func(peer string, hashes []common.Hash) {
+			if err := f.fetchTxs(peer, hashes); err != nil {
+				log.Debug("Failed to request transactions", "peer", peer, "err", err)
+			}
+		}(peer, hashes)
+	}
+}
+
+// unfetch returns a transaction which the given peer failed to deliver to the
+// retrieval queue, leaving it to the other peers that announced it.
+func (f *TxFetcher) unfetch(hash common.Hash, peer string) {
+	delete(f.fetching, hash)
+	if announces := f.announces[peer]; announces != nil {
+		delete(announces, hash)
+		if len(announces) == 0 {
+			delete(f.announces, peer)
+		}
+	}
+	f.forgetAnnounce(hash, peer)
+}
+
+// forgetAnnounce removes a peer from the announcers of a transaction, dropping
+// the transaction altogether if nobody else announced it.
+func (f *TxFetcher) forgetAnnounce(hash common.Hash, peer string) {
+	announced := f.announced[hash]
+	if announced == nil {
+		return
+	}
+	delete(announced, peer)
+	if len(announced) == 0 {
+		delete(f.announced, hash)
+		delete(f.waiting, hash)
+	}
+}
+
+// forgetTx removes all traces of a transaction from the fetcher.
+func (f *TxFetcher) forgetTx(hash common.Hash) {
+	for peer := range f.announced[hash] {
+		if announces := f.announces[peer]; announces != nil {
+			delete(announces, hash)
+			if len(announces) == 0 {
+				delete(f.announces, peer)
+			}
+		}
+	}
+	delete(f.announced, hash)
+	delete(f.waiting, hash)
+	delete(f.fetching, hash)
+}
@@ -0,0 +1,175 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/mclock"
+	"github.com/etherzero/go-etherzero/core/types"
+)
+
+// txFetchRequest is a transaction retrieval request issued by the fetcher.
+type txFetchRequest struct {
+	peer   string
+	hashes []common.Hash
+}
+
+// txFetcherTester is a test simulator for the transaction fetcher.
+type txFetcherTester struct {
+	fetcher  *TxFetcher
+	clock    *mclock.Simulated
+	requests chan *txFetchRequest
+
+	pool map[common.Hash]*types.Transaction
+	lock sync.Mutex
+}
+
+func newTxFetcherTester() *txFetcherTester {
+	tester := &txFetcherTester{
+		clock:    new(mclock.Simulated),
+		requests: make(chan *txFetchRequest, 16),
+		pool:     make(map[common.Hash]*types.Transaction),
+	}
+	tester.fetcher = newTxFetcher(
+		tester.hasTx,
+		tester.addTxs,
+		func(peer string, hashes []common.Hash) error {
+			tester.requests <- &txFetchRequest{peer: peer, hashes: hashes}
+			return nil
+		},
+		tester.clock,
+	)
+	tester.fetcher.Start()
+	return tester
+}
+
+// hasTx checks whether a transaction is in the tester's pool.
+func (tester *txFetcherTester) hasTx(hash common.Hash) bool {
+	tester.lock.Lock()
+	defer tester.lock.Unlock()
+
+	return tester.pool[hash] != nil
+}
+
+// addTxs adds a batch of transactions to the tester's pool.
+func (tester *txFetcherTester) addTxs(txs []*types.Transaction) []error {
+	tester.lock.Lock()
+	defer tester.lock.Unlock()
+
+	for _, tx := range txs {
+		tester.pool[tx.Hash()] = tx
+	}
+	return make([]error, len(txs))
+}
+
+// expectRequest waits for the fetcher to request the given transaction.
+func (tester *txFetcherTester) expectRequest(t *testing.T, hash common.Hash) *txFetchRequest {
+	select {
+	case req := <-tester.requests:
+		if len(req.hashes) != 1 || req.hashes[0] != hash {
+			t.Fatalf("requested hashes mismatch: have %v, want %v", req.hashes, hash)
+		}
+		return req
+	case <-time.After(time.Second):
+		t.Fatalf("transaction %x not requested", hash)
+	}
+	return nil
+}
+
+// expectNoRequest verifies that the fetcher doesn't request anything.
+func (tester *txFetcherTester) expectNoRequest(t *testing.T) {
+	select {
+	case req := <-tester.requests:
+		t.Fatalf("unexpected request to %s: %v", req.peer, req.hashes)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func testTransaction(nonce uint64) *types.Transaction {
+	return types.NewTransaction(nonce, common.Address{}, nil, 0, nil, nil)
+}
+
+// Tests that announced transactions arriving through a broadcast in time are
+// not explicitly requested.
+func TestTxFetcherBroadcastArrival(t *testing.T) {
+	tester := newTxFetcherTester()
+	defer tester.fetcher.Stop()
+
+	tx := testTransaction(0)
+	tester.fetcher.Notify("A", []common.Hash{tx.Hash()})
+	tester.clock.WaitForTimers(1)
+
+	tester.fetcher.Enqueue("B", []*types.Transaction{tx}, false)
+	tester.clock.Run(txArriveTimeout)
+	tester.expectNoRequest(t)
+}
+
+// Tests that announced transactions are requested once the arrival timeout
+// passes, and requested from another announcer if the first one times out.
+func TestTxFetcherRetrievalTimeout(t *testing.T) {
+	tester := newTxFetcherTester()
+	defer tester.fetcher.Stop()
+
+	hash := testTransaction(0).Hash()
+	tester.fetcher.Notify("A", []common.Hash{hash})
+	tester.fetcher.Notify("B", []common.Hash{hash})
+	tester.clock.WaitForTimers(1)
+
+	tester.clock.Run(txArriveTimeout)
+	first := tester.expectRequest(t, hash)
+	tester.expectNoRequest(t)
+
+	tester.clock.WaitForTimers(1)
+	tester.clock.Run(txFetchTimeout)
+	if second := tester.expectRequest(t, hash); second.peer == first.peer {
+		t.Fatalf("timed out peer %s queried again", first.peer)
+	}
+	// With both announcers timed out, the transaction is forgotten
+	tester.clock.WaitForTimers(1)
+	tester.clock.Run(txFetchTimeout)
+	tester.expectNoRequest(t)
+}
+
+// Tests that transactions missing from a reply are immediately requested from
+// the other announcers, while delivered ones are not requested again.
+func TestTxFetcherPartialReply(t *testing.T) {
+	tester := newTxFetcherTester()
+	defer tester.fetcher.Stop()
+
+	tx := testTransaction(0)
+	tester.fetcher.Notify("A", []common.Hash{tx.Hash()})
+	tester.fetcher.Notify("B", []common.Hash{tx.Hash()})
+	tester.clock.WaitForTimers(1)
+
+	tester.clock.Run(txArriveTimeout)
+	first := tester.expectRequest(t, tx.Hash())
+
+	tester.fetcher.Enqueue(first.peer, nil, true)
+	second := tester.expectRequest(t, tx.Hash())
+	if second.peer == first.peer {
+		t.Fatalf("peer %s missing the transaction queried again", first.peer)
+	}
+	tester.fetcher.Enqueue(second.peer, []*types.Transaction{tx}, true)
+	tester.fetcher.Notify("C", []common.Hash{tx.Hash()})
+	tester.clock.WaitForTimers(1)
+	tester.clock.Run(txFetchTimeout)
+	tester.expectNoRequest(t)
+}
@@ -31,6 +31,8 @@ import (
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/event"
+	"github.com/etherzero/go-etherzero/internal/ethapi"
+	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/rpc"
 )
 
@@ -133,9 +135,21 @@ func (api *PublicFilterAPI) NewPendingTransactionFilter() rpc.ID {
 	return pendingTxSub.ID
 }
 
+// PendingTransactionsCriteria are the options of a pending transactions
+// subscription, restricting it to transactions sent from or to the given
+// addresses and selecting whether full transactions are delivered.
+type PendingTransactionsCriteria struct {
+	FullTx bool             `json:"fullTx"`
+	From   []common.Address `json:"from"`
+	To     []common.Address `json:"to"`
+}
+
 // NewPendingTransactions creates a subscription that is triggered each time a transaction
 // enters the transaction pool and was signed from one of the transactions this nodes manages.
-func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+//
+// If criteria are given, only transactions sent from or to the listed addresses are
+// delivered, as full transaction objects if requested.
+func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context, crit *PendingTransactionsCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -143,6 +157,32 @@ func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Su
 
 	rpcSub := notifier.CreateSubscription()
 
+	if crit != nil {
+		go func() {
+			txs := make(chan []*types.Transaction, 128)
+			pendingTxSub := api.events.SubscribeFullPendingTxs(TransactionCriteria{From: crit.From, To: crit.To}, txs)
+
+			for {
+				select {
+				case txs := <-txs:
+					for _, tx := range txs {
+						if crit.FullTx {
+							notifier.Notify(rpcSub.ID, ethapi.NewRPCPendingTransaction(tx))
+						} else {
+							notifier.Notify(rpcSub.ID, tx.Hash())
+						}
+					}
+				case <-rpcSub.Err():
+					pendingTxSub.Unsubscribe()
+					return
+				case <-notifier.Closed():
+					pendingTxSub.Unsubscribe()
+					return
+				}
+			}
+		}()
+		return rpcSub, nil
+	}
 	go func() {
 		txHashes := make(chan []common.Hash, 128)
 		pendingTxSub := api.events.SubscribePendingTxs(txHashes)
@@ -168,6 +208,45 @@ func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Su
 	return rpcSub, nil
 }
 
+// TransactionReceipts creates a subscription that delivers the receipts of the given
+// transactions when they are included in the canonical chain. If a reorg removes a
+// transaction from the block it was reported in, its receipt is delivered again
+// with the removed property set to true.
+func (api *PublicFilterAPI) TransactionReceipts(ctx context.Context, hashes []common.Hash) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	receipts := make(chan []*ReceiptEvent, 128)
+	receiptsSub, err := api.events.SubscribeTransactionReceipts(hashes, receipts)
+	if err != nil {
+		return nil, err
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		for {
+			select {
+			case events := <-receipts:
+				for _, ev := range events {
+					fields := ethapi.RPCMarshalReceipt(ev.Receipt, ev.Tx, ev.BlockHash, ev.BlockNumber, ev.Index)
+					fields["removed"] = ev.Removed
+					notifier.Notify(rpcSub.ID, fields)
+				}
+			case <-rpcSub.Err():
+				receiptsSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				receiptsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // NewBlockFilter creates a filter that fetches blocks that are imported into the chain.
 // It is part of the filter package since polling goes with eth_getFilterChanges.
 //
@@ -249,14 +328,64 @@ func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc
 	if err != nil {
 		return nil, err
 	}
+	// If the range starts in the past, replay the historical logs up to the
+	// current head before streaming the live ones
+	var (
+		history  <-chan []*types.Log
+		replayed uint64
+		cancel   = func() {}
+	)
+	if crit.FromBlock != nil && crit.FromBlock.Sign() >= 0 {
+		if header, _ := api.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber); header != nil && header.Number.Cmp(crit.FromBlock) >= 0 {
+			head := header.Number.Uint64()
+			end := resolveBlock(crit.ToBlock, head)
+			if end > head {
+				end = head
+			}
+			if end >= crit.FromBlock.Uint64() && end-crit.FromBlock.Uint64() >= maxReplayBlocks {
+				logsSub.Unsubscribe()
+				return nil, fmt.Errorf("log replay spans more than %d blocks, use eth_getLogsPage", maxReplayBlocks)
+			}
+			var replayCtx context.Context
+			replayCtx, cancel = context.WithCancel(context.Background())
 
+			replayed = head
+			history = api.replayLogs(replayCtx, crit, replayed)
+		}
+	}
 	go func() {
+		defer cancel()
 
+		// Live logs arriving during the replay are held back until it's done
+		var pending []*types.Log
 		for {
 			select {
+			case logs, ok := <-history:
+				if !ok {
+					for _, log := range pending {
+						if log.Removed || log.BlockNumber > replayed {
+							notifier.Notify(rpcSub.ID, log)
+						}
+					}
+					history, pending = nil, nil
+					continue
+				}
+				for _, log := range logs {
+					notifier.Notify(rpcSub.ID, log)
+				}
 			case logs := <-matchedLogs:
+				if history != nil {
+					// Drop subscribers whose replay can't keep up with the chain
+					if len(pending)+len(logs) > maxPendingLogs {
+						log.Warn("Dropping lagging log subscription", "id", rpcSub.ID, "pending", len(pending)+len(logs))
+						logsSub.Unsubscribe()
+						return
+					}
+					pending = append(pending, logs...)
+					continue
+				}
 				for _, log := range logs {
-					notifier.Notify(rpcSub.ID, &log)
+					notifier.Notify(rpcSub.ID, log)
 				}
 			case <-rpcSub.Err(): // client send an unsubscribe request
 				logsSub.Unsubscribe()
@@ -342,11 +471,7 @@ func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([
 		filter = NewRangeFilter(api.backend, begin, end, crit.Addresses, crit.Topics)
 	}
 	// Run the filter and return all the logs
-	logs, err := filter.Logs(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return returnLogs(logs), err
+	return api.cappedLogs(ctx, filter)
 }
 
 // UninstallFilter removes the filter with the given filter id.
@@ -397,11 +522,7 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 		filter = NewRangeFilter(api.backend, begin, end, f.crit.Addresses, f.crit.Topics)
 	}
 	// Run the filter and return all the logs
-	logs, err := filter.Logs(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return returnLogs(logs), nil
+	return api.cappedLogs(ctx, filter)
 }
 
 // GetFilterChanges returns the logs for the filter with the given id since
@@ -22,11 +22,14 @@ import (
 	"math/big"
 
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/bitutil"
 	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/bloombits"
+	"github.com/etherzero/go-etherzero/core/rawdb"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/event"
+	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/rpc"
 )
 
@@ -45,6 +48,9 @@ type Backend interface {
 
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
+
+	LogIndexStatus() (uint64, uint64) // section size and number of sections of the log index
+	RPCLogCap() int                   // global cap on the logs returned by a single query
 }
 
 // Filter can be used to retrieve and filter logs.
@@ -58,6 +64,9 @@ type Filter struct {
 	block      common.Hash // Block hash if filtering a single block
 	begin, end int64       // Range interval if filtering multiple blocks
 
+	limit     int  // Number of logs after which range filtering stops (0 = unlimited)
+	truncated bool // Whether the last range filtering stopped at the limit
+
 	matcher *bloombits.Matcher
 }
 
@@ -114,6 +123,18 @@ func newFilter(backend Backend, addresses []common.Address, topics [][]common.Ha
 	}
 }
 
+// SetLimit sets the number of logs after which range filtering stops. Blocks are
+// never split, so the logs of the block reaching the limit are all returned.
+func (f *Filter) SetLimit(limit int) {
+	f.limit = limit
+}
+
+// Truncated returns whether the last range filtering stopped at the limit before
+// reaching the end of the range, and the block number to continue from.
+func (f *Filter) Truncated() (uint64, bool) {
+	return uint64(f.begin), f.truncated
+}
+
 // Logs searches the blockchain for matching log entries, returning all from the
 // first block that contains matches, updating the start of the filter accordingly.
 func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
@@ -142,30 +163,164 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if f.end == -1 {
 		end = head
 	}
-	// Gather all indexed logs, and finish with non indexed ones
+	f.truncated = false
+
+	// Serve sparse queries from the exact log index where available
 	var (
 		logs []*types.Log
 		err  error
 	)
+	if f.sparse() {
+		size, sections := f.backend.LogIndexStatus()
+		if indexed := params.GenesisBlockNumber + sections*size; sections > 0 && indexed > uint64(f.begin) {
+			if indexed > end {
+				logs, err = f.logIndexLogs(ctx, end, logs)
+			} else {
+				logs, err = f.logIndexLogs(ctx, indexed-1, logs)
+			}
+			if err != nil || f.truncated {
+				return logs, err
+			}
+		}
+	}
+	// Gather all bloom indexed logs, and finish with non indexed ones
 	size, sections := f.backend.BloomStatus()
 	if indexed := sections * size; indexed > uint64(f.begin) {
 		if indexed > end {
-			logs, err = f.indexedLogs(ctx, end)
+			logs, err = f.indexedLogs(ctx, end, logs)
 		} else {
-			logs, err = f.indexedLogs(ctx, indexed-1)
+			logs, err = f.indexedLogs(ctx, indexed-1, logs)
+		}
+		if err != nil || f.truncated {
+			return logs, err
 		}
+	}
+	return f.unindexedLogs(ctx, end, logs)
+}
+
+// sparse returns whether the filter restricts the addresses or topics of the
+// logs, permitting them to be looked up in the log index.
+func (f *Filter) sparse() bool {
+	if len(f.addresses) > 0 {
+		return true
+	}
+	for _, sub := range f.topics {
+		if len(sub) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// full checks whether the limit of the filter was reached by the logs gathered
+// up to the given block, marking the results truncated if the range continues.
+func (f *Filter) full(logs []*types.Log, number, end uint64) bool {
+	if f.limit <= 0 || len(logs) < f.limit {
+		return false
+	}
+	f.truncated = number < end
+	return true
+}
+
+// logIndexLogs appends the logs matching the filter criteria based on the exact
+// log index of the blocks containing each address and topic.
+func (f *Filter) logIndexLogs(ctx context.Context, end uint64, logs []*types.Log) ([]*types.Log, error) {
+	size, _ := f.backend.LogIndexStatus()
+	if f.begin < int64(params.GenesisBlockNumber) {
+		f.begin = int64(params.GenesisBlockNumber)
+	}
+	for f.begin <= int64(end) {
+		var (
+			section = (uint64(f.begin) - params.GenesisBlockNumber) / size
+			first   = params.GenesisBlockNumber + section*size
+			last    = first + size - 1
+		)
+		if last > end {
+			last = end
+		}
+		bits, err := f.sectionMatches(section, size)
 		if err != nil {
 			return logs, err
 		}
+		for number := uint64(f.begin); number <= last; number++ {
+			if bits[(number-first)/8]&(1<<(7-(number-first)%8)) == 0 {
+				continue
+			}
+			header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if header == nil || err != nil {
+				return logs, err
+			}
+			found, err := f.checkMatches(ctx, header)
+			if err != nil {
+				return logs, err
+			}
+			logs = append(logs, found...)
+			if f.full(logs, number, end) {
+				f.begin = int64(number) + 1
+				return logs, nil
+			}
+		}
+		f.begin = int64(last) + 1
+
+		select {
+		case <-ctx.Done():
+			return logs, ctx.Err()
+		default:
+		}
+	}
+	return logs, nil
+}
+
+// sectionMatches returns the bit vector of the blocks within a log index section
+// which contain the addresses and topics of the filter.
+func (f *Filter) sectionMatches(section, size uint64) ([]byte, error) {
+	head := rawdb.ReadCanonicalHash(f.db, params.GenesisBlockNumber+(section+1)*size-1)
+
+	// Blocks must match any item of every non-wildcard clause
+	var clauses [][][]byte
+	if len(f.addresses) > 0 {
+		clause := make([][]byte, len(f.addresses))
+		for i, address := range f.addresses {
+			clause[i] = address.Bytes()
+		}
+		clauses = append(clauses, clause)
 	}
-	rest, err := f.unindexedLogs(ctx, end)
-	logs = append(logs, rest...)
-	return logs, err
+	for _, sub := range f.topics {
+		if len(sub) == 0 {
+			continue
+		}
+		clause := make([][]byte, len(sub))
+		for i, topic := range sub {
+			clause[i] = topic.Bytes()
+		}
+		clauses = append(clauses, clause)
+	}
+	var matches []byte
+	for _, clause := range clauses {
+		union := make([]byte, size/8)
+		for _, item := range clause {
+			compressed, err := rawdb.ReadLogIndex(f.db, item, section, head)
+			if err != nil {
+				continue // item absent from the section
+			}
+			bits, err := bitutil.DecompressBytes(compressed, int(size/8))
+			if err != nil {
+				return nil, err
+			}
+			bitutil.ORBytes(union, union, bits)
+		}
+		if matches == nil {
+			matches = union
+		} else {
+			bitutil.ANDBytes(matches, matches, union)
+		}
+	}
+	return matches, nil
 }
 
-// indexedLogs returns the logs matching the filter criteria based on the bloom
+// indexedLogs appends the logs matching the filter criteria based on the bloom
 // bits indexed available locally or via the network.
-func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
+func (f *Filter) indexedLogs(ctx context.Context, end uint64, logs []*types.Log) ([]*types.Log, error) {
 	// Create a matcher session and request servicing from the backend
 	matches := make(chan uint64, 64)
 
@@ -177,9 +332,7 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 
 	f.backend.ServiceFilter(ctx, session)
 
-	// Iterate over the matches until exhausted or context closed
-	var logs []*types.Log
-
+	// Iterate over the matches until exhausted, limit reached or context closed
 	for {
 		select {
 		case number, ok := <-matches:
@@ -203,6 +356,9 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 				return logs, err
 			}
 			logs = append(logs, found...)
+			if f.full(logs, number, end) {
+				return logs, nil
+			}
 
 		case <-ctx.Done():
 			return logs, ctx.Err()
@@ -210,11 +366,9 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 	}
 }
 
-// indexedLogs returns the logs matching the filter criteria based on raw block
+// unindexedLogs appends the logs matching the filter criteria based on raw block
 // iteration and bloom matching.
-func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
-	var logs []*types.Log
-
+func (f *Filter) unindexedLogs(ctx context.Context, end uint64, logs []*types.Log) ([]*types.Log, error) {
 	for ; f.begin <= int64(end); f.begin++ {
 		header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(f.begin))
 		if header == nil || err != nil {
@@ -225,6 +379,10 @@ func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, e
 			return logs, err
 		}
 		logs = append(logs, found...)
+		if f.full(logs, uint64(f.begin), end) {
+			f.begin++
+			return logs, nil
+		}
 	}
 	return logs, nil
 }
@@ -53,6 +53,12 @@ const (
 	PendingTransactionsSubscription
 	// BlocksSubscription queries hashes for blocks that are imported
 	BlocksSubscription
+	// FullPendingTransactionsSubscription queries full transactions entering
+	// the pending state, filtered by sender and recipient
+	FullPendingTransactionsSubscription
+	// TransactionReceiptsSubscription queries receipts of watched transactions
+	// when they are included in or removed from the canonical chain
+	TransactionReceiptsSubscription
 	// LastSubscription keeps track of the last index
 	LastIndexSubscription
 )
@@ -68,22 +74,52 @@ const (
 	logsChanSize = 10
 	// chainEvChanSize is the size of channel listening to ChainEvent.
 	chainEvChanSize = 10
+
+	// maxWatchedTransactions is the maximum number of transactions a single
+	// receipts subscription may watch.
+	maxWatchedTransactions = 1000
+	// receiptFinality is the number of blocks after which the inclusion of a
+	// watched transaction is considered final and no longer watched.
+	receiptFinality = 64
 )
 
 var (
 	ErrInvalidSubscriptionID = errors.New("invalid id")
 )
 
+// TransactionCriteria restricts a full pending transaction subscription to the
+// transactions sent from or to any of the given addresses. An empty list matches
+// any address.
+type TransactionCriteria struct {
+	From []common.Address
+	To   []common.Address
+}
+
+// ReceiptEvent is delivered to a receipts subscription when a watched transaction
+// is included in the canonical chain, or removed from it by a reorg.
+type ReceiptEvent struct {
+	Receipt     *types.Receipt
+	Tx          *types.Transaction
+	BlockHash   common.Hash
+	BlockNumber uint64
+	Index       uint64
+	Removed     bool
+}
+
 type subscription struct {
 	id        rpc.ID
 	typ       Type
 	created   time.Time
 	logsCrit  ethereum.FilterQuery
+	txsCrit   TransactionCriteria
 	logs      chan []*types.Log
 	hashes    chan []common.Hash
 	headers   chan *types.Header
-	installed chan struct{} // closed when the filter is installed
-	err       chan error    // closed when the filter is uninstalled
+	txs       chan []*types.Transaction
+	receipts  chan []*ReceiptEvent
+	watched   map[common.Hash]*ReceiptEvent // watched transactions, mapped to their delivered inclusion
+	installed chan struct{}                 // closed when the filter is installed
+	err       chan error                    // closed when the filter is uninstalled
 }
 
 // EventSystem creates subscriptions, processes events and broadcasts them to the
@@ -175,6 +211,8 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.logs:
 			case <-sub.f.hashes:
 			case <-sub.f.headers:
+			case <-sub.f.txs:
+			case <-sub.f.receipts:
 			}
 		}
 
@@ -242,6 +280,8 @@ func (es *EventSystem) subscribeMinedPendingLogs(crit ethereum.FilterQuery, logs
 		logs:      logs,
 		hashes:    make(chan []common.Hash),
 		headers:   make(chan *types.Header),
+		txs:       make(chan []*types.Transaction),
+		receipts:  make(chan []*ReceiptEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -259,6 +299,8 @@ func (es *EventSystem) subscribeLogs(crit ethereum.FilterQuery, logs chan []*typ
 		logs:      logs,
 		hashes:    make(chan []common.Hash),
 		headers:   make(chan *types.Header),
+		txs:       make(chan []*types.Transaction),
+		receipts:  make(chan []*ReceiptEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -276,6 +318,8 @@ func (es *EventSystem) subscribePendingLogs(crit ethereum.FilterQuery, logs chan
 		logs:      logs,
 		hashes:    make(chan []common.Hash),
 		headers:   make(chan *types.Header),
+		txs:       make(chan []*types.Transaction),
+		receipts:  make(chan []*ReceiptEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -292,6 +336,8 @@ func (es *EventSystem) SubscribeNewHeads(headers chan *types.Header) *Subscripti
 		logs:      make(chan []*types.Log),
 		hashes:    make(chan []common.Hash),
 		headers:   headers,
+		txs:       make(chan []*types.Transaction),
+		receipts:  make(chan []*ReceiptEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -308,12 +354,64 @@ func (es *EventSystem) SubscribePendingTxs(hashes chan []common.Hash) *Subscript
 		logs:      make(chan []*types.Log),
 		hashes:    hashes,
 		headers:   make(chan *types.Header),
+		txs:       make(chan []*types.Transaction),
+		receipts:  make(chan []*ReceiptEvent),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribeFullPendingTxs creates a subscription that writes the transactions
+// entering the transaction pool which match the given criteria.
+func (es *EventSystem) SubscribeFullPendingTxs(crit TransactionCriteria, txs chan []*types.Transaction) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       FullPendingTransactionsSubscription,
+		created:   time.Now(),
+		txsCrit:   crit,
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan []common.Hash),
+		headers:   make(chan *types.Header),
+		txs:       txs,
+		receipts:  make(chan []*ReceiptEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
 	return es.subscribe(sub)
 }
 
+// SubscribeTransactionReceipts creates a subscription that writes the receipts of
+// the given transactions once they are included in the canonical chain, and again
+// marked as removed if a reorg drops them from it. Transactions already included
+// at the time of subscribing are delivered right away.
+func (es *EventSystem) SubscribeTransactionReceipts(hashes []common.Hash, receipts chan []*ReceiptEvent) (*Subscription, error) {
+	if es.lightMode {
+		return nil, errors.New("transaction receipts subscription not supported in light mode")
+	}
+	if len(hashes) > maxWatchedTransactions {
+		return nil, fmt.Errorf("too many transactions to watch: %d > %d", len(hashes), maxWatchedTransactions)
+	}
+	watched := make(map[common.Hash]*ReceiptEvent, len(hashes))
+	for _, hash := range hashes {
+		watched[hash] = nil
+	}
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       TransactionReceiptsSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan []common.Hash),
+		headers:   make(chan *types.Header),
+		txs:       make(chan []*types.Transaction),
+		receipts:  receipts,
+		watched:   watched,
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub), nil
+}
+
 type filterIndex map[Type]map[rpc.ID]*subscription
 
 // broadcast event to filters that match criteria.
@@ -355,12 +453,17 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 		for _, f := range filters[PendingTransactionsSubscription] {
 			f.hashes <- hashes
 		}
+		for _, f := range filters[FullPendingTransactionsSubscription] {
+			if txs := filterTransactions(e.Txs, f.txsCrit); len(txs) > 0 {
+				f.txs <- txs
+			}
+		}
 	case core.ChainEvent:
 		for _, f := range filters[BlocksSubscription] {
 			f.headers <- e.Block.Header()
 		}
 		if es.lightMode && len(filters[LogsSubscription]) > 0 {
-			es.lightFilterNewHead(e.Block.Header(), func(header *types.Header, remove bool) {
+			es.filterNewHead(e.Block.Header(), func(header *types.Header, remove bool) {
 				for _, f := range filters[LogsSubscription] {
 					if matchedLogs := es.lightFilterLogs(header, f.logsCrit.Addresses, f.logsCrit.Topics, remove); len(matchedLogs) > 0 {
 						f.logs <- matchedLogs
@@ -368,10 +471,154 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 				}
 			})
 		}
+		if !es.lightMode {
+			// Receipts subscriptions are refused in light mode, leaving the head
+			// tracking to match the watched transactions of the blocks added to
+			// or rolled back from the canonical chain
+			subs := filters[TransactionReceiptsSubscription]
+			switch {
+			case len(subs) == 0:
+				es.lastHead = e.Block.Header()
+			case es.lastHead == nil:
+				es.lastHead = e.Block.Header()
+				es.updateReceipts(subs, es.lastHead, false)
+			default:
+				es.filterNewHead(e.Block.Header(), func(header *types.Header, remove bool) {
+					es.updateReceipts(subs, header, remove)
+				})
+			}
+		}
+	}
+}
+
+// checkReceipts delivers the receipts of the watched transactions which were
+// already included in the canonical chain when the subscription was installed.
+func (es *EventSystem) checkReceipts(f *subscription) {
+	db := es.backend.ChainDb()
+
+	var events []*ReceiptEvent
+	for hash := range f.watched {
+		tx, blockHash, number, index := rawdb.ReadTransaction(db, hash)
+		if tx == nil {
+			continue
+		}
+		receipts := rawdb.ReadReceipts(db, blockHash, number)
+		if uint64(len(receipts)) <= index {
+			continue
+		}
+		included := &ReceiptEvent{
+			Receipt:     receipts[index],
+			Tx:          tx,
+			BlockHash:   blockHash,
+			BlockNumber: number,
+			Index:       index,
+		}
+		events = append(events, included)
+		f.watched[hash] = included
+	}
+	if len(events) > 0 {
+		f.receipts <- events
+	}
+}
+
+// updateReceipts matches the transactions of a block added to the canonical chain
+// against the watched transactions of the receipts subscriptions, or marks the
+// inclusions in a rolled back block as removed. Transactions whose inclusion is
+// buried deep enough to be final are no longer watched.
+func (es *EventSystem) updateReceipts(subs map[rpc.ID]*subscription, header *types.Header, remove bool) {
+	var (
+		db     = es.backend.ChainDb()
+		hash   = header.Hash()
+		number = header.Number.Uint64()
+	)
+	if remove {
+		for _, f := range subs {
+			var events []*ReceiptEvent
+			for txHash, delivered := range f.watched {
+				if delivered != nil && delivered.BlockHash == hash {
+					removed := *delivered
+					removed.Removed = true
+					events = append(events, &removed)
+					f.watched[txHash] = nil
+				}
+			}
+			if len(events) > 0 {
+				f.receipts <- events
+			}
+		}
+		return
+	}
+	body := rawdb.ReadBody(db, hash, number)
+	if body == nil {
+		return
+	}
+	var receipts types.Receipts // retrieved on the first match only
+	for _, f := range subs {
+		var events []*ReceiptEvent
+		for i, tx := range body.Transactions {
+			delivered, ok := f.watched[tx.Hash()]
+			if !ok || (delivered != nil && delivered.BlockHash == hash) {
+				continue
+			}
+			if delivered != nil {
+				removed := *delivered
+				removed.Removed = true
+				events = append(events, &removed)
+				f.watched[tx.Hash()] = nil
+			}
+			if receipts == nil {
+				receipts = rawdb.ReadReceipts(db, hash, number)
+			}
+			if len(receipts) <= i {
+				continue
+			}
+			included := &ReceiptEvent{
+				Receipt:     receipts[i],
+				Tx:          tx,
+				BlockHash:   hash,
+				BlockNumber: number,
+				Index:       uint64(i),
+			}
+			events = append(events, included)
+			f.watched[tx.Hash()] = included
+		}
+		for txHash, delivered := range f.watched {
+			if delivered != nil && delivered.BlockNumber+receiptFinality <= number {
+				delete(f.watched, txHash)
+			}
+		}
+		if len(events) > 0 {
+			f.receipts <- events
+		}
 	}
 }
 
-func (es *EventSystem) lightFilterNewHead(newHeader *types.Header, callBack func(*types.Header, bool)) {
+// filterTransactions returns the transactions matching the given sender and
+// recipient criteria.
+func filterTransactions(txs []*types.Transaction, crit TransactionCriteria) []*types.Transaction {
+	var matched []*types.Transaction
+	for _, tx := range txs {
+		if len(crit.To) > 0 && (tx.To() == nil || !includes(crit.To, *tx.To())) {
+			continue
+		}
+		if len(crit.From) > 0 {
+			var signer types.Signer = types.FrontierSigner{}
+			if tx.Protected() {
+				signer = types.NewEIP155Signer(tx.ChainId())
+			}
+			from, err := types.Sender(signer, tx)
+			if err != nil || !includes(crit.From, from) {
+				continue
+			}
+		}
+		matched = append(matched, tx)
+	}
+	return matched
+}
+
+// filterNewHead tracks the head of the canonical chain, invoking the callback
+// for every header rolled back or added since the previous head.
+func (es *EventSystem) filterNewHead(newHeader *types.Header, callBack func(*types.Header, bool)) {
 	oldh := es.lastHead
 	es.lastHead = newHeader
 	if oldh == nil {
@@ -487,6 +734,11 @@ func (es *EventSystem) eventLoop() {
 			}
 			close(f.installed)
 
+			if f.typ == TransactionReceiptsSubscription {
+				// deliver the receipts of transactions that were already included
+				es.checkReceipts(f)
+			}
+
 		case f := <-es.uninstall:
 			if f.typ == MinedAndPendingLogsSubscription {
 				// the type are logs and pending logs subscriptions
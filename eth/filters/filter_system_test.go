@@ -18,6 +18,7 @@ package filters
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -32,6 +33,8 @@ import (
 	"github.com/etherzero/go-etherzero/core/bloombits"
 	"github.com/etherzero/go-etherzero/core/rawdb"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/event"
 	"github.com/etherzero/go-etherzero/params"
@@ -124,6 +127,14 @@ func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
 
+func (b *testBackend) LogIndexStatus() (uint64, uint64) {
+	return params.BloomBitsBlocks, 0
+}
+
+func (b *testBackend) RPCLogCap() int {
+	return 0
+}
+
 func (b *testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	requests := make(chan chan *bloombits.Retrieval)
 
@@ -273,6 +284,160 @@ func TestPendingTxFilter(t *testing.T) {
 	}
 }
 
+// TestFullPendingTxFilter tests whether full pending transaction subscriptions only
+// deliver the transactions matching their sender and recipient criteria.
+func TestFullPendingTxFilter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux     = new(event.TypeMux)
+		db      = ethdb.NewMemDatabase()
+		txFeed  = new(event.Feed)
+		backend = &testBackend{mux, db, 0, txFeed, new(event.Feed), new(event.Feed), new(event.Feed)}
+		es      = NewEventSystem(mux, backend, false)
+
+		key1, _ = crypto.GenerateKey()
+		key2, _ = crypto.GenerateKey()
+		signer  = types.NewEIP155Signer(big.NewInt(1))
+		to1     = common.HexToAddress("0x01")
+		to2     = common.HexToAddress("0x02")
+	)
+	sign := func(key *ecdsa.PrivateKey, nonce uint64, to common.Address) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, to, new(big.Int), 0, new(big.Int), nil), signer, key)
+		return tx
+	}
+	transactions := []*types.Transaction{
+		sign(key1, 0, to1), sign(key1, 1, to2), sign(key2, 0, to1), sign(key2, 1, to2),
+	}
+	tests := []struct {
+		crit TransactionCriteria
+		want []*types.Transaction
+	}{
+		{TransactionCriteria{}, transactions},
+		{TransactionCriteria{From: []common.Address{crypto.PubkeyToAddress(key1.PublicKey)}}, transactions[:2]},
+		{TransactionCriteria{To: []common.Address{to2}}, []*types.Transaction{transactions[1], transactions[3]}},
+		{TransactionCriteria{From: []common.Address{crypto.PubkeyToAddress(key2.PublicKey)}, To: []common.Address{to1}}, transactions[2:3]},
+	}
+	for i, tt := range tests {
+		txs := make(chan []*types.Transaction, 1)
+		sub := es.SubscribeFullPendingTxs(tt.crit, txs)
+
+		txFeed.Send(core.NewTxsEvent{Txs: transactions})
+		select {
+		case have := <-txs:
+			if len(have) != len(tt.want) {
+				t.Fatalf("test %d: transaction count mismatch: have %d, want %d", i, len(have), len(tt.want))
+			}
+			for j := range have {
+				if have[j].Hash() != tt.want[j].Hash() {
+					t.Errorf("test %d: transaction %d mismatch: have %x, want %x", i, j, have[j].Hash(), tt.want[j].Hash())
+				}
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("test %d: timeout waiting for transactions", i)
+		}
+		sub.Unsubscribe()
+	}
+}
+
+// TestTransactionReceipts tests whether receipts subscriptions deliver the receipts
+// of watched transactions on inclusion, and again when a reorg removes them.
+func TestTransactionReceipts(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux       = new(event.TypeMux)
+		db        = ethdb.NewMemDatabase()
+		chainFeed = new(event.Feed)
+		backend   = &testBackend{mux, db, 0, new(event.Feed), new(event.Feed), new(event.Feed), chainFeed}
+		es        = NewEventSystem(mux, backend, false)
+
+		tx       = types.NewTransaction(0, common.HexToAddress("0x01"), new(big.Int), 0, new(big.Int), nil)
+		receipts = make(chan []*ReceiptEvent, 1)
+		blocks   []*types.Block
+	)
+	// extend creates a block on top of the parent, optionally including the
+	// watched transaction, and makes it the head of the canonical chain
+	extend := func(parent *types.Block, include bool) *types.Block {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number(), common.Big1),
+			Difficulty: big.NewInt(1),
+			Extra:      []byte{byte(len(blocks))}, // keep sibling hashes distinct
+			Protocol:   &devotedb.DevoteProtocol{},
+		}
+		var (
+			txs    []*types.Transaction
+			receps types.Receipts
+		)
+		if include {
+			txs, receps = []*types.Transaction{tx}, types.Receipts{types.NewReceipt(nil, false, 0)}
+		}
+		block := types.NewBlockWithHeader(header).WithBody(txs, nil)
+		blocks = append(blocks, block)
+
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receps)
+		if include {
+			rawdb.WriteTxLookupEntries(db, block)
+		}
+		chainFeed.Send(core.ChainEvent{Block: block, Hash: block.Hash()})
+		return block
+	}
+	expect := func(block *types.Block, removed bool) {
+		t.Helper()
+		select {
+		case events := <-receipts:
+			if len(events) != 1 {
+				t.Fatalf("event count mismatch: have %d, want 1", len(events))
+			}
+			if events[0].Tx.Hash() != tx.Hash() || events[0].BlockHash != block.Hash() || events[0].Removed != removed {
+				t.Fatalf("event mismatch: have tx %x in %x (removed %v), want in %x (removed %v)",
+					events[0].Tx.Hash(), events[0].BlockHash, events[0].Removed, block.Hash(), removed)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for receipts")
+		}
+	}
+	genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1), Protocol: &devotedb.DevoteProtocol{}})
+	rawdb.WriteBlock(db, genesis)
+	chainFeed.Send(core.ChainEvent{Block: genesis, Hash: genesis.Hash()})
+
+	first := extend(genesis, true)
+
+	// Already included transactions are delivered right away
+	sub, err := es.SubscribeTransactionReceipts([]common.Hash{tx.Hash()}, receipts)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+	expect(first, false)
+
+	// Reorg the block out of the chain, including the transaction in a block
+	// of the new chain below its head
+	rawdb.DeleteTxLookupEntry(db, tx.Hash())
+	fork := extend(genesis, false)
+	second := extend(fork, true)
+	head := extend(second, false)
+	expect(first, true)
+	expect(second, false)
+
+	// Once the inclusion is final the transaction is no longer watched, so
+	// reorging it out doesn't deliver any event
+	for i := 0; i < receiptFinality; i++ {
+		head = extend(head, false)
+	}
+	extend(fork, false)
+	select {
+	case events := <-receipts:
+		t.Fatalf("event delivered for final inclusion: %v", events[0])
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, err := es.SubscribeTransactionReceipts(make([]common.Hash, maxWatchedTransactions+1), receipts); err == nil {
+		t.Fatal("subscription above the watch limit succeeded")
+	}
+}
+
 // TestLogFilterCreation test whether a given filter criteria makes sense.
 // If not it must return an error.
 func TestLogFilterCreation(t *testing.T) {
@@ -0,0 +1,201 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/rlp"
+	"github.com/etherzero/go-etherzero/rpc"
+)
+
+const (
+	// defaultLogPageSize is the number of logs after which a page is cut if no
+	// global log cap is configured.
+	defaultLogPageSize = 10000
+
+	// logsReplayPage is the number of historical logs retrieved at once when
+	// replaying them into a subscription.
+	logsReplayPage = 1000
+
+	// maxReplayBlocks is the maximum number of historical blocks a subscription
+	// may replay the logs of. Wider ranges must be retrieved via eth_getLogsPage.
+	maxReplayBlocks = 100000
+
+	// maxPendingLogs is the maximum number of live logs held back while the
+	// historical ones are replayed, after which the subscription is dropped.
+	maxPendingLogs = 10000
+
+	// logCursorLength is the length of an encoded log cursor: the next block,
+	// the last block and the criteria hash, 8 bytes each.
+	logCursorLength = 24
+)
+
+var errInvalidCursor = errors.New("invalid log cursor")
+
+// LogPage is a page of the results of a paginated log query.
+type LogPage struct {
+	Logs   []*types.Log  `json:"logs"`
+	Cursor hexutil.Bytes `json:"cursor,omitempty"` // Continuation of the query, absent on its last page
+}
+
+// cappedLogs runs a filter, refusing to return more logs than the global cap
+// permits, pointing the caller at the paginated query instead.
+func (api *PublicFilterAPI) cappedLogs(ctx context.Context, filter *Filter) ([]*types.Log, error) {
+	limit := api.backend.RPCLogCap()
+	filter.SetLimit(limit)
+
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, truncated := filter.Truncated(); truncated || (limit > 0 && len(logs) > limit) {
+		return nil, fmt.Errorf("query returned more than %d results, use eth_getLogsPage to paginate", limit)
+	}
+	return returnLogs(logs), nil
+}
+
+// GetLogsPage returns a page of the logs matching the given criteria. Pages are
+// cut at block boundaries once the global log cap is reached, and carry a cursor
+// which retrieves the next page when passed back along with the same criteria.
+//
+// The range of the query is fixed at its first page: if it ends at the latest
+// block, subsequent pages don't extend into newly arrived blocks.
+func (api *PublicFilterAPI) GetLogsPage(ctx context.Context, crit FilterCriteria, cursor *hexutil.Bytes) (*LogPage, error) {
+	if crit.BlockHash != nil {
+		logs, err := NewBlockFilter(api.backend, *crit.BlockHash, crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &LogPage{Logs: returnLogs(logs)}, nil
+	}
+	var begin, end uint64
+	if cursor != nil {
+		var err error
+		if begin, end, err = decodeLogCursor(*cursor, crit); err != nil {
+			return nil, err
+		}
+	} else {
+		header, err := api.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+		if header == nil || err != nil {
+			return nil, err
+		}
+		head := header.Number.Uint64()
+		begin, end = resolveBlock(crit.FromBlock, head), resolveBlock(crit.ToBlock, head)
+	}
+	if begin > end {
+		return &LogPage{Logs: []*types.Log{}}, nil
+	}
+	filter := NewRangeFilter(api.backend, int64(begin), int64(end), crit.Addresses, crit.Topics)
+
+	limit := api.backend.RPCLogCap()
+	if limit <= 0 {
+		limit = defaultLogPageSize
+	}
+	filter.SetLimit(limit)
+
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page := &LogPage{Logs: returnLogs(logs)}
+	if next, truncated := filter.Truncated(); truncated {
+		page.Cursor = encodeLogCursor(next, end, crit)
+	}
+	return page, nil
+}
+
+// replayLogs retrieves the historical logs matching the criteria up to the given
+// head in pages, delivering them on the returned channel. The channel is closed
+// once all logs were delivered, or the context is cancelled.
+func (api *PublicFilterAPI) replayLogs(ctx context.Context, crit FilterCriteria, head uint64) <-chan []*types.Log {
+	history := make(chan []*types.Log)
+
+	go func() {
+		defer close(history)
+
+		end := resolveBlock(crit.ToBlock, head)
+		if end > head {
+			end = head
+		}
+		filter := NewRangeFilter(api.backend, crit.FromBlock.Int64(), int64(end), crit.Addresses, crit.Topics)
+		filter.SetLimit(logsReplayPage)
+		for {
+			logs, err := filter.Logs(ctx)
+			if err != nil {
+				if err != context.Canceled {
+					log.Warn("Failed to replay historical logs", "err", err)
+				}
+				return
+			}
+			if len(logs) > 0 {
+				select {
+				case history <- logs:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if _, truncated := filter.Truncated(); !truncated {
+				return
+			}
+		}
+	}()
+	return history
+}
+
+// resolveBlock converts an RPC block number of a query into an absolute one,
+// resolving the latest and pending blocks to the current head.
+func resolveBlock(number *big.Int, head uint64) uint64 {
+	if number == nil || number.Sign() < 0 {
+		return head
+	}
+	return number.Uint64()
+}
+
+// criteriaHash returns a short digest of the address and topic criteria of a
+// query, binding cursors to the query they continue.
+func criteriaHash(crit FilterCriteria) []byte {
+	blob, _ := rlp.EncodeToBytes([]interface{}{crit.Addresses, crit.Topics})
+	return crypto.Keccak256(blob)[:8]
+}
+
+// encodeLogCursor creates the cursor continuing a query from the given block.
+func encodeLogCursor(next, end uint64, crit FilterCriteria) hexutil.Bytes {
+	cursor := make([]byte, logCursorLength)
+	binary.BigEndian.PutUint64(cursor[0:], next)
+	binary.BigEndian.PutUint64(cursor[8:], end)
+	copy(cursor[16:], criteriaHash(crit))
+	return cursor
+}
+
+// decodeLogCursor returns the range of blocks a cursor continues a query with,
+// verifying it was created for the given criteria.
+func decodeLogCursor(cursor []byte, crit FilterCriteria) (uint64, uint64, error) {
+	if len(cursor) != logCursorLength || !bytes.Equal(cursor[16:], criteriaHash(crit)) {
+		return 0, 0, errInvalidCursor
+	}
+	return binary.BigEndian.Uint64(cursor[0:]), binary.BigEndian.Uint64(cursor[8:]), nil
+}
@@ -0,0 +1,235 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/bitutil"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/core/rawdb"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/event"
+	"github.com/etherzero/go-etherzero/params"
+	"github.com/etherzero/go-etherzero/rpc"
+)
+
+// logIndexBackend is a test backend with a configurable log index and log cap.
+type logIndexBackend struct {
+	*testBackend
+	sections uint64
+	logCap   int
+}
+
+func (b *logIndexBackend) LogIndexStatus() (uint64, uint64) {
+	return params.BloomBitsBlocks, b.sections
+}
+
+func (b *logIndexBackend) RPCLogCap() int {
+	return b.logCap
+}
+
+// newLogIndexBackend creates a chain of the given length, with addr emitting a
+// log in every tenth block.
+func newLogIndexBackend(blocks uint64, addr common.Address) *logIndexBackend {
+	db := ethdb.NewMemDatabase()
+
+	var head *types.Header
+	for i := uint64(0); i < blocks; i++ {
+		number := params.GenesisBlockNumber + i
+
+		receipt := types.NewReceipt(nil, false, 0)
+		if i%10 == 3 {
+			receipt.Logs = []*types.Log{{Address: addr, Topics: []common.Hash{}, BlockNumber: number}}
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+		header := &types.Header{
+			Number:     new(big.Int).SetUint64(number),
+			Difficulty: big.NewInt(1),
+			Bloom:      receipt.Bloom,
+			Protocol:   &devotedb.DevoteProtocol{},
+		}
+		if head != nil {
+			header.ParentHash = head.Hash()
+		}
+		rawdb.WriteHeader(db, header)
+		rawdb.WriteCanonicalHash(db, header.Hash(), number)
+		rawdb.WriteReceipts(db, header.Hash(), number, types.Receipts{receipt})
+		head = header
+	}
+	rawdb.WriteHeadBlockHash(db, head.Hash())
+
+	backend := &testBackend{new(event.TypeMux), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+	return &logIndexBackend{testBackend: backend}
+}
+
+func TestLogIndexFilter(t *testing.T) {
+	addr := common.HexToAddress("0x1")
+	backend := newLogIndexBackend(params.BloomBitsBlocks+100, addr)
+
+	// Index the first section, deliberately leaving out the first block with logs
+	bits := make([]byte, params.BloomBitsBlocks/8)
+	for i := uint64(13); i < params.BloomBitsBlocks; i += 10 {
+		bits[i/8] |= 1 << (7 - i%8)
+	}
+	head := rawdb.ReadCanonicalHash(backend.db, params.GenesisBlockNumber+params.BloomBitsBlocks-1)
+	rawdb.WriteLogIndex(backend.db, addr.Bytes(), 0, head, bitutil.CompressBytes(bits))
+
+	begin, end := int64(params.GenesisBlockNumber), int64(params.GenesisBlockNumber+params.BloomBitsBlocks+99)
+
+	logs, err := NewRangeFilter(backend, begin, end, []common.Address{addr}, nil).Logs(context.Background())
+	if err != nil {
+		t.Fatalf("unindexed filtering failed: %v", err)
+	}
+	if len(logs) != 420 {
+		t.Fatalf("unindexed log count mismatch: have %d, want %d", len(logs), 420)
+	}
+	backend.sections = 1
+
+	indexed, err := NewRangeFilter(backend, begin, end, []common.Address{addr}, nil).Logs(context.Background())
+	if err != nil {
+		t.Fatalf("indexed filtering failed: %v", err)
+	}
+	if len(indexed) != len(logs)-1 {
+		t.Fatalf("indexed log count mismatch: have %d, want %d", len(indexed), len(logs)-1)
+	}
+	for i, log := range indexed {
+		if log.BlockNumber != logs[i+1].BlockNumber {
+			t.Fatalf("log %d: block mismatch: have %d, want %d", i, log.BlockNumber, logs[i+1].BlockNumber)
+		}
+	}
+	// Queries without address or topic criteria can't use the index
+	if all, _ := NewRangeFilter(backend, begin, end, nil, nil).Logs(context.Background()); len(all) != len(logs) {
+		t.Fatalf("wildcard log count mismatch: have %d, want %d", len(all), len(logs))
+	}
+}
+
+func TestGetLogsPage(t *testing.T) {
+	addr := common.HexToAddress("0x1")
+	backend := newLogIndexBackend(1000, addr)
+	backend.logCap = 30
+
+	api := NewPublicFilterAPI(backend, false)
+	crit := FilterCriteria{FromBlock: new(big.Int).SetUint64(params.GenesisBlockNumber), Addresses: []common.Address{addr}}
+
+	if _, err := api.GetLogs(context.Background(), crit); err == nil {
+		t.Fatal("log query exceeding the cap succeeded")
+	}
+	var (
+		cursor *hexutil.Bytes
+		pages  int
+		logs   []*types.Log
+	)
+	for {
+		page, err := api.GetLogsPage(context.Background(), crit, cursor)
+		if err != nil {
+			t.Fatalf("page %d: query failed: %v", pages, err)
+		}
+		if len(page.Logs) > backend.logCap {
+			t.Fatalf("page %d: log count above cap: %d", pages, len(page.Logs))
+		}
+		logs = append(logs, page.Logs...)
+		if pages++; page.Cursor == nil {
+			break
+		}
+		next := page.Cursor
+		cursor = &next
+	}
+	if len(logs) != 100 || pages != 4 {
+		t.Fatalf("pagination mismatch: have %d logs in %d pages, want %d in %d", len(logs), pages, 100, 4)
+	}
+	for i := 1; i < len(logs); i++ {
+		if logs[i].BlockNumber <= logs[i-1].BlockNumber {
+			t.Fatalf("log %d: out of order block %d after %d", i, logs[i].BlockNumber, logs[i-1].BlockNumber)
+		}
+	}
+	// Cursors must not be usable with different criteria
+	cursor = new(hexutil.Bytes)
+	*cursor = encodeLogCursor(params.GenesisBlockNumber, params.GenesisBlockNumber+999, crit)
+	crit.Addresses = append(crit.Addresses, common.HexToAddress("0x2"))
+	if _, err := api.GetLogsPage(context.Background(), crit, cursor); err != errInvalidCursor {
+		t.Fatalf("cursor with different criteria: error mismatch: have %v, want %v", err, errInvalidCursor)
+	}
+}
+
+func TestLogsReplay(t *testing.T) {
+	addr := common.HexToAddress("0x1")
+	backend := newLogIndexBackend(1000, addr)
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", NewPublicFilterAPI(backend, false)); err != nil {
+		t.Fatalf("failed to register filter API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	// Subscribe from the middle of the chain, replaying the historical logs
+	var (
+		head = params.GenesisBlockNumber + 999
+		logs = make(chan types.Log, 100)
+		crit = map[string]interface{}{
+			"fromBlock": hexutil.EncodeUint64(params.GenesisBlockNumber + 500),
+			"address":   []common.Address{addr},
+		}
+	)
+	sub, err := client.EthSubscribe(context.Background(), logs, "logs", crit)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// Live logs arriving during the replay must follow the historical ones
+	backend.logsFeed.Send([]*types.Log{{Address: addr, Topics: []common.Hash{}, BlockNumber: head + 1}})
+
+	for i := 0; i < 51; i++ {
+		select {
+		case log := <-logs:
+			want := params.GenesisBlockNumber + 503 + uint64(i)*10
+			if i == 50 {
+				want = head + 1
+			}
+			if log.BlockNumber != want {
+				t.Fatalf("log %d: block mismatch: have %d, want %d", i, log.BlockNumber, want)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("log %d: timeout", i)
+		}
+	}
+	select {
+	case log := <-logs:
+		t.Fatalf("unexpected log in block %d", log.BlockNumber)
+	case <-time.After(100 * time.Millisecond):
+	}
+	// Replays spanning too many blocks are refused outright
+	crit["fromBlock"] = hexutil.EncodeUint64(head - maxReplayBlocks + 1)
+	if _, err := client.EthSubscribe(context.Background(), logs, "logs", crit); err != nil {
+		t.Fatalf("replay of the maximum span refused: %v", err)
+	}
+	crit["fromBlock"] = hexutil.EncodeUint64(head - maxReplayBlocks)
+	if _, err := client.EthSubscribe(context.Background(), logs, "logs", crit); err == nil {
+		t.Fatal("replay above the maximum span accepted")
+	}
+}
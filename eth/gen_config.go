@@ -23,10 +23,13 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		NetworkId               uint64
 		SyncMode                downloader.SyncMode
 		NoPruning               bool
-		LightServ               int  `toml:",omitempty"`
-		LightPeers              int  `toml:",omitempty"`
-		SkipBcVersionCheck      bool `toml:"-"`
-		DatabaseHandles         int  `toml:"-"`
+		LightServ               int      `toml:",omitempty"`
+		LightPeers              int      `toml:",omitempty"`
+		LightPrice              uint64   `toml:",omitempty"`
+		ULCServers              []string `toml:",omitempty"`
+		ULCFraction             int      `toml:",omitempty"`
+		SkipBcVersionCheck      bool     `toml:"-"`
+		DatabaseHandles         int      `toml:"-"`
 		DatabaseCache           int
 		TrieCleanCache          int
 		TrieDirtyCache          int
@@ -54,6 +57,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.NoPruning = c.NoPruning
 	enc.LightServ = c.LightServ
 	enc.LightPeers = c.LightPeers
+	enc.LightPrice = c.LightPrice
+	enc.ULCServers = c.ULCServers
+	enc.ULCFraction = c.ULCFraction
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
@@ -85,10 +91,13 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		NetworkId               *uint64
 		SyncMode                *downloader.SyncMode
 		NoPruning               *bool
-		LightServ               *int  `toml:",omitempty"`
-		LightPeers              *int  `toml:",omitempty"`
-		SkipBcVersionCheck      *bool `toml:"-"`
-		DatabaseHandles         *int  `toml:"-"`
+		LightServ               *int     `toml:",omitempty"`
+		LightPeers              *int     `toml:",omitempty"`
+		LightPrice              *uint64  `toml:",omitempty"`
+		ULCServers              []string `toml:",omitempty"`
+		ULCFraction             *int     `toml:",omitempty"`
+		SkipBcVersionCheck      *bool    `toml:"-"`
+		DatabaseHandles         *int     `toml:"-"`
 		DatabaseCache           *int
 		TrieCleanCache          *int
 		TrieDirtyCache          *int
@@ -131,6 +140,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.LightPeers != nil {
 		c.LightPeers = *dec.LightPeers
 	}
+	if dec.LightPrice != nil {
+		c.LightPrice = *dec.LightPrice
+	}
+	if dec.ULCServers != nil {
+		c.ULCServers = dec.ULCServers
+	}
+	if dec.ULCFraction != nil {
+		c.ULCFraction = *dec.ULCFraction
+	}
 	if dec.SkipBcVersionCheck != nil {
 		c.SkipBcVersionCheck = *dec.SkipBcVersionCheck
 	}
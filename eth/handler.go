@@ -29,6 +29,7 @@ import (
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/consensus"
 	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/forkid"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/eth/downloader"
 	"github.com/etherzero/go-etherzero/eth/fetcher"
@@ -37,8 +38,10 @@ import (
 	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/p2p"
 	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/p2p/enr"
 	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/rlp"
+	"github.com/hashicorp/golang-lru"
 )
 
 const (
@@ -55,24 +58,38 @@ const (
 
 var (
 	syncChallengeTimeout = 15 * time.Second // Time allowance for a node to reply to the sync progress challenge
+	forkChallengeTimeout = 15 * time.Second // Time allowance for a node to reply to the fork block challenge
 )
 
 // errIncompatibleConfig is returned if the requested protocols and configs are
 // not compatible (low protocol version restrictions and high requirements).
 var errIncompatibleConfig = errors.New("incompatible configuration")
 
+// protocolError is an error caused by a remote peer violating the protocol, as
+// opposed to a failure of the connection itself.
+type protocolError struct {
+	code errCode
+	text string
+}
+
+func (e *protocolError) Error() string {
+	return fmt.Sprintf("%v - %v", e.code, e.text)
+}
+
 func errResp(code errCode, format string, v ...interface{}) error {
-	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
+	return &protocolError{code: code, text: fmt.Sprintf(format, v...)}
 }
 
 type ProtocolManager struct {
 	networkID uint64
 
 	fastSync  uint32 // Flag whether fast sync is enabled (gets disabled if we already have blocks)
+	snapSync  uint32 // Flag whether fast sync should retrieve the pivot state as ranges
 	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
-	checkpointNumber uint64      // Block number for the sync progress validator to cross reference
-	checkpointHash   common.Hash // Block hash for the sync progress validator to cross reference
+	checkpointNumber uint64                 // Block number for the sync progress validator to cross reference
+	checkpointHash   common.Hash            // Block hash for the sync progress validator to cross reference
+	syncCheckpoint   *params.SyncCheckpoint // Block any synchronised chain must contain (weak subjectivity)
 
 	txpool      txPool
 	blockchain  *core.BlockChain
@@ -81,6 +98,7 @@ type ProtocolManager struct {
 
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
+	txFetcher  *fetcher.TxFetcher
 	peers      *peerSet
 
 	SubProtocols []p2p.Protocol
@@ -90,7 +108,15 @@ type ProtocolManager struct {
 	txsSub        event.Subscription
 	minedBlockSub *event.TypeMuxSubscription
 
-	whitelist map[uint64]common.Hash
+	whitelist     map[uint64]common.Hash
+	private       *privateTxSet // Transactions withheld from gossip
+	compactBlocks *lru.Cache    // Recently propagated blocks, serving the transactions of compact blocks
+	shortTxs      *shortTxIndex // Pooled transactions by short id, reassembling compact blocks
+
+	masternodeChecks  *lru.Cache   // Contract lookups of the masternodes advertised by discovered nodes
+	masternodeCheckCh chan [8]byte // Advertised masternodes queued for a contract lookup
+
+	forkFilter forkid.Filter // Fork ID filter rejecting peers on incompatible forks
 
 	// channels for fetcher, syncer, txsyncLoop
 	newPeerCh   chan *peer
@@ -106,39 +132,60 @@ type ProtocolManager struct {
 
 // NewProtocolManager returns a new Ethereum sub protocol manager. The Ethereum sub protocol manages peers capable
 // with the Ethereum network.
-func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, networkID uint64, mux *event.TypeMux, txpool txPool, engine consensus.Engine, blockchain *core.BlockChain, chaindb ethdb.Database, whitelist map[uint64]common.Hash) (*ProtocolManager, error) {
+func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, networkID uint64, mux *event.TypeMux, txpool txPool, engine consensus.Engine, blockchain *core.BlockChain, chaindb ethdb.Database, whitelist map[uint64]common.Hash, checkpoint *params.SyncCheckpoint) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
-		networkID:   networkID,
-		eventMux:    mux,
-		txpool:      txpool,
-		blockchain:  blockchain,
-		chainconfig: config,
-		peers:       newPeerSet(),
-		whitelist:   whitelist,
-		newPeerCh:   make(chan *peer),
-		noMorePeers: make(chan struct{}),
-		txsyncCh:    make(chan *txsync),
-		quitSync:    make(chan struct{}),
-	}
-	// Figure out whether to allow fast sync or not
-	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > params.GenesisBlockNumber {
-		log.Warn("Blockchain not empty, fast sync disabled")
-		mode = downloader.FullSync
-	}
-	if mode == downloader.FastSync {
+		networkID:      networkID,
+		eventMux:       mux,
+		txpool:         txpool,
+		blockchain:     blockchain,
+		chainconfig:    config,
+		peers:          newPeerSet(),
+		whitelist:      whitelist,
+		forkFilter:     forkid.NewFilter(blockchain),
+		syncCheckpoint: checkpoint,
+		private:        newPrivateTxSet(),
+		shortTxs:       newShortTxIndex(),
+		newPeerCh:      make(chan *peer),
+		noMorePeers:    make(chan struct{}),
+		txsyncCh:       make(chan *txsync),
+		quitSync:       make(chan struct{}),
+	}
+	manager.compactBlocks, _ = lru.New(maxCompactBlocks)
+	manager.masternodeChecks, _ = lru.New(maxMasternodeChecks)
+	manager.masternodeCheckCh = make(chan [8]byte, maxPendingMasternodes)
+
+	// Figure out whether to allow fast sync or not. A node still behind the sync
+	// checkpoint may fast sync past it, since the checkpoint anchors the chain.
+	if (mode == downloader.FastSync || mode == downloader.SnapSync) && blockchain.CurrentBlock().NumberU64() > params.GenesisBlockNumber {
+		if checkpoint != nil && blockchain.CurrentBlock().NumberU64() < checkpoint.Number {
+			log.Info("Blockchain behind sync checkpoint, fast syncing", "number", blockchain.CurrentBlock().NumberU64(), "checkpoint", checkpoint.Number)
+		} else {
+			log.Warn("Blockchain not empty, fast sync disabled")
+			mode = downloader.FullSync
+		}
+	}
+	if mode == downloader.FastSync || mode == downloader.SnapSync {
 		manager.fastSync = uint32(1)
 	}
+	if mode == downloader.SnapSync {
+		manager.snapSync = uint32(1)
+	}
 	// If we have trusted checkpoints, enforce them on the chain
 	if checkpoint, ok := params.TrustedCheckpoints[blockchain.Genesis().Hash()]; ok {
 		manager.checkpointNumber = (checkpoint.SectionIndex+1)*params.CHTFrequencyClient - 1
 		manager.checkpointHash = checkpoint.SectionHead
 	}
+	// A pinned sync checkpoint supersedes the CHT one for challenging peers
+	if checkpoint != nil && checkpoint.Hash != (common.Hash{}) {
+		manager.checkpointNumber = checkpoint.Number
+		manager.checkpointHash = checkpoint.Hash
+	}
 	// Initiate a sub-protocol for every implemented version we can handle
 	manager.SubProtocols = make([]p2p.Protocol, 0, len(ProtocolVersions))
 	for i, version := range ProtocolVersions {
 		// Skip protocol version if incompatible with the mode of operation
-		if mode == downloader.FastSync && version < eth63 {
+		if (mode == downloader.FastSync || mode == downloader.SnapSync) && version < eth63 {
 			continue
 		}
 		// Compatible; initialise the sub-protocol
@@ -167,13 +214,16 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 				}
 				return nil
 			},
+			Attributes:   []enr.Entry{manager.currentEntry()},
+			DialFilter:   manager.dialFilter,
+			DialPriority: manager.dialPriority,
 		})
 	}
 	if len(manager.SubProtocols) == 0 {
 		return nil, errIncompatibleConfig
 	}
 	// Construct the different synchronisation mechanisms
-	manager.downloader = downloader.New(mode, manager.checkpointNumber, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
+	manager.downloader = downloader.New(mode, manager.checkpointNumber, checkpoint, engine, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
 
 	validator := func(header *types.Header) error {
 		return engine.VerifyHeader(blockchain, header, true)
@@ -190,11 +240,32 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		atomic.StoreUint32(&manager.acceptTxs, 1) // Mark initial sync done on any fetcher import
 		return manager.blockchain.InsertChain(blocks)
 	}
-	manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.removePeer)
+	manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.dropInvalidPeer)
+
+	fetchTx := func(peer string, hashes []common.Hash) error {
+		p := manager.peers.Peer(peer)
+		if p == nil {
+			return errNotRegistered
+		}
+		return p.RequestTxs(hashes)
+	}
+	hasTx := func(hash common.Hash) bool {
+		return manager.txpool.Get(hash) != nil
+	}
+	manager.txFetcher = fetcher.NewTxFetcher(hasTx, manager.txpool.AddRemotes, fetchTx)
 
 	return manager, nil
 }
 
+// dropInvalidPeer penalizes a peer that propagated an invalid block before
+// removing it.
+func (pm *ProtocolManager) dropInvalidPeer(id string) {
+	if peer := pm.peers.Peer(id); peer != nil {
+		peer.Peer.AdjustScore(p2p.ScoreInvalid, "invalid propagated block")
+	}
+	pm.removePeer(id)
+}
+
 func (pm *ProtocolManager) removePeer(id string) {
 	// Short circuit if the peer was already removed
 	peer := pm.peers.Peer(id)
@@ -205,6 +276,7 @@ func (pm *ProtocolManager) removePeer(id string) {
 
 	// Unregister the peer from the downloader and Ethereum peer set
 	pm.downloader.UnregisterPeer(id)
+	pm.txFetcher.Drop(id)
 	if err := pm.peers.Unregister(id); err != nil {
 		log.Error("Peer removal failed", "peer", id, "err", err)
 	}
@@ -221,6 +293,7 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.txsCh = make(chan core.NewTxsEvent, txChanSize)
 	pm.txsSub = pm.txpool.SubscribeNewTxsEvent(pm.txsCh)
 	go pm.txBroadcastLoop()
+	go pm.privateTxLoop()
 
 	// broadcast mined blocks
 	pm.minedBlockSub = pm.eventMux.Subscribe(core.NewMinedBlockEvent{})
@@ -229,6 +302,9 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	// start sync handlers
 	go pm.syncer()
 	go pm.txsyncLoop()
+
+	// look up the masternodes advertised by dial candidates
+	go pm.masternodeCheckLoop()
 }
 
 func (pm *ProtocolManager) Stop() {
@@ -277,7 +353,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		number  = head.Number.Uint64()
 		td      = pm.blockchain.GetTd(hash, number)
 	)
-	if err := p.Handshake(pm.networkID, td, hash, genesis.Hash()); err != nil {
+	if err := p.Handshake(pm.networkID, td, hash, genesis.Hash(), forkid.NewID(pm.blockchain), pm.forkFilter); err != nil {
 		p.Log().Debug("Ethereum handshake failed", "err", err)
 		return err
 	}
@@ -318,6 +394,25 @@ func (pm *ProtocolManager) handle(p *peer) error {
 			}
 		}()
 	}
+	// Peers before etz/66 don't advertise a fork id in the handshake, so request
+	// the latest fork block passed locally to make sure they are on the same side
+	if fork, ok := forkid.PassedFork(pm.blockchain); ok && p.version < etz66 {
+		if err := p.RequestHeadersByNumber(fork, 1, 0, false); err != nil {
+			return err
+		}
+		p.forkNum = fork
+		p.forkDrop = time.AfterFunc(forkChallengeTimeout, func() {
+			p.Log().Debug("Timed out fork block challenge, dropping", "addr", p.RemoteAddr(), "type", p.Name())
+			pm.removePeer(p.id)
+		})
+		// Make sure it's cleaned up if the peer dies off
+		defer func() {
+			if p.forkDrop != nil {
+				p.forkDrop.Stop()
+				p.forkDrop = nil
+			}
+		}()
+	}
 	// If we have any explicit whitelist block hashes, request them
 	for number := range pm.whitelist {
 		if err := p.RequestHeadersByNumber(number, 1, 0, false); err != nil {
@@ -328,6 +423,9 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	for {
 		if err := pm.handleMsg(p); err != nil {
 			p.Log().Debug("Ethereum message handling failed", "err", err)
+			if _, ok := err.(*protocolError); ok {
+				p.Peer.AdjustScore(p2p.ScoreInvalid, err.Error())
+			}
 			return err
 		}
 	}
@@ -459,6 +557,15 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 				p.Log().Warn("Dropping unsynced node during fast sync", "addr", p.RemoteAddr(), "type", p.Name())
 				return errors.New("unsynced node cannot serve fast sync")
 			}
+		} else if len(headers) == 0 && p.forkDrop != nil {
+			// Possibly an empty reply to the fork block challenge. A peer behind the
+			// fork can't have it, one ahead must reply and is dropped by the timer
+			local := pm.blockchain.GetHeaderByNumber(p.forkNum)
+			if _, td := p.Head(); local == nil || td.Cmp(pm.blockchain.GetTd(local.Hash(), p.forkNum)) < 0 {
+				p.forkDrop.Stop()
+				p.forkDrop = nil
+				return nil
+			}
 		}
 		// Filter out any explicitly requested headers, deliver the rest to the downloader
 		filter := len(headers) == 1
@@ -470,11 +577,27 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 				p.syncDrop = nil
 
 				// Validate the header and either drop the peer or continue
+				if sc := pm.syncCheckpoint; sc != nil && sc.Number == pm.checkpointNumber {
+					if err := downloader.VerifyCheckpointSeal(pm.blockchain.Engine(), sc, headers[0]); err != nil {
+						return fmt.Errorf("checkpoint seal invalid: %v", err)
+					}
+				}
 				if headers[0].Hash() != pm.checkpointHash {
 					return errors.New("checkpoint hash mismatch")
 				}
 				return nil
 			}
+			// If it's a potential fork block check, validate against the local chain
+			if p.forkDrop != nil && headers[0].Number.Uint64() == p.forkNum {
+				p.forkDrop.Stop()
+				p.forkDrop = nil
+
+				if local := pm.blockchain.GetHeaderByNumber(p.forkNum); local != nil && headers[0].Hash() != local.Hash() {
+					p.Log().Debug("Fork block mismatch, dropping peer", "number", p.forkNum, "hash", headers[0].Hash(), "want", local.Hash())
+					return errors.New("fork block mismatch")
+				}
+				return nil
+			}
 			// Otherwise if it's a whitelisted block, validate against the set
 			if want, ok := pm.whitelist[headers[0].Number.Uint64()]; ok {
 				if hash := headers[0].Hash(); want != hash {
@@ -631,6 +754,60 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			log.Debug("Failed to deliver receipts", "err", err)
 		}
 
+	case p.version >= etz65 && msg.Code == GetAccountRangeMsg:
+		// Decode the account range retrieval message
+		var req getAccountRangeData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		accounts, proof := pm.serveAccountRange(&req)
+		return p.SendAccountRange(req.ID, accounts, proof)
+
+	case p.version >= etz65 && msg.Code == AccountRangeMsg:
+		// A range of accounts arrived to one of our previous requests
+		var res accountRangeData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		hashes := make([]common.Hash, len(res.Accounts))
+		accounts := make([][]byte, len(res.Accounts))
+		for i, account := range res.Accounts {
+			hashes[i], accounts[i] = account.Hash, account.Body
+		}
+		// Deliver all to the downloader
+		if err := pm.downloader.DeliverAccountRange(p.id, res.ID, hashes, accounts, res.Proof); err != nil {
+			log.Debug("Failed to deliver account range", "err", err)
+		}
+
+	case p.version >= etz65 && msg.Code == GetStorageRangesMsg:
+		// Decode the storage ranges retrieval message
+		var req getStorageRangesData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		slots, proof := pm.serveStorageRanges(&req)
+		return p.SendStorageRanges(req.ID, slots, proof)
+
+	case p.version >= etz65 && msg.Code == StorageRangesMsg:
+		// Ranges of storage slots arrived to one of our previous requests
+		var res storageRangesData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		hashes := make([][]common.Hash, len(res.Slots))
+		slots := make([][][]byte, len(res.Slots))
+		for i, storage := range res.Slots {
+			hashes[i] = make([]common.Hash, len(storage))
+			slots[i] = make([][]byte, len(storage))
+			for j, slot := range storage {
+				hashes[i][j], slots[i][j] = slot.Hash, slot.Body
+			}
+		}
+		// Deliver all to the downloader
+		if err := pm.downloader.DeliverStorageRanges(p.id, res.ID, hashes, slots, res.Proof); err != nil {
+			log.Debug("Failed to deliver storage ranges", "err", err)
+		}
+
 	case msg.Code == NewBlockHashesMsg:
 		var announces newBlockHashesData
 		if err := msg.Decode(&announces); err != nil {
@@ -663,25 +840,65 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		// Mark the peer as owning the block and schedule it for import
 		p.MarkBlock(request.Block.Hash())
 		pm.fetcher.Enqueue(p.id, request.Block)
+		pm.updatePropagatedHead(p, request.Block.Header(), request.TD)
 
-		// Assuming the block is importable by the peer, but possibly not yet done so,
-		// calculate the head hash and TD that the peer truly must have.
-		var (
-			trueHead = request.Block.ParentHash()
-			trueTD   = new(big.Int).Sub(request.TD, request.Block.Difficulty())
-		)
-		// Update the peer's total difficulty if better than the previous
-		if _, td := p.Head(); trueTD.Cmp(td) > 0 {
-			p.SetHead(trueHead, trueTD)
+	case p.version >= etz68 && msg.Code == CompactBlockMsg:
+		// Retrieve and decode the propagated compact block
+		var request compactBlockData
+		if err := msg.Decode(&request); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if request.Header == nil || request.TD == nil {
+			return errResp(ErrDecode, "compact block header or TD is nil")
+		}
+		txs, err := pm.compactTxs(&request)
+		if err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		for _, prefilled := range request.Prefilled {
+			p.MarkTransaction(prefilled.Tx.Hash())
+		}
+		// Mark the peer as owning the block and schedule it for reassembly, unless
+		// it's already known or queued for import
+		hash := request.Header.Hash()
+		p.MarkBlock(hash)
+		if txs != nil {
+			pm.fetcher.EnqueueCompact(p.id, request.Header, request.Uncles, txs, msg.ReceivedAt, p.RequestBlockTxs, p.RequestOneHeader, p.RequestBodies)
+		}
+		pm.updatePropagatedHead(p, request.Header, request.TD)
 
-			// Schedule a sync if above ours. Note, this will not fire a sync for a gap of
-			// a single block (as the true TD is below the propagated block), however this
-			// scenario should easily be covered by the fetcher.
-			currentBlock := pm.blockchain.CurrentBlock()
-			if trueTD.Cmp(pm.blockchain.GetTd(currentBlock.Hash(), currentBlock.NumberU64())) > 0 {
-				go pm.synchronise(p)
+	case p.version >= etz68 && msg.Code == GetBlockTxsMsg:
+		// Decode the transaction retrieval query
+		var query getBlockTxsData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		block := pm.compactBlock(query.Hash)
+		if block == nil {
+			return p.SendBlockTxs(query.Hash, nil)
+		}
+		txs := block.Transactions()
+		found := make([]*types.Transaction, 0, len(query.Indexes))
+		for _, index := range query.Indexes {
+			if index >= uint64(len(txs)) {
+				return errResp(ErrDecode, "transaction index %d out of range (%d transactions)", index, len(txs))
 			}
+			found = append(found, txs[index])
 		}
+		return p.SendBlockTxs(query.Hash, found)
+
+	case p.version >= etz68 && msg.Code == BlockTxsMsg:
+		// Missing transactions of a compact block arrived
+		var data blockTxsData
+		if err := msg.Decode(&data); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		for i, tx := range data.Txs {
+			if tx == nil {
+				return errResp(ErrDecode, "transaction %d is nil", i)
+			}
+		}
+		pm.fetcher.DeliverBlockTxs(p.id, data.Hash, data.Txs)
 
 	case msg.Code == TxMsg:
 		// Transactions arrived, make sure we have a valid and fresh chain to handle them
@@ -698,9 +915,91 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			if tx == nil {
 				return errResp(ErrDecode, "transaction %d is nil", i)
 			}
-			p.MarkTransaction(tx.Hash())
+			if p.MarkReceivedTransaction(tx.Hash()) {
+				p.Peer.AdjustScore(p2p.ScoreDuplicate, "duplicate transaction")
+			}
+		}
+		pm.txFetcher.Enqueue(p.id, txs, false)
+
+	case p.version >= etz67 && msg.Code == NewPooledTransactionHashesMsg:
+		// New transactions were announced, make sure we're ready to handle them
+		if atomic.LoadUint32(&pm.acceptTxs) == 0 {
+			break
+		}
+		var hashes []common.Hash
+		if err := msg.Decode(&hashes); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		for _, hash := range hashes {
+			p.MarkTransaction(hash)
+		}
+		pm.txFetcher.Notify(p.id, hashes)
+
+	case p.version >= etz67 && msg.Code == GetPooledTransactionsMsg:
+		// Decode the retrieval message
+		msgStream := rlp.NewStream(msg.Payload, uint64(msg.Size))
+		if _, err := msgStream.List(); err != nil {
+			return err
+		}
+		// Gather transactions until the fetch or network limits is reached
+		var (
+			hash   common.Hash
+			bytes  int
+			hashes []common.Hash
+			txs    []rlp.RawValue
+		)
+		for bytes < softResponseLimit && len(txs) < fetcher.MaxTxRetrievals {
+			// Retrieve the hash of the next transaction
+			if err := msgStream.Decode(&hash); err == rlp.EOL {
+				break
+			} else if err != nil {
+				return errResp(ErrDecode, "msg %v: %v", msg, err)
+			}
+			// Retrieve the requested transaction, skipping unknown and private ones
+			tx := pm.txpool.Get(hash)
+			if tx == nil || len(pm.private.filter(types.Transactions{tx})) == 0 {
+				continue
+			}
+			if encoded, err := rlp.EncodeToBytes(tx); err != nil {
+				log.Error("Failed to encode transaction", "err", err)
+			} else {
+				hashes = append(hashes, hash)
+				txs = append(txs, encoded)
+				bytes += len(encoded)
+			}
 		}
-		pm.txpool.AddRemotes(txs)
+		return p.SendPooledTransactionsRLP(hashes, txs)
+
+	case p.version >= etz67 && msg.Code == PooledTransactionsMsg:
+		// Requested transactions arrived, make sure we're ready to handle them
+		if atomic.LoadUint32(&pm.acceptTxs) == 0 {
+			break
+		}
+		var txs []*types.Transaction
+		if err := msg.Decode(&txs); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		for i, tx := range txs {
+			if tx == nil {
+				return errResp(ErrDecode, "transaction %d is nil", i)
+			}
+			p.MarkReceivedTransaction(tx.Hash())
+		}
+		pm.txFetcher.Enqueue(p.id, txs, true)
+
+	case p.version >= etz65 && msg.Code == PrivateTxMsg:
+		// Private transaction arrived, make sure we have a valid and fresh chain to handle it
+		if atomic.LoadUint32(&pm.acceptTxs) == 0 {
+			break
+		}
+		var req privateTxData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if req.Tx == nil {
+			return errResp(ErrDecode, "private transaction is nil")
+		}
+		pm.handlePrivateTx(p, &req)
 
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
@@ -708,6 +1007,29 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	return nil
 }
 
+// updatePropagatedHead updates the head of a peer that propagated a block with
+// the given total difficulty, scheduling a sync if the peer is ahead of us.
+func (pm *ProtocolManager) updatePropagatedHead(p *peer, header *types.Header, td *big.Int) {
+	// Assuming the block is importable by the peer, but possibly not yet done so,
+	// calculate the head hash and TD that the peer truly must have.
+	var (
+		trueHead = header.ParentHash
+		trueTD   = new(big.Int).Sub(td, header.Difficulty)
+	)
+	// Update the peer's total difficulty if better than the previous
+	if _, td := p.Head(); trueTD.Cmp(td) > 0 {
+		p.SetHead(trueHead, trueTD)
+
+		// Schedule a sync if above ours. Note, this will not fire a sync for a gap of
+		// a single block (as the true TD is below the propagated block), however this
+		// scenario should easily be covered by the fetcher.
+		currentBlock := pm.blockchain.CurrentBlock()
+		if trueTD.Cmp(pm.blockchain.GetTd(currentBlock.Hash(), currentBlock.NumberU64())) > 0 {
+			go pm.synchronise(p)
+		}
+	}
+}
+
 // BroadcastBlock will either propagate a block to a subset of it's peers, or
 // will only announce it's availability (depending what's requested).
 func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
@@ -724,6 +1046,15 @@ func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
 			log.Error("Propagating dangling block", "number", block.Number(), "hash", hash)
 			return
 		}
+		// Keep the block around to serve the peers receiving it in compact form
+		pm.compactBlocks.Add(hash, block)
+
+		// Push the block to the witnesses of the upcoming slots first, as they
+		// need it before their slot begins
+		witnesses, peers := splitWitnessPeers(peers, pm.upcomingWitnesses(block.Header()))
+		for _, peer := range witnesses {
+			peer.AsyncSendNewBlock(block, td)
+		}
 		// Send the block to a subset of our peers
 		transferLen := int(math.Sqrt(float64(len(peers))))
 		if transferLen < minBroadcastPeers {
@@ -736,7 +1067,7 @@ func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
 		for _, peer := range transfer {
 			peer.AsyncSendNewBlock(block, td)
 		}
-		log.Trace("Propagated block", "hash", hash, "recipients", len(transfer), "duration", common.PrettyDuration(time.Since(block.ReceivedAt)))
+		log.Trace("Propagated block", "hash", hash, "witnesses", len(witnesses), "recipients", len(transfer), "duration", common.PrettyDuration(time.Since(block.ReceivedAt)))
 		return
 	}
 	// Otherwise if the block is indeed in out own chain, announce it
@@ -751,20 +1082,30 @@ func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
 // BroadcastTxs will propagate a batch of transactions to all peers which are not known to
 // already have the given transaction.
 func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
-	var txset = make(map[*peer]types.Transactions)
-
-	// Broadcast transactions to a batch of peers not knowing about it
+	var (
+		txset  = make(map[*peer]types.Transactions)
+		annset = make(map[*peer][]common.Hash)
+	)
+	// Broadcast transactions to a batch of peers not knowing about it, and only
+	// announce them to the rest of the peers supporting announcements
 	for _, tx := range txs {
 		peers := pm.peers.PeersWithoutTx(tx.Hash())
-		for _, peer := range peers {
-			txset[peer] = append(txset[peer], tx)
+		direct := int(math.Sqrt(float64(len(peers))))
+		for i, peer := range peers {
+			if i < direct || peer.version < etz67 {
+				txset[peer] = append(txset[peer], tx)
+			} else {
+				annset[peer] = append(annset[peer], tx.Hash())
+			}
 		}
 		log.Trace("Broadcast transaction", "hash", tx.Hash(), "recipients", len(peers))
 	}
-	// FIXME include this again: peers = peers[:int(math.Sqrt(float64(len(peers))))]
 	for peer, txs := range txset {
 		peer.AsyncSendTransactions(txs)
 	}
+	for peer, hashes := range annset {
+		peer.AsyncSendPooledTransactionHashes(hashes)
+	}
 }
 
 // Mined broadcast loop
@@ -782,7 +1123,10 @@ func (pm *ProtocolManager) txBroadcastLoop() {
 	for {
 		select {
 		case event := <-pm.txsCh:
-			pm.BroadcastTxs(event.Txs)
+			pm.shortTxs.add(event.Txs)
+			if txs := pm.private.filter(event.Txs); len(txs) > 0 {
+				pm.BroadcastTxs(txs)
+			}
 
 		// Err() channel will be closed when unsubscribing.
 		case <-pm.txsSub.Err():
@@ -0,0 +1,109 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/bitutil"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/rawdb"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// LogIndexer implements a core.ChainIndexer, building up an exact index of the
+// blocks containing logs of each address and topic, permitting sparse log
+// queries to be served without scanning bloom matches.
+type LogIndexer struct {
+	size    uint64            // section size to generate the index for
+	db      ethdb.Database    // database instance to write index data and metadata into
+	items   map[string][]byte // block bit vectors of the section, keyed by address or topic
+	section uint64            // Section is the section number being processed currently
+	head    common.Hash       // Head is the hash of the last header processed
+}
+
+// NewLogIndexer returns a chain indexer that generates the log index for the
+// canonical chain.
+func NewLogIndexer(db ethdb.Database, size, confirms uint64) *core.ChainIndexer {
+	backend := &LogIndexer{
+		db:   db,
+		size: size,
+	}
+	table := ethdb.NewTable(db, string(rawdb.LogIndexIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, size, confirms, bloomThrottling, "logindex")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new log index section.
+func (b *LogIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	b.items, b.section, b.head = make(map[string][]byte), section, common.Hash{}
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, marking the block in the bit
+// vectors of the addresses and topics of its logs.
+func (b *LogIndexer) Process(ctx context.Context, header *types.Header) error {
+	b.head = header.Hash()
+	if header.Bloom == (types.Bloom{}) {
+		return nil // no logs in the block
+	}
+	number := header.Number.Uint64()
+	receipts := rawdb.ReadReceipts(b.db, b.head, number)
+	if receipts == nil {
+		return fmt.Errorf("receipts of block #%d [%x…] not found", number, b.head[:4])
+	}
+	index := number - params.GenesisBlockNumber - b.section*b.size
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			b.mark(log.Address.Bytes(), index)
+			for _, topic := range log.Topics {
+				b.mark(topic.Bytes(), index)
+			}
+		}
+	}
+	return nil
+}
+
+// mark sets the bit of a block within the section in the vector of an item.
+func (b *LogIndexer) mark(item []byte, index uint64) {
+	bits, ok := b.items[string(item)]
+	if !ok {
+		bits = make([]byte, b.size/8)
+		b.items[string(item)] = bits
+	}
+	bits[index/8] |= 1 << (7 - index%8)
+}
+
+// Commit implements core.ChainIndexerBackend, writing out the bit vectors of
+// the section into the database.
+func (b *LogIndexer) Commit() error {
+	batch := b.db.NewBatch()
+	for item, bits := range b.items {
+		rawdb.WriteLogIndex(batch, []byte(item), b.section, b.head, bitutil.CompressBytes(bits))
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	return batch.Write()
+}
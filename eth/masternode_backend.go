@@ -95,12 +95,24 @@ func (self *MasternodeManager) Start(srvr *p2p.Server, mux *event.TypeMux) {
 
 	go self.masternodeLoop()
 	go self.checkSyncing()
+	go self.witnessPeerLoop()
 }
 
 func (self *MasternodeManager) Stop() {
 
 }
 
+// setMasternode records whether the local node is an active masternode, and
+// advertises it in the node record.
+func (self *MasternodeManager) setMasternode(active bool) {
+	if active {
+		atomic.StoreUint32(&self.IsMasternode, 1)
+	} else {
+		atomic.StoreUint32(&self.IsMasternode, 0)
+	}
+	self.advertise()
+}
+
 func (self *MasternodeManager) masternodeLoop() {
 	xy := self.srvr.Self().XY()
 	has, err := self.contract.Has(nil, self.srvr.Self().X8())
@@ -109,9 +121,9 @@ func (self *MasternodeManager) masternodeLoop() {
 	}
 	if has {
 		fmt.Println("### It's already been a masternode! ")
-		atomic.StoreUint32(&self.IsMasternode, 1)
+		self.setMasternode(true)
 	} else {
-		atomic.StoreUint32(&self.IsMasternode, 0)
+		self.setMasternode(false)
 		if self.srvr.IsMasternode {
 			data := "0x2f926732" + common.Bytes2Hex(xy[:])
 			fmt.Printf("### Masternode Transaction Data: %s\n", data)
@@ -143,12 +155,12 @@ func (self *MasternodeManager) masternodeLoop() {
 		select {
 		case join := <-joinCh:
 			if bytes.Equal(join.Id[:], xy[:]) {
-				atomic.StoreUint32(&self.IsMasternode, 1)
+				self.setMasternode(true)
 				fmt.Println("### Become a masternode! ")
 			}
 		case quit := <-quitCh:
 			if bytes.Equal(quit.Id[:], xy[0:8]) {
-				atomic.StoreUint32(&self.IsMasternode, 0)
+				self.setMasternode(false)
 				fmt.Println("### Remove a masternode! ")
 			}
 		case err := <-joinSub.Err():
@@ -167,7 +179,7 @@ func (self *MasternodeManager) masternodeLoop() {
 				has, err := self.contract.Has(nil, self.srvr.Self().X8())
 				if has && err == nil {
 					fmt.Println("### Set masternode flag")
-					atomic.StoreUint32(&self.IsMasternode, 1)
+					self.setMasternode(true)
 				}else{
 					continue
 				}
@@ -46,6 +46,10 @@ var (
 	reqStateInTrafficMeter    = metrics.NewRegisteredMeter("eth/req/states/in/traffic", nil)
 	reqStateOutPacketsMeter   = metrics.NewRegisteredMeter("eth/req/states/out/packets", nil)
 	reqStateOutTrafficMeter   = metrics.NewRegisteredMeter("eth/req/states/out/traffic", nil)
+	reqRangeInPacketsMeter    = metrics.NewRegisteredMeter("eth/req/ranges/in/packets", nil)
+	reqRangeInTrafficMeter    = metrics.NewRegisteredMeter("eth/req/ranges/in/traffic", nil)
+	reqRangeOutPacketsMeter   = metrics.NewRegisteredMeter("eth/req/ranges/out/packets", nil)
+	reqRangeOutTrafficMeter   = metrics.NewRegisteredMeter("eth/req/ranges/out/traffic", nil)
 	reqReceiptInPacketsMeter  = metrics.NewRegisteredMeter("eth/req/receipts/in/packets", nil)
 	reqReceiptInTrafficMeter  = metrics.NewRegisteredMeter("eth/req/receipts/in/traffic", nil)
 	reqReceiptOutPacketsMeter = metrics.NewRegisteredMeter("eth/req/receipts/out/packets", nil)
@@ -96,6 +100,8 @@ func (rw *meteredMsgReadWriter) ReadMsg() (p2p.Msg, error) {
 		packets, traffic = reqStateInPacketsMeter, reqStateInTrafficMeter
 	case rw.version >= eth63 && msg.Code == ReceiptsMsg:
 		packets, traffic = reqReceiptInPacketsMeter, reqReceiptInTrafficMeter
+	case rw.version >= etz65 && (msg.Code == AccountRangeMsg || msg.Code == StorageRangesMsg):
+		packets, traffic = reqRangeInPacketsMeter, reqRangeInTrafficMeter
 
 	case msg.Code == NewBlockHashesMsg:
 		packets, traffic = propHashInPacketsMeter, propHashInTrafficMeter
@@ -123,6 +129,8 @@ func (rw *meteredMsgReadWriter) WriteMsg(msg p2p.Msg) error {
 		packets, traffic = reqStateOutPacketsMeter, reqStateOutTrafficMeter
 	case rw.version >= eth63 && msg.Code == ReceiptsMsg:
 		packets, traffic = reqReceiptOutPacketsMeter, reqReceiptOutTrafficMeter
+	case rw.version >= etz65 && (msg.Code == AccountRangeMsg || msg.Code == StorageRangesMsg):
+		packets, traffic = reqRangeOutPacketsMeter, reqRangeOutTrafficMeter
 
 	case msg.Code == NewBlockHashesMsg:
 		packets, traffic = propHashOutPacketsMeter, propHashOutTrafficMeter
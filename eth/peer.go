@@ -25,6 +25,7 @@ import (
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/forkid"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/p2p"
 	"github.com/etherzero/go-etherzero/rlp"
@@ -45,6 +46,11 @@ const (
 	// contain a single transaction, or thousands.
 	maxQueuedTxs = 128
 
+	// maxQueuedTxAnns is the maximum number of transaction announcements to queue
+	// up before dropping broadcasts. Announcements are small, so more of them are
+	// kept than full transaction lists.
+	maxQueuedTxAnns = 4 * maxQueuedTxs
+
 	// maxQueuedProps is the maximum number of block propagations to queue up before
 	// dropping broadcasts. There's not much point in queueing stale blocks, so a few
 	// that might cover uncles should be enough.
@@ -80,31 +86,37 @@ type peer struct {
 
 	version  int         // Protocol version negotiated
 	syncDrop *time.Timer // Timed connection dropper if sync progress isn't validated in time
+	forkDrop *time.Timer // Timed connection dropper if the fork block isn't validated in time (pre etz/66)
+	forkNum  uint64      // Number of the fork block requested from the peer
 
 	head common.Hash
 	td   *big.Int
 	lock sync.RWMutex
 
-	knownTxs    mapset.Set                // Set of transaction hashes known to be known by this peer
-	knownBlocks mapset.Set                // Set of block hashes known to be known by this peer
-	queuedTxs   chan []*types.Transaction // Queue of transactions to broadcast to the peer
-	queuedProps chan *propEvent           // Queue of blocks to broadcast to the peer
-	queuedAnns  chan *types.Block         // Queue of blocks to announce to the peer
-	term        chan struct{}             // Termination channel to stop the broadcaster
+	knownTxs     mapset.Set                // Set of transaction hashes known to be known by this peer
+	receivedTxs  mapset.Set                // Set of transaction hashes sent in full by this peer
+	knownBlocks  mapset.Set                // Set of block hashes known to be known by this peer
+	queuedTxs    chan []*types.Transaction // Queue of transactions to broadcast to the peer
+	queuedTxAnns chan []common.Hash        // Queue of transactions to announce to the peer
+	queuedProps  chan *propEvent           // Queue of blocks to broadcast to the peer
+	queuedAnns   chan *types.Block         // Queue of blocks to announce to the peer
+	term         chan struct{}             // Termination channel to stop the broadcaster
 }
 
 func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 	return &peer{
-		Peer:        p,
-		rw:          rw,
-		version:     version,
-		id:          fmt.Sprintf("%x", p.ID().Bytes()[:8]),
-		knownTxs:    mapset.NewSet(),
-		knownBlocks: mapset.NewSet(),
-		queuedTxs:   make(chan []*types.Transaction, maxQueuedTxs),
-		queuedProps: make(chan *propEvent, maxQueuedProps),
-		queuedAnns:  make(chan *types.Block, maxQueuedAnns),
-		term:        make(chan struct{}),
+		Peer:         p,
+		rw:           rw,
+		version:      version,
+		id:           fmt.Sprintf("%x", p.ID().Bytes()[:8]),
+		knownTxs:     mapset.NewSet(),
+		receivedTxs:  mapset.NewSet(),
+		knownBlocks:  mapset.NewSet(),
+		queuedTxs:    make(chan []*types.Transaction, maxQueuedTxs),
+		queuedTxAnns: make(chan []common.Hash, maxQueuedTxAnns),
+		queuedProps:  make(chan *propEvent, maxQueuedProps),
+		queuedAnns:   make(chan *types.Block, maxQueuedAnns),
+		term:         make(chan struct{}),
 	}
 }
 
@@ -120,8 +132,18 @@ func (p *peer) broadcast() {
 			}
 			p.Log().Trace("Broadcast transactions", "count", len(txs))
 
+		case hashes := <-p.queuedTxAnns:
+			if err := p.SendPooledTransactionHashes(hashes); err != nil {
+				return
+			}
+			p.Log().Trace("Announced transactions", "count", len(hashes))
+
 		case prop := <-p.queuedProps:
-			if err := p.SendNewBlock(prop.block, prop.td); err != nil {
+			send := p.SendNewBlock
+			if p.version >= etz68 {
+				send = p.SendCompactBlock
+			}
+			if err := send(prop.block, prop.td); err != nil {
 				return
 			}
 			p.Log().Trace("Propagated block", "number", prop.block.Number(), "hash", prop.block.Hash(), "td", prop.td)
@@ -193,6 +215,21 @@ func (p *peer) MarkTransaction(hash common.Hash) {
 	p.knownTxs.Add(hash)
 }
 
+// MarkReceivedTransaction marks a transaction as sent in full by the peer, also
+// marking it as known. It returns whether the peer sent it before.
+func (p *peer) MarkReceivedTransaction(hash common.Hash) bool {
+	p.MarkTransaction(hash)
+	if p.receivedTxs.Contains(hash) {
+		return true
+	}
+	// If we reached the memory allowance, drop a previously received transaction hash
+	for p.receivedTxs.Cardinality() >= maxKnownTxs {
+		p.receivedTxs.Pop()
+	}
+	p.receivedTxs.Add(hash)
+	return false
+}
+
 // SendTransactions sends transactions to the peer and includes the hashes
 // in its transaction hash set for future reference.
 func (p *peer) SendTransactions(txs types.Transactions) error {
@@ -215,6 +252,39 @@ func (p *peer) AsyncSendTransactions(txs []*types.Transaction) {
 	}
 }
 
+// SendPooledTransactionHashes announces the availability of a batch of pool
+// transactions through a hash notification, and includes the hashes in the
+// peer's transaction hash set for future reference.
+func (p *peer) SendPooledTransactionHashes(hashes []common.Hash) error {
+	for _, hash := range hashes {
+		p.knownTxs.Add(hash)
+	}
+	return p2p.Send(p.rw, NewPooledTransactionHashesMsg, hashes)
+}
+
+// AsyncSendPooledTransactionHashes queues a batch of transaction hashes for
+// announcement to a remote peer. If the peer's announcement queue is full, the
+// event is silently dropped.
+func (p *peer) AsyncSendPooledTransactionHashes(hashes []common.Hash) {
+	select {
+	case p.queuedTxAnns <- hashes:
+		for _, hash := range hashes {
+			p.knownTxs.Add(hash)
+		}
+	default:
+		p.Log().Debug("Dropping transaction announcement", "count", len(hashes))
+	}
+}
+
+// SendPooledTransactionsRLP sends a batch of pool transactions to the remote
+// peer, corresponding to the ones requested, from an already RLP encoded format.
+func (p *peer) SendPooledTransactionsRLP(hashes []common.Hash, txs []rlp.RawValue) error {
+	for _, hash := range hashes {
+		p.knownTxs.Add(hash)
+	}
+	return p2p.Send(p.rw, PooledTransactionsMsg, txs)
+}
+
 // SendNewBlockHashes announces the availability of a number of blocks through
 // a hash notification.
 func (p *peer) SendNewBlockHashes(hashes []common.Hash, numbers []uint64) error {
@@ -247,6 +317,34 @@ func (p *peer) SendNewBlock(block *types.Block, td *big.Int) error {
 	return p2p.Send(p.rw, NewBlockMsg, []interface{}{block, td})
 }
 
+// SendCompactBlock propagates a block to a remote peer, sending only the short
+// ids of the transactions the peer is known to have.
+func (p *peer) SendCompactBlock(block *types.Block, td *big.Int) error {
+	p.knownBlocks.Add(block.Hash())
+
+	txs := block.Transactions()
+	request := &compactBlockData{
+		Header: block.Header(),
+		Uncles: block.Uncles(),
+		TxIDs:  make([]shortTxID, len(txs)),
+		TD:     td,
+	}
+	for i, tx := range txs {
+		hash := tx.Hash()
+		request.TxIDs[i] = newShortTxID(block.ParentHash(), hash)
+		if !p.knownTxs.Contains(hash) {
+			request.Prefilled = append(request.Prefilled, &prefilledTx{Index: uint64(i), Tx: tx})
+		}
+	}
+	return p2p.Send(p.rw, CompactBlockMsg, request)
+}
+
+// SendBlockTxs sends the transactions of a compact block requested by the
+// remote peer.
+func (p *peer) SendBlockTxs(hash common.Hash, txs []*types.Transaction) error {
+	return p2p.Send(p.rw, BlockTxsMsg, &blockTxsData{Hash: hash, Txs: txs})
+}
+
 // AsyncSendNewBlock queues an entire block for propagation to a remote peer. If
 // the peer's broadcast queue is full, the event is silently dropped.
 func (p *peer) AsyncSendNewBlock(block *types.Block, td *big.Int) {
@@ -286,6 +384,40 @@ func (p *peer) SendReceiptsRLP(receipts []rlp.RawValue) error {
 	return p2p.Send(p.rw, ReceiptsMsg, receipts)
 }
 
+// SendAccountRange sends a batch of account trie leaves, along with the edge
+// proofs of the range, to the remote peer.
+func (p *peer) SendAccountRange(id uint64, accounts []*accountData, proof [][]byte) error {
+	return p2p.Send(p.rw, AccountRangeMsg, &accountRangeData{ID: id, Accounts: accounts, Proof: proof})
+}
+
+// SendStorageRanges sends a batch of storage trie leaves of multiple accounts,
+// along with the edge proofs of the last range, to the remote peer.
+func (p *peer) SendStorageRanges(id uint64, slots [][]*storageData, proof [][]byte) error {
+	return p2p.Send(p.rw, StorageRangesMsg, &storageRangesData{ID: id, Slots: slots, Proof: proof})
+}
+
+// SendPrivateTransaction hands a transaction to the peer to be kept private
+// until the given expiry, and includes the hash in its transaction hash set for
+// future reference.
+func (p *peer) SendPrivateTransaction(tx *types.Transaction, expiry uint64) error {
+	p.knownTxs.Add(tx.Hash())
+	return p2p.Send(p.rw, PrivateTxMsg, &privateTxData{Tx: tx, Expiry: expiry})
+}
+
+// RequestTxs fetches a batch of announced transactions from the remote peer's
+// pool.
+func (p *peer) RequestTxs(hashes []common.Hash) error {
+	p.Log().Debug("Fetching batch of transactions", "count", len(hashes))
+	return p2p.Send(p.rw, GetPooledTransactionsMsg, hashes)
+}
+
+// RequestBlockTxs fetches the transactions of a compact block missing from the
+// local pool.
+func (p *peer) RequestBlockTxs(hash common.Hash, indexes []uint64) error {
+	p.Log().Debug("Fetching compact block transactions", "hash", hash, "count", len(indexes))
+	return p2p.Send(p.rw, GetBlockTxsMsg, &getBlockTxsData{Hash: hash, Indexes: indexes})
+}
+
 // RequestOneHeader is a wrapper around the header query functions to fetch a
 // single header. It is used solely by the fetcher.
 func (p *peer) RequestOneHeader(hash common.Hash) error {
@@ -321,6 +453,21 @@ func (p *peer) RequestNodeData(hashes []common.Hash) error {
 	return p2p.Send(p.rw, GetNodeDataMsg, hashes)
 }
 
+// RequestAccountRange fetches a batch of consecutive accounts from the account
+// trie of the given state root, starting at origin and ending around limit.
+func (p *peer) RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching range of accounts", "root", root, "origin", origin, "limit", limit, "bytes", common.StorageSize(bytes))
+	return p2p.Send(p.rw, GetAccountRangeMsg, &getAccountRangeData{ID: id, Root: root, Origin: origin, Limit: limit, Bytes: bytes})
+}
+
+// RequestStorageRanges fetches a batch of storage slots belonging to one or more
+// accounts of the given state root. If slots from only one account are requested,
+// an origin marker may also be used to retrieve from there.
+func (p *peer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error {
+	p.Log().Debug("Fetching ranges of storage slots", "root", root, "accounts", len(accounts), "origin", common.Bytes2Hex(origin), "bytes", common.StorageSize(bytes))
+	return p2p.Send(p.rw, GetStorageRangesMsg, &getStorageRangesData{ID: id, Root: root, Accounts: accounts, Origin: origin, Limit: limit, Bytes: bytes})
+}
+
 // RequestReceipts fetches a batch of transaction receipts from a remote node.
 func (p *peer) RequestReceipts(hashes []common.Hash) error {
 	p.Log().Debug("Fetching batch of receipts", "count", len(hashes))
@@ -328,13 +475,28 @@ func (p *peer) RequestReceipts(hashes []common.Hash) error {
 }
 
 // Handshake executes the eth protocol handshake, negotiating version number,
-// network IDs, difficulties, head and genesis blocks.
-func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis common.Hash) error {
+// network IDs, difficulties, head and genesis blocks. From etz/66 on, the fork
+// IDs are exchanged too, and peers on incompatible forks rejected.
+func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis common.Hash, forkID forkid.ID, forkFilter forkid.Filter) error {
 	// Send out own handshake in a new thread
 	errc := make(chan error, 2)
-	var status statusData // safe to read after two values have been received from errc
 
+	var (
+		status   statusData   // safe to read after two values have been received from errc
+		status66 statusData66 // safe to read after two values have been received from errc
+	)
 	go func() {
+		if p.version >= etz66 {
+			errc <- p2p.Send(p.rw, StatusMsg, &statusData66{
+				ProtocolVersion: uint32(p.version),
+				NetworkId:       network,
+				TD:              td,
+				CurrentBlock:    head,
+				GenesisBlock:    genesis,
+				ForkID:          forkID,
+			})
+			return
+		}
 		errc <- p2p.Send(p.rw, StatusMsg, &statusData{
 			ProtocolVersion: uint32(p.version),
 			NetworkId:       network,
@@ -344,6 +506,10 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 		})
 	}()
 	go func() {
+		if p.version >= etz66 {
+			errc <- p.readStatus66(network, &status66, genesis, forkFilter)
+			return
+		}
 		errc <- p.readStatus(network, &status, genesis)
 	}()
 	timeout := time.NewTimer(handshakeTimeout)
@@ -358,7 +524,11 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 			return p2p.DiscReadTimeout
 		}
 	}
-	p.td, p.head = status.TD, status.CurrentBlock
+	if p.version >= etz66 {
+		p.td, p.head = status66.TD, status66.CurrentBlock
+	} else {
+		p.td, p.head = status.TD, status.CurrentBlock
+	}
 	return nil
 }
 
@@ -389,6 +559,36 @@ func (p *peer) readStatus(network uint64, status *statusData, genesis common.Has
 	return nil
 }
 
+func (p *peer) readStatus66(network uint64, status *statusData66, genesis common.Hash, forkFilter forkid.Filter) (err error) {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Code != StatusMsg {
+		return errResp(ErrNoStatusMsg, "first msg has code %x (!= %x)", msg.Code, StatusMsg)
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	// Decode the handshake and make sure everything matches
+	if err := msg.Decode(&status); err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	if status.NetworkId != network {
+		return errResp(ErrNetworkIdMismatch, "%d (!= %d)", status.NetworkId, network)
+	}
+	if int(status.ProtocolVersion) != p.version {
+		return errResp(ErrProtocolVersionMismatch, "%d (!= %d)", status.ProtocolVersion, p.version)
+	}
+	if status.GenesisBlock != genesis {
+		return errResp(ErrGenesisBlockMismatch, "%x (!= %x)", status.GenesisBlock[:8], genesis[:8])
+	}
+	if err := forkFilter(status.ForkID); err != nil {
+		return errResp(ErrForkIDRejected, "%v", err)
+	}
+	return nil
+}
+
 // String implements fmt.Stringer.
 func (p *peer) String() string {
 	return fmt.Sprintf("Peer %s [%s]", p.id,
@@ -492,6 +692,26 @@ func (ps *peerSet) PeersWithoutTx(hash common.Hash) []*peer {
 	return list
 }
 
+// WitnessPeers retrieves a list of peers capable of receiving private transactions
+// that are run by any of the given masternodes.
+func (ps *peerSet) WitnessPeers(witnesses []string) []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	ids := make(map[string]struct{}, len(witnesses))
+	for _, witness := range witnesses {
+		ids[witness] = struct{}{}
+	}
+	list := make([]*peer, 0, len(witnesses))
+	for _, p := range ps.peers {
+		x8 := p.Node().X8()
+		if _, ok := ids[fmt.Sprintf("%x", x8[:])]; ok && p.version >= etz65 {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
 // BestPeer retrieves the known peer with the currently highest total difficulty.
 func (ps *peerSet) BestPeer() *peer {
 	ps.lock.RLock()
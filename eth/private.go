@@ -0,0 +1,271 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus/devote"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/log"
+)
+
+const (
+	privateTxSlots      = 8                 // Number of upcoming slots whose witnesses receive private transactions
+	maxPrivateTxs       = 4096              // Maximum number of private transactions tracked at once
+	maxRemotePrivateTxs = maxPrivateTxs / 2 // Maximum number of private transactions handed over by remote peers
+	maxPeerPrivateTxs   = 64                // Maximum number of private transactions handed over by a single peer
+	maxPrivateTxExpiry  = 10 * time.Minute  // Maximum amount of time a transaction is kept private
+
+	// DefaultPrivateTxExpiry is the amount of time a transaction is kept private
+	// if the submitter doesn't request otherwise.
+	DefaultPrivateTxExpiry = time.Minute
+
+	privateTxCheckInterval = time.Second // Time interval to check for expired private transactions
+)
+
+var (
+	errNoWitnessPeers     = errors.New("no upcoming witness connected")
+	errNoWitnessSchedule  = errors.New("witness schedule unavailable")
+	errPrivateTxsFull     = errors.New("too many private transactions")
+	errPeerPrivateTxsFull = errors.New("too many private transactions from peer")
+	errPrivateTxExpired   = errors.New("private transaction expired")
+)
+
+// privateTx is a transaction withheld from gossip until its expiry.
+type privateTx struct {
+	tx      *types.Transaction
+	origin  string    // Id of the peer that handed the transaction over, empty if local
+	expiry  time.Time // Time after which the transaction is no longer private
+	publish bool      // Whether to publish the transaction on expiry, or drop it
+}
+
+// privateTxSet is the set of transactions handed directly to upcoming witnesses
+// instead of being broadcast to the network.
+type privateTxSet struct {
+	txs    map[common.Hash]*privateTx
+	peers  map[string]int // Number of transactions handed over by each remote peer
+	remote int            // Number of transactions handed over by all remote peers
+	lock   sync.RWMutex
+}
+
+// newPrivateTxSet creates a new, empty set of private transactions.
+func newPrivateTxSet() *privateTxSet {
+	return &privateTxSet{
+		txs:   make(map[common.Hash]*privateTx),
+		peers: make(map[string]int),
+	}
+}
+
+// add inserts a transaction handed over by the given peer, or submitted locally
+// if the origin is empty, into the private set. Remote peers may only fill their
+// share of the set, so they can't crowd out each other or local submissions.
+func (s *privateTxSet) add(tx *types.Transaction, origin string, expiry time.Time, publish bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	hash := tx.Hash()
+	if old, ok := s.txs[hash]; ok {
+		// Already private, only extend the expiry of local submissions
+		if origin == "" {
+			s.untrack(old)
+			s.txs[hash] = &privateTx{tx: tx, expiry: expiry, publish: publish}
+		}
+		return nil
+	}
+	if len(s.txs) >= maxPrivateTxs {
+		return errPrivateTxsFull
+	}
+	if origin != "" {
+		if s.remote >= maxRemotePrivateTxs {
+			return errPrivateTxsFull
+		}
+		if s.peers[origin] >= maxPeerPrivateTxs {
+			return errPeerPrivateTxsFull
+		}
+		s.peers[origin]++
+		s.remote++
+	}
+	s.txs[hash] = &privateTx{tx: tx, origin: origin, expiry: expiry, publish: publish}
+	return nil
+}
+
+// remove deletes a transaction from the private set.
+func (s *privateTxSet) remove(hash common.Hash) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if ptx, ok := s.txs[hash]; ok {
+		s.untrack(ptx)
+		delete(s.txs, hash)
+	}
+}
+
+// untrack releases the slot of a private transaction held by its origin peer.
+//
+// Note, this method assumes the set lock is held!
+func (s *privateTxSet) untrack(ptx *privateTx) {
+	if ptx.origin == "" {
+		return
+	}
+	if s.peers[ptx.origin]--; s.peers[ptx.origin] <= 0 {
+		delete(s.peers, ptx.origin)
+	}
+	s.remote--
+}
+
+// filter returns the transactions of the given batch that are not private.
+func (s *privateTxSet) filter(txs types.Transactions) types.Transactions {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if len(s.txs) == 0 {
+		return txs
+	}
+	public := make(types.Transactions, 0, len(txs))
+	for _, tx := range txs {
+		if _, ok := s.txs[tx.Hash()]; !ok {
+			public = append(public, tx)
+		}
+	}
+	return public
+}
+
+// expire removes and returns all the transactions whose privacy expired.
+func (s *privateTxSet) expire(now time.Time) []*privateTx {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var expired []*privateTx
+	for hash, ptx := range s.txs {
+		if now.After(ptx.expiry) {
+			expired = append(expired, ptx)
+			s.untrack(ptx)
+			delete(s.txs, hash)
+		}
+	}
+	return expired
+}
+
+// SendPrivateTx adds a local transaction to the pool and hands it directly to
+// the connected masternodes scheduled as witnesses for the upcoming slots over
+// their authenticated connections, without gossiping it. Once the expiry passes,
+// the transaction is either published to the network or dropped from the pool.
+func (pm *ProtocolManager) SendPrivateTx(tx *types.Transaction, expiry time.Duration, publish bool) error {
+	if expiry <= 0 {
+		expiry = DefaultPrivateTxExpiry
+	}
+	if expiry > maxPrivateTxExpiry {
+		expiry = maxPrivateTxExpiry
+	}
+	// Find the upcoming witnesses we can reach directly
+	engine, ok := pm.blockchain.Engine().(*devote.Devote)
+	if !ok {
+		return errNoWitnessSchedule
+	}
+	witnesses, err := engine.Schedule(pm.blockchain.CurrentHeader(), privateTxSlots)
+	if err != nil {
+		log.Debug("Failed to retrieve witness schedule", "err", err)
+		return errNoWitnessSchedule
+	}
+	peers := pm.peers.WitnessPeers(witnesses)
+	if len(peers) == 0 && !pm.isWitness(witnesses) {
+		return errNoWitnessPeers
+	}
+	// Withhold the transaction from gossip before it enters the pool
+	hash, deadline := tx.Hash(), time.Now().Add(expiry)
+	if err := pm.private.add(tx, "", deadline, publish); err != nil {
+		return err
+	}
+	if err := pm.txpool.AddLocal(tx); err != nil {
+		pm.private.remove(hash)
+		return err
+	}
+	for _, p := range peers {
+		if err := p.SendPrivateTransaction(tx, uint64(deadline.Unix())); err != nil {
+			p.Log().Debug("Failed to send private transaction", "hash", hash, "err", err)
+		}
+	}
+	log.Debug("Sent private transaction", "hash", hash, "witnesses", len(witnesses), "recipients", len(peers), "expiry", common.PrettyDuration(expiry))
+	return nil
+}
+
+// isWitness returns whether the local node is a masternode among the given
+// witnesses.
+func (pm *ProtocolManager) isWitness(witnesses []string) bool {
+	if pm.mm == nil || atomic.LoadUint32(&pm.mm.IsMasternode) == 0 {
+		return false
+	}
+	for _, witness := range witnesses {
+		if witness == pm.mm.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// handlePrivateTx keeps a transaction handed over by a remote peer private until
+// its expiry. Only masternodes, which may become witnesses, accept them, and each
+// peer may only keep a limited number of transactions private at once.
+func (pm *ProtocolManager) handlePrivateTx(p *peer, req *privateTxData) {
+	if pm.mm == nil || atomic.LoadUint32(&pm.mm.IsMasternode) == 0 {
+		return
+	}
+	hash := req.Tx.Hash()
+	p.MarkTransaction(hash)
+
+	deadline := time.Unix(int64(req.Expiry), 0)
+	if limit := time.Now().Add(maxPrivateTxExpiry); deadline.After(limit) {
+		deadline = limit
+	}
+	if err := pm.private.add(req.Tx, p.id, deadline, false); err != nil {
+		p.Log().Debug("Dropping private transaction", "hash", hash, "err", err)
+		return
+	}
+	if errs := pm.txpool.AddRemotes([]*types.Transaction{req.Tx}); errs[0] != nil {
+		pm.private.remove(hash)
+	}
+}
+
+// privateTxLoop publishes or drops the private transactions whose expiry passed.
+func (pm *ProtocolManager) privateTxLoop() {
+	ticker := time.NewTicker(privateTxCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			var publish types.Transactions
+			for _, ptx := range pm.private.expire(now) {
+				if ptx.publish {
+					publish = append(publish, ptx.tx)
+				} else {
+					pm.txpool.RemoveTx(ptx.tx.Hash(), errPrivateTxExpired)
+				}
+			}
+			if len(publish) > 0 {
+				pm.BroadcastTxs(publish)
+			}
+		case <-pm.quitSync:
+			return
+		}
+	}
+}
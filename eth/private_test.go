@@ -0,0 +1,156 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/eth/downloader"
+	"github.com/etherzero/go-etherzero/p2p"
+)
+
+// Tests that private transactions are withheld from broadcasts until they
+// expire.
+func TestPrivateTxSet(t *testing.T) {
+	set := newPrivateTxSet()
+
+	private := newTestTransaction(testAccount, 0, 0)
+	public := newTestTransaction(testAccount, 1, 0)
+
+	now := time.Now()
+	set.add(private, "", now.Add(time.Minute), true)
+
+	txs := set.filter(types.Transactions{private, public})
+	if len(txs) != 1 || txs[0] != public {
+		t.Fatalf("filtered transactions mismatch: have %v, want [%x]", txs, public.Hash())
+	}
+	if expired := set.expire(now); len(expired) != 0 {
+		t.Fatalf("expired transactions mismatch: have %d, want %d", len(expired), 0)
+	}
+	expired := set.expire(now.Add(2 * time.Minute))
+	if len(expired) != 1 || expired[0].tx != private || !expired[0].publish {
+		t.Fatalf("expired transactions mismatch: have %v", expired)
+	}
+	if txs := set.filter(types.Transactions{private, public}); len(txs) != 2 {
+		t.Fatalf("filtered transactions mismatch after expiry: have %d, want %d", len(txs), 2)
+	}
+}
+
+// Tests that remote peers can only keep a limited number of transactions private,
+// both individually and together, while local submissions are always accepted.
+func TestPrivateTxSetLimits(t *testing.T) {
+	set := newPrivateTxSet()
+	expiry := time.Now().Add(time.Minute)
+
+	// Fill up the allowance of a single peer
+	var nonce uint64
+	next := func() *types.Transaction {
+		nonce++
+		return newTestTransaction(testAccount, nonce, 0)
+	}
+	first := next()
+	if err := set.add(first, "peer", expiry, false); err != nil {
+		t.Fatalf("failed to add first private transaction: %v", err)
+	}
+	for i := 1; i < maxPeerPrivateTxs; i++ {
+		if err := set.add(next(), "peer", expiry, false); err != nil {
+			t.Fatalf("failed to add private transaction %d: %v", i, err)
+		}
+	}
+	if err := set.add(next(), "peer", expiry, false); err != errPeerPrivateTxsFull {
+		t.Fatalf("peer overflow error mismatch: have %v, want %v", err, errPeerPrivateTxsFull)
+	}
+	// Re-adding a known transaction is fine, removing one frees up a slot
+	if err := set.add(first, "peer", expiry, false); err != nil {
+		t.Fatalf("failed to re-add known private transaction: %v", err)
+	}
+	set.remove(first.Hash())
+	if err := set.add(next(), "peer", expiry, false); err != nil {
+		t.Fatalf("failed to add private transaction after removal: %v", err)
+	}
+	// Fill up the allowance of all remote peers and ensure locals still get in
+	for i := maxPeerPrivateTxs; i < maxRemotePrivateTxs; i++ {
+		if err := set.add(next(), fmt.Sprintf("peer #%d", i/maxPeerPrivateTxs), expiry, false); err != nil {
+			t.Fatalf("failed to add private transaction %d: %v", i, err)
+		}
+	}
+	if err := set.add(next(), "other", expiry, false); err != errPrivateTxsFull {
+		t.Fatalf("remote overflow error mismatch: have %v, want %v", err, errPrivateTxsFull)
+	}
+	if err := set.add(next(), "", expiry, true); err != nil {
+		t.Fatalf("failed to add local private transaction: %v", err)
+	}
+	// Expiring the transactions should release all remote slots
+	set.expire(expiry.Add(time.Second))
+	if set.remote != 0 || len(set.peers) != 0 {
+		t.Fatalf("remote slots not released: %d remote, %d peers", set.remote, len(set.peers))
+	}
+}
+
+// Tests that private transactions handed over by peers are added to the pool of
+// masternodes, but withheld from the transactions synced to other peers.
+func TestRecvPrivateTransaction(t *testing.T) {
+	txAdded := make(chan []*types.Transaction, 2)
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, txAdded)
+	pm.acceptTxs = 1 // mark synced to accept transactions
+	pm.mm = &MasternodeManager{IsMasternode: 1}
+	defer pm.Stop()
+
+	p, _ := newTestPeer("sender", etz65, pm, true)
+	defer p.close()
+
+	private := newTestTransaction(testAccount, 0, 0)
+	if err := p2p.Send(p.app, PrivateTxMsg, &privateTxData{Tx: private, Expiry: uint64(time.Now().Add(time.Minute).Unix())}); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	select {
+	case added := <-txAdded:
+		if len(added) != 1 || added[0].Hash() != private.Hash() {
+			t.Fatalf("added transactions mismatch: have %v, want [%x]", added, private.Hash())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("private transaction not added within 2 seconds")
+	}
+	if txs := pm.private.filter(types.Transactions{private}); len(txs) != 0 {
+		t.Fatalf("received transaction not kept private")
+	}
+	// Add a public transaction and ensure only that is synced to new peers
+	public := newTestTransaction(testAccount, 1, 0)
+	pm.txpool.AddRemotes([]*types.Transaction{public})
+	<-txAdded
+
+	receiver, _ := newTestPeer("receiver", 63, pm, true)
+	defer receiver.close()
+
+	msg, err := receiver.app.ReadMsg()
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if msg.Code != TxMsg {
+		t.Fatalf("message code mismatch: have %d, want %d", msg.Code, TxMsg)
+	}
+	var txs []*types.Transaction
+	if err := msg.Decode(&txs); err != nil {
+		t.Fatalf("failed to decode transactions: %v", err)
+	}
+	if len(txs) != 1 || txs[0].Hash() != public.Hash() {
+		t.Fatalf("synced transactions mismatch: have %d, want only [%x]", len(txs), public.Hash())
+	}
+}
@@ -23,7 +23,9 @@ import (
 
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/forkid"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/event"
 	"github.com/etherzero/go-etherzero/rlp"
 )
@@ -33,16 +35,20 @@ const (
 	eth62 = 62
 	eth63 = 63
 	etz64 = 64
+	etz65 = 65
+	etz66 = 66
+	etz67 = 67
+	etz68 = 68
 )
 
 // ProtocolName is the official short name of the protocol used during capability negotiation.
 var ProtocolName = "etz"
 
 // ProtocolVersions are the supported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{etz64, eth63, eth62}
+var ProtocolVersions = []uint{etz68, etz67, etz66, etz65, etz64, eth63, eth62}
 
 // ProtocolLengths are the number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{35, 17, 8}
+var ProtocolLengths = []uint64{35, 35, 35, 35, 35, 17, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -63,6 +69,23 @@ const (
 	NodeDataMsg    = 0x0e
 	GetReceiptsMsg = 0x0f
 	ReceiptsMsg    = 0x10
+
+	// Protocol messages belonging to etz/65
+	GetAccountRangeMsg  = 0x11
+	AccountRangeMsg     = 0x12
+	GetStorageRangesMsg = 0x13
+	StorageRangesMsg    = 0x14
+	PrivateTxMsg        = 0x15
+
+	// Protocol messages belonging to etz/67
+	NewPooledTransactionHashesMsg = 0x16
+	GetPooledTransactionsMsg      = 0x17
+	PooledTransactionsMsg         = 0x18
+
+	// Protocol messages belonging to etz/68
+	CompactBlockMsg = 0x19
+	GetBlockTxsMsg  = 0x1a
+	BlockTxsMsg     = 0x1b
 )
 
 type errCode int
@@ -77,6 +100,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrForkIDRejected
 )
 
 func (e errCode) String() string {
@@ -94,12 +118,23 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrForkIDRejected:          "Fork ID rejected",
 }
 
 type txPool interface {
+	// AddLocal should add the given transaction to the pool as a local one.
+	AddLocal(*types.Transaction) error
+
 	// AddRemotes should add the given transactions to the pool.
 	AddRemotes([]*types.Transaction) []error
 
+	// Get should retrieve the transaction with the given hash from the pool, or
+	// nil if it's not known.
+	Get(hash common.Hash) *types.Transaction
+
+	// RemoveTx should remove the given transaction from the pool.
+	RemoveTx(hash common.Hash, reason error)
+
 	// Pending should return pending transactions.
 	// The slice should be modifiable by the caller.
 	Pending() (map[common.Address]types.Transactions, error)
@@ -118,6 +153,17 @@ type statusData struct {
 	GenesisBlock    common.Hash
 }
 
+// statusData66 is the network packet for the status message from etz/66 on,
+// additionally carrying the fork identifier of the sender.
+type statusData66 struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	TD              *big.Int
+	CurrentBlock    common.Hash
+	GenesisBlock    common.Hash
+	ForkID          forkid.ID
+}
+
 // newBlockHashesData is the network packet for the block announcements.
 type newBlockHashesData []struct {
 	Hash   common.Hash // Hash of one particular block being announced
@@ -174,6 +220,50 @@ type newBlockData struct {
 	TD    *big.Int
 }
 
+// shortTxID is the abbreviated hash identifying a transaction in a compact block.
+type shortTxID [8]byte
+
+// newShortTxID returns the short id of a transaction hash within a block with the
+// given parent. Salting the ids per block prevents transactions from being crafted
+// ahead of time to collide with others.
+func newShortTxID(salt common.Hash, hash common.Hash) shortTxID {
+	var id shortTxID
+	copy(id[:], crypto.Keccak256(salt[:], hash[:]))
+	return id
+}
+
+// prefilledTx is a transaction sent in full within a compact block, as the
+// recipient isn't known to have it.
+type prefilledTx struct {
+	Index uint64             // Position of the transaction in the block
+	Tx    *types.Transaction // Transaction sent in full
+}
+
+// compactBlockData is the network packet for the compact block propagation
+// message, carrying the short ids of the block's transactions instead of the
+// transactions themselves.
+type compactBlockData struct {
+	Header    *types.Header
+	Uncles    []*types.Header
+	TxIDs     []shortTxID    // Short ids of all the transactions of the block
+	Prefilled []*prefilledTx // Transactions the recipient isn't known to have
+	TD        *big.Int
+}
+
+// getBlockTxsData represents a query for the transactions of a compact block
+// missing from the pool of the recipient.
+type getBlockTxsData struct {
+	Hash    common.Hash // Hash of the compact block
+	Indexes []uint64    // Positions of the missing transactions in the block
+}
+
+// blockTxsData is the network packet for the missing transactions of a compact
+// block, in the order of the query.
+type blockTxsData struct {
+	Hash common.Hash
+	Txs  []*types.Transaction
+}
+
 // blockBody represents the data content of a single block.
 type blockBody struct {
 	Transactions []*types.Transaction // Transactions contained within a block
@@ -182,3 +272,55 @@ type blockBody struct {
 
 // blockBodiesData is the network packet for block content distribution.
 type blockBodiesData []*blockBody
+
+// getAccountRangeData represents an account trie range query.
+type getAccountRangeData struct {
+	ID     uint64      // Request id to match up responses with
+	Root   common.Hash // State root of the account trie to serve
+	Origin common.Hash // Hash of the first account to retrieve
+	Limit  common.Hash // Hash of the last account to retrieve
+	Bytes  uint64      // Soft limit at which to stop returning data
+}
+
+// accountData represents a single account in an account range response.
+type accountData struct {
+	Hash common.Hash  // Hash of the account address
+	Body rlp.RawValue // RLP encoded state.Account
+}
+
+// accountRangeData is the network packet for account trie range distribution.
+type accountRangeData struct {
+	ID       uint64         // Request id of the query being answered
+	Accounts []*accountData // Consecutive accounts from the requested range
+	Proof    [][]byte       // Edge proofs of the returned range (empty if the whole trie)
+}
+
+// getStorageRangesData represents a storage trie range query for a set of accounts.
+type getStorageRangesData struct {
+	ID       uint64        // Request id to match up responses with
+	Root     common.Hash   // State root of the account trie the storage belongs to
+	Accounts []common.Hash // Hashes of the accounts whose storage to retrieve
+	Origin   []byte        // Hash of the first slot to retrieve (first account only)
+	Limit    []byte        // Hash of the last slot to retrieve (last account only)
+	Bytes    uint64        // Soft limit at which to stop returning data
+}
+
+// storageData represents a single slot in a storage range response.
+type storageData struct {
+	Hash common.Hash // Hash of the storage slot key
+	Body []byte      // RLP encoded slot value
+}
+
+// storageRangesData is the network packet for storage trie range distribution.
+type storageRangesData struct {
+	ID    uint64           // Request id of the query being answered
+	Slots [][]*storageData // Consecutive slots of the storage tries of the requested accounts
+	Proof [][]byte         // Edge proofs of the last returned range (empty if the whole trie)
+}
+
+// privateTxData is the network packet for handing a transaction directly to an
+// upcoming witness, without it being gossiped until it expires.
+type privateTxData struct {
+	Tx     *types.Transaction // Transaction to include in an upcoming block
+	Expiry uint64             // Unix time after which the transaction is dropped
+}
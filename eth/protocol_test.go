@@ -18,15 +18,18 @@ package eth
 
 import (
 	"fmt"
+	"math/big"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/forkid"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/eth/downloader"
 	"github.com/etherzero/go-etherzero/p2p"
+	"github.com/etherzero/go-etherzero/p2p/enode"
 	"github.com/etherzero/go-etherzero/rlp"
 )
 
@@ -92,6 +95,79 @@ func testStatusMsgErrors(t *testing.T, protocol int) {
 	}
 }
 
+// Tests that from etz/66 on, peers announcing an incompatible fork ID are
+// rejected during the handshake.
+func TestHandshakeForkID(t *testing.T) {
+	var (
+		genesis = common.Hash{1}
+		head    = common.Hash{2}
+		local   = forkid.ID{Hash: [4]byte{1, 2, 3, 4}}
+		remote  = forkid.ID{Hash: [4]byte{5, 6, 7, 8}}
+	)
+	filter := func(id forkid.ID) error {
+		if id != local {
+			return forkid.ErrLocalIncompatibleOrStale
+		}
+		return nil
+	}
+	tests := []struct {
+		version int
+		fork    forkid.ID
+		fail    bool
+	}{
+		{etz66, local, false},
+		{etz66, remote, true},
+		{etz65, remote, false}, // Legacy peers don't announce fork IDs
+	}
+	for i, tt := range tests {
+		app, net := p2p.MsgPipe()
+		var (
+			p1 = newPeer(tt.version, p2p.NewPeer(enode.ID{1}, "local", nil), app)
+			p2 = newPeer(tt.version, p2p.NewPeer(enode.ID{2}, "remote", nil), net)
+		)
+		errc := make(chan error, 1)
+		go func() {
+			errc <- p2.Handshake(DefaultConfig.NetworkId, big.NewInt(1), head, genesis, tt.fork, filter)
+		}()
+		err := p1.Handshake(DefaultConfig.NetworkId, big.NewInt(1), head, genesis, local, filter)
+		if tt.fail {
+			want := errResp(ErrForkIDRejected, "%v", forkid.ErrLocalIncompatibleOrStale)
+			if err == nil || err.Error() != want.Error() {
+				t.Errorf("test %d: error mismatch: have %v, want %v", i, err, want)
+			}
+		} else if err != nil {
+			t.Errorf("test %d: handshake failed: %v", i, err)
+		}
+		app.Close()
+		<-errc
+	}
+}
+
+// Tests that only transactions the peer sent itself are reported as resent, not
+// the ones sent or announced to it.
+func TestMarkReceivedTransaction(t *testing.T) {
+	p := newPeer(etz67, p2p.NewPeer(enode.ID{1}, "remote", nil), nil)
+
+	sent := types.NewTransaction(0, common.Address{}, new(big.Int), 0, new(big.Int), nil)
+	announced := types.NewTransaction(1, common.Address{}, new(big.Int), 0, new(big.Int), nil)
+	received := types.NewTransaction(2, common.Address{}, new(big.Int), 0, new(big.Int), nil)
+
+	p.MarkTransaction(sent.Hash())
+	p.MarkTransaction(announced.Hash())
+
+	for _, tx := range []*types.Transaction{sent, announced, received} {
+		if p.MarkReceivedTransaction(tx.Hash()) {
+			t.Errorf("transaction %d reported as resent on first receipt", tx.Nonce())
+		}
+	}
+	if !p.MarkReceivedTransaction(received.Hash()) {
+		t.Errorf("resent transaction not reported")
+	}
+	if !p.knownTxs.Contains(received.Hash()) {
+		t.Errorf("received transaction not marked as known")
+	}
+}
+
 // This test checks that received transactions are added to the local pool.
 func TestRecvTransactions62(t *testing.T) { testRecvTransactions(t, 62) }
 func TestRecvTransactions63(t *testing.T) { testRecvTransactions(t, 63) }
@@ -123,6 +199,7 @@ func testRecvTransactions(t *testing.T, protocol int) {
 // This test checks that pending transactions are sent.
 func TestSendTransactions62(t *testing.T) { testSendTransactions(t, 62) }
 func TestSendTransactions63(t *testing.T) { testSendTransactions(t, 63) }
+func TestSendTransactions67(t *testing.T) { testSendTransactions(t, etz67) }
 
 func testSendTransactions(t *testing.T, protocol int) {
 	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
@@ -136,7 +213,13 @@ func testSendTransactions(t *testing.T, protocol int) {
 	}
 	pm.txpool.AddRemotes(alltxs)
 
-	// Connect several peers. They should all receive the pending transactions.
+	// Make one of the transactions private, it must not be synced to anyone
+	private := newTestTransaction(testAccount, uint64(len(alltxs)), 0)
+	pm.txpool.AddRemotes([]*types.Transaction{private})
+	pm.private.add(private, "", time.Now().Add(time.Hour), true)
+
+	// Connect several peers. They should all receive the pending transactions,
+	// peers supporting announcements only their hashes.
 	var wg sync.WaitGroup
 	checktxs := func(p *testPeer) {
 		defer wg.Done()
@@ -146,18 +229,30 @@ func testSendTransactions(t *testing.T, protocol int) {
 			seen[tx.Hash()] = false
 		}
 		for n := 0; n < len(alltxs) && !t.Failed(); {
-			var txs []*types.Transaction
+			var hashes []common.Hash
 			msg, err := p.app.ReadMsg()
 			if err != nil {
 				t.Errorf("%v: read error: %v", p.Peer, err)
-			} else if msg.Code != TxMsg {
-				t.Errorf("%v: got code %d, want TxMsg", p.Peer, msg.Code)
-			}
-			if err := msg.Decode(&txs); err != nil {
-				t.Errorf("%v: %v", p.Peer, err)
+			} else if protocol >= etz67 {
+				if msg.Code != NewPooledTransactionHashesMsg {
+					t.Errorf("%v: got code %d, want NewPooledTransactionHashesMsg", p.Peer, msg.Code)
+				}
+				if err := msg.Decode(&hashes); err != nil {
+					t.Errorf("%v: %v", p.Peer, err)
+				}
+			} else {
+				if msg.Code != TxMsg {
+					t.Errorf("%v: got code %d, want TxMsg", p.Peer, msg.Code)
+				}
+				var txs []*types.Transaction
+				if err := msg.Decode(&txs); err != nil {
+					t.Errorf("%v: %v", p.Peer, err)
+				}
+				for _, tx := range txs {
+					hashes = append(hashes, tx.Hash())
+				}
 			}
-			for _, tx := range txs {
-				hash := tx.Hash()
+			for _, hash := range hashes {
 				seentx, want := seen[hash]
 				if seentx {
 					t.Errorf("%v: got tx more than once: %x", p.Peer, hash)
@@ -178,6 +273,122 @@ func testSendTransactions(t *testing.T, protocol int) {
 	wg.Wait()
 }
 
+// Tests that pooled transactions are served on request, skipping the unknown
+// and the private ones.
+func TestGetPooledTransactions67(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	var (
+		public  = newTestTransaction(testAccount, 0, 0)
+		private = newTestTransaction(testAccount, 1, 0)
+		unknown = newTestTransaction(testAccount, 2, 0)
+	)
+	pm.txpool.AddRemotes([]*types.Transaction{public, private})
+	pm.private.add(private, "", time.Now().Add(time.Hour), true)
+
+	// Connect a peer and wait for the initial sync, announcing the public transaction
+	p, _ := newTestPeer("peer", etz67, pm, true)
+	defer p.close()
+
+	if err := p2p.ExpectMsg(p.app, NewPooledTransactionHashesMsg, []common.Hash{public.Hash()}); err != nil {
+		t.Fatalf("transaction announcement mismatch: %v", err)
+	}
+	hashes := []common.Hash{unknown.Hash(), private.Hash(), public.Hash()}
+	if err := p2p.Send(p.app, GetPooledTransactionsMsg, hashes); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	if err := p2p.ExpectMsg(p.app, PooledTransactionsMsg, []*types.Transaction{public}); err != nil {
+		t.Errorf("pooled transactions mismatch: %v", err)
+	}
+	if p.peer.knownTxs.Contains(private.Hash()) {
+		t.Errorf("private transaction marked as known")
+	}
+}
+
+// Tests that transactions are broadcast in full to the square root of the peers
+// and only announced to the rest, unless they don't support announcements.
+func TestBroadcastTransactions(t *testing.T) {
+	var tests = []struct {
+		version   int
+		peers     int
+		broadcast int
+		announce  int
+	}{
+		{etz66, 4, 4, 0},
+		{etz67, 1, 1, 0},
+		{etz67, 4, 2, 2},
+		{etz67, 9, 3, 6},
+		{etz67, 16, 4, 12},
+	}
+	for _, tt := range tests {
+		testBroadcastTransactions(t, tt.version, tt.peers, tt.broadcast, tt.announce)
+	}
+}
+
+func testBroadcastTransactions(t *testing.T, version int, total int, broadcast int, announce int) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	var peers []*testPeer
+	for i := 0; i < total; i++ {
+		p, _ := newTestPeer(fmt.Sprintf("peer #%d", i), version, pm, true)
+		defer p.close()
+		peers = append(peers, p)
+	}
+	for pm.peers.Len() < total {
+		time.Sleep(10 * time.Millisecond)
+	}
+	tx := newTestTransaction(testAccount, 0, 0)
+	pm.BroadcastTxs(types.Transactions{tx})
+
+	codes := make(chan uint64, total)
+	for _, p := range peers {
+		go func(p *testPeer) {
+			msg, err := p.app.ReadMsg()
+			if err != nil {
+				return
+			}
+			var hashes []common.Hash
+			switch msg.Code {
+			case TxMsg:
+				var txs []*types.Transaction
+				if err := msg.Decode(&txs); err != nil {
+					t.Errorf("%v: %v", p.Peer, err)
+				}
+				for _, tx := range txs {
+					hashes = append(hashes, tx.Hash())
+				}
+			case NewPooledTransactionHashesMsg:
+				if err := msg.Decode(&hashes); err != nil {
+					t.Errorf("%v: %v", p.Peer, err)
+				}
+			}
+			if len(hashes) != 1 || hashes[0] != tx.Hash() {
+				t.Errorf("%v: transaction mismatch: have %x, want %x", p.Peer, hashes, tx.Hash())
+			}
+			codes <- msg.Code
+		}(p)
+	}
+	var broadcasts, announces int
+	for i := 0; i < total; i++ {
+		select {
+		case code := <-codes:
+			switch code {
+			case TxMsg:
+				broadcasts++
+			case NewPooledTransactionHashesMsg:
+				announces++
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("version %d, %d peers: transaction reached %d peers, want %d", version, total, i, total)
+		}
+	}
+	if broadcasts != broadcast || announces != announce {
+		t.Errorf("version %d, %d peers: broadcast/announced to %d/%d peers, want %d/%d", version, total, broadcasts, announces, broadcast, announce)
+	}
+}
+
 // Tests that the custom union field encoder and decoder works correctly.
 func TestGetBlockHeadersDataEncodeDecode(t *testing.T) {
 	// Create a "random" hash for testing
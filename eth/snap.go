@@ -0,0 +1,170 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/eth/downloader"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/rlp"
+	"github.com/etherzero/go-etherzero/trie"
+)
+
+// serveAccountRange gathers a range of consecutive accounts from the account
+// trie of the requested state root, starting at the requested origin and
+// continuing until the limit is passed or the response grows too large. The
+// range is returned along with the proofs of its two edges. If the state is
+// not available, an empty response is returned.
+func (pm *ProtocolManager) serveAccountRange(req *getAccountRangeData) ([]*accountData, [][]byte) {
+	limit := req.Bytes
+	if limit > softResponseLimit {
+		limit = softResponseLimit
+	}
+	tr, err := pm.blockchain.StateCache().OpenTrie(req.Root)
+	if err != nil {
+		return nil, nil
+	}
+	var (
+		accounts []*accountData
+		size     uint64
+	)
+	it := trie.NewIterator(tr.NodeIterator(req.Origin[:]))
+	for it.Next() {
+		hash := common.BytesToHash(it.Key)
+		accounts = append(accounts, &accountData{Hash: hash, Body: common.CopyBytes(it.Value)})
+
+		// Include the first account past the limit to prove the range ends there
+		size += uint64(common.HashLength + len(it.Value))
+		if bytes.Compare(hash[:], req.Limit[:]) >= 0 || size >= limit {
+			break
+		}
+	}
+	if it.Err != nil {
+		log.Debug("Failed to iterate account range", "root", req.Root, "err", it.Err)
+		return nil, nil
+	}
+	// Generate the edge proofs of the range
+	proof := ethdb.NewMemDatabase()
+	if err := tr.Prove(req.Origin[:], 0, proof); err != nil {
+		return nil, nil
+	}
+	if len(accounts) > 0 {
+		if err := tr.Prove(accounts[len(accounts)-1].Hash[:], 0, proof); err != nil {
+			return nil, nil
+		}
+	}
+	return accounts, proofNodes(proof)
+}
+
+// serveStorageRanges gathers the storage slots of the requested accounts, until
+// the response grows too large or too many tries are served. Storage tries are returned in full, except for
+// the last one, which may be cut short (or started from the requested origin),
+// in which case the proofs of its edges are also returned.
+func (pm *ProtocolManager) serveStorageRanges(req *getStorageRangesData) ([][]*storageData, [][]byte) {
+	limit := req.Bytes
+	if limit > softResponseLimit {
+		limit = softResponseLimit
+	}
+	db := pm.blockchain.StateCache()
+	accTrie, err := db.OpenTrie(req.Root)
+	if err != nil {
+		return nil, nil
+	}
+	var (
+		slots [][]*storageData
+		size  uint64
+	)
+	for i, account := range req.Accounts {
+		if size >= limit || i >= downloader.MaxStorageFetch {
+			break
+		}
+		// Account the requested hash too, empty tries would be free otherwise
+		size += common.HashLength
+
+		// Retrieve the account by its hash, stopping at the first unknown one
+		it := trie.NewIterator(accTrie.NodeIterator(account[:]))
+		if !it.Next() || !bytes.Equal(it.Key, account[:]) {
+			break
+		}
+		var acc state.Account
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			break
+		}
+		stTrie, err := db.OpenStorageTrie(account, acc.Root)
+		if err != nil {
+			break
+		}
+		// Gather the storage slots, starting at the origin for the first account
+		var origin common.Hash
+		if i == 0 && len(req.Origin) > 0 {
+			origin = common.BytesToHash(req.Origin)
+		}
+		var (
+			storage []*storageData
+			partial = origin != (common.Hash{})
+		)
+		it = trie.NewIterator(stTrie.NodeIterator(origin[:]))
+		for it.Next() {
+			hash := common.BytesToHash(it.Key)
+			storage = append(storage, &storageData{Hash: hash, Body: common.CopyBytes(it.Value)})
+
+			size += uint64(common.HashLength + len(it.Value))
+			if size >= limit {
+				partial = true
+				break
+			}
+			if i == len(req.Accounts)-1 && len(req.Limit) > 0 && bytes.Compare(hash[:], req.Limit) >= 0 {
+				partial = true
+				break
+			}
+		}
+		if it.Err != nil {
+			log.Debug("Failed to iterate storage range", "account", account, "err", it.Err)
+			break
+		}
+		slots = append(slots, storage)
+
+		// If the storage trie was served partially, prove the range and stop
+		if partial {
+			proof := ethdb.NewMemDatabase()
+			if err := stTrie.Prove(origin[:], 0, proof); err != nil {
+				return nil, nil
+			}
+			if len(storage) > 0 {
+				if err := stTrie.Prove(storage[len(storage)-1].Hash[:], 0, proof); err != nil {
+					return nil, nil
+				}
+			}
+			return slots, proofNodes(proof)
+		}
+	}
+	return slots, nil
+}
+
+// proofNodes flattens a proof database into the list of its nodes.
+func proofNodes(proof *ethdb.MemDatabase) [][]byte {
+	nodes := make([][]byte, 0, proof.Len())
+	for _, key := range proof.Keys() {
+		node, _ := proof.Get(key)
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
@@ -0,0 +1,263 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/core/vm"
+)
+
+// callFrame is a single call of the call tracer's output, with the fields in
+// the same order as the JavaScript call tracer emits them.
+type callFrame struct {
+	Type    string       `json:"type,omitempty"`
+	From    string       `json:"from,omitempty"`
+	To      string       `json:"to,omitempty"`
+	Value   string       `json:"value,omitempty"`
+	Gas     string       `json:"gas,omitempty"`
+	GasUsed string       `json:"gasUsed,omitempty"`
+	Input   string       `json:"input,omitempty"`
+	Output  string       `json:"output,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Time    string       `json:"time,omitempty"`
+	Calls   []*callFrame `json:"calls,omitempty"`
+
+	gasIn   uint64 // Gas available before the call opcode
+	gasCost uint64 // Gas cost of the call opcode
+	gas     uint64 // Gas available at the start of the call
+	hasGas  bool   // Whether execution descended into the call
+	outOff  int64  // Memory offset of the call's return data
+	outLen  int64  // Memory size of the call's return data
+}
+
+// callTracer is the native implementation of call_tracer.js, which collects
+// the full call tree of a transaction.
+type callTracer struct {
+	interrupter
+
+	callstack []*callFrame // Calls under execution, the bottom one being the transaction
+	descended bool         // Whether the last opcode entered a new call
+
+	typ     string         // Type of the transaction call, CALL or CREATE
+	from    common.Address // Sender of the transaction
+	to      common.Address // Recipient of the transaction
+	input   []byte         // Input data of the transaction
+	gas     uint64         // Gas available to the transaction call
+	value   *big.Int       // Value transferred by the transaction
+	output  []byte         // Output data of the transaction call
+	gasUsed uint64         // Gas used by the transaction call
+	time    string         // Time the execution took
+	err     error          // Error the transaction call ended with
+}
+
+// newCallTracer creates a native call tracer.
+func newCallTracer() ResultTracer {
+	return &callTracer{callstack: []*callFrame{{}}}
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *callTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.typ = "CALL"
+	if create {
+		t.typ = "CREATE"
+	}
+	t.from, t.to, t.input, t.gas, t.value = from, to, input, gas, value
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *callTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.stopped() {
+		return nil
+	}
+	if err != nil {
+		t.fault(err)
+		return nil
+	}
+	syscall := op&0xf0 == 0xf0
+
+	// Enter a new frame on contract creations and calls
+	if syscall && (op == vm.CREATE || op == vm.CREATE2) {
+		inOff := peekStack(stack, 1).Int64()
+		inEnd := inOff + peekStack(stack, 2).Int64()
+
+		t.callstack = append(t.callstack, &callFrame{
+			Type:    op.String(),
+			From:    hexutil.Encode(contract.Address().Bytes()),
+			Input:   hexutil.Encode(sliceMemory(memory, inOff, inEnd)),
+			gasIn:   gas,
+			gasCost: cost,
+			Value:   hexBig(peekStack(stack, 0)),
+		})
+		t.descended = true
+		return nil
+	}
+	if syscall && op == vm.SELFDESTRUCT {
+		top := t.callstack[len(t.callstack)-1]
+		top.Calls = append(top.Calls, &callFrame{
+			Type:  op.String(),
+			From:  hexutil.Encode(contract.Address().Bytes()),
+			To:    hexutil.Encode(common.BigToAddress(peekStack(stack, 0)).Bytes()),
+			Value: hexBig(env.StateDB.GetBalance(contract.Address())),
+		})
+		return nil
+	}
+	if syscall && (op == vm.CALL || op == vm.CALLCODE || op == vm.DELEGATECALL || op == vm.STATICCALL) {
+		to := common.BigToAddress(peekStack(stack, 1))
+		if _, ok := vm.PrecompiledContractsByzantium[to]; ok {
+			return nil
+		}
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		inOff := peekStack(stack, 2+off).Int64()
+		inEnd := inOff + peekStack(stack, 3+off).Int64()
+
+		call := &callFrame{
+			Type:    op.String(),
+			From:    hexutil.Encode(contract.Address().Bytes()),
+			To:      hexutil.Encode(to.Bytes()),
+			Input:   hexutil.Encode(sliceMemory(memory, inOff, inEnd)),
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  peekStack(stack, 4+off).Int64(),
+			outLen:  peekStack(stack, 5+off).Int64(),
+		}
+		if op != vm.DELEGATECALL && op != vm.STATICCALL {
+			call.Value = hexBig(peekStack(stack, 2))
+		}
+		t.callstack = append(t.callstack, call)
+		t.descended = true
+		return nil
+	}
+	// If we've just descended into an inner call, retrieve its true allowance
+	if t.descended {
+		if depth >= len(t.callstack) {
+			top := t.callstack[len(t.callstack)-1]
+			top.gas, top.hasGas = gas, true
+		}
+		t.descended = false
+	}
+	if syscall && op == vm.REVERT {
+		t.callstack[len(t.callstack)-1].Error = "execution reverted"
+		return nil
+	}
+	// If we've just returned from an inner call, pop its frame
+	if depth == len(t.callstack)-1 {
+		call := t.callstack[len(t.callstack)-1]
+		t.callstack = t.callstack[:len(t.callstack)-1]
+
+		if call.Type == vm.CREATE.String() || call.Type == vm.CREATE2.String() {
+			call.GasUsed = hexInt(int64(call.gasIn) - int64(call.gasCost) - int64(gas))
+
+			if ret := peekStack(stack, 0); ret.Sign() != 0 {
+				addr := common.BigToAddress(ret)
+				call.To = hexutil.Encode(addr.Bytes())
+				call.Output = hexutil.Encode(env.StateDB.GetCode(addr))
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		} else if call.hasGas {
+			call.GasUsed = hexInt(int64(call.gasIn) - int64(call.gasCost) + int64(call.gas) - int64(gas))
+
+			if ret := peekStack(stack, 0); ret.Sign() != 0 {
+				call.Output = hexutil.Encode(sliceMemory(memory, call.outOff, call.outOff+call.outLen))
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		}
+		if call.hasGas {
+			call.Gas = hexInt(int64(call.gas))
+		}
+		top := t.callstack[len(t.callstack)-1]
+		top.Calls = append(top.Calls, call)
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *callTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if !t.stopped() {
+		t.fault(err)
+	}
+	return nil
+}
+
+// fault pops the failed call off the stack, recording the error.
+func (t *callTracer) fault(err error) {
+	// If the topmost call already reverted, don't handle the additional fault again
+	if t.callstack[len(t.callstack)-1].Error != "" {
+		return
+	}
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+
+	call.Error = err.Error()
+	if call.hasGas {
+		call.Gas = hexInt(int64(call.gas))
+		call.GasUsed = call.Gas
+	}
+	// Flatten the failed call into its parent, or keep it as the root
+	if len(t.callstack) > 0 {
+		top := t.callstack[len(t.callstack)-1]
+		top.Calls = append(top.Calls, call)
+		return
+	}
+	t.callstack = append(t.callstack, call)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	t.output, t.gasUsed, t.time, t.err = output, gasUsed, d.String(), err
+	return nil
+}
+
+// GetResult returns the call tree of the transaction, or any accumulated error.
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	result := &callFrame{
+		Type:    t.typ,
+		From:    hexutil.Encode(t.from.Bytes()),
+		To:      hexutil.Encode(t.to.Bytes()),
+		Value:   hexBig(t.value),
+		Gas:     hexInt(int64(t.gas)),
+		GasUsed: hexInt(int64(t.gasUsed)),
+		Input:   hexutil.Encode(t.input),
+		Output:  hexutil.Encode(t.output),
+		Time:    t.time,
+		Calls:   t.callstack[0].Calls,
+	}
+	if t.callstack[0].Error != "" {
+		result.Error = t.callstack[0].Error
+	} else if t.err != nil {
+		result.Error = t.err.Error()
+	}
+	if result.Error != "" {
+		result.Output = ""
+	}
+	res, err := encodeResult(result)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
@@ -2,11 +2,11 @@
 // sources:
 // 4byte_tracer.js (2.933kB)
 // bigram_tracer.js (1.712kB)
-// call_tracer.js (8.643kB)
+// call_tracer.js (8.836kB)
 // evmdis_tracer.js (4.194kB)
 // noop_tracer.js (1.271kB)
 // opcount_tracer.js (1.372kB)
-// prestate_tracer.js (4.234kB)
+// prestate_tracer.js (4.260kB)
 // trigram_tracer.js (1.788kB)
 // unigram_tracer.js (1.51kB)
 
@@ -117,7 +117,7 @@ func bigram_tracerJs() (*asset, error) {
 	return a, nil
 }
 
-var _call_tracerJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd4\x59\x5f\x6f\x1b\xb7\xb2\x7f\x96\x3e\xc5\x24\x0f\xb5\x84\x28\x92\x93\xf4\xf6\x02\x76\xd5\x0b\x5d\x47\x49\x0d\xb8\x71\x60\x2b\x0d\x82\x20\x0f\xd4\xee\xac\xc4\x9a\x4b\x6e\x49\xae\xe4\x3d\xa9\xbf\xfb\xc1\x0c\xb9\xab\xd5\x1f\x3b\x6e\x0f\xce\x41\xcf\x8b\xa0\x5d\xce\x0c\x87\x33\xbf\xf9\xc7\x1d\x8d\xe0\xcc\x14\x95\x95\x8b\xa5\x87\x97\xc7\x2f\xfe\x17\x66\x4b\x84\x85\x79\x8e\x7e\x89\x16\xcb\x1c\x26\xa5\x5f\x1a\xeb\xba\xa3\x11\xcc\x96\xd2\x41\x26\x15\x82\x74\x50\x08\xeb\xc1\x64\xe0\x77\xe8\x95\x9c\x5b\x61\xab\x61\x77\x34\x0a\x3c\x07\x97\x49\x42\x66\x11\xc1\x99\xcc\xaf\x85\xc5\x13\xa8\x4c\x09\x89\xd0\x60\x31\x95\xce\x5b\x39\x2f\x3d\x82\xf4\x20\x74\x3a\x32\x16\x72\x93\xca\xac\x22\x91\xd2\x43\xa9\x53\xb4\xbc\xb5\x47\x9b\xbb\x5a\x8f\xb7\xef\x3e\xc0\x05\x3a\x87\x16\xde\xa2\x46\x2b\x14\xbc\x2f\xe7\x4a\x26\x70\x21\x13\xd4\x0e\x41\x38\x28\xe8\x8d\x5b\x62\x0a\x73\x16\x47\x8c\x6f\x48\x95\xeb\xa8\x0a\xbc\x31\xa5\x4e\x85\x97\x46\x0f\x00\x25\x69\x0e\x2b\xb4\x4e\x1a\x0d\xaf\xea\xad\xa2\xc0\x01\x18\x4b\x42\x7a\xc2\xd3\x01\x2c\x98\x82\xf8\xfa\x20\x74\x05\x4a\xf8\x0d\xeb\x23\x0c\xb2\x39\x77\x0a\x52\xf3\x36\x4b\x53\x20\xf8\xa5\xf0\x74\xea\xb5\x54\x0a\xe6\x08\xa5\xc3\xac\x54\x03\x92\x36\x2f\x3d\x7c\x3c\x9f\xfd\x7c\xf9\x61\x06\x93\x77\x9f\xe0\xe3\xe4\xea\x6a\xf2\x6e\xf6\xe9\x14\xd6\xd2\x2f\x4d\xe9\x01\x57\x18\x44\xc9\xbc\x50\x12\x53\x58\x0b\x6b\x85\xf6\x15\x98\x8c\x24\xfc\x32\xbd\x3a\xfb\x79\xf2\x6e\x36\xf9\xff\xf3\x8b\xf3\xd9\x27\x30\x16\xde\x9c\xcf\xde\x4d\xaf\xaf\xe1\xcd\xe5\x15\x4c\xe0\xfd\xe4\x6a\x76\x7e\xf6\xe1\x62\x72\x05\xef\x3f\x5c\xbd\xbf\xbc\x9e\x0e\xe1\x1a\x49\x2b\x24\xfe\x6f\xdb\x3c\x63\xef\x59\x84\x14\xbd\x90\xca\xd5\x96\xf8\x64\x4a\x70\x4b\x53\xaa\x14\x96\x62\x85\x60\x31\x41\xb9\xc2\x14\x04\x24\xa6\xa8\x1e\xed\x54\x92\x25\x94\xd1\x0b\x3e\xf3\xbd\x80\x84\xf3\x0c\xb4\xf1\x03\x70\x88\xf0\xe3\xd2\xfb\xe2\x64\x34\x5a\xaf\xd7\xc3\x85\x2e\x87\xc6\x2e\x46\x2a\x88\x73\xa3\x9f\x86\x5d\x92\x99\x08\xa5\x66\x56\x24\x68\xc9\x39\x02\xb2\x92\xcc\xaf\xcc\x5a\x83\xb7\x42\x3b\x91\x90\xab\xe9\x7f\xc2\x60\x14\x1e\xf0\x96\x9e\xbc\x23\xd0\x82\xc5\xc2\x58\xfa\xaf\x54\x8d\x33\xa9\x3d\x5a\x2d\x14\xcb\x76\x90\x8b\x14\x61\x5e\x81\x68\x0b\x1c\xb4\x0f\x43\x30\x0a\xee\x06\xa9\x33\x63\x73\x86\xe5\xb0\xfb\xb5\xdb\x89\x1a\x3a\x2f\x92\x1b\x52\x90\xe4\x27\xa5\xb5\xa8\x3d\x99\xb2\xb4\x4e\xae\x90\x49\x20\xd0\x44\x7b\x4e\x7f\xfd\x05\xf0\x16\x93\x32\x48\xea\x34\x42\x4e\xe0\xf3\xd7\xbb\x2f\x83\x2e\x8b\x4e\xd1\x25\xa8\x53\x4c\xf9\x7c\x37\x0e\xd6\x4b\xb6\x28\xac\xf1\x68\x85\xf0\x5b\xe9\x7c\x8b\x26\xb3\x26\x07\xa1\xc1\x94\x84\xf8\xb6\x75\xa4\xf6\x86\x05\x0a\xfa\xaf\xd1\xb2\x46\xc3\x6e\xa7\x61\x3e\x81\x4c\x28\x87\x71\x5f\xe7\xb1\xa0\xd3\x48\xbd\x32\x37\x24\xd9\x58\x82\xb0\xad\xc0\x14\x89\x49\x63\x30\xd0\x39\x9a\x63\xa0\x1b\x76\x3b\xc4\x77\x02\x59\xa9\x79\xdb\x9e\x32\x8b\x01\xa4\xf3\x3e\x7c\xed\x76\x48\xec\x99\x28\x7c\x69\x91\xed\x89\xd6\x1a\xeb\x40\xe6\x39\xa6\x52\x78\x54\x55\xb7\xd3\x59\x09\x1b\x16\x60\x0c\xca\x2c\x86\x0b\xf4\x53\x7a\xec\xf5\x4f\xbb\x9d\x8e\xcc\xa0\x17\x56\x9f\x8c\xc7\x9c\x7d\x32\xa9\x31\x0d\xe2\x3b\x7e\x29\xdd\x30\x13\xa5\xf2\xcd\xbe\xc4\xd4\xb1\xe8\x4b\xab\xe9\xef\x5d\xd0\xe2\x23\x82\xd1\xaa\x82\x84\xb2\x8c\x98\x53\x78\xba\xca\x79\xcc\xe3\xe1\xdc\x00\x32\xe1\xc8\x84\x32\x83\x35\x42\x61\xf1\x79\xb2\x44\xf2\x9d\x4e\x30\x6a\xe9\x2a\xc7\x4e\x1d\x03\xed\x36\x34\xc5\xd0\x9b\x77\x65\x3e\x47\xdb\xeb\xc3\x77\x70\x7c\x9b\x1d\xf7\x61\x3c\xe6\x3f\xb5\xee\x91\x27\xea\x4b\x52\x4c\x11\x0f\xca\xfc\xd7\xde\x4a\xbd\x08\x67\x8d\xba\x9e\x67\x20\x40\xe3\x1a\x12\xa3\x19\xd4\xe4\x95\x39\x4a\xbd\x80\xc4\xa2\xf0\x98\x0e\x40\xa4\x29\x78\x13\x90\xd7\xe0\x6c\x7b\x4b\xf8\xee\x3b\xe8\xd1\x66\x63\x38\x3a\xbb\x9a\x4e\x66\xd3\x23\xf8\xe3\x0f\x08\x6f\x9e\x86\x37\x2f\x9f\xf6\x5b\x9a\x49\x7d\x99\x65\x51\x39\x16\x38\x2c\x10\x6f\x7a\x2f\xfa\xc3\x95\x50\x25\x5e\x66\x41\xcd\x48\x3b\xd5\x29\x8c\x23\xcf\xb3\x5d\x9e\x97\x5b\x3c\xc4\x34\x1a\xc1\xc4\x39\xcc\xe7\x0a\xf7\x03\x32\x46\x2c\x07\xaf\xf3\x94\xb1\x08\x7d\x89\xc9\x0b\x85\x84\xaa\x7a\xd7\x68\x7e\xd6\xb8\xe3\xab\x02\x4f\x00\x00\x4c\x31\xe0\x17\x14\x0b\xfc\xc2\x9b\x9f\xf1\x96\x7d\x54\x9b\x90\x50\x35\x49\x53\x8b\xce\xf5\xfa\xfd\x40\x2e\x75\x51\xfa\x93\x2d\xf2\x1c\x73\x63\xab\xa1\xa3\x84\xd4\xe3\xa3\x0d\xc2\x49\x6b\x9e\x85\x70\xe7\x9a\x78\x22\x52\xdf\x0a\xd7\xdb\x2c\x9d\x19\xe7\x4f\xea\x25\x7a\xa8\xd7\xd8\x16\xc4\x76\x74\x7c\x7b\xb4\x6f\xad\xe3\xfe\x06\x09\x2f\x7e\xe8\x13\xcb\xdd\x69\x83\xef\x26\x4d\x0c\x8b\xd2\x2d\x7b\x0c\xa7\xcd\xea\x26\x15\x8c\xc1\xdb\x12\x0f\xc2\x9f\x21\xb5\x0f\x27\x87\x2a\xa3\x5c\xe2\x6d\x99\x30\xac\x16\x82\x33\x0d\x47\xba\xa0\xcc\xeb\xca\x39\xdb\xdc\x1b\xb3\x8f\xae\x08\xae\xeb\xe9\xc5\x9b\xd7\xd3\xeb\xd9\xd5\x87\xb3\xd9\x51\x0b\x4e\x0a\x33\x4f\x4a\x6d\x9f\x41\xa1\x5e\xf8\x25\xeb\x4f\xe2\xb6\x57\x3f\x13\xcf\xf3\x17\x5f\xc2\x1b\x18\x1f\x08\xf9\xce\xc3\x1c\xf0\xf9\x0b\xcb\xbe\xdb\x37\xdf\x36\x69\x30\xe6\xd7\x00\x22\x53\xdc\xb5\x13\xc7\x81\x58\xcc\xd1\x2f\x4d\xca\xc9\x31\x11\x21\xbf\xd6\x56\x4c\x8d\xc6\x3f\x1f\x91\x93\x8b\x8b\x56\x3c\xf2\xf3\xd9\xe5\xeb\x76\x8c\x1e\xbd\x9e\x5e\x4c\xdf\x4e\x66\xd3\x5d\xda\xeb\xd9\x64\x76\x7e\xc6\x6f\xeb\xf0\x1d\x8d\xe0\xfa\x46\x16\x9c\x65\x39\x77\x99\xbc\xe0\x76\xb1\xd1\xd7\x0d\xc0\x2f\x0d\x35\x62\x36\x16\x91\x4c\xe8\xa4\x4e\xee\xae\x76\x9a\x37\xe4\x32\x53\xc7\xca\x7e\x2a\x68\x03\xb5\xdf\xb8\x51\xba\xf7\x16\xe3\xa6\x69\xcf\x9b\x5a\xaf\x8d\x41\x83\x47\x38\x01\x72\x92\xe9\x3d\xfe\x90\xf0\x7f\x70\x0c\x27\xf0\x22\x66\x92\x07\x52\xd5\x4b\x78\x46\xe2\xff\x42\xc2\x7a\x75\x80\xf3\xef\x99\xb6\xbc\x61\xe2\x9a\xdc\x9b\xff\x7c\x3a\x33\xa5\xbf\xcc\xb2\x13\xd8\x35\xe2\xf7\x7b\x46\x6c\xe8\x2f\x50\xef\xd3\xff\xcf\x1e\xfd\x26\xf5\x11\xaa\x4c\x01\x4f\xf6\x20\x12\x12\xcf\x93\x9d\x38\x88\xc6\xe5\x16\x87\xa5\xc1\xf8\x9e\x64\xfb\x72\x1b\xc3\xf7\x65\x8b\x7f\x29\xd9\x1e\x6c\xd5\xa8\x21\xdb\x6e\xc6\x06\x60\xd1\x5b\x89\x2b\x1a\xb7\x8e\x1c\x8b\xa4\xa6\xd5\xac\x85\x4e\x70\x08\x1f\x31\x48\xd4\x88\x9c\x5c\x62\x93\x4b\x3d\x0a\xf7\x7d\xd4\xa8\xc6\x71\x85\x21\x26\xb8\x17\xb5\x08\xb9\xa8\x68\x5c\xc9\x4a\x7d\x53\xc1\x42\x38\x48\x2b\x2d\x72\x99\xb8\x20\x8f\x1b\x5c\x8b\x0b\x61\x59\xac\xc5\xdf\x4b\x74\x34\xfb\x10\x90\x45\xe2\x4b\xa1\x54\x05\x0b\x49\x03\x0c\x71\xf7\x5e\xbe\x3a\x3e\x06\xe7\x65\x81\x3a\x1d\xc0\x0f\xaf\x46\x3f\x7c\x0f\xb6\x54\xd8\x1f\x76\x5b\x69\xbc\x39\x6a\xf4\x06\x2d\x44\xf4\xbc\xc6\xc2\x2f\x7b\x7d\xf8\xe9\x9e\x7a\x70\x4f\x72\x3f\x48\x0b\xcf\xe1\xc5\x97\x21\xe9\x35\xde\xc2\x6d\xf0\x24\xa0\x72\x18\xa5\xd1\xd0\x77\xf9\xfa\xb2\x77\x23\xac\x50\x62\x8e\xfd\x13\x1e\x02\xd9\x56\x6b\x11\xa7\x00\x72\x0a\x14\x4a\x48\x0d\x22\x49\x4c\xa9\x3d\x19\xbe\x6e\xe8\x55\x45\xf9\xfd\xc8\xd7\xf2\x78\x5e\x12\x49\x82\xce\xd5\xe9\x9e\xbd\x46\xea\x88\x9c\xb8\x41\x6a\x27\x53\x6c\x79\x85\xb2\x83\xe1\xd4\x1c\x29\x68\x9c\xac\x05\xe6\xc6\xd1\x26\x73\x84\xb5\xa5\xe1\xc3\x49\x9d\xf0\xf4\x9d\x22\x59\xdb\x81\xd1\x20\x40\x19\x1e\xf9\x39\xc6\x41\xd8\x85\x1b\x86\x7c\x4f\xdb\x52\xce\xd1\x66\x3d\xdc\x06\x72\x1b\xaa\xdc\xe6\xef\xb4\x03\x1a\xf0\x56\x3a\xcf\x5d\x25\x69\x29\x1d\x04\x24\x4b\xbd\x18\x40\x61\x0a\xce\xd3\xdf\x2a\x67\x31\x59\x5f\x4d\x7f\x9d\x5e\x35\xc5\xff\xf1\x4e\xac\xfb\xfe\xa7\xcd\x58\x04\x96\x66\x0e\x8f\xe9\xd3\x03\x8d\xfc\x01\x40\x8d\xef\x01\x14\xc9\xdf\xd4\xc6\xf7\xad\xe3\x28\xe1\xfc\xc6\x31\x0b\x0c\x33\x4d\x5b\x01\x57\x2a\xef\x76\x72\xf7\x6e\x72\x30\x45\x5d\x21\x48\x29\x4e\x3b\x94\xd8\x77\xbb\xed\xad\x85\x4d\xd3\xbd\xc1\xe7\x79\xcb\xc6\x6b\x6e\xb9\x02\x51\x2b\x35\xf0\x7a\xdd\xbb\x89\x50\x0d\x58\x77\x53\x7a\x82\x03\xd5\xef\x4d\xf2\x5b\x08\xf7\xc1\xb1\xd7\x63\xfa\x9b\xcb\xc5\xb9\xf6\xbd\x7a\xf1\x5c\xc3\x73\xa8\x1f\x28\xa9\xc3\xf3\xad\x28\x3a\x90\x1d\x3b\x29\x2a\xf4\x08\x1b\x11\xa7\xb0\xf3\x8a\x04\x05\x73\xb0\xd1\x2c\xfa\xfd\xe2\x7c\x1c\xa5\x91\xc1\x9e\x58\xf4\x43\xfc\xbd\x14\xca\xf5\x8e\x9b\x66\x21\x9c\xc0\x1b\x2e\x6f\xe3\xa6\xc0\xd5\x15\x90\x78\xb6\xda\x8f\x28\x30\xb0\x45\x6b\xd4\x6c\xe9\x3c\x54\xad\x14\x1f\x94\x10\x45\xc4\xb4\xd1\xf8\x32\x02\xf3\x50\xff\xd9\x69\x13\xc0\xd3\xa6\x21\xc8\x84\x54\xa5\xc5\xa7\xa7\x70\x20\xed\xb8\xd2\x66\x22\x61\x5f\x3a\x04\x9e\x58\x1d\x38\x93\xe3\xd2\xac\x83\x02\x87\x92\xd7\x3e\x38\x1a\x1c\xec\x94\x0f\xbe\x7a\x11\x0e\x4a\x27\x16\xd8\x02\x47\x63\xf0\xda\x51\x07\xc7\xe8\xbf\x0c\x9d\x67\xcd\xe3\x37\x50\x14\x76\xf9\x26\x34\x1e\xc2\xc6\x41\x2f\xef\x75\x39\x35\x11\xf7\x3a\xad\x87\x5a\xd5\xd0\x8a\x34\xc8\xf9\x33\x7e\xff\xf7\x38\x3e\x78\x3e\xfe\x3e\x36\xd0\x76\x69\xc3\x19\xb7\x89\xc3\x49\x37\xed\xcd\xb7\x51\xd0\xac\xde\x07\x80\xfb\x3a\x27\x82\xaa\xfe\x0d\x13\xbf\x81\x2b\x37\x3b\xf4\x54\x58\x5c\x49\x53\x52\x1d\xc3\xff\xa6\xc9\xb0\xe9\xfc\xee\xba\x9d\xbb\x78\x45\xc6\xee\x6b\xdf\x91\xad\x97\xf1\x8a\x37\x34\x4d\xad\x2a\x62\xb8\xc4\xc6\x9b\xb3\x2c\x5c\xbe\x76\x98\xff\x81\xbb\xb2\x18\xef\xde\x14\xd4\x15\xc4\x22\xa5\x2c\x8a\xb4\x6a\xea\xe2\x20\xf4\x23\xb0\x14\x3a\x8d\x33\x89\x48\x53\x49\xf2\x18\x8b\xa4\xa1\x58\x08\xa9\xbb\x07\xcd\xf8\xcd\x62\x7c\x08\x19\x7b\x2d\x6e\xbb\x9e\xc6\x59\x92\x06\x3f\xd6\xb8\xfb\x88\xba\xb9\x13\x4b\xbb\xd7\x7e\xf1\xe6\xd0\x68\x57\xe6\xdc\x10\x83\x58\x09\xa9\x04\x0d\x61\xdc\x68\xe9\x14\x12\x85\x42\x87\xcb\x7e\xcc\xbc\x59\xa1\x75\xdd\x47\x80\xfc\xaf\x60\x7c\x27\x39\xd6\x8f\xd1\x1c\x8f\x8f\xd9\xc7\x46\x6c\x38\xfe\x1b\x25\xbc\x8f\xf0\x6a\x99\x37\x44\x96\xf4\xfc\x1d\x08\xb5\xef\x3e\x2e\xa4\xb8\x75\x22\x9a\x9f\xe0\xb8\xd5\x9e\xff\x5d\x82\x6c\x1f\x62\x17\x4d\x9b\x16\x0f\xef\x8d\x19\x80\x42\xc1\xc3\x52\xfd\x95\xa6\x6e\x4b\x1f\x9a\xdd\xea\xe8\x0d\x8d\xdd\x5e\xf8\xf2\xf5\xd6\x12\xeb\x8b\x90\xd0\xe1\xcf\x11\x35\x48\x8f\x56\xd0\x58\x44\xe8\x8a\x1f\x16\x48\x4b\xc7\xe2\xd8\x2f\x92\x82\x2e\x0a\x8e\xb7\xfc\x54\x9f\xa5\x5e\x0c\xbb\x9d\xf0\xbe\x15\xef\x89\xbf\xdd\xc4\x7b\x28\x86\xcc\x19\xaf\x06\x9a\x9b\x81\xc4\xdf\x72\xd3\xc8\xd3\xf3\xce\xf5\x00\xad\xd1\xab\x30\x5a\xef\x5c\x06\x30\x63\xbc\x10\xd8\xbd\x73\xa4\x35\x7e\xb7\x05\x70\x26\x5d\x08\x17\xc4\xec\x84\x84\xbf\xdd\x8f\x88\x9a\x81\x82\xe1\xe4\x30\x03\x2d\x1d\x60\xda\xb9\xa0\x20\x62\x7e\x15\x56\x43\x61\x3f\x69\xaf\x86\x57\xf1\xa0\x32\x6f\xd9\x46\xe6\x6c\x9b\xbb\xd3\xc3\x49\xee\xb8\xc6\xe3\xe1\x64\x46\x36\x6f\x00\x7b\x0f\x6b\x7b\xe4\xd8\x27\x79\x28\x55\xb2\xf4\x3a\xb3\xdd\xc3\xca\xd2\x5b\xad\x87\xbf\x7d\xbc\xc8\x86\xb8\xad\xe2\x16\xcd\x21\x21\x31\xcf\x44\xba\x60\xd9\x5a\x40\x40\x75\xd0\x95\x11\x2d\xff\x81\x51\x62\x3b\x7e\xea\x25\xb0\x18\xbe\x43\x70\x43\x4a\xe1\x63\xe6\x5c\xfc\x4b\x47\xd3\xe4\x26\x2e\x52\x74\xd2\x62\x0a\x99\x44\x95\x82\x49\xd1\xf2\xac\xfa\x9b\x33\x3a\x7c\x71\x42\x2b\x49\x62\xf8\xb2\x16\x3e\x72\xf3\xf7\x3e\x2d\x13\xf4\x15\x64\x28\xf8\xd3\x91\x37\x50\x08\xe7\x20\x47\x41\xd3\x69\x56\x2a\x55\x81\xb1\x29\x92\xf0\x66\x5c\xa3\x90\x34\x50\x3a\xb4\x0e\xd6\x4b\x13\xcb\x24\x77\x69\x05\x35\x9d\xd2\x0f\xe2\x8d\x8c\x74\x85\x12\x15\x48\x4f\x25\x39\x1e\xaa\x1d\xa5\xcd\xf7\x1a\xfe\xe8\x63\xa8\xea\xee\x87\x68\x3d\xd8\x6d\xc7\x28\xbf\xa6\xa7\xed\xe8\x8c\x73\xcd\x76\x5c\x6e\xee\xaa\xb6\x83\xb0\x2e\x1b\xdb\x91\xd6\x2e\x42\xdb\xe1\xc4\x2b\xfc\xb4\x1d\x48\xad\x7e\x99\x17\x18\x1c\x0d\x03\x3f\xed\x84\x16\x6b\x19\x63\x2b\x7c\x9d\x6c\xc8\xf9\x69\x10\x01\x43\x5e\xec\x91\x71\x6e\xb0\xa2\x4c\x1c\x6c\xd4\x2a\x2b\xe1\xc5\xe7\x1b\xac\xbe\x1c\xae\x22\x11\x8e\x2d\xba\xa6\x6c\xd4\x90\x0e\x6b\x0f\x04\x72\xa3\x85\x1c\x1f\x9f\x82\xfc\xb1\xcd\x50\x57\x3e\x90\xcf\x9e\xd5\x7b\xb6\xd7\x3f\xcb\x2f\x75\x74\x36\x88\xdf\x59\xef\x6f\x69\x14\x63\x24\xd0\x50\x50\x74\xef\xba\xff\x0c\x00\x00\xff\xff\x00\x24\x55\x1f\xc3\x21\x00\x00")
+var _call_tracerJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x59\x5b\x6f\x1b\xb7\xf2\x7f\x96\x3e\xc5\x24\x0f\xb5\x84\x28\x92\x9c\xf4\xdf\x3f\x60\x57\x3d\x50\x1d\x25\x15\xe0\xc6\x81\xad\x34\x08\x0c\x3f\x50\xbb\xb3\x12\x6b\x8a\xdc\x92\x5c\xc9\x6a\xeb\xef\x7e\x30\xbc\xec\x45\x92\x1d\xa7\x38\xe7\x20\x6f\xbb\x24\x67\x38\x9c\xf9\xcd\x8d\x1c\x0c\xe0\x4c\xe5\x5b\xcd\x17\x4b\x0b\xaf\x86\xc7\xff\x0f\xb3\x25\xc2\x42\xbd\x44\xbb\x44\xfd\x27\x6a\x05\xe3\xc2\x2e\x95\x36\xed\xc1\x00\x66\x4b\x6e\x20\xe3\x02\x81\x1b\xc8\x99\xb6\xa0\x32\xb0\xbb\x04\x82\xcf\x35\xd3\xdb\x7e\x7b\x30\xf0\x44\x87\xe7\x89\x47\xa6\x11\xc1\xa8\xcc\x6e\x98\xc6\x13\xd8\xaa\x02\x12\x26\x41\x63\xca\x8d\xd5\x7c\x5e\x58\x04\x6e\x81\xc9\x74\xa0\x34\xac\x54\xca\xb3\x2d\xf1\xe4\x16\x0a\x99\xa2\x76\x9b\x5b\xd4\x2b\x13\x25\x79\xf7\xfe\x23\x9c\xa3\x31\xa8\xe1\x1d\x4a\xd4\x4c\xc0\x87\x62\x2e\x78\x02\xe7\x3c\x41\x69\x10\x98\x81\x9c\x46\xcc\x12\x53\x98\x3b\x76\x44\xf8\x96\x44\xb9\x0a\xa2\xc0\x5b\x55\xc8\x94\x59\xae\x64\x0f\x90\x93\xe8\xb0\x46\x6d\xb8\x92\xf0\x3a\x6e\x15\x18\xf6\x40\x69\x62\xd2\x61\x96\x0e\xa0\x41\xe5\x44\xd7\x05\x26\xb7\x20\x98\xad\x48\x9f\xa2\x91\xea\xe0\x29\x70\xe9\xce\xb7\x54\x39\x82\x5d\x32\x4b\xaa\xd8\x70\x21\x60\x8e\x50\x18\xcc\x0a\xd1\x23\x76\xf3\xc2\xc2\xa7\xe9\xec\x97\x8b\x8f\x33\x18\xbf\xff\x0c\x9f\xc6\x97\x97\xe3\xf7\xb3\xcf\xa7\xb0\xe1\x76\xa9\x0a\x0b\xb8\x46\xcf\x8a\xaf\x72\xc1\x31\x85\x0d\xd3\x9a\x49\xbb\x05\x95\x11\x87\x5f\x27\x97\x67\xbf\x8c\xdf\xcf\xc6\x3f\x4f\xcf\xa7\xb3\xcf\xa0\x34\xbc\x9d\xce\xde\x4f\xae\xae\xe0\xed\xc5\x25\x8c\xe1\xc3\xf8\x72\x36\x3d\xfb\x78\x3e\xbe\x84\x0f\x1f\x2f\x3f\x5c\x5c\x4d\xfa\x70\x85\x24\x15\x12\xfd\x97\x95\x9e\x39\xf3\x69\x84\x14\x2d\xe3\xc2\x44\x55\x7c\x56\x05\x98\xa5\x2a\x44\x0a\x4b\xb6\x46\xd0\x98\x20\x5f\x63\x0a\x0c\x12\x95\x6f\x9f\x6c\x55\xe2\xc5\x84\x92\x0b\x77\xe6\x87\x31\x09\xd3\x0c\xa4\xb2\x3d\x30\x88\xf0\xe3\xd2\xda\xfc\x64\x30\xd8\x6c\x36\xfd\x85\x2c\xfa\x4a\x2f\x06\xc2\xf3\x33\x83\x9f\xfa\x6d\x62\x9a\x30\x21\x66\x9a\x25\xa8\xc9\x3a\x0c\xb2\x82\xf4\x2f\xd4\x46\x82\xd5\x4c\x1a\x96\x90\xb1\xe9\x9b\x96\x38\x2b\xe1\x1d\xfd\x59\x43\xb0\x05\x8d\xb9\xd2\xf4\x2d\x44\x44\x1a\x97\x16\xb5\x64\xc2\xf1\x36\xb0\x62\x29\xc2\x7c\x0b\xac\xce\xb0\x57\x3f\x0d\x01\xc9\xdb\x1b\xb8\xcc\x94\x5e\x39\x60\xf6\xdb\x7f\xb5\x5b\x41\x42\x63\x59\x72\x4b\x02\x12\xff\xa4\xd0\x1a\xa5\x25\x5d\x16\xda\xf0\x35\xba\x25\xe0\xd7\x04\x85\x4e\x7e\xfb\x15\xf0\x0e\x93\xc2\x73\x6a\x95\x4c\x4e\xe0\xfa\xaf\xfb\x9b\x5e\xdb\xb1\x4e\xd1\x24\x28\x53\x4c\x49\xb4\xe4\xd6\xc0\x66\xe9\x54\x0a\x1b\x3c\x5a\x23\xfc\x5e\x18\x5b\x5b\x93\x69\xb5\x02\x26\x41\x15\x84\xf9\xba\x76\xb8\xb4\xca\x31\x64\xf4\x2d\x51\x3b\x89\xfa\xed\x56\x49\x7c\x02\x19\x13\x06\xc3\xbe\xc6\x62\x4e\xa7\xe1\x72\xad\x6e\x31\x75\xe8\xc1\x35\xea\x2d\xa8\x3c\x51\x69\xf0\x06\x3a\x6b\x79\x0c\x34\xfd\x76\x8b\xe8\x4e\x20\x2b\xa4\xdb\xb6\x23\xd4\xa2\x07\xe9\xbc\x0b\x7f\xb5\x5b\xb4\xfb\x19\xcb\x6d\xa1\xd1\x39\x26\x6a\xad\xb4\x01\xbe\x5a\x61\xca\x99\x45\xb1\x6d\xb7\x5a\x6b\xa6\xfd\x04\x8c\x40\xa8\x45\x7f\x81\x76\x42\xbf\x9d\xee\x69\xbb\xd5\xe2\x19\x74\xfc\xec\xb3\xd1\xc8\xc5\x9f\x8c\x4b\x4c\x3d\xfb\x96\x5d\x72\xd3\xcf\x58\x21\x6c\xb9\x2f\x11\xb5\x34\xda\x42\x4b\xfa\xbc\xf7\x52\x7c\x42\x50\x52\x6c\x21\xa1\x38\xc3\xe6\xe4\x9f\x66\x6b\x2c\xae\xc2\xe1\x4c\x0f\x32\x66\x48\x85\x3c\x83\x0d\x42\xae\xf1\x65\xb2\xc4\xe4\x16\x94\x4c\x30\x48\x69\xb6\x86\x54\x08\x23\xa0\xdd\xfa\x2a\xef\x5b\xf5\xbe\x58\xcd\x51\x77\xba\xf0\x1d\x0c\xef\xb2\x61\x17\x46\x23\xf7\x11\x65\x0f\x34\x41\x5e\x3a\xab\xca\xc3\x41\x1d\xfd\x95\xd5\x5c\x2e\x3a\xdd\x9a\xac\xd3\x0c\x18\x48\xdc\x40\xa2\x24\x41\xc0\x92\x55\xe6\xc8\xe5\x02\x12\x8d\xcc\x62\xda\x03\x96\xa6\x60\x95\x43\x55\x85\xb3\xe6\x96\xf0\xdd\x77\xd0\xa1\xcd\x46\x70\x74\x76\x39\x19\xcf\x26\x47\xf0\xf7\xdf\xe0\x47\x9e\xfb\x91\x57\xcf\xbb\x35\xc9\xb8\xbc\xc8\xb2\x20\x9c\xc3\x65\x3f\x47\xbc\xed\x1c\x77\xfb\x6b\x26\x0a\xbc\xc8\xbc\x98\x61\xed\x44\xa6\x30\x0a\x34\x2f\x76\x69\x5e\x35\x68\xc8\x24\x83\x01\x8c\x8d\xc1\xd5\x5c\xe0\xbe\x43\x06\x8f\x75\xce\x6b\xac\xd2\x3e\x76\x25\x6a\x95\x0b\x24\x54\xc5\x5d\x83\xfa\x9d\xc4\x2d\xbb\xcd\xf1\x04\x00\x40\xe5\x3d\x37\x40\xbe\xe0\x06\xac\xfa\x05\xef\x9c\x8d\xa2\x0a\x09\x55\xe3\x34\xd5\x68\x4c\xa7\xdb\xf5\xcb\xb9\xcc\x0b\x7b\xd2\x58\xbe\xc2\x95\xd2\xdb\xbe\xa1\x80\xd4\x71\x47\xeb\xf9\x93\x46\x9a\x05\x33\x53\x49\x34\x01\xa9\xef\x98\xe9\x54\x53\x67\xca\xd8\x93\x38\x45\x3f\x71\xce\xe9\x82\xc8\x8e\x86\x77\x47\xfb\xda\x1a\x76\x2b\x24\x1c\xff\xd0\x25\x76\xf7\xa7\x25\xbe\xcb\x30\xd1\xcf\x0b\xb3\xec\xd0\x6f\xb7\x9a\xad\x42\xc1\x08\xac\x2e\xf0\x20\xfc\x1d\xa4\xf6\xe1\x64\x50\x64\x14\x4b\xac\x2e\x12\x07\xab\x05\x73\x91\xc6\x79\x3a\xa3\xc8\x6b\x8a\x39\xed\x07\x56\xa9\x7d\x74\x05\x70\x5d\x4d\xce\xdf\xbe\x99\x5c\xcd\x2e\x3f\x9e\xcd\x8e\x6a\x70\x12\x98\x59\x18\xc1\xce\x19\x04\xca\x85\x5d\x3a\xf9\xc9\x3f\x9a\xb3\xd7\x44\xf3\xf2\xf8\xc6\x8f\xc0\xe8\x80\xcb\xb7\x1e\xa7\x80\xeb\x1b\xc7\xfb\x3e\x4a\x41\xa0\x08\x98\x3e\x08\x86\xd3\xf6\x17\x78\x7a\xad\x37\x20\xb7\x03\x38\x8f\x1f\xfa\x09\xe6\xb6\xaa\x06\x43\xab\xe2\x5e\x8f\x5b\xbd\xdb\xc4\x4a\x40\x4a\x3a\x27\x69\x7f\x66\x82\xc9\x04\xfd\x1e\xfb\x58\xa9\x07\xbd\x03\x71\x64\x85\x76\xa9\xa8\xb2\x59\xab\xc4\x65\xb1\x0a\x01\xa9\x92\xf8\xf5\xd1\x64\x7c\x7e\x5e\x8b\x25\xee\xff\xec\xe2\x4d\x3d\xbe\x1c\xbd\x99\x9c\x4f\xde\x8d\x67\x93\xdd\xb5\x57\xb3\xf1\x6c\x7a\xe6\x46\x63\xe8\x19\x0c\xe0\xea\x96\xe7\x2e\x43\xb8\xb8\xab\x56\xb9\x2b\x77\x4b\x79\x4d\x0f\xec\x52\x51\x19\xa9\x43\x02\xcc\x98\x4c\x62\x62\x32\xd1\xd4\x56\x11\xdc\x1e\x52\xf7\xf1\x8e\xba\x4b\x08\x72\xf3\x41\x23\xc5\x1a\x2e\x30\xed\x58\x15\xe5\xaa\x14\x5a\xa1\x49\xb9\x00\xd9\x79\xfa\x21\xe1\x5f\x30\x84\x13\x38\x0e\x51\xf0\x91\x30\xfb\x0a\x5e\x80\xca\xb2\x7f\x10\x6c\x5f\x1f\xa0\xfc\x36\x43\x6e\x70\x8c\xca\x35\xfe\xf7\xa1\x58\x15\xf6\x22\xcb\x4e\x60\x57\x89\xdf\xef\x29\xb1\x5c\x7f\x8e\x72\x7f\xfd\xff\xed\xad\xaf\xc2\x36\xf9\x8d\xca\xe1\xd9\x1e\x44\x7c\xd0\x7c\xb6\xe3\x07\x41\xb9\x14\x6d\xbc\xf1\x61\xf4\x40\xa2\x78\xd5\xc4\x70\x15\xe9\xfe\x83\x89\xe2\x60\x99\x49\xc5\x64\xb3\x90\xec\x81\x46\xab\x39\xae\xa9\x59\x3c\x32\x8e\x25\x15\xdc\x6a\x43\x91\xaa\x0f\x9f\x68\x83\xc1\x00\x24\x52\x25\xab\x62\x81\x0e\x3c\x03\x8a\x62\xae\xc8\x0e\xbd\x16\xb1\xa3\x0e\x91\x72\x0f\xc2\x8a\x6d\xa9\xd7\xca\x0a\x79\xbb\x85\x05\x33\x90\x6e\x25\x5b\xf1\x84\xdc\x7c\x30\x70\x74\xa0\x71\xc1\xb4\x63\xab\xf1\x8f\x02\x0d\x35\x6e\x54\x3b\xb0\xc4\x16\x4c\x88\x2d\x2c\x38\x75\x5f\x44\xdd\x79\xf5\x7a\x38\x04\x63\x79\x8e\x32\xed\xc1\x0f\xaf\x07\x3f\x7c\x0f\xba\x10\xd8\xed\x87\x08\xd7\xd4\x4e\xb0\x06\x99\x30\xa0\xe7\x0d\xe6\x76\xd9\xe9\xc2\x4f\x0f\xe4\xb2\x68\xbf\xe6\xe4\xf5\xc1\xb5\xf0\x12\x8e\x6f\xfa\x24\x57\x59\xec\xba\x12\xc2\x5b\x12\x50\x18\x0c\xdc\xa8\x89\xbf\x78\x73\xd1\xb9\x65\x9a\x09\x36\xc7\xee\x89\xbb\x25\x70\xba\xda\xb0\xd0\xc1\x90\x51\x20\x17\x8c\x4b\x60\x49\xa2\x0a\x69\x49\xf1\xb1\x19\x11\x5b\x48\x95\x3c\xb2\x91\x9f\x6b\xf6\x58\x92\xa0\x31\x31\xdc\x3b\xab\x91\x38\x6c\x45\xd4\xc0\xa5\xe1\xc4\x37\xee\x44\x4a\x35\xca\x85\xe6\xb0\x82\x7a\xe1\xc8\x70\xa5\x8c\x15\xce\x5a\x1b\x4d\x6d\xa0\xe1\x32\x21\x38\x40\x8a\xa4\x6d\x03\x4a\x02\x03\xa1\xdc\x95\x85\x2b\xb7\x80\xe9\x85\xe9\xfb\x78\x4f\xdb\x52\x99\x27\xd5\xa6\xdf\x04\x72\x85\xbb\x91\x6f\x51\x76\x4a\x19\x09\x78\xc7\x8d\xa5\x04\xe6\xf4\xc1\x0d\x81\xb1\xd0\x92\xcb\x45\x0f\x72\x95\x93\x67\x7e\x31\x9d\x85\x60\x7d\x39\xf9\x6d\x72\x59\x16\x2e\x4f\x37\x62\xec\x59\x9e\x97\x2d\x1d\x68\xea\x97\x2c\xa6\xcf\x0f\x34\x21\x07\x00\x35\x7a\x00\x50\xc4\x3f\x88\x33\x18\xc0\x87\xda\x71\x04\x33\xb6\x32\xcc\x02\xad\x1b\xad\x0b\x60\x0a\x61\xcd\x4e\xec\xde\xd9\x24\x57\x79\xcc\x10\x24\x14\xb1\xeb\x53\x60\xdf\xed\x14\x1a\x13\x55\xc3\x50\xe1\x73\x5a\xd3\x31\x41\x92\x81\x5f\x54\x0b\x0d\x6e\x3e\x24\x04\x2a\x33\x28\x31\xbb\x94\xa3\x0a\x4b\x70\x48\x54\x8a\x55\xf0\x5b\x30\xf3\xd1\x60\x5a\x85\xbf\x39\x5f\x4c\xa5\xed\xc4\xc9\xa9\x84\x97\x10\x7f\x28\xa8\xc3\xcb\x86\x17\x1d\x88\x8e\xad\x14\x05\x5a\x2c\xa9\xa6\xf2\x14\x76\x86\x88\x91\x57\x87\x53\x9a\x46\xbb\x9f\x9c\x87\x81\x1b\x29\xec\x99\x46\xdb\xc7\x3f\x0a\x26\x4c\x67\x58\x16\x0b\xae\x9b\xef\x5b\xe5\xd2\xdb\xa8\x4c\x70\x31\x03\x12\x4d\x5d\xb8\x50\x7f\x84\x83\x07\x6d\x44\x32\x5f\xf4\x9d\xa9\x14\x1f\xe5\x10\x58\x84\xb0\x51\xda\x32\x00\xf3\x50\xed\xdc\xaa\x2f\x80\xe7\x65\x41\x90\x31\x2e\x0a\x8d\xcf\x4f\xe1\x40\xd8\x31\x85\xce\x58\xe2\x82\x82\x41\x70\xdd\xb6\x01\xa3\x56\xb8\x54\x1b\x2f\xc0\xa1\xe0\xb5\x0f\x8e\x58\x18\xec\xa6\x0f\xc2\x08\xc5\x82\xc2\xb0\x05\xd6\xc0\x51\x2a\x3c\x1a\x0a\x9e\x3d\x7c\xa6\xaf\x87\xce\x8b\xf2\xf7\x0b\x28\x6a\xb7\x9e\x04\x8d\xc7\xb0\x71\xd0\xca\x7b\x55\x4e\x5c\xe4\xda\xce\xda\x4f\x14\xd5\x97\x22\x25\x72\xbe\xc6\xee\xff\x1d\xc3\x7b\xcb\xb7\xee\xbf\xca\xd1\x76\xd7\xfa\x82\xac\xb9\xd8\x9f\xb4\x2a\x6f\xbe\x8c\x82\x72\xf6\x21\x00\x1c\x88\x0d\xf7\x21\xc2\x4e\xe5\xef\x98\xd8\x0a\xae\xae\xd8\xa1\xbf\x5c\xe3\x9a\xab\x82\xf2\x18\x7e\x3b\x5d\xed\x63\x4b\x77\x2a\xbf\xfb\x76\xeb\x3e\x5c\xef\x39\xbf\xad\xdf\xef\x6d\x96\xe1\x7e\xda\x17\x4d\xd5\xcd\x24\x25\x6b\xba\x51\x74\x17\x63\x0e\x21\x74\xcd\xe7\xe8\x1f\xb9\xe7\x0b\xfe\x6e\x55\xbe\x52\x65\x92\x12\x1a\x59\xba\x2d\xf3\x62\xcf\xd7\x23\xb0\x64\x32\x0d\x3d\x09\x4b\x53\x4e\xfc\x5c\x10\x22\x09\xd9\x82\x71\x19\xf2\xe5\xce\x49\x0f\xea\xbc\x9e\x8c\x0f\x21\x63\xaf\xc4\xad\xe7\xd3\xd0\x4b\x52\xe3\xe7\x24\x6e\x3f\x21\x6f\xee\xf8\xd2\xee\x95\x65\xb8\xf5\x54\xd2\x14\x2b\x57\x10\x03\x5b\x33\x2e\x18\x35\x61\x14\x6b\x28\xbe\x25\x02\x99\x74\x45\x15\xdd\x34\x28\x7a\xe4\x08\x27\x7e\x14\xe4\xff\x04\xe3\x3b\xc1\x31\xfe\x06\x75\x3c\xdd\x67\x9f\xea\xb1\xfe\xf8\x6f\x05\xb3\x36\xc0\xab\xa6\x5e\xef\x59\xdc\xba\x77\x2c\x94\xb6\xfd\x34\x97\x22\x28\xb8\x35\x3f\xc1\x30\xa8\xe2\x5b\x72\xb2\x7d\x88\x9d\x97\x65\x5a\x38\xbc\x55\xaa\x07\x02\xa9\xfe\xe6\x36\x3e\x31\xc5\xb2\xb4\xb9\x55\x93\x79\xf4\x5e\x5f\xd8\xed\xb9\x2f\xe9\x94\x58\x85\x8b\x10\xff\x9c\x33\x47\x94\xc0\x2d\x6a\xba\x2a\x06\x42\x57\x78\x14\x21\x47\x30\x2e\x18\x10\x4d\xc6\x29\x01\x04\xc6\xe1\x85\x82\xea\x34\x2e\x17\xfd\x76\xcb\x8f\xd7\xfc\x3d\xb1\x77\x95\xbf\x93\xd5\x02\x65\xb8\x1a\x28\x6f\x06\x12\x7b\xe7\x8a\xc6\x5e\x7b\xff\x7a\x80\xe6\xaa\x6b\xb2\x9d\xcb\x00\x9a\x8c\x17\x02\xbb\xf7\xa5\x34\xe7\xc6\x1a\x00\x77\x5b\x2c\x98\xf1\x6c\x76\x5c\xc2\xde\xed\x7b\x44\x24\x20\x67\x38\x39\x4c\x40\x53\x07\x88\x76\x2e\x28\x68\xb1\x1b\xf2\xe2\xfa\xc4\x5e\xde\x04\xd2\xac\x1f\x0a\x07\xe5\xab\x9a\x6e\xf8\x0a\x69\xf4\x3e\x22\x7b\x07\x69\xc3\x88\xc7\xc3\xc1\x8c\x74\x5e\x02\xf6\x01\xd2\x88\xc4\xc3\xdc\x1f\x0b\x95\x8e\x7b\x8c\x6c\x0f\x90\x9e\xb6\x9b\xa5\x87\xbd\x7b\x3a\xcb\x72\x71\x5d\xc4\xc6\x9a\x43\x4c\x42\x9c\x09\xeb\xbc\x66\x23\x03\xef\x7b\x3e\x76\x38\x44\xf3\x3f\x31\x70\xac\xfb\x4f\x9c\xa2\xf7\x39\xf7\x86\xe2\x0a\x52\x72\x1f\x35\x77\xc9\xbf\x30\xd4\x4d\x56\x7e\x91\xa2\xe1\x9a\x5e\xc1\x38\x8a\x14\x14\x3d\x7b\x53\xaf\xfa\xbb\xa1\xc7\x08\x7a\x2d\x43\xcd\x99\xe0\x7f\xba\xfb\xc9\xbe\x7f\xa4\x77\x6f\x95\x92\x27\x68\xb7\x90\x21\x73\xcf\x5e\x56\x41\xce\x8c\x81\x15\x32\xea\x4e\xe9\x25\x73\x0b\x4a\xa7\x48\xcc\xcb\x76\x8d\x5c\x52\xd1\xf3\xb2\xa6\xe7\x3e\x15\xd2\xa4\x2b\xcf\x73\x2a\x3a\xb9\xed\x85\x1b\x19\x6e\x72\xc1\xb6\xc0\x2d\xa5\xe4\x70\xa8\xba\x97\x96\x6f\x4d\xe4\xa2\x46\x69\x0a\x01\x7b\x2e\x1a\x1b\xbb\xa6\x8f\x12\x76\x9c\x7b\x36\xbd\x33\xf4\x35\x4d\xbf\xac\xee\xaa\x9a\x4e\x18\xd3\x46\xd3\xd3\xe2\x28\xfd\x35\xdd\xc9\xcd\x38\x4f\x6a\x3a\x52\xcc\x29\x71\xc2\x81\xa6\x24\x70\x7f\x3b\xae\x45\x04\xd1\xb7\x5c\x86\x36\xe5\x72\xf7\xd7\x0b\x80\x21\x2b\x76\x48\x39\xb7\xb8\xa5\x48\xec\x75\x14\x90\x46\x70\xf4\x03\xd7\xb7\xb8\xbd\x39\x9c\x45\x02\x1c\x6b\xeb\xca\xb4\x11\x21\xed\xe7\x1e\x71\xe4\x52\x0a\x3e\x1a\x9e\x02\xff\xb1\x4e\x10\x33\x1f\xf0\x17\x2f\xe2\x9e\xf5\xf9\x6b\x7e\x13\xbd\x33\x22\xa0\xb1\xe1\x35\xbf\xa9\xea\xdb\x9a\x8f\xf8\x35\xa7\xed\xd6\x7d\xfb\xbe\xfd\xef\x01\x00\x8c\xef\xdd\x95\x84\x22\x00\x00")
 
 func call_tracerJsBytes() ([]byte, error) {
 	return bindataRead(
@@ -133,7 +133,7 @@ func call_tracerJs() (*asset, error) {
 	}
 
 	info := bindataFileInfo{name: "call_tracer.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe9, 0xef, 0x68, 0xda, 0xd8, 0x9, 0xf5, 0xd5, 0x71, 0xa8, 0x8a, 0xfb, 0x30, 0xe8, 0xf0, 0x72, 0x14, 0x36, 0x6b, 0x62, 0x5a, 0x4e, 0xff, 0x16, 0xdc, 0xd3, 0x2c, 0x68, 0x7b, 0x79, 0x9f, 0xd3}}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x12, 0x59, 0x3e, 0x51, 0xe, 0x57, 0x45, 0xbd, 0x3c, 0x50, 0x10, 0x5b, 0x60, 0x8d, 0x37, 0xc3, 0xdb, 0x37, 0x76, 0xa, 0x79, 0x8d, 0xe5, 0x15, 0x3f, 0x1e, 0x8d, 0xf7, 0x58, 0x10, 0x5c, 0xe}}
 	return a, nil
 }
 
@@ -197,7 +197,7 @@ func opcount_tracerJs() (*asset, error) {
 	return a, nil
 }
 
-var _prestate_tracerJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x57\xdd\x6f\xdb\x38\x12\x7f\x96\xfe\x8a\x41\x5f\x6c\xa3\xae\xdc\x64\x81\x3d\xc0\xb9\x1c\xa0\xba\x6e\x1b\x20\x9b\x04\xb6\x7b\xb9\xdc\x62\x1f\x28\x72\x24\x73\x4d\x93\x02\x49\xd9\xf1\x15\xf9\xdf\x0f\x43\x7d\xf8\xa3\x49\xd3\xdd\x37\x9b\x1c\xfe\xe6\xfb\x37\xa3\xd1\x08\x26\xa6\xdc\x59\x59\x2c\x3d\x9c\xbf\x3f\xfb\x07\x2c\x96\x08\x85\x79\x87\x7e\x89\x16\xab\x35\xa4\x95\x5f\x1a\xeb\xe2\xd1\x08\x16\x4b\xe9\x20\x97\x0a\x41\x3a\x28\x99\xf5\x60\x72\xf0\x27\xf2\x4a\x66\x96\xd9\x5d\x12\x8f\x46\xf5\x9b\x67\xaf\x09\x21\xb7\x88\xe0\x4c\xee\xb7\xcc\xe2\x18\x76\xa6\x02\xce\x34\x58\x14\xd2\x79\x2b\xb3\xca\x23\x48\x0f\x4c\x8b\x91\xb1\xb0\x36\x42\xe6\x3b\x82\x94\x1e\x2a\x2d\xd0\x06\xd5\x1e\xed\xda\xb5\x76\x7c\xbe\xf9\x0a\xd7\xe8\x1c\x5a\xf8\x8c\x1a\x2d\x53\x70\x57\x65\x4a\x72\xb8\x96\x1c\xb5\x43\x60\x0e\x4a\x3a\x71\x4b\x14\x90\x05\x38\x7a\xf8\x89\x4c\x99\x37\xa6\xc0\x27\x53\x69\xc1\xbc\x34\x7a\x08\x28\xc9\x72\xd8\xa0\x75\xd2\x68\xf8\xa5\x55\xd5\x00\x0e\xc1\x58\x02\xe9\x33\x4f\x0e\x58\x30\x25\xbd\x1b\x00\xd3\x3b\x50\xcc\xef\x9f\xfe\x44\x40\xf6\x7e\x0b\x90\x3a\xa8\x59\x9a\x12\xc1\x2f\x99\x27\xaf\xb7\x52\x29\xc8\x10\x2a\x87\x79\xa5\x86\x84\x96\x55\x1e\xee\xaf\x16\x5f\x6e\xbf\x2e\x20\xbd\x79\x80\xfb\x74\x36\x4b\x6f\x16\x0f\x17\xb0\x95\x7e\x69\x2a\x0f\xb8\xc1\x1a\x4a\xae\x4b\x25\x51\xc0\x96\x59\xcb\xb4\xdf\x81\xc9\x09\xe1\xb7\xe9\x6c\xf2\x25\xbd\x59\xa4\x1f\xae\xae\xaf\x16\x0f\x60\x2c\x7c\xba\x5a\xdc\x4c\xe7\x73\xf8\x74\x3b\x83\x14\xee\xd2\xd9\xe2\x6a\xf2\xf5\x3a\x9d\xc1\xdd\xd7\xd9\xdd\xed\x7c\x9a\xc0\x1c\xc9\x2a\xa4\xf7\xaf\xc7\x3c\x0f\xd9\xb3\x08\x02\x3d\x93\xca\xb5\x91\x78\x30\x15\xb8\xa5\xa9\x94\x80\x25\xdb\x20\x58\xe4\x28\x37\x28\x80\x01\x37\xe5\xee\xa7\x93\x4a\x58\x4c\x19\x5d\x04\x9f\x5f\x2c\x48\xb8\xca\x41\x1b\x3f\x04\x87\x08\xff\x5c\x7a\x5f\x8e\x47\xa3\xed\x76\x9b\x14\xba\x4a\x8c\x2d\x46\xaa\x86\x73\xa3\x7f\x25\x31\x61\x96\x16\x9d\x67\x1e\x17\x96\x71\xb4\x60\x2a\x5f\x56\xde\x81\xab\xf2\x5c\x72\x89\xda\x83\xd4\xb9\xb1\xeb\x50\x29\xe0\x0d\x70\x8b\xcc\x23\x30\x50\x86\x33\x05\xf8\x88\xbc\x0a\x77\x75\xa4\x43\xb9\x5a\xa6\x1d\xe3\xe1\x34\xb7\x66\x4d\xbe\x56\xce\xd3\x0f\xe7\x70\x9d\x29\x14\x50\xa0\x46\x27\x1d\x64\xca\xf0\x55\x12\x7f\x8b\xa3\x03\x63\xa8\x4e\x82\x87\x8d\x50\xa8\x8d\x2d\xf6\x2c\x42\x56\x49\x25\xa4\x2e\x92\x38\x6a\xa5\xc7\xa0\x2b\xa5\x86\x71\x80\x50\xc6\xac\xaa\x32\xe5\xdc\x54\xc1\xf6\x3f\x91\xfb\x1a\xcc\x95\xc8\x65\x4e\xc5\xc1\xba\x5b\x6f\xc2\x55\xa7\xd7\x64\x24\x9f\xc4\xd1\x11\xcc\x18\xf2\x4a\x07\x77\xfa\x4c\x08\x3b\x04\x91\x0d\xbe\xc5\x51\xb4\x61\x96\xb0\xe0\x12\xbc\xf9\x82\x8f\xe1\x72\x70\x11\x47\x91\xcc\xa1\xef\x97\xd2\x25\x2d\xf0\xef\x8c\xf3\x3f\xe0\xf2\xf2\x32\x34\x75\x2e\x35\x8a\x01\x10\x44\xf4\x9c\x58\x7d\x13\x65\x4c\x31\xcd\x71\x0c\xbd\xf7\x8f\x3d\x78\x0b\x22\x4b\x0a\xf4\x1f\xea\xd3\x5a\x59\xe2\xcd\xdc\x5b\xa9\x8b\xfe\xd9\xaf\x83\x61\x78\xa5\x4d\x78\x03\x8d\xf8\x8d\xe9\x84\xeb\x7b\x6e\x44\xb8\x6e\x6c\xae\xa5\x26\x46\x34\x42\x8d\x94\xf3\xc6\xb2\x02\xc7\xf0\xed\x89\xfe\x3f\x91\x57\x4f\x71\xf4\x74\x14\xe5\x79\x2d\xf4\x42\x94\x1b\x08\x40\xed\x6d\x57\xe7\x85\xa4\x4e\x3d\x4c\x40\xc0\xfb\x51\x12\xe6\xad\x29\x27\x49\x58\xe1\xee\xf5\x4c\xd0\x85\x14\x8f\xdd\xc5\x0a\x77\x83\x8b\xf8\xc5\x14\x25\x8d\xd1\xbf\x4b\xf1\xf8\xb3\xf9\x3a\x79\x73\x14\xd7\x39\x49\xed\xed\x1d\x0c\x4e\xe2\x68\xd1\x55\xca\x53\xb9\x4b\xbd\x31\x2b\x22\xae\x25\xc5\x47\xa9\x10\x12\x53\x52\xb6\x5c\xcd\x1c\x19\xa2\x06\xe9\xd1\x32\xa2\x4e\xb3\x41\x4b\x53\x03\x2c\xfa\xca\x6a\xd7\x85\x31\x97\x9a\xa9\x16\xb8\x89\xba\xb7\x8c\xd7\x3d\x53\x9f\x1f\xc4\x92\xfb\xc7\x10\xc5\xe0\xdd\x68\x04\xa9\x07\x72\x11\x4a\x23\xb5\x1f\xc2\x16\x41\x23\x0a\x6a\x7c\x81\xa2\xe2\x3e\xe0\xf5\x36\x4c\x55\xd8\xab\x9b\x9b\x28\x32\x3c\x35\x15\x4d\x82\x83\xe6\x1f\x06\x03\xd7\x66\x13\x46\x5c\xc6\xf8\x0a\x9a\x86\x33\x56\x16\x52\xc7\x4d\x38\x8f\x9a\x8d\x2c\x4a\x08\x38\x98\x15\x72\x45\x49\xa4\x93\x0f\x4c\xc1\x25\x64\xb2\xb8\xd2\xfe\x24\x79\x75\xd0\xdb\xa7\x83\x3f\x92\xa6\x79\x12\x47\x84\xd7\x3f\x1f\x0c\xe1\xec\xd7\xae\x22\xbc\x21\x28\x78\x1d\xcc\x9b\x97\xa1\xe2\xd3\x62\x78\xfe\x59\x50\x43\x1d\xfc\x36\x68\x4d\x5c\x95\x51\x3a\x6a\x3f\x43\x1c\x8f\xbb\xf8\xe2\x07\xb8\xc7\xbe\xb5\xb8\x4d\x68\x12\x26\xc4\xcb\xa0\x75\x8a\x3e\x22\xb7\xb8\x26\x56\xa7\x2c\x70\xa6\x14\xda\x9e\x83\xc0\x19\xc3\xa6\x9c\x42\xbe\x70\x5d\xfa\x5d\xcb\xf5\x9e\xd9\x02\xbd\x7b\xdd\xb0\x80\xf3\xee\x5d\x4b\x81\x21\x14\xbb\x12\xe1\xf2\x12\x7a\x93\xd9\x34\x5d\x4c\x7b\x4d\x1b\x8d\x46\x70\x8f\x61\x13\xca\x94\xcc\x84\xda\x81\x40\x85\x1e\x6b\xbb\x8c\x0e\x21\xea\x28\x61\x48\x2b\x0d\x2d\x1b\xf8\x28\x9d\x97\xba\x80\x9a\x29\xb6\x34\x57\x1b\xb8\xd0\x23\x9c\x55\x8e\xaa\xf5\x64\x08\x79\x43\x1b\x85\x45\xe2\x15\xe2\xff\xd0\x6e\x4c\xc9\x6e\x03\xc9\xa5\x75\x1e\x4a\xc5\x38\x26\x84\xd7\x19\xf3\x72\x7e\x9b\x4e\x26\xd5\xb3\xd0\x82\x01\x68\x3f\xe0\x98\xa2\x01\x49\xea\x1d\xf4\x5b\x8c\x41\x1c\x45\xb6\x95\x3e\xc0\xbe\xd8\x53\x82\xf3\x58\x1e\x12\x02\x2d\x16\xb8\x41\xa2\xd0\xc0\x06\xf5\x30\x24\x5d\xff\xfe\xad\x99\xbe\xe8\x92\x38\xa2\x77\x07\x7d\xad\x4c\x71\xdc\xd7\xa2\x0e\x0b\xaf\xac\xa5\xfc\x77\x14\x9c\x53\x8f\xff\x59\x39\x4f\x31\xb5\x14\x9e\x86\x2d\x9e\x23\xc9\x40\x89\x34\x6d\x07\xdf\x93\x21\xcd\xad\x30\x27\x48\x5d\x33\xa5\xea\x6d\xae\x34\x1e\xb5\x97\x4c\xa9\x1d\xe5\x61\x6b\x69\x8d\xa1\xc5\x65\x08\x4e\x92\x54\x60\x9c\x20\x2a\x35\x57\x95\xa8\xcb\x20\xd4\x71\x83\xe7\x82\xcd\xc7\xfb\xcf\x1a\x9d\x63\x05\x26\x54\x49\xb9\x7c\x6c\x36\x48\x0d\xbd\x9a\xe4\xfa\x83\x5e\xd2\x19\x79\x4c\x31\xca\x14\x49\x5b\x64\x44\xd3\xa9\x10\x16\x9d\xeb\x0f\x1a\xce\xe9\x32\x7b\xbf\x44\x4d\xc1\x07\x8d\x5b\xe8\x56\x13\xc6\x39\xad\x6a\x62\x08\x4c\x08\xa2\xb6\x93\x35\x22\x8e\x22\xb7\x95\x9e\x2f\x21\x68\x32\xe5\xbe\x17\x07\x4d\xfd\x73\xe6\x10\xde\x4c\xff\xb3\x98\xdc\x7e\x9c\x4e\x6e\xef\x1e\xde\x8c\xe1\xe8\x6c\x7e\xf5\xdf\x69\x77\xf6\x21\xbd\x4e\x6f\x26\xd3\x37\xe3\x30\x9b\x9f\x71\xc8\x9b\xd6\x05\x52\xe8\x3c\xe3\xab\xa4\x44\x5c\xf5\xdf\x1f\xf3\xc0\xde\xc1\x28\xca\x2c\xb2\xd5\xc5\xde\x98\xba\x41\x1b\x1d\x2d\xe5\xc2\x25\xbc\x18\xac\x8b\x97\xad\x99\x34\xf2\xfd\x96\xc8\xf7\xab\x48\xa0\x8a\xd7\xed\x38\xff\xcb\x86\x84\xde\x61\x7c\x35\x06\xc7\x14\x6d\xc0\xf2\x7f\xf4\xe5\x92\xe7\x0e\xfd\x10\x50\x0b\xb3\x25\xe6\xeb\x50\xeb\x9b\x06\xf7\x20\x64\x67\x83\x9a\x41\x6f\xf3\xfe\xa0\x13\x26\xb0\xef\x45\xcf\x9f\x13\x45\x2d\xe0\xb2\x45\x7f\x1b\x5e\xbe\x1e\xa8\xf3\x26\x52\x27\x0a\x7e\x39\xd9\xf0\xc2\xfd\x1a\xd7\xc6\xee\x9a\x71\x74\xe0\xdf\x8f\xa3\x9a\x5e\x5f\x77\xf5\x44\x7f\xa8\xc8\xba\x83\x8f\xd3\xeb\xe9\xe7\x74\x31\x3d\x92\x9a\x2f\xd2\xc5\xd5\xa4\x3e\xfa\xcb\x85\x77\xf6\xd3\x85\xd7\x9b\xcf\x17\xb7\xb3\x69\x6f\xdc\xfc\xbb\xbe\x4d\x3f\xf6\xbe\x53\xd8\x6c\x81\x3f\x6a\x5d\x6f\xee\x8d\x15\x7f\xa7\x03\x0e\x36\xb2\x9c\x3d\xb7\x90\x05\x6a\xe7\xbe\x3a\xf9\xe0\x01\xa6\x5b\x56\xce\xeb\x8f\xbe\x28\xbc\x7f\x96\x87\x9f\xe2\xa7\xf8\xff\x01\x00\x00\xff\xff\xb1\x28\x85\x2a\x8a\x10\x00\x00")
+var _prestate_tracerJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x57\x5b\x6f\xdb\x3a\x12\x7e\x96\x7e\xc5\xa0\x2f\xb6\x51\x57\x6e\x72\x80\xb3\x80\xb3\x59\x40\x75\x9c\x36\x80\x4f\x12\xd8\xce\x66\xb3\x07\xe7\x81\x22\x47\x32\x1b\x9a\x14\x48\xca\x8e\x5b\xe4\xbf\x2f\x86\x92\x7c\xc9\xa5\xc9\x9e\x37\x8b\x1c\x7e\x73\xff\x66\x3c\x18\xc0\xc8\x94\x1b\x2b\x8b\x85\x87\xe3\xcf\x47\xff\x80\xf9\x02\xa1\x30\x9f\xd0\x2f\xd0\xfe\x40\x6b\x20\xad\xfc\xc2\x58\x17\x0f\x06\x30\x5f\x48\x07\xb9\x54\x08\xd2\x41\xc9\xac\x07\x93\x83\x7f\xfa\x40\xc9\xcc\x32\xbb\x49\xe2\xc1\xa0\x7e\xf4\xf2\x3d\x61\xe4\x16\x11\x9c\xc9\xfd\x9a\x59\x1c\xc2\xc6\x54\xc0\x99\x06\x8b\x42\x3a\x6f\x65\x56\x79\x04\xe9\x81\x69\x31\x30\x16\x96\x46\xc8\x7c\x43\x98\xd2\x43\xa5\x05\xda\xa0\xdc\xa3\x5d\xba\xd6\x92\xaf\x97\x37\x30\x41\xe7\xd0\xc2\x57\xd4\x68\x99\x82\xeb\x2a\x53\x92\xc3\x44\x72\xd4\x0e\x81\x39\x28\xe9\xc4\x2d\x50\x40\x16\xe0\xe8\xe1\x39\x99\x32\x6b\x4c\x81\x73\x53\x69\xc1\xbc\x34\xba\x0f\x28\xc9\x74\x58\xa1\x75\xd2\x68\xf8\xad\x55\xd5\x00\xf6\xc1\x58\x02\xe9\x32\x4f\x0e\x58\x30\x25\xbd\xeb\x01\xd3\x1b\x50\xcc\xef\x9e\xbe\x27\x22\x3b\xc7\x05\x48\x1d\xfc\x5b\x98\x12\xc1\x2f\x98\xa7\x50\xac\xa5\x52\x90\x21\x54\x0e\xf3\x4a\xf5\x09\x2e\xab\x3c\xdc\x5e\xcc\xbf\x5d\xdd\xcc\x21\xbd\xbc\x83\xdb\x74\x3a\x4d\x2f\xe7\x77\x27\xb0\x96\x7e\x61\x2a\x0f\xb8\xc2\x1a\x4a\x2e\x4b\x25\x51\xc0\x9a\x59\xcb\xb4\xdf\x80\xc9\x09\xe1\x8f\xf1\x74\xf4\x2d\xbd\x9c\xa7\x5f\x2e\x26\x17\xf3\x3b\x30\x16\xce\x2f\xe6\x97\xe3\xd9\x0c\xce\xaf\xa6\x90\xc2\x75\x3a\x9d\x5f\x8c\x6e\x26\xe9\x14\xae\x6f\xa6\xd7\x57\xb3\x71\x02\x33\x24\xab\x90\xde\xbf\x1d\xf4\x3c\xa4\xcf\x22\x08\xf4\x4c\x2a\xd7\x86\xe2\xce\x54\xe0\x16\xa6\x52\x02\x16\x6c\x85\x60\x91\xa3\x5c\xa1\x00\x06\xdc\x94\x9b\x77\x67\x95\xb0\x98\x32\xba\x08\x3e\xbf\x5e\x93\x70\x91\x83\x36\xbe\x0f\x0e\x11\xfe\xb9\xf0\xbe\x1c\x0e\x06\xeb\xf5\x3a\x29\x74\x95\x18\x5b\x0c\x54\x8d\xe7\x06\xff\x4a\x62\x02\x2d\x2d\x3a\xcf\x3c\xce\x2d\xe3\x68\xc1\x54\xbe\xac\xbc\x03\x57\xe5\xb9\xe4\x12\xb5\x07\xa9\x73\x63\x97\xa1\x56\xc0\x1b\xe0\x16\x99\x47\x60\xa0\x0c\x67\x0a\xf0\x01\x79\x15\xee\xea\x50\x93\x65\xde\x32\xed\x18\x0f\xa7\xb9\x35\x4b\x72\xb6\x72\x9e\x7e\x38\x87\xcb\x4c\xa1\x80\x02\x35\x3a\xe9\x20\x53\x86\xdf\x27\xf1\xcf\x38\xda\x33\x86\x0a\x85\x80\x5a\xa1\x50\x1c\x6b\xec\x58\x84\xac\x92\x4a\x48\x5d\x24\x71\xd4\x4a\x0f\x41\x57\x4a\xf5\xe3\x00\xa1\x8c\xb9\xaf\xca\x94\x73\x53\x05\xdb\xbf\x23\xf7\x04\x80\xe0\x4a\xe4\x32\xa7\xea\x60\xdb\x5b\x6f\xc2\xd5\x56\xaf\xc9\x48\x3e\x89\xa3\x03\x98\x21\xe4\x95\x0e\xee\x74\x99\x10\xb6\x0f\x22\xeb\xfd\x8c\xa3\x68\xc5\x2c\x30\xce\xe1\x14\xbc\xf9\x86\x0f\xe1\xb2\x77\x12\x47\x91\xcc\xa1\xeb\x17\xd2\x25\x2d\xf0\x9f\x8c\xf3\xbf\xe0\xf4\xf4\x34\xb4\x75\x2e\x35\x8a\x1e\x10\x44\xf4\x92\x58\x7d\x13\x65\x4c\x31\xcd\x71\x08\x9d\xcf\x0f\x1d\xf8\x08\x22\x4b\x0a\xf4\x5f\xea\xd3\x5a\x59\xe2\xcd\xcc\x5b\xa9\x8b\xee\xd1\xef\xbd\x7e\x78\xa5\x4d\x78\x03\x8d\xf8\xa5\xd9\x0a\xd7\xf7\xdc\x88\x70\xdd\xd8\x5c\x4b\x8d\x8c\x68\x84\x1a\x29\xe7\x8d\x65\x05\x0e\xe1\xe7\x23\x7d\x3f\x92\x57\x8f\x71\xf4\x78\x10\xe5\x59\x2d\xf4\x4a\x94\x1b\x08\x40\xed\xed\xb6\xd0\x0b\x49\xad\xba\x9f\x80\x80\xf7\xab\x24\x34\x5a\x9e\x25\xe1\x1e\x37\x6f\x67\x82\x52\x24\xc5\xc3\xf6\xe2\x1e\x37\xbd\x93\xf8\xd5\x14\x25\x8d\xd1\x7f\x4a\xf1\xf0\xde\x7c\x3d\x79\xd3\x28\xaa\xe3\x3a\x23\xe4\x9d\xbd\xbd\xde\x93\x38\x5a\x74\x95\xf2\x54\xee\x52\xaf\xcc\x3d\x31\xd7\x82\xe2\xa3\x54\x88\x96\x29\x29\x5b\xae\xa6\x8e\x0c\x51\x83\xf4\x68\x19\x71\xa7\x59\xa1\xa5\xb9\x01\x16\x7d\x65\xb5\xdb\x86\x31\x97\x9a\xa9\x16\xb8\x89\xba\xb7\x8c\xd7\x3d\x53\x9f\xef\xc5\x92\xfb\x87\x10\xc5\xe0\xdd\x60\x00\xa9\x07\x72\x11\x4a\x23\xb5\xef\xc3\x1a\x41\x23\x0a\x6a\x7c\x81\xa2\xe2\x74\x8b\xd0\x59\x31\x55\x61\xa7\x6e\x6e\xe2\xc8\x88\xb4\x9b\xca\xa3\xdd\x6f\xfe\x7e\x30\x70\x69\x56\x61\xc8\x65\x8c\xdf\x43\xd3\x70\xc6\xca\x42\xea\xb8\x09\xe7\x41\xb3\x75\xb9\x7f\x48\x08\x38\x98\x15\x72\x45\x49\xa4\x93\x2f\x4c\xc1\x29\x64\xb2\xb8\xd0\xfe\x49\xf2\xea\xa0\xb7\x4f\x7b\x7f\x25\x4d\xf3\x24\x8e\x08\xaf\x7b\xdc\xeb\xc3\xd1\xef\xdb\x8a\xf0\x86\xa0\xe0\x6d\x30\x6f\x5e\x87\x6a\xad\x7f\xe3\x59\x50\x43\x1d\xfc\x31\x68\x4d\x5c\x95\x51\x3a\x7c\x10\x0c\x71\x3c\xec\xe2\x93\x5f\xe0\x1e\xfa\xd6\xe2\x36\xa1\x49\x98\x10\xaf\x83\xd6\xd9\x3d\x43\x6e\x71\x49\xac\x4e\x59\xe0\x4c\x29\xb4\x1d\x07\x81\x33\xfa\x4d\x39\x85\x7c\xe1\xb2\xf4\x9b\x96\xeb\x3d\xb3\x05\x7a\xf7\xb6\x61\x01\xe7\xd3\xa7\x96\x02\xc9\x18\xbf\x29\x11\x4e\x4f\xa1\x33\x9a\x8e\xd3\xf9\xb8\xd3\xb4\xd1\x60\x00\xb7\x64\x80\x86\x4c\xc9\x4c\xa8\x0d\x08\x54\xe8\xc3\xc4\x05\x6e\x74\x08\xd1\x96\x12\xfa\xb4\xd4\xd0\xba\x81\x0f\xd2\x79\xa9\x0b\x08\xc7\xb0\xa6\xc1\xda\xc0\x85\x1e\xe1\xac\x72\x28\x9e\x0d\x21\x6f\x68\xa5\xb0\x48\xe4\x4e\xfc\x1f\xda\x8d\x29\xb9\x5d\x41\x72\x69\x9d\x87\x52\x31\x8e\x09\xe1\x6d\x8d\x79\xd9\x5d\x2a\x8b\xa6\x93\x29\xaa\xd3\xd0\x82\x01\x68\x37\xe0\x98\xa2\x01\x49\xea\x1d\x74\x5b\x8c\x5e\x1c\x45\xb6\x95\xde\xc3\x3e\xd9\x51\x82\xf3\x58\xee\x13\x02\x6d\x16\xb8\x42\xa2\xd0\xc0\x06\xf5\xa6\x44\xba\xfe\xfd\x47\x33\x7d\xd1\x25\x71\x44\xef\xf6\xfa\x5a\x99\xe2\xb0\xaf\x45\x1d\x16\x5e\x59\x4b\xf9\xdf\x52\x70\x4e\x3d\xfe\xbd\x72\x9e\x62\x6a\x89\x5a\x1a\xb6\x78\x89\x24\x03\x25\xd2\xb4\xed\x3d\x27\x43\x9a\x5b\x61\x4e\x90\x17\xcd\x94\xaa\xd7\xb9\xd2\x78\xd4\x5e\x32\xa5\x36\x94\x87\xb5\xa5\x3d\x66\x81\x16\xfb\xe0\x24\x49\x11\x4e\x2d\x2a\x35\x57\x95\xa0\x13\x84\xd0\x1c\x0d\x9e\x0b\x36\x1f\x2e\x40\x4b\x74\x8e\x15\x98\x50\x25\xe5\xf2\xa1\x59\x21\x35\x74\x6a\x92\xeb\xf6\x3a\x49\x1c\xbd\x48\x31\xca\x14\x49\x5b\x64\x44\xd3\xa9\x10\x16\x9d\xeb\xf6\x1a\xce\xd9\x66\xf6\x76\x81\x9a\x82\x0f\x1a\xd7\x4d\xcd\x49\x47\x93\x86\x76\x35\xd1\x07\x26\x04\x51\xdb\x93\x35\x22\x8e\x22\xb7\x96\x9e\x2f\x20\x68\x32\xe5\xae\x17\x7b\x4d\xfd\x73\xe6\x10\x3e\x8c\xff\x33\x1f\x5d\x9d\x8d\x47\x57\xd7\x77\x1f\x86\x70\x70\x36\xbb\xf8\xef\x78\x7b\xf6\x25\x9d\xa4\x97\xa3\xdd\xf7\x6c\x3c\x39\x3f\x1b\xcf\xe6\xd3\x9b\xd1\xfc\xc3\x30\x8e\x5e\xf6\xd2\x9b\xd6\x2f\xb2\xc2\x79\xc6\xef\x93\x12\xf1\xbe\xfb\xf9\x90\x1c\x76\x5e\x47\x51\x66\x91\xdd\x9f\xec\x2c\xac\xbb\xb6\xd1\xd1\xf2\x30\x9c\xc2\xab\x11\x3c\x79\xdd\x9a\x51\x23\xdf\x6d\xd9\x7d\xb7\x9f\xd0\xc9\x3b\xec\x38\xfe\xbf\x0d\xa1\xd2\x21\xc7\x87\xe0\x98\xa2\xb5\x58\xfe\xc0\x3e\x98\x3c\x77\xe8\xfb\x80\x5a\x98\x35\xd1\xe1\x16\xb5\xbe\x69\x70\xf7\x42\x76\xd4\xab\x69\xf5\x2a\xef\xf6\xb6\xc2\x4e\xfe\xc0\xe7\xa2\xc7\x2f\x89\xa2\x16\x70\xda\xe8\x85\x8f\xc1\x8c\xb7\x03\x75\xdc\x44\xea\x89\x82\xdf\x0e\xd3\xd7\x0f\x06\x2c\x71\x69\xec\xa6\x99\x51\x7b\xfe\xfd\x3a\xaa\xe9\x64\xb2\x2d\xaa\x51\x3a\x99\x50\x35\x6e\x0f\xce\xc6\x93\xf1\xd7\x74\x3e\x3e\x90\x9a\xcd\xd3\xf9\xc5\xa8\x3e\x7a\xdd\x83\x36\x0b\x4f\x2c\x3f\x7a\x77\xe1\x75\x66\xb3\xf9\xd5\x74\xdc\x19\x36\x5f\x93\xab\xf4\xac\xf3\x4c\x61\xb3\x1a\xfe\xaa\x9f\xbd\xb9\x35\x56\xfc\x9d\x0e\xd8\x5b\xd3\x72\xf6\xd2\x96\x46\x1c\xc4\xb8\xaf\x9e\xfc\x0b\x02\xa6\x5b\xaa\xce\xeb\xbf\x82\x51\xce\x0e\x97\xae\x1d\x39\x3f\xc6\x8f\xf1\xff\x06\x00\xf5\x16\xef\x01\xa4\x10\x00\x00")
 
 func prestate_tracerJsBytes() ([]byte, error) {
 	return bindataRead(
@@ -213,7 +213,7 @@ func prestate_tracerJs() (*asset, error) {
 	}
 
 	info := bindataFileInfo{name: "prestate_tracer.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe9, 0x79, 0x70, 0x4f, 0xc5, 0x78, 0x57, 0x63, 0x6f, 0x5, 0x31, 0xce, 0x3e, 0x5d, 0xbd, 0x71, 0x4, 0x46, 0x78, 0xcd, 0x1d, 0xcd, 0xb9, 0xd8, 0x10, 0xff, 0xe6, 0xc5, 0x59, 0xb9, 0x25, 0x6e}}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x7e, 0x81, 0x91, 0x42, 0xed, 0x1b, 0xbe, 0x41, 0xf9, 0xed, 0xb6, 0xe, 0xde, 0xee, 0x24, 0xe8, 0xb7, 0x5e, 0x2, 0x7, 0x36, 0x48, 0x44, 0x1c, 0x9a, 0x73, 0x82, 0x25, 0x18, 0xd, 0x83, 0x81}}
 	return a, nil
 }
 
@@ -61,7 +61,13 @@
 			if (this.callstack[left-1].calls === undefined) {
 				this.callstack[left-1].calls = [];
 			}
-			this.callstack[left-1].calls.push({type: op});
+			var from = log.contract.getAddress();
+			this.callstack[left-1].calls.push({
+				type:  op,
+				from:  toHex(from),
+				to:    toHex(toAddress(log.stack.peek(0).toString(16))),
+				value: '0x' + db.getBalance(from).toString(16)
+			});
 			return
 		}
 		// If a new method invocation is being done, add to the call stack
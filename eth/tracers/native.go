@@ -0,0 +1,121 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/log"
+)
+
+// ResultTracer is a vm.Tracer which assembles a JSON result out of the traced
+// execution, and which can be aborted midway.
+type ResultTracer interface {
+	vm.Tracer
+
+	// GetResult returns the JSON result of the trace, or any accumulated error.
+	GetResult() (json.RawMessage, error)
+
+	// Stop terminates execution of the tracer at the first opportune moment.
+	Stop(err error)
+}
+
+// native contains all the built in Go tracers by name. They produce the exact
+// same output as their JavaScript counterparts, without the cost of running
+// every step through the duktape VM.
+var native = map[string]func() ResultTracer{
+	"callTracer":     newCallTracer,
+	"prestateTracer": newPrestateTracer,
+}
+
+// NewTracer instantiates a tracer by name, preferring the native implementation
+// of a built in tracer if one exists. Any other name or code is handed to the
+// JavaScript tracer.
+func NewTracer(code string) (ResultTracer, error) {
+	if constructor, ok := native[code]; ok {
+		return constructor(), nil
+	}
+	return New(code)
+}
+
+// interrupter implements the abort mechanics shared by the native tracers.
+type interrupter struct {
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (i *interrupter) Stop(err error) {
+	i.reason = err
+	atomic.StoreUint32(&i.interrupt, 1)
+}
+
+// stopped returns whether the tracer was interrupted.
+func (i *interrupter) stopped() bool {
+	return atomic.LoadUint32(&i.interrupt) > 0
+}
+
+// peekStack returns the n-th item from the top of the stack, or zero if the
+// stack is not deep enough, as the JavaScript tracers see it.
+func peekStack(stack *vm.Stack, n int) *big.Int {
+	data := stack.Data()
+	if len(data) <= n {
+		log.Warn("Tracer accessed out of bound stack", "size", len(data), "index", n)
+		return new(big.Int)
+	}
+	return data[len(data)-n-1]
+}
+
+// sliceMemory returns a copy of the memory between the given offsets, or nil
+// if out of bounds, as the JavaScript tracers see it.
+func sliceMemory(memory *vm.Memory, begin, end int64) []byte {
+	if memory.Len() < int(end) {
+		log.Warn("Tracer accessed out of bound memory", "available", memory.Len(), "offset", begin, "size", end-begin)
+		return nil
+	}
+	return memory.Get(begin, end-begin)
+}
+
+// hexBig formats a big integer the way the JavaScript tracers' bigInt does.
+func hexBig(n *big.Int) string {
+	if n == nil {
+		return "0x0"
+	}
+	return "0x" + n.Text(16)
+}
+
+// hexInt formats a signed integer the way the JavaScript tracers' bigInt does.
+func hexInt(n int64) string {
+	return hexBig(big.NewInt(n))
+}
+
+// encodeResult serializes a native trace result the same way duktape encodes
+// the result of a JavaScript tracer.
+func encodeResult(v interface{}) (json.RawMessage, error) {
+	buf := new(bytes.Buffer)
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
@@ -0,0 +1,233 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/crypto"
+)
+
+// orderedMap is a JSON object retaining the insertion order of its keys, the
+// same way JavaScript objects do.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// newOrderedMap creates an empty insertion ordered JSON object.
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]interface{})}
+}
+
+// get retrieves the value of a key, or nil if it's not present.
+func (m *orderedMap) get(key string) interface{} {
+	return m.values[key]
+}
+
+// set inserts a new key or updates an existing one in place.
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// remove deletes a key from the object.
+func (m *orderedMap) remove(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// MarshalJSON implements json.Marshaler, emitting the keys in insertion order.
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := encodeResult(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := encodeResult(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// prestateAccount is the state of an account prior to the traced transaction,
+// with the fields in the same order as the JavaScript prestate tracer emits them.
+type prestateAccount struct {
+	Balance string      `json:"balance"`
+	Nonce   int64       `json:"nonce"`
+	Code    string      `json:"code"`
+	Storage *orderedMap `json:"storage"`
+}
+
+// prestateTracer is the native implementation of prestate_tracer.js, which
+// collects the accounts and storage slots a transaction touched, as they were
+// before its execution.
+type prestateTracer struct {
+	interrupter
+
+	prestate *orderedMap // Touched accounts, keyed by hex address
+	db       vm.StateDB  // State database the transaction is executed on
+
+	create bool           // Whether the transaction is a contract creation
+	from   common.Address // Sender of the transaction
+	to     common.Address // Recipient of the transaction
+	value  *big.Int       // Value transferred by the transaction
+}
+
+// newPrestateTracer creates a native prestate tracer.
+func newPrestateTracer() ResultTracer {
+	return new(prestateTracer)
+}
+
+// lookupAccount retrieves the state of an account if not yet known.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	acc := hexutil.Encode(addr.Bytes())
+	if t.prestate.get(acc) == nil {
+		t.prestate.set(acc, &prestateAccount{
+			Balance: hexBig(t.db.GetBalance(addr)),
+			Nonce:   int64(t.db.GetNonce(addr)),
+			Code:    hexutil.Encode(t.db.GetCode(addr)),
+			Storage: newOrderedMap(),
+		})
+	}
+}
+
+// lookupStorage retrieves a storage slot of an account if not yet known.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+
+	storage := t.prestate.get(hexutil.Encode(addr.Bytes())).(*prestateAccount).Storage
+	idx := hexutil.Encode(key.Bytes())
+	if storage.get(idx) == nil {
+		storage.set(idx, hexutil.Encode(t.db.GetState(addr, key).Bytes()))
+	}
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *prestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.create, t.from, t.to, t.value = create, from, to, value
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.stopped() {
+		return nil
+	}
+	t.db = env.StateDB
+	if t.prestate == nil {
+		t.prestate = newOrderedMap()
+		t.lookupAccount(contract.Address())
+	}
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.BALANCE, vm.SELFDESTRUCT:
+		t.lookupAccount(common.BigToAddress(peekStack(stack, 0)))
+
+	case vm.CREATE:
+		from := contract.Address()
+		t.lookupAccount(crypto.CreateAddress(from, t.db.GetNonce(from)))
+
+	case vm.CREATE2:
+		offset := peekStack(stack, 1).Int64()
+		end := offset + peekStack(stack, 2).Int64()
+		code := sliceMemory(memory, offset, end)
+
+		salt := common.BigToHash(peekStack(stack, 3))
+		t.lookupAccount(crypto.CreateAddress2(contract.Address(), salt, crypto.Keccak256(code)))
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(common.BigToAddress(peekStack(stack, 1)))
+
+	case vm.SSTORE, vm.SLOAD:
+		t.lookupStorage(contract.Address(), common.BigToHash(peekStack(stack, 0)))
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// GetResult returns the pre-transaction state of the touched accounts, or any
+// accumulated error.
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	if t.prestate == nil {
+		t.prestate = newOrderedMap()
+	}
+	if t.db != nil {
+		t.lookupAccount(t.from)
+		t.lookupAccount(t.to)
+
+		// Undo the value transfer and nonce increment of the transaction
+		value := t.value
+		if value == nil {
+			value = new(big.Int)
+		}
+		from := t.prestate.get(hexutil.Encode(t.from.Bytes())).(*prestateAccount)
+		to := t.prestate.get(hexutil.Encode(t.to.Bytes())).(*prestateAccount)
+
+		toBal, _ := new(big.Int).SetString(to.Balance[2:], 16)
+		fromBal, _ := new(big.Int).SetString(from.Balance[2:], 16)
+
+		to.Balance = hexBig(toBal.Sub(toBal, value))
+		from.Balance = hexBig(fromBal.Add(fromBal, value))
+		from.Nonce--
+
+		if t.create {
+			t.prestate.remove(hexutil.Encode(t.to.Bytes()))
+		}
+	}
+	res, err := encodeResult(t.prestate)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
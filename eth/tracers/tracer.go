@@ -177,7 +177,8 @@ func (sw *stackWrapper) pushObject(vm *duktape.Context) {
 
 // dbWrapper provides a JavaScript wrapper around vm.Database.
 type dbWrapper struct {
-	db vm.StateDB
+	db     vm.StateDB
+	number *big.Int // Number of the block being traced, for power regeneration
 }
 
 // pushObject assembles a JSVM object wrapping a swappable database and pushes it
@@ -192,6 +193,13 @@ func (dw *dbWrapper) pushObject(vm *duktape.Context) {
 	})
 	vm.PutPropString(obj, "getBalance")
 
+	// Push the wrapper for statedb.GetPower
+	vm.PushGoFunction(func(ctx *duktape.Context) int {
+		pushBigInt(dw.db.GetPower(common.BytesToAddress(popSlice(ctx)), dw.number), ctx)
+		return 1
+	})
+	vm.PutPropString(obj, "getPower")
+
 	// Push the wrapper for statedb.GetNonce
 	vm.PushGoFunction(func(ctx *duktape.Context) int {
 		ctx.PushInt(int(dw.db.GetNonce(common.BytesToAddress(popSlice(ctx)))))
@@ -549,6 +557,7 @@ func (jst *Tracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost
 		jst.memoryWrapper.memory = memory
 		jst.contractWrapper.contract = contract
 		jst.dbWrapper.db = env.StateDB
+		jst.dbWrapper.number = env.BlockNumber
 
 		*jst.pcValue = uint(pc)
 		*jst.gasValue = uint(gas)
@@ -597,6 +606,16 @@ func (jst *Tracer) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, er
 	return nil
 }
 
+// CapturePower implements the PowerTracer interface to expose how the message
+// paid for its gas in the context.
+func (jst *Tracer) CapturePower(payment *vm.GasPayment) error {
+	jst.ctx["powerBefore"] = payment.PowerBefore
+	if payment.PowerAfter != nil {
+		jst.ctx["powerAfter"] = payment.PowerAfter
+	}
+	return nil
+}
+
 // GetResult calls the Javascript 'result' function and returns its value, or any accumulated error
 func (jst *Tracer) GetResult() (json.RawMessage, error) {
 	// Transform the context into a JavaScript object and inject into the state
@@ -17,6 +17,7 @@
 package tracers
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/json"
@@ -26,18 +27,19 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/common/hexutil"
 	"github.com/etherzero/go-etherzero/common/math"
 	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/core/vm"
 	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/rlp"
-	"github.com/etherzero/go-etherzero/tests"
 )
 
 // To generate a new callTracer test, copy paste the makeTest method below into
@@ -120,6 +122,30 @@ type callTracerTest struct {
 	Result  *callTrace    `json:"result"`
 }
 
+// makePreState creates a state database with the given accounts, crediting the
+// sender of the traced transaction with the power needed to pay for its gas.
+func makePreState(db ethdb.Database, accounts core.GenesisAlloc, from common.Address, tx *types.Transaction, number *big.Int) *state.StateDB {
+	sdb := state.NewDatabase(db)
+	statedb, _ := state.New(common.Hash{}, sdb)
+	for addr, a := range accounts {
+		statedb.SetCode(addr, a.Code)
+		statedb.SetNonce(addr, a.Nonce)
+		statedb.SetBalance(addr, a.Balance, number)
+		for k, v := range a.Storage {
+			statedb.SetState(addr, k, v)
+		}
+	}
+	// Power is only granted above a minimum balance, top it up if needed
+	if min := big.NewInt(1e16); statedb.GetBalance(from).Cmp(min) < 0 {
+		statedb.SetBalance(from, min, number)
+	}
+	statedb.SetPower(from, new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasPrice()))
+	// Commit and re-open to start with a clean state.
+	root, _ := statedb.Commit(false)
+	statedb, _ = state.New(root, sdb)
+	return statedb
+}
+
 func TestPrestateTracerCreate2(t *testing.T) {
 	unsigned_tx := types.NewTransaction(1, common.HexToAddress("0x00000000000000000000000000000000deadbeef"),
 		new(big.Int), 5000000, big.NewInt(1), []byte{})
@@ -167,13 +193,13 @@ func TestPrestateTracerCreate2(t *testing.T) {
 		Code:    []byte{},
 		Balance: big.NewInt(500000000000000),
 	}
-	statedb := tests.MakePreState(ethdb.NewMemDatabase(), alloc)
+	statedb := makePreState(ethdb.NewMemDatabase(), alloc, origin, tx, context.BlockNumber)
 	// Create the tracer, the EVM environment and run it
 	tracer, err := New("prestateTracer")
 	if err != nil {
 		t.Fatalf("failed to create call tracer: %v", err)
 	}
-	evm := vm.NewEVM(context, statedb, params.MainnetChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	evm := vm.NewEVM(context, statedb, params.AllEthashProtocolChanges, vm.Config{Debug: true, Tracer: tracer})
 
 	msg, err := tx.AsMessage(signer)
 	if err != nil {
@@ -240,7 +266,7 @@ func TestCallTracer(t *testing.T) {
 				GasLimit:    uint64(test.Context.GasLimit),
 				GasPrice:    tx.GasPrice(),
 			}
-			statedb := tests.MakePreState(ethdb.NewMemDatabase(), test.Genesis.Alloc)
+			statedb := makePreState(ethdb.NewMemDatabase(), test.Genesis.Alloc, origin, tx, context.BlockNumber)
 
 			// Create the tracer, the EVM environment and run it
 			tracer, err := New("callTracer")
@@ -273,3 +299,212 @@ func TestCallTracer(t *testing.T) {
 		})
 	}
 }
+
+// teeTracer feeds the same execution to multiple tracers.
+type teeTracer []vm.Tracer
+
+func (t teeTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	for _, tracer := range t {
+		tracer.CaptureStart(from, to, create, input, gas, value)
+	}
+	return nil
+}
+
+func (t teeTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	for _, tracer := range t {
+		tracer.CaptureState(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+	}
+	return nil
+}
+
+func (t teeTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	for _, tracer := range t {
+		tracer.CaptureFault(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+	}
+	return nil
+}
+
+func (t teeTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	for _, tracer := range t {
+		tracer.CaptureEnd(output, gasUsed, d, err)
+	}
+	return nil
+}
+
+// Iterates over all the input-output datasets in the tracer test harness and
+// checks that the native tracers produce the exact same output as their
+// JavaScript counterparts.
+func TestNativeTracers(t *testing.T) {
+	files, err := ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), "call_tracer_") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			// Call tracer test found, read if from disk
+			blob, err := ioutil.ReadFile(filepath.Join("testdata", file.Name()))
+			if err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			}
+			test := new(callTracerTest)
+			if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			// Configure a blockchain with the given prestate
+			tx := new(types.Transaction)
+			if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+				t.Fatalf("failed to parse testcase input: %v", err)
+			}
+			signer := types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
+			origin, _ := signer.Sender(tx)
+
+			context := vm.Context{
+				CanTransfer: core.CanTransfer,
+				Transfer:    core.Transfer,
+				Origin:      origin,
+				Coinbase:    test.Context.Miner,
+				BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+				Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+				Difficulty:  (*big.Int)(test.Context.Difficulty),
+				GasLimit:    uint64(test.Context.GasLimit),
+				GasPrice:    tx.GasPrice(),
+			}
+			statedb := makePreState(ethdb.NewMemDatabase(), test.Genesis.Alloc, origin, tx, context.BlockNumber)
+
+			// Run the JavaScript and native tracers side by side
+			var scripts, natives []ResultTracer
+			for _, name := range []string{"callTracer", "prestateTracer"} {
+				script, err := New(name)
+				if err != nil {
+					t.Fatalf("failed to create JavaScript %s: %v", name, err)
+				}
+				native, err := NewTracer(name)
+				if err != nil {
+					t.Fatalf("failed to create native %s: %v", name, err)
+				}
+				if _, ok := native.(*Tracer); ok {
+					t.Fatalf("native %s not found", name)
+				}
+				scripts, natives = append(scripts, script), append(natives, native)
+			}
+			tracer := teeTracer{scripts[0], scripts[1], natives[0], natives[1]}
+			evm := vm.NewEVM(context, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+
+			msg, err := tx.AsMessage(signer)
+			if err != nil {
+				t.Fatalf("failed to prepare transaction for tracing: %v", err)
+			}
+			st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+			if _, _, _, err = st.TransitionDb(); err != nil {
+				t.Fatalf("failed to execute transaction: %v", err)
+			}
+			// Retrieve the trace results and compare them byte by byte
+			for i := range scripts {
+				want, err := scripts[i].GetResult()
+				if err != nil {
+					t.Fatalf("failed to retrieve JavaScript trace result: %v", err)
+				}
+				have, err := natives[i].GetResult()
+				if err != nil {
+					t.Fatalf("failed to retrieve native trace result: %v", err)
+				}
+				if !bytes.Equal(have, want) {
+					t.Fatalf("trace mismatch: \nhave %s\nwant %s", have, want)
+				}
+			}
+		})
+	}
+}
+
+// Tests that the JavaScript and native tracers both report the beneficiary and
+// the refunded balance of self-destructing contracts.
+func TestSelfdestructTracers(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	var (
+		origin      = crypto.PubkeyToAddress(key.PublicKey)
+		destructed  = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		beneficiary = common.HexToAddress("0x00000000000000000000000000000000cafebabe")
+		signer      = types.HomesteadSigner{}
+	)
+	tx, _ := types.SignTx(types.NewTransaction(0, destructed, new(big.Int), 100000, big.NewInt(1), nil), signer, key)
+
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Origin:      origin,
+		BlockNumber: big.NewInt(1),
+		Time:        big.NewInt(1),
+		Difficulty:  big.NewInt(1),
+		GasLimit:    uint64(6000000),
+		GasPrice:    big.NewInt(1),
+	}
+	alloc := core.GenesisAlloc{
+		destructed: {
+			Code:    append(append([]byte{byte(vm.PUSH20)}, beneficiary.Bytes()...), byte(vm.SELFDESTRUCT)),
+			Balance: big.NewInt(1000),
+		},
+	}
+	statedb := makePreState(ethdb.NewMemDatabase(), alloc, origin, tx, context.BlockNumber)
+
+	var scripts, natives []ResultTracer
+	for _, name := range []string{"callTracer", "prestateTracer"} {
+		script, err := New(name)
+		if err != nil {
+			t.Fatalf("failed to create JavaScript %s: %v", name, err)
+		}
+		native, err := NewTracer(name)
+		if err != nil {
+			t.Fatalf("failed to create native %s: %v", name, err)
+		}
+		scripts, natives = append(scripts, script), append(natives, native)
+	}
+	tracer := teeTracer{scripts[0], scripts[1], natives[0], natives[1]}
+	evm := vm.NewEVM(context, statedb, params.AllEthashProtocolChanges, vm.Config{Debug: true, Tracer: tracer})
+
+	msg, err := tx.AsMessage(signer)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	if _, _, _, err = core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas())).TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	for i := range scripts {
+		want, err := scripts[i].GetResult()
+		if err != nil {
+			t.Fatalf("failed to retrieve JavaScript trace result: %v", err)
+		}
+		have, err := natives[i].GetResult()
+		if err != nil {
+			t.Fatalf("failed to retrieve native trace result: %v", err)
+		}
+		if !bytes.Equal(have, want) {
+			t.Fatalf("trace mismatch: \nhave %s\nwant %s", have, want)
+		}
+	}
+	// Ensure the self-destruct frame and the beneficiary's prestate are reported
+	res, _ := natives[0].GetResult()
+	call := new(callTrace)
+	if err := json.Unmarshal(res, call); err != nil {
+		t.Fatalf("failed to unmarshal call trace: %v", err)
+	}
+	if len(call.Calls) != 1 {
+		t.Fatalf("inner call count mismatch: have %d, want %d", len(call.Calls), 1)
+	}
+	if inner := call.Calls[0]; inner.Type != "SELFDESTRUCT" || inner.From != destructed || inner.To != beneficiary || inner.Value.ToInt().Int64() != 1000 {
+		t.Errorf("self-destruct frame mismatch: have %+v", inner)
+	}
+	res, _ = natives[1].GetResult()
+	prestate := make(map[common.Address]json.RawMessage)
+	if err := json.Unmarshal(res, &prestate); err != nil {
+		t.Fatalf("failed to unmarshal prestate trace: %v", err)
+	}
+	if _, ok := prestate[beneficiary]; !ok {
+		t.Errorf("beneficiary missing from prestate")
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/etherzero/go-etherzero/accounts/abi/bind"
+	"github.com/etherzero/go-etherzero/common/mclock"
+	"github.com/etherzero/go-etherzero/consensus/devote"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/metrics"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+)
+
+const (
+	witnessCycleWindow = 60 // Seconds before the end of a cycle from which the next cycle's candidates are connected
+	maxWitnessPeers    = 64 // Maximum number of masternodes kept connected as protected peers
+	blockWitnessSlots  = 3  // Number of upcoming slots whose witnesses receive new blocks first
+
+	witnessResolveBackoff    = 30 * time.Second // Delay before retrying a masternode that failed to resolve
+	maxWitnessResolveBackoff = 10 * time.Minute // Maximum delay, reached by doubling on repeated failures
+)
+
+// missedWitnessGauge counts the scheduled witnesses that couldn't be resolved
+// and are thus not kept connected for their slots.
+var missedWitnessGauge = metrics.NewRegisteredGauge("eth/witness/missed", nil)
+
+// resolveFailure tracks the backoff of a masternode that failed to resolve.
+type resolveFailure struct {
+	retry   mclock.AbsTime // Time before which the masternode isn't resolved again
+	backoff time.Duration  // Delay applied after the next failure
+}
+
+// witnessPeerSet tracks the masternodes kept connected as protected static peers
+// because they are scheduled to seal blocks soon.
+type witnessPeerSet struct {
+	nodes    map[string]*enode.Node     // Protected peers, keyed by masternode id
+	failures map[string]*resolveFailure // Masternodes that failed to resolve, keyed by id
+	clock    mclock.Clock
+}
+
+// newWitnessPeerSet creates a new, empty set of protected witness peers.
+func newWitnessPeerSet(clock mclock.Clock) *witnessPeerSet {
+	return &witnessPeerSet{
+		nodes:    make(map[string]*enode.Node),
+		failures: make(map[string]*resolveFailure),
+		clock:    clock,
+	}
+}
+
+// update replaces the protected peers with the masternodes of the given ids,
+// resolving newly scheduled ones with the given function. Masternodes failing
+// to resolve are retried with an exponential backoff. The nodes to connect and
+// to release are returned.
+func (s *witnessPeerSet) update(ids []string, resolve func(id string) *enode.Node) (added, removed []*enode.Node) {
+	var (
+		now    = s.clock.Now()
+		wanted = make(map[string]struct{}, len(ids))
+	)
+	for _, id := range ids {
+		if len(wanted) >= maxWitnessPeers {
+			break
+		}
+		wanted[id] = struct{}{}
+		if _, ok := s.nodes[id]; ok {
+			continue
+		}
+		failure := s.failures[id]
+		if failure != nil && now < failure.retry {
+			continue
+		}
+		node := resolve(id)
+		if node == nil {
+			if failure == nil {
+				failure = &resolveFailure{backoff: witnessResolveBackoff}
+				s.failures[id] = failure
+			}
+			failure.retry = now.Add(failure.backoff)
+			if failure.backoff *= 2; failure.backoff > maxWitnessResolveBackoff {
+				failure.backoff = maxWitnessResolveBackoff
+			}
+			continue
+		}
+		delete(s.failures, id)
+		s.nodes[id] = node
+		added = append(added, node)
+	}
+	for id, node := range s.nodes {
+		if _, ok := wanted[id]; !ok {
+			delete(s.nodes, id)
+			removed = append(removed, node)
+		}
+	}
+	for id := range s.failures {
+		if _, ok := wanted[id]; !ok {
+			delete(s.failures, id)
+		}
+	}
+	missedWitnessGauge.Update(int64(len(wanted) - len(s.nodes)))
+	return added, removed
+}
+
+// witnessPeerLoop keeps the masternodes scheduled as witnesses in the current
+// cycle, and the candidates of the next one as it draws near, connected as
+// static peers exempt from the peer limit. This gives block producers direct
+// links to each other, so blocks reach the next witness before its slot begins.
+func (self *MasternodeManager) witnessPeerLoop() {
+	engine, ok := self.eth.engine.(*devote.Devote)
+	if !ok {
+		return
+	}
+	headCh := make(chan core.ChainHeadEvent, 10)
+	headSub := self.eth.blockchain.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+
+	// Nodes configured by the user are never touched
+	configured := make(map[enode.ID]struct{})
+	for _, node := range self.srvr.StaticNodes {
+		configured[node.ID()] = struct{}{}
+	}
+	for _, node := range self.srvr.TrustedNodes {
+		configured[node.ID()] = struct{}{}
+	}
+	set := newWitnessPeerSet(mclock.System{})
+	for {
+		select {
+		case ev := <-headCh:
+			var ids []string
+			if atomic.LoadUint32(&self.IsMasternode) == 1 && atomic.LoadInt32(&self.syncing) == 0 {
+				witnesses, err := engine.Witnesses(ev.Block.Header(), witnessCycleWindow)
+				if err != nil {
+					log.Debug("Failed to retrieve witnesses", "number", ev.Block.Number(), "err", err)
+					continue
+				}
+				for _, id := range witnesses {
+					if id != self.ID {
+						ids = append(ids, id)
+					}
+				}
+			}
+			added, removed := set.update(ids, self.resolveMasternode)
+			for _, node := range added {
+				if _, ok := configured[node.ID()]; !ok {
+					self.srvr.AddTrustedPeer(node)
+					self.srvr.AddPeer(node)
+				}
+			}
+			for _, node := range removed {
+				if _, ok := configured[node.ID()]; !ok {
+					self.srvr.RemovePeer(node)
+					self.srvr.RemoveTrustedPeer(node)
+				}
+			}
+			if len(added) > 0 || len(removed) > 0 {
+				log.Debug("Updated witness peers", "added", len(added), "removed", len(removed), "total", len(set.nodes))
+			}
+
+		case <-headSub.Err():
+			return
+		}
+	}
+}
+
+// resolveMasternode retrieves the node record of a masternode from the contract.
+// The endpoint of the node is looked up through discovery when dialing it.
+func (self *MasternodeManager) resolveMasternode(id string) *enode.Node {
+	blob, err := hex.DecodeString(id)
+	if err != nil || len(blob) != 8 {
+		return nil
+	}
+	var x8 [8]byte
+	copy(x8[:], blob)
+
+	ctx, err := masternode.GetMasternodeContext(new(bind.CallOpts), self.contract, x8)
+	if err != nil || ctx.Node.ENode == nil {
+		log.Debug("Failed to resolve masternode", "id", id, "err", err)
+		return nil
+	}
+	return ctx.Node.ENode
+}
+
+// splitWitnessPeers separates the peers run by any of the given masternodes from
+// the rest.
+func splitWitnessPeers(peers []*peer, witnesses []string) (witness []*peer, rest []*peer) {
+	if len(witnesses) == 0 {
+		return nil, peers
+	}
+	ids := make(map[string]struct{}, len(witnesses))
+	for _, id := range witnesses {
+		ids[id] = struct{}{}
+	}
+	for _, p := range peers {
+		x8 := p.Node().X8()
+		if _, ok := ids[fmt.Sprintf("%x", x8[:])]; ok {
+			witness = append(witness, p)
+		} else {
+			rest = append(rest, p)
+		}
+	}
+	return witness, rest
+}
+
+// upcomingWitnesses returns the witnesses scheduled for the slots following the
+// given block, or nil if they can't be determined.
+func (pm *ProtocolManager) upcomingWitnesses(header *types.Header) []string {
+	engine, ok := pm.blockchain.Engine().(*devote.Devote)
+	if !ok || header.Protocol == nil {
+		return nil
+	}
+	witnesses, err := engine.Schedule(header, blockWitnessSlots)
+	if err != nil {
+		log.Trace("Failed to retrieve witness schedule", "number", header.Number, "err", err)
+		return nil
+	}
+	return witnesses
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common/mclock"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+)
+
+// Tests that the protected witness peers follow the scheduled masternodes,
+// resolving each of them only once.
+func TestWitnessPeerSet(t *testing.T) {
+	var (
+		set      = newWitnessPeerSet(new(mclock.Simulated))
+		nodes    = make(map[string]*enode.Node)
+		resolved = make(map[string]int)
+	)
+	resolve := func(id string) *enode.Node {
+		resolved[id]++
+		if id == "unknown" {
+			return nil
+		}
+		if _, ok := nodes[id]; !ok {
+			key, _ := crypto.GenerateKey()
+			nodes[id] = enode.NewV4(&key.PublicKey, nil, 0, 0)
+		}
+		return nodes[id]
+	}
+	added, removed := set.update([]string{"a", "b", "unknown"}, resolve)
+	if len(added) != 2 || len(removed) != 0 {
+		t.Fatalf("initial update mismatch: have %d added, %d removed, want 2 and 0", len(added), len(removed))
+	}
+	added, removed = set.update([]string{"b", "c"}, resolve)
+	if len(added) != 1 || added[0] != nodes["c"] {
+		t.Fatalf("added nodes mismatch: have %v, want [%v]", added, nodes["c"])
+	}
+	if len(removed) != 1 || removed[0] != nodes["a"] {
+		t.Fatalf("removed nodes mismatch: have %v, want [%v]", removed, nodes["a"])
+	}
+	if resolved["b"] != 1 {
+		t.Errorf("protected node resolved %d times, want once", resolved["b"])
+	}
+	if _, removed = set.update(nil, resolve); len(removed) != 2 || len(set.nodes) != 0 {
+		t.Fatalf("release mismatch: have %d removed, %d left, want 2 and 0", len(removed), len(set.nodes))
+	}
+}
+
+// Tests that masternodes failing to resolve are retried with an exponential
+// backoff.
+func TestWitnessPeerSetBackoff(t *testing.T) {
+	var (
+		clock    = new(mclock.Simulated)
+		set      = newWitnessPeerSet(clock)
+		resolved int
+		node     *enode.Node
+	)
+	resolve := func(id string) *enode.Node {
+		resolved++
+		return node
+	}
+	set.update([]string{"a"}, resolve)
+	set.update([]string{"a"}, resolve)
+	if resolved != 1 {
+		t.Fatalf("failed node resolved %d times during backoff, want once", resolved)
+	}
+	// The backoff doubles on every failure
+	clock.Run(witnessResolveBackoff)
+	set.update([]string{"a"}, resolve)
+	clock.Run(witnessResolveBackoff)
+	set.update([]string{"a"}, resolve)
+	if resolved != 2 {
+		t.Fatalf("failed node resolved %d times before the doubled backoff, want twice", resolved)
+	}
+	// Once resolvable the node is protected and its failures forgotten
+	key, _ := crypto.GenerateKey()
+	node = enode.NewV4(&key.PublicKey, nil, 0, 0)
+
+	clock.Run(witnessResolveBackoff)
+	if added, _ := set.update([]string{"a"}, resolve); len(added) != 1 || added[0] != node {
+		t.Fatalf("added nodes mismatch: have %v, want [%v]", added, node)
+	}
+	if len(set.failures) != 0 {
+		t.Errorf("failures not cleared: %v", set.failures)
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/bloombits"
+	"github.com/etherzero/go-etherzero/core/rawdb"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/event"
+	"github.com/etherzero/go-etherzero/internal/ethapi"
+	"github.com/etherzero/go-etherzero/params"
+	"github.com/etherzero/go-etherzero/rpc"
+)
+
+func TestBuildSchema(t *testing.T) {
+	// Make sure the schema can be parsed and matched up to the object model.
+	if _, err := New(nil); err != nil {
+		t.Errorf("Could not construct GraphQL handler: %v", err)
+	}
+}
+
+// testBackend is a chain of headers and receipts, serving just enough of the
+// API and filter backends to resolve block ranges and logs.
+type testBackend struct {
+	ethapi.Backend // Panic on anything not needed by the tests
+
+	db     ethdb.Database
+	head   *types.Header
+	logCap int
+}
+
+// newTestBackend creates a chain of the given length, with a single log emitted
+// in every block.
+func newTestBackend(blocks uint64, logCap int) *testBackend {
+	db := ethdb.NewMemDatabase()
+
+	var head *types.Header
+	for number := uint64(0); number < blocks; number++ {
+		receipt := types.NewReceipt(nil, false, 0)
+		receipt.Logs = []*types.Log{{
+			Address:     common.HexToAddress("0x1"),
+			Topics:      []common.Hash{},
+			BlockNumber: number,
+			TxHash:      common.BigToHash(new(big.Int).SetUint64(number + 1)),
+		}}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+		header := &types.Header{
+			Number:     new(big.Int).SetUint64(number),
+			Difficulty: big.NewInt(1),
+			Bloom:      receipt.Bloom,
+			Protocol:   &devotedb.DevoteProtocol{},
+		}
+		if head != nil {
+			header.ParentHash = head.Hash()
+		}
+		rawdb.WriteHeader(db, header)
+		rawdb.WriteCanonicalHash(db, header.Hash(), number)
+		rawdb.WriteReceipts(db, header.Hash(), number, types.Receipts{receipt})
+		head = header
+	}
+	return &testBackend{db: db, head: head, logCap: logCap}
+}
+
+func (b *testBackend) ChainDb() ethdb.Database {
+	return b.db
+}
+
+func (b *testBackend) CurrentBlock() *types.Block {
+	return types.NewBlockWithHeader(b.head)
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
+	if blockNr == rpc.LatestBlockNumber {
+		return b.head, nil
+	}
+	hash := rawdb.ReadCanonicalHash(b.db, uint64(blockNr))
+	return rawdb.ReadHeader(b.db, hash, uint64(blockNr)), nil
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	number := rawdb.ReadHeaderNumber(b.db, hash)
+	if number == nil {
+		return nil, nil
+	}
+	return rawdb.ReadHeader(b.db, hash, *number), nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	number := rawdb.ReadHeaderNumber(b.db, hash)
+	if number == nil {
+		return nil, nil
+	}
+	return rawdb.ReadReceipts(b.db, hash, *number), nil
+}
+
+func (b *testBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
+	receipts, _ := b.GetReceipts(ctx, hash)
+
+	logs := make([][]*types.Log, len(receipts))
+	for i, receipt := range receipts {
+		logs[i] = receipt.Logs
+	}
+	return logs, nil
+}
+
+func (b *testBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
+	return nil
+}
+
+func (b *testBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
+	return nil
+}
+
+func (b *testBackend) BloomStatus() (uint64, uint64) {
+	return params.BloomBitsBlocks, 0
+}
+
+func (b *testBackend) LogIndexStatus() (uint64, uint64) {
+	return params.BloomBitsBlocks, 0
+}
+
+func (b *testBackend) RPCLogCap() int {
+	return b.logCap
+}
+
+func (b *testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {}
+
+// Tests that block ranges are clamped to the chain head and that queries
+// spanning too many blocks are refused.
+func TestBlocksQuery(t *testing.T) {
+	service, err := New(newTestBackend(10, 0))
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	tests := []struct {
+		query string
+		count int  // Number of blocks returned
+		fail  bool // Whether the query is refused
+	}{
+		{`{ blocks(from: 2, to: 5) { number } }`, 4, false},
+		{`{ blocks(from: 2) { number } }`, 8, false},
+		{`{ blocks(from: 2, to: 1000000) { number } }`, 8, false},
+		{`{ blocks(from: 20, to: 30) { number } }`, 0, false},
+		{`{ blocks(from: 5, to: 2) { number } }`, 0, false},
+	}
+	for i, tt := range tests {
+		resp := service.Query(context.Background(), tt.query, nil)
+		if (len(resp.Errors) > 0) != tt.fail {
+			t.Errorf("test %d: error mismatch: have %v, want failure %v", i, resp.Errors, tt.fail)
+			continue
+		}
+		var result struct {
+			Blocks []struct{ Number string }
+		}
+		if err := json.Unmarshal(resp.Data, &result); err != nil {
+			t.Fatalf("test %d: failed to decode response: %v", i, err)
+		}
+		if len(result.Blocks) != tt.count {
+			t.Errorf("test %d: block count mismatch: have %d, want %d", i, len(result.Blocks), tt.count)
+		}
+	}
+	// Ranges within the chain but spanning too many blocks are refused
+	service, _ = New(newTestBackend(maxQueriedBlocks+10, 0))
+
+	query := fmt.Sprintf(`{ blocks(from: 0, to: %d) { number } }`, maxQueriedBlocks)
+	if resp := service.Query(context.Background(), query, nil); len(resp.Errors) == 0 {
+		t.Errorf("oversized block range accepted")
+	}
+	query = fmt.Sprintf(`{ blocks(from: 1, to: %d) { number } }`, maxQueriedBlocks)
+	if resp := service.Query(context.Background(), query, nil); len(resp.Errors) > 0 {
+		t.Errorf("maximum block range refused: %v", resp.Errors)
+	}
+}
+
+// Tests that log queries are refused if they match more logs than the global
+// log cap permits.
+func TestLogsQuery(t *testing.T) {
+	tests := []struct {
+		logCap int
+		query  string
+		count  int  // Number of logs returned
+		fail   bool // Whether the query is refused
+	}{
+		{0, `{ logs(filter: {fromBlock: 0, toBlock: 9}) { index } }`, 10, false},
+		{10, `{ logs(filter: {fromBlock: 0, toBlock: 9}) { index } }`, 10, false},
+		{5, `{ logs(filter: {fromBlock: 0, toBlock: 9}) { index } }`, 0, true},
+		{5, `{ logs(filter: {fromBlock: 3, toBlock: 7}) { index } }`, 5, false},
+		{5, `{ logs(filter: {fromBlock: 5}) { index } }`, 5, false},
+	}
+	for i, tt := range tests {
+		service, err := New(newTestBackend(10, tt.logCap))
+		if err != nil {
+			t.Fatalf("test %d: failed to create service: %v", i, err)
+		}
+		resp := service.Query(context.Background(), tt.query, nil)
+		if (len(resp.Errors) > 0) != tt.fail {
+			t.Errorf("test %d: error mismatch: have %v, want failure %v", i, resp.Errors, tt.fail)
+			continue
+		}
+		if tt.fail {
+			continue
+		}
+		var result struct {
+			Logs []struct{ Index int }
+		}
+		if err := json.Unmarshal(resp.Data, &result); err != nil {
+			t.Fatalf("test %d: failed to decode response: %v", i, err)
+		}
+		if len(result.Logs) != tt.count {
+			t.Errorf("test %d: log count mismatch: have %d, want %d", i, len(result.Logs), tt.count)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/etherzero/go-etherzero/internal/ethapi"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/p2p"
+	"github.com/etherzero/go-etherzero/rpc"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+// queryTimeout is the maximum time a single GraphQL request may execute for.
+const queryTimeout = 30 * time.Second
+
+// Service encapsulates a GraphQL service, served on the node's HTTP endpoint.
+type Service struct {
+	backend ethapi.Backend    // The backend that queries will operate on
+	schema  *graphqlgo.Schema // The parsed GraphQL schema
+}
+
+// New constructs a new GraphQL service instance, resolving queries against the
+// given API backend.
+func New(backend ethapi.Backend) (*Service, error) {
+	schema, err := graphqlgo.ParseSchema(schema, &Resolver{backend})
+	if err != nil {
+		return nil, err
+	}
+	return &Service{backend: backend, schema: schema}, nil
+}
+
+// Protocols returns the list of protocols exported by this service.
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs returns the list of APIs exported by this service.
+func (s *Service) APIs() []rpc.API { return nil }
+
+// HTTPHandlers implements node.HTTPService, serving the GraphQL queries on the
+// /graphql path of the node's HTTP endpoint.
+func (s *Service) HTTPHandlers() map[string]http.Handler {
+	return map[string]http.Handler{
+		"/graphql": http.TimeoutHandler(&relay.Handler{Schema: s.schema}, queryTimeout, "GraphQL query timed out"),
+	}
+}
+
+// Start is called after all services have been constructed and the networking
+// layer was also initialized to spawn any goroutines required by the service.
+func (s *Service) Start(server *p2p.Server) error {
+	log.Info("GraphQL service enabled", "path", "/graphql")
+	return nil
+}
+
+// Stop terminates all goroutines belonging to the service, blocking until they
+// are all terminated.
+func (s *Service) Stop() error {
+	return nil
+}
+
+// Query executes a GraphQL query directly against the service, without going
+// through the HTTP endpoint.
+func (s *Service) Query(ctx context.Context, query string, variables map[string]interface{}) *graphqlgo.Response {
+	return s.schema.Exec(ctx, query, "", variables)
+}
@@ -153,7 +153,7 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	for account, txs := range pending {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+			dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx)
 		}
 		content["pending"][account.Hex()] = dump
 	}
@@ -161,7 +161,7 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	for account, txs := range queue {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+			dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx)
 		}
 		content["queued"][account.Hex()] = dump
 	}
@@ -212,6 +212,80 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// RPCTxLifecycle represents the lifecycle stage of a transaction in the pool
+// that will serialize to the RPC representation.
+type RPCTxLifecycle struct {
+	Hash       common.Hash    `json:"hash"`
+	Status     string         `json:"status"`
+	ReplacedBy *common.Hash   `json:"replacedBy,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+	Timestamp  hexutil.Uint64 `json:"timestamp"`
+}
+
+// newRPCTxLifecycle returns a lifecycle record that will serialize to the RPC
+// representation.
+func newRPCTxLifecycle(record *core.TxLifecycleRecord) *RPCTxLifecycle {
+	result := &RPCTxLifecycle{
+		Hash:      record.Hash,
+		Status:    record.Status.String(),
+		Reason:    record.Reason,
+		Timestamp: hexutil.Uint64(record.Time.Unix()),
+	}
+	if record.Status == core.TxLifecycleReplaced {
+		replacedBy := record.ReplacedBy
+		result.ReplacedBy = &replacedBy
+	}
+	return result
+}
+
+// Lifecycle returns the last known lifecycle stage of a transaction that went
+// through the pool: queued, pending, replaced (along with the replacing
+// transaction), dropped (along with the reason) or included. Records of
+// transactions that left the pool are only retained for a limited time.
+func (s *PublicTxPoolAPI) Lifecycle(hash common.Hash) *RPCTxLifecycle {
+	if record := s.b.TxLifecycle(hash); record != nil {
+		return newRPCTxLifecycle(record)
+	}
+	return nil
+}
+
+// TxLifecycle creates a subscription that is triggered each time a transaction
+// moves between the lifecycle stages of the pool. If hashes are given, only the
+// changes of those transactions are reported.
+func (s *PublicTxPoolAPI) TxLifecycle(ctx context.Context, hashes []common.Hash) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	filter := make(map[common.Hash]struct{}, len(hashes))
+	for _, hash := range hashes {
+		filter[hash] = struct{}{}
+	}
+	go func() {
+		events := make(chan core.TxLifecycleEvent, 128)
+		sub := s.b.SubscribeTxLifecycleEvent(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				for i := range ev.Records {
+					if _, ok := filter[ev.Records[i].Hash]; len(filter) == 0 || ok {
+						notifier.Notify(rpcSub.ID, newRPCTxLifecycle(&ev.Records[i]))
+					}
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -442,7 +516,7 @@ func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args SendTxArgs
 		log.Warn("Failed transaction send attempt", "from", args.From, "to", args.To, "value", args.Value.ToInt(), "err", err)
 		return common.Hash{}, err
 	}
-	return submitTransaction(ctx, s.b, signed)
+	return SubmitTransaction(ctx, s.b, signed)
 }
 
 // SignTransaction will create a transaction from the given arguments and
@@ -545,13 +619,7 @@ func (s *PrivateAccountAPI) SignAndSendTransaction(ctx context.Context, args Sen
 }
 
 func (s *PublicBlockChainAPI) GetPower(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*big.Int, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
-	if state == nil || err != nil {
-		return nil, err
-	}
-	header, _ := s.b.HeaderByNumber(context.Background(), rpc.LatestBlockNumber) // latest header should always be available
-	b := state.GetPower(address, header.Number)
-	return b, state.Error()
+	return s.b.GetPower(ctx, address, blockNr)
 }
 
 // PublicBlockChainAPI provides an API to access the Ethereum blockchain.
@@ -757,13 +825,9 @@ type CallArgs struct {
 	Data     hexutil.Bytes   `json:"data"`
 }
 
-func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
-	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
-
-	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
-	if state == nil || err != nil {
-		return nil, 0, false, err
-	}
+// callMessage assembles the message of a call, filling in the sender, gas and gas
+// price defaults if none were set.
+func (s *PublicBlockChainAPI) callMessage(args CallArgs, defaultGas uint64) types.Message {
 	// Set sender address or use a default if none specified
 	addr := args.From
 	if addr == (common.Address{}) {
@@ -776,14 +840,23 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	// Set default gas & gas price if none were set
 	gas, gasPrice := uint64(args.Gas), args.GasPrice.ToInt()
 	if gas == 0 {
-		gas = math.MaxUint64 / 2
+		gas = defaultGas
 	}
 	if gasPrice.Sign() == 0 {
 		gasPrice = new(big.Int).SetUint64(defaultGasPrice)
 	}
+	return types.NewMessage(addr, args.To, 0, args.Value.ToInt(), gas, gasPrice, args.Data, false)
+}
+
+func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
+	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, 0, false, err
+	}
 	// Create new call message
-	msg := types.NewMessage(addr, args.To, 0, args.Value.ToInt(), gas, gasPrice, args.Data, false)
+	msg := s.callMessage(args, math.MaxUint64/2)
 
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
@@ -877,11 +950,14 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 
 // ExecutionResult groups all structured logs emitted by the EVM
 // while replaying a transaction in debug mode as well as transaction
-// execution status, the amount of gas used and the return value
+// execution status, the amount of gas used, the return value and
+// how the gas was paid for
 type ExecutionResult struct {
 	Gas         uint64         `json:"gas"`
 	Failed      bool           `json:"failed"`
 	ReturnValue string         `json:"returnValue"`
+	PowerBefore *hexutil.Big   `json:"powerBefore,omitempty"`
+	PowerAfter  *hexutil.Big   `json:"powerAfter,omitempty"`
 	StructLogs  []StructLogRes `json:"structLogs"`
 }
 
@@ -1055,8 +1131,8 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 	return result
 }
 
-// newRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
-func newRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
+// NewRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
+func NewRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
 	return newRPCTransaction(tx, common.Hash{}, 0, 0)
 }
 
@@ -1168,7 +1244,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, has
 	}
 	// No finalized transaction, try to retrieve it from the pool
 	if tx := s.b.GetPoolTransaction(hash); tx != nil {
-		return newRPCPendingTransaction(tx)
+		return NewRPCPendingTransaction(tx)
 	}
 	// Transaction unknown, return as such
 	return nil
@@ -1202,8 +1278,12 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if len(receipts) <= int(index) {
 		return nil, nil
 	}
-	receipt := receipts[index]
+	return RPCMarshalReceipt(receipts[index], tx, blockHash, blockNumber, index), nil
+}
 
+// RPCMarshalReceipt converts the receipt of a transaction included at the given
+// position of a block to its RPC representation.
+func RPCMarshalReceipt(receipt *types.Receipt, tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64) map[string]interface{} {
 	var signer types.Signer = types.FrontierSigner{}
 	if tx.Protected() {
 		signer = types.NewEIP155Signer(tx.ChainId())
@@ -1213,7 +1293,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	fields := map[string]interface{}{
 		"blockHash":         blockHash,
 		"blockNumber":       hexutil.Uint64(blockNumber),
-		"transactionHash":   hash,
+		"transactionHash":   tx.Hash(),
 		"transactionIndex":  hexutil.Uint64(index),
 		"from":              from,
 		"to":                tx.To(),
@@ -1241,7 +1321,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
-	return fields, nil
+	return fields
 }
 
 // sign is a helper function that signs a transaction with the private key of the given address.
@@ -1329,8 +1409,8 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	return types.NewTransaction(uint64(*args.Nonce), *args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
 }
 
-// submitTransaction is a helper function that submits tx to txPool and logs a message.
-func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
+// SubmitTransaction is a helper function that submits tx to txPool and logs a message.
+func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
 	if err := b.SendTx(ctx, tx); err != nil {
 		return common.Hash{}, err
 	}
@@ -1382,7 +1462,7 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 	if err != nil {
 		return common.Hash{}, err
 	}
-	return submitTransaction(ctx, s.b, signed)
+	return SubmitTransaction(ctx, s.b, signed)
 }
 
 // SendRawTransaction will add the signed transaction to the transaction pool.
@@ -1392,7 +1472,39 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
 		return common.Hash{}, err
 	}
-	return submitTransaction(ctx, s.b, tx)
+	return SubmitTransaction(ctx, s.b, tx)
+}
+
+// PrivateTxArgs represents the options of a private transaction submission.
+type PrivateTxArgs struct {
+	Expiry  *hexutil.Uint64 `json:"expiry"`  // Seconds the transaction is withheld from gossip
+	Publish bool            `json:"publish"` // Whether to publish the transaction on expiry, or drop it
+}
+
+// SendPrivateRawTransaction will add the signed transaction to the transaction
+// pool and hand it directly to the masternodes scheduled as the upcoming witnesses
+// instead of gossiping it. Once it expires, the transaction is either published
+// to the network or dropped.
+func (s *PublicTransactionPoolAPI) SendPrivateRawTransaction(ctx context.Context, encodedTx hexutil.Bytes, args *PrivateTxArgs) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	var (
+		expiry  time.Duration
+		publish bool
+	)
+	if args != nil {
+		if args.Expiry != nil {
+			expiry = time.Duration(*args.Expiry) * time.Second
+		}
+		publish = args.Publish
+	}
+	if err := s.b.SendPrivateTx(ctx, tx, expiry, publish); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Submitted private transaction", "fullhash", tx.Hash().Hex(), "recipient", tx.To())
+	return tx.Hash(), nil
 }
 
 // Sign calculates an ECDSA signature for:
@@ -1474,7 +1586,7 @@ func (s *PublicTransactionPoolAPI) PendingTransactions() ([]*RPCTransaction, err
 		}
 		from, _ := types.Sender(signer, tx)
 		if _, exists := accounts[from]; exists {
-			transactions = append(transactions, newRPCPendingTransaction(tx))
+			transactions = append(transactions, NewRPCPendingTransaction(tx))
 		}
 	}
 	return transactions, nil
@@ -0,0 +1,362 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/common/math"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/rpc"
+)
+
+const (
+	maxBundleCalls = 256              // Maximum number of calls simulated in a single bundle
+	bundleTimeout  = 10 * time.Second // Maximum amount of time a bundle simulation can run
+)
+
+// OverrideAccount is the set of account fields to override before simulating
+// a call. Unset fields keep their current values.
+type OverrideAccount struct {
+	Nonce     *hexutil.Uint64             `json:"nonce"`
+	Code      *hexutil.Bytes              `json:"code"`
+	Balance   *hexutil.Big                `json:"balance"`
+	Power     *hexutil.Big                `json:"power"`
+	StateDiff map[common.Hash]common.Hash `json:"stateDiff"`
+}
+
+// StateOverride is the set of accounts to override before simulating a call.
+type StateOverride map[common.Address]OverrideAccount
+
+// Apply overrides the given accounts in the state of the block with the given
+// number. Power is recomputed from the balance, so overriding the power of an
+// account holding less than the minimum balance is rejected.
+func (diff StateOverride) Apply(statedb *state.StateDB, number *big.Int) error {
+	for addr, account := range diff {
+		if account.Nonce != nil {
+			statedb.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Code != nil {
+			statedb.SetCode(addr, *account.Code)
+		}
+		if account.Balance != nil {
+			statedb.SetBalance(addr, (*big.Int)(account.Balance), number)
+		}
+		if account.Power != nil {
+			if balance := statedb.GetBalance(addr); balance.Cmp(state.MinPowerBalance) < 0 {
+				return fmt.Errorf("account %s: power override requires a balance of at least %v, have %v", addr.Hex(), state.MinPowerBalance, balance)
+			}
+			// Settle the regenerated power first, so the override isn't recomputed
+			statedb.SetBalance(addr, statedb.GetBalance(addr), number)
+			statedb.SetPower(addr, new(big.Int).Set((*big.Int)(account.Power)))
+		}
+		for key, value := range account.StateDiff {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	return nil
+}
+
+// BlockOverrides is the set of header fields to override in the block the
+// calls are simulated in.
+type BlockOverrides struct {
+	Number   *hexutil.Big    `json:"number"`
+	Time     *hexutil.Uint64 `json:"timestamp"`
+	Coinbase *common.Address `json:"coinbase"`
+	Witness  *string         `json:"witness"`
+}
+
+// Apply returns a copy of the header with the overrides applied.
+func (o *BlockOverrides) Apply(header *types.Header) *types.Header {
+	header = types.CopyHeader(header)
+	if o == nil {
+		return header
+	}
+	if o.Number != nil {
+		header.Number = new(big.Int).Set((*big.Int)(o.Number))
+	}
+	if o.Time != nil {
+		header.Time = uint64(*o.Time)
+	}
+	if o.Coinbase != nil {
+		header.Coinbase = *o.Coinbase
+	}
+	if o.Witness != nil {
+		header.Witness = *o.Witness
+	}
+	return header
+}
+
+// BundleCall is a single call of a simulated bundle, along with the state
+// overrides to apply right before executing it.
+type BundleCall struct {
+	CallArgs
+	StateOverrides StateOverride `json:"stateOverrides"`
+}
+
+// BundleCallResult is the outcome of a single simulated call of a bundle.
+type BundleCallResult struct {
+	ReturnValue hexutil.Bytes  `json:"returnValue"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Failed      bool           `json:"failed"`
+	Error       string         `json:"error,omitempty"`
+	Power       *hexutil.Big   `json:"power"` // Power of the sender after the call
+}
+
+// CallMany executes an ordered list of calls on top of the state of the given
+// block, each one seeing the state changes of the previous ones. Unlike Call,
+// the senders pay for gas out of their actual power, so calls may fail due to
+// running out of it, unless the power is overridden.
+func (s *PublicBlockChainAPI) CallMany(ctx context.Context, calls []BundleCall, blockNr rpc.BlockNumber, blockOverrides *BlockOverrides) ([]*BundleCallResult, error) {
+	defer func(start time.Time) {
+		log.Debug("Executing EVM call bundle finished", "calls", len(calls), "runtime", time.Since(start))
+	}(time.Now())
+
+	if len(calls) > maxBundleCalls {
+		return nil, fmt.Errorf("too many calls in bundle: %d > %d", len(calls), maxBundleCalls)
+	}
+	statedb, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	header = blockOverrides.Apply(header)
+
+	// Make sure the context is cancelled when the bundle has completed
+	ctx, cancel := context.WithTimeout(ctx, bundleTimeout)
+	defer cancel()
+
+	var (
+		results = make([]*BundleCallResult, 0, len(calls))
+		gp      = new(core.GasPool).AddGas(math.MaxUint64)
+	)
+	for i, call := range calls {
+		if err := call.StateOverrides.Apply(statedb, header.Number); err != nil {
+			return nil, fmt.Errorf("call %d: %v", i, err)
+		}
+
+		msg := s.callMessage(call.CallArgs, header.GasLimit)
+		result, err := s.applyBundleCall(ctx, msg, statedb, header, gp)
+		if err != nil {
+			return nil, fmt.Errorf("call %d: %v", i, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// applyBundleCall executes a single message of a bundle on top of the given state.
+func (s *PublicBlockChainAPI) applyBundleCall(ctx context.Context, msg types.Message, statedb *state.StateDB, header *types.Header, gp *core.GasPool) (*BundleCallResult, error) {
+	// Retain the sender's actual funds, the backend grants it unlimited ones.
+	// None of this is done by the call itself, so don't track it as such.
+	tracker := statedb.AccessTracker()
+	statedb.SetAccessTracker(nil)
+
+	var (
+		from    = msg.From()
+		balance = new(big.Int).Set(statedb.GetBalance(from))
+		power   = new(big.Int).Set(statedb.GetPower(from, header.Number))
+	)
+	evm, vmError, err := s.b.GetEVM(ctx, msg, statedb, header)
+	if err != nil {
+		return nil, err
+	}
+	statedb.SetBalance(from, balance, header.Number)
+	statedb.SetPower(from, power)
+	statedb.SetAccessTracker(tracker)
+
+	// Wait for the context to be done and cancel the evm. Even if the
+	// EVM has finished, cancelling may be done (repeatedly)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			evm.Cancel()
+		case <-done:
+		}
+	}()
+	res, gas, failed, err := core.ApplyMessage(evm, msg, gp)
+	if err := vmError(); err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", bundleTimeout)
+	}
+	result := &BundleCallResult{
+		ReturnValue: res,
+		GasUsed:     hexutil.Uint64(gas),
+		Failed:      failed || err != nil,
+		Power:       (*hexutil.Big)(new(big.Int).Set(statedb.GetPower(from, header.Number))),
+	}
+	// Consensus errors, such as running out of power, fail the call but not the bundle
+	if err != nil {
+		result.Error = err.Error()
+	}
+	// Finalise the state so the next call sees the changes of this one
+	statedb.Finalise(s.b.ChainConfig().IsEIP158(header.Number))
+	return result, nil
+}
+
+// AccessListEntry is an account accessed by a call, along with the storage
+// slots accessed.
+type AccessListEntry struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+// StateChange is the change of a single state field made by a call.
+type StateChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AccountChanges is the set of changes a call made to an account. Unchanged
+// fields are omitted.
+type AccountChanges struct {
+	Balance *StateChange                 `json:"balance,omitempty"`
+	Power   *StateChange                 `json:"power,omitempty"`
+	Nonce   *StateChange                 `json:"nonce,omitempty"`
+	Code    *StateChange                 `json:"code,omitempty"`
+	Storage map[common.Hash]*StateChange `json:"storage,omitempty"`
+}
+
+// CallInspection is the outcome of a call along with everything it accessed
+// and changed in the state.
+type CallInspection struct {
+	ReturnValue hexutil.Bytes                      `json:"returnValue"`
+	GasUsed     hexutil.Uint64                     `json:"gasUsed"`
+	Failed      bool                               `json:"failed"`
+	Error       string                             `json:"error,omitempty"`
+	Reads       []AccessListEntry                  `json:"reads"`
+	Writes      []AccessListEntry                  `json:"writes"`
+	StateDiff   map[common.Address]*AccountChanges `json:"stateDiff"`
+}
+
+// InspectCall executes the given call on top of the state of the given block,
+// returning the accounts and storage slots it read and wrote, along with the
+// resulting changes. As with CallMany, the sender pays for gas out of its
+// actual power, previewing the exact effects of signing the call.
+//
+// Gas estimation isn't instrumented, as its binary search executes the call
+// with many different allowances. Inspecting the call with the estimated gas
+// reports the effects of the transaction that would be signed.
+func (s *PublicBlockChainAPI) InspectCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (*CallInspection, error) {
+	defer func(start time.Time) {
+		log.Debug("Executing EVM call inspection finished", "runtime", time.Since(start))
+	}(time.Now())
+
+	statedb, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, bundleTimeout)
+	defer cancel()
+
+	// Execute the call with state access tracking enabled
+	var (
+		pre     = statedb.Copy()
+		tracker = state.NewAccessTracker()
+	)
+	statedb.SetAccessTracker(tracker)
+
+	msg := s.callMessage(args, header.GasLimit)
+	result, err := s.applyBundleCall(ctx, msg, statedb, header, new(core.GasPool).AddGas(math.MaxUint64))
+	if err != nil {
+		return nil, err
+	}
+	statedb.SetAccessTracker(nil)
+
+	// Assemble the accessed accounts and diff the written ones
+	inspection := &CallInspection{
+		ReturnValue: result.ReturnValue,
+		GasUsed:     result.GasUsed,
+		Failed:      result.Failed,
+		Error:       result.Error,
+		Reads:       accessList(tracker.Reads),
+		Writes:      accessList(tracker.Writes),
+		StateDiff:   make(map[common.Address]*AccountChanges),
+	}
+	for addr, slots := range tracker.Writes {
+		if changes := diffAccount(addr, slots, pre, statedb, header.Number); changes != nil {
+			inspection.StateDiff[addr] = changes
+		}
+	}
+	return inspection, nil
+}
+
+// accessList flattens a set of state accesses into a list sorted by address and
+// storage slot.
+func accessList(set map[common.Address]map[common.Hash]struct{}) []AccessListEntry {
+	list := make([]AccessListEntry, 0, len(set))
+	for addr, slots := range set {
+		entry := AccessListEntry{Address: addr, StorageKeys: make([]common.Hash, 0, len(slots))}
+		for key := range slots {
+			entry.StorageKeys = append(entry.StorageKeys, key)
+		}
+		sort.Slice(entry.StorageKeys, func(i, j int) bool {
+			return bytes.Compare(entry.StorageKeys[i][:], entry.StorageKeys[j][:]) < 0
+		})
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].Address[:], list[j].Address[:]) < 0
+	})
+	return list
+}
+
+// diffAccount compares an account and the given storage slots between two
+// states, returning nil if nothing changed.
+func diffAccount(addr common.Address, slots map[common.Hash]struct{}, pre, post *state.StateDB, number *big.Int) *AccountChanges {
+	var (
+		changes = new(AccountChanges)
+		changed bool
+	)
+	if from, to := pre.GetBalance(addr), post.GetBalance(addr); from.Cmp(to) != 0 {
+		changes.Balance, changed = &StateChange{From: (*hexutil.Big)(from), To: (*hexutil.Big)(to)}, true
+	}
+	if from, to := pre.GetPower(addr, number), post.GetPower(addr, number); from.Cmp(to) != 0 {
+		changes.Power, changed = &StateChange{From: (*hexutil.Big)(from), To: (*hexutil.Big)(to)}, true
+	}
+	if from, to := pre.GetNonce(addr), post.GetNonce(addr); from != to {
+		changes.Nonce, changed = &StateChange{From: hexutil.Uint64(from), To: hexutil.Uint64(to)}, true
+	}
+	if pre.GetCodeHash(addr) != post.GetCodeHash(addr) {
+		changes.Code, changed = &StateChange{From: hexutil.Bytes(pre.GetCode(addr)), To: hexutil.Bytes(post.GetCode(addr))}, true
+	}
+	for key := range slots {
+		if from, to := pre.GetState(addr, key), post.GetState(addr, key); from != to {
+			if changes.Storage == nil {
+				changes.Storage = make(map[common.Hash]*StateChange)
+			}
+			changes.Storage[key], changed = &StateChange{From: from, To: to}, true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return changes
+}
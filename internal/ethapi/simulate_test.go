@@ -0,0 +1,318 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/common/math"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+	"github.com/etherzero/go-etherzero/rpc"
+)
+
+var (
+	simSender  = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	simCounter = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	simNumber  = common.HexToAddress("0x3000000000000000000000000000000000000003")
+	simPoor    = common.HexToAddress("0x4000000000000000000000000000000000000004")
+
+	// simCounterCode increments storage slot 0 and returns its new value
+	simCounterCode = common.FromHex("0x6000546001018060005560005260206000f3")
+	// simNumberCode returns the number of the block it's executed in
+	simNumberCode = common.FromHex("0x4360005260206000f3")
+	// simCoinbaseCode returns the coinbase of the block it's executed in
+	simCoinbaseCode = common.FromHex("0x4160005260206000f3")
+
+	simPower = big.NewInt(1e9) // Power the sender starts out with
+)
+
+// simBackend is a minimal API backend serving calls on top of a single block.
+type simBackend struct {
+	Backend // Panic on anything not needed by the simulations
+
+	db     state.Database
+	root   common.Hash
+	header *types.Header
+}
+
+// newSimBackend creates a backend with a funded sender and a few contracts
+// deployed in the state of its only block.
+func newSimBackend(t *testing.T) *simBackend {
+	db := state.NewDatabase(ethdb.NewMemDatabase())
+	statedb, _ := state.New(common.Hash{}, db)
+
+	number := big.NewInt(1)
+	statedb.SetBalance(simSender, big.NewInt(1e18), number)
+	statedb.SetPower(simSender, simPower)
+	statedb.SetBalance(simPoor, big.NewInt(1e15), number)
+	statedb.SetCode(simCounter, simCounterCode)
+	statedb.SetCode(simNumber, simNumberCode)
+
+	root, err := statedb.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	return &simBackend{
+		db:   db,
+		root: root,
+		header: &types.Header{
+			Number:     number,
+			GasLimit:   params.GenesisGasLimit,
+			Difficulty: big.NewInt(1),
+			Time:       1000,
+		},
+	}
+}
+
+func (b *simBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	statedb, err := state.New(b.root, b.db)
+	return statedb, types.CopyHeader(b.header), err
+}
+
+func (b *simBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header) (*vm.EVM, func() error, error) {
+	state.SetBalance(msg.From(), math.MaxBig256, header.Number)
+	state.SetPower(msg.From(), math.MaxBig256)
+	vmError := func() error { return nil }
+
+	context := core.NewEVMContext(msg, header, nil, &header.Coinbase)
+	return vm.NewEVM(context, state, b.ChainConfig(), vm.Config{}), vmError, nil
+}
+
+func (b *simBackend) ChainConfig() *params.ChainConfig {
+	return params.AllEthashProtocolChanges
+}
+
+// simCall creates a bundle call from the sender to the given contract.
+func simCall(to common.Address) BundleCall {
+	return BundleCall{
+		CallArgs: CallArgs{
+			From:     simSender,
+			To:       &to,
+			Gas:      hexutil.Uint64(100000),
+			GasPrice: hexutil.Big(*big.NewInt(1)),
+		},
+	}
+}
+
+// Tests that the calls of a bundle see the changes of the previous ones, but
+// that none of them are persisted across bundles.
+func TestCallManySequencing(t *testing.T) {
+	api := NewPublicBlockChainAPI(newSimBackend(t))
+
+	for i := 0; i < 2; i++ {
+		results, err := api.CallMany(context.Background(), []BundleCall{simCall(simCounter), simCall(simCounter), simCall(simCounter)}, rpc.LatestBlockNumber, nil)
+		if err != nil {
+			t.Fatalf("bundle %d: failed to simulate: %v", i, err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("bundle %d: result count mismatch: have %d, want 3", i, len(results))
+		}
+		power := simPower
+		for j, result := range results {
+			if result.Failed {
+				t.Fatalf("bundle %d, call %d: failed: %s", i, j, result.Error)
+			}
+			if have, want := new(big.Int).SetBytes(result.ReturnValue), big.NewInt(int64(j+1)); have.Cmp(want) != 0 {
+				t.Errorf("bundle %d, call %d: counter mismatch: have %v, want %v", i, j, have, want)
+			}
+			// The sender pays for gas out of its own power, call after call
+			power = new(big.Int).Sub(power, new(big.Int).SetUint64(uint64(result.GasUsed)))
+			if have := result.Power.ToInt(); have.Cmp(power) != 0 {
+				t.Errorf("bundle %d, call %d: power mismatch: have %v, want %v", i, j, have, power)
+			}
+		}
+	}
+	// Bundles exceeding the call limit are rejected outright
+	calls := make([]BundleCall, maxBundleCalls+1)
+	for i := range calls {
+		calls[i] = simCall(simCounter)
+	}
+	if _, err := api.CallMany(context.Background(), calls, rpc.LatestBlockNumber, nil); err == nil {
+		t.Errorf("oversized bundle accepted")
+	}
+}
+
+// Tests that state overrides are applied right before their own call, on top
+// of the changes of the previous calls.
+func TestCallManyStateOverrides(t *testing.T) {
+	api := NewPublicBlockChainAPI(newSimBackend(t))
+
+	var (
+		code    = hexutil.Bytes(simNumberCode)
+		slot    = common.BigToHash(big.NewInt(41))
+		power   = hexutil.Big(*big.NewInt(5e8))
+		none    = hexutil.Big(*new(big.Int))
+		balance = hexutil.Big(*big.NewInt(1e16))
+		nonce   = hexutil.Uint64(7)
+	)
+	first := simCall(simCounter)
+	first.StateOverrides = StateOverride{
+		simCounter: {StateDiff: map[common.Hash]common.Hash{{}: slot}},
+		simSender:  {Power: &power},
+	}
+	second := simCall(simCounter)
+	second.StateOverrides = StateOverride{
+		simSender: {Power: &none},
+	}
+	third := simCall(simCounter)
+	third.StateOverrides = StateOverride{
+		simCounter: {Code: &code},
+		simSender:  {Power: &power, Nonce: &nonce},
+	}
+	results, err := api.CallMany(context.Background(), []BundleCall{first, second, third}, rpc.LatestBlockNumber, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	// The storage override is seen by the call, which pays out of the overridden power
+	if have := new(big.Int).SetBytes(results[0].ReturnValue); have.Int64() != 42 {
+		t.Errorf("storage override: counter mismatch: have %v, want 42", have)
+	}
+	if have, want := results[0].Power.ToInt(), new(big.Int).Sub(power.ToInt(), new(big.Int).SetUint64(uint64(results[0].GasUsed))); have.Cmp(want) != 0 {
+		t.Errorf("power override: power mismatch: have %v, want %v", have, want)
+	}
+	// Without power the call fails, but the bundle goes on
+	if !results[1].Failed || results[1].Error == "" {
+		t.Errorf("powerless call succeeded")
+	}
+	// The code override replaces the counter, keeping its storage
+	if have := new(big.Int).SetBytes(results[2].ReturnValue); have.Int64() != 1 {
+		t.Errorf("code override: block number mismatch: have %v, want 1", have)
+	}
+	// Power can't be granted to accounts below the minimum balance, unless
+	// their balance is overridden along with it
+	poor := simCall(simCounter)
+	poor.From = simPoor
+	poor.StateOverrides = StateOverride{simPoor: {Power: &power}}
+	if _, err := api.CallMany(context.Background(), []BundleCall{poor}, rpc.LatestBlockNumber, nil); err == nil {
+		t.Errorf("power override below the minimum balance accepted")
+	}
+	poor.StateOverrides = StateOverride{simPoor: {Power: &power, Balance: &balance}}
+	results, err = api.CallMany(context.Background(), []BundleCall{poor}, rpc.LatestBlockNumber, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate funded power override: %v", err)
+	}
+	if results[0].Failed {
+		t.Errorf("funded power override failed: %s", results[0].Error)
+	}
+}
+
+// Tests that block overrides are seen by all the calls of the bundle.
+func TestCallManyBlockOverrides(t *testing.T) {
+	api := NewPublicBlockChainAPI(newSimBackend(t))
+
+	var (
+		coinbase = common.HexToAddress("0x5000000000000000000000000000000000000005")
+		code     = hexutil.Bytes(simCoinbaseCode)
+		number   = hexutil.Big(*big.NewInt(1000))
+	)
+	coinbaseCall := simCall(simCounter)
+	coinbaseCall.StateOverrides = StateOverride{simCounter: {Code: &code}}
+
+	overrides := &BlockOverrides{Number: &number, Coinbase: &coinbase}
+	results, err := api.CallMany(context.Background(), []BundleCall{simCall(simNumber), coinbaseCall}, rpc.LatestBlockNumber, overrides)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	if have := new(big.Int).SetBytes(results[0].ReturnValue); have.Cmp(number.ToInt()) != 0 {
+		t.Errorf("block number mismatch: have %v, want %v", have, number.ToInt())
+	}
+	if have := common.BytesToAddress(results[1].ReturnValue); have != coinbase {
+		t.Errorf("coinbase mismatch: have %x, want %x", have, coinbase)
+	}
+}
+
+// Tests that call inspection reports the state accessed by the call along with
+// the changes it made, leaving out the funds granted by the backend.
+func TestInspectCall(t *testing.T) {
+	api := NewPublicBlockChainAPI(newSimBackend(t))
+
+	call := simCall(simCounter)
+	inspection, err := api.InspectCall(context.Background(), call.CallArgs, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to inspect call: %v", err)
+	}
+	if inspection.Failed {
+		t.Fatalf("call failed: %s", inspection.Error)
+	}
+	if have := new(big.Int).SetBytes(inspection.ReturnValue); have.Int64() != 1 {
+		t.Errorf("counter mismatch: have %v, want 1", have)
+	}
+	var (
+		slot  = common.Hash{}
+		reads = map[common.Address][]common.Hash{
+			simSender:  {},
+			simCounter: {slot},
+		}
+		writes = map[common.Address][]common.Hash{
+			simSender:  {},
+			simCounter: {slot},
+		}
+	)
+	for name, pair := range map[string]struct {
+		have []AccessListEntry
+		want map[common.Address][]common.Hash
+	}{"reads": {inspection.Reads, reads}, "writes": {inspection.Writes, writes}} {
+		if len(pair.have) != len(pair.want) {
+			t.Errorf("%s: account count mismatch: have %v, want %v", name, pair.have, pair.want)
+			continue
+		}
+		for _, entry := range pair.have {
+			want, ok := pair.want[entry.Address]
+			if !ok {
+				t.Errorf("%s: unexpected account %x", name, entry.Address)
+				continue
+			}
+			if len(entry.StorageKeys) != len(want) || (len(want) > 0 && entry.StorageKeys[0] != want[0]) {
+				t.Errorf("%s: account %x slots mismatch: have %v, want %v", name, entry.Address, entry.StorageKeys, want)
+			}
+		}
+	}
+	// The counter's storage and the sender's power and nonce changed, nothing else
+	if len(inspection.StateDiff) != 2 {
+		t.Fatalf("state diff account count mismatch: have %d, want 2", len(inspection.StateDiff))
+	}
+	counter := inspection.StateDiff[simCounter]
+	if counter == nil || counter.Balance != nil || counter.Power != nil || counter.Nonce != nil || counter.Code != nil {
+		t.Fatalf("counter diff mismatch: %+v", counter)
+	}
+	if change := counter.Storage[slot]; change == nil || change.From != (common.Hash{}) || change.To != common.BigToHash(common.Big1) {
+		t.Errorf("counter storage diff mismatch: %+v", change)
+	}
+	sender := inspection.StateDiff[simSender]
+	if sender == nil || sender.Balance != nil || sender.Code != nil || sender.Storage != nil {
+		t.Fatalf("sender diff mismatch: %+v", sender)
+	}
+	if sender.Nonce == nil || sender.Nonce.From != hexutil.Uint64(0) || sender.Nonce.To != hexutil.Uint64(1) {
+		t.Errorf("sender nonce diff mismatch: %+v", sender.Nonce)
+	}
+	spent := new(big.Int).Sub(simPower, new(big.Int).SetUint64(uint64(inspection.GasUsed)))
+	if sender.Power == nil || sender.Power.From.(*hexutil.Big).ToInt().Cmp(simPower) != 0 || sender.Power.To.(*hexutil.Big).ToInt().Cmp(spent) != 0 {
+		t.Errorf("sender power diff mismatch: %+v", sender.Power)
+	}
+}
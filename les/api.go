@@ -0,0 +1,58 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+)
+
+// PrivateLightServerAPI provides an API to manage the paid service a light
+// server offers to its priority clients.
+type PrivateLightServerAPI struct {
+	server *LesServer
+}
+
+// NewPrivateLightServerAPI creates a new light server API.
+func NewPrivateLightServerAPI(server *LesServer) *PrivateLightServerAPI {
+	return &PrivateLightServerAPI{server: server}
+}
+
+// Balance returns the service balance of a client.
+func (api *PrivateLightServerAPI) Balance(id enode.ID) (hexutil.Uint64, error) {
+	if api.server.priorityPool == nil {
+		return 0, errNoPriorityPool
+	}
+	return hexutil.Uint64(api.server.priorityPool.balance(id)), nil
+}
+
+// AddBalance tops up the service balance of a client and returns the new
+// balance.
+func (api *PrivateLightServerAPI) AddBalance(id enode.ID, amount hexutil.Uint64) (hexutil.Uint64, error) {
+	if api.server.priorityPool == nil {
+		return 0, errNoPriorityPool
+	}
+	return hexutil.Uint64(api.server.priorityPool.deposit(id, uint64(amount))), nil
+}
+
+// PriorityClients returns the number of priority clients connected.
+func (api *PrivateLightServerAPI) PriorityClients() (int, error) {
+	if api.server.priorityPool == nil {
+		return 0, errNoPriorityPool
+	}
+	return api.server.priorityPool.count(), nil
+}
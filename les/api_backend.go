@@ -18,7 +18,12 @@ package les
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"math/big"
+	"strings"
+	"time"
 
 	"github.com/etherzero/go-etherzero/accounts"
 	"github.com/etherzero/go-etherzero/common"
@@ -83,6 +88,16 @@ func (b *LesApiBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.
 	return light.NewState(ctx, header, b.eth.odr), header, nil
 }
 
+// GetPower returns the power of an account in the state of the given block, as
+// accumulated up to the current head, computed from the proven account.
+func (b *LesApiBackend) GetPower(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*big.Int, error) {
+	header, err := b.HeaderByNumber(ctx, blockNr)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	return light.GetPower(ctx, b.eth.odr, header, address, b.eth.blockchain.CurrentHeader().Number)
+}
+
 func (b *LesApiBackend) GetBlock(ctx context.Context, blockHash common.Hash) (*types.Block, error) {
 	return b.eth.blockchain.GetBlockByHash(ctx, blockHash)
 }
@@ -115,6 +130,12 @@ func (b *LesApiBackend) SendTx(ctx context.Context, signedTx *types.Transaction)
 	return b.eth.txPool.Add(ctx, signedTx)
 }
 
+// SendPrivateTx always fails, light clients can't reach the upcoming witnesses
+// directly.
+func (b *LesApiBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction, expiry time.Duration, publish bool) error {
+	return errors.New("private transactions not supported by light clients")
+}
+
 func (b *LesApiBackend) RemoveTx(txHash common.Hash) {
 	b.eth.txPool.RemoveTx(txHash)
 }
@@ -143,6 +164,16 @@ func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.S
 	return b.eth.txPool.SubscribeNewTxsEvent(ch)
 }
 
+// TxLifecycle returns the last known status of a local transaction, as tracked
+// by the light pool and reported by the servers relaying it.
+func (b *LesApiBackend) TxLifecycle(txHash common.Hash) *core.TxLifecycleRecord {
+	return b.eth.txPool.Lifecycle(txHash)
+}
+
+func (b *LesApiBackend) SubscribeTxLifecycleEvent(ch chan<- core.TxLifecycleEvent) event.Subscription {
+	return b.eth.txPool.SubscribeTxLifecycleEvent(ch)
+}
+
 func (b *LesApiBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return b.eth.blockchain.SubscribeChainEvent(ch)
 }
@@ -191,6 +222,10 @@ func (b *LesApiBackend) RPCGasCap() *big.Int {
 	return b.eth.config.RPCGasCap
 }
 
+func (b *LesApiBackend) RPCLogCap() int {
+	return b.eth.config.RPCLogCap
+}
+
 func (b *LesApiBackend) BloomStatus() (uint64, uint64) {
 	if b.eth.bloomIndexer == nil {
 		return 0, 0
@@ -199,6 +234,11 @@ func (b *LesApiBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocksClient, sections
 }
 
+// LogIndexStatus reports no log index, light clients don't maintain one.
+func (b *LesApiBackend) LogIndexStatus() (uint64, uint64) {
+	return params.BloomBitsBlocksClient, 0
+}
+
 func (b *LesApiBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	for i := 0; i < bloomFilterThreads; i++ {
 		go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, b.eth.bloomRequests)
@@ -221,9 +261,23 @@ func (b *LesApiBackend) Ns() int64 {
 	return 0
 }
 
-// GetInfo return related info in masternode contract
+// GetInfo return related info in masternode contract, read from the proven
+// storage of the contract at the current head
 func (b *LesApiBackend) GetInfo(nodeid string) string {
-	return ""
+	var id [8]byte
+	node, err := hex.DecodeString(strings.TrimPrefix(nodeid, "0x"))
+	if err != nil || len(node) != len(id) {
+		return ""
+	}
+	copy(id[:], node)
+
+	info, err := light.GetMasternodeInfo(context.Background(), b.eth.odr, b.eth.blockchain.CurrentHeader(), id)
+	if err != nil || info == nil {
+		return ""
+	}
+	return fmt.Sprintf("Id1: %v,Id2:%v,PreId:0x%v,NextId:0x%v,BlockNumber:%v,Account:%v,BlockOnlineAcc:%v,BloakLastPing:%v",
+		info.Id1.String(), info.Id2.String(), common.Bytes2Hex(info.PreId[:]), common.Bytes2Hex(info.NextId[:]), info.BlockNumber.String(), info.Account.String(),
+		info.BlockOnlineAcc.String(), info.BlockLastPing.String())
 }
 
 // GetEnode return related Enodeinfo in enodeinfo contract
@@ -0,0 +1,71 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/common/mclock"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/rpc"
+)
+
+// Tests that client balances can be topped up and queried through the API, and
+// that the API fails if paid service is disabled.
+func TestLightServerAPIBalance(t *testing.T) {
+	api := NewPrivateLightServerAPI(&LesServer{})
+	if _, err := api.AddBalance(enode.ID{1}, 100); err != errNoPriorityPool {
+		t.Fatalf("top up error mismatch: have %v, want %v", err, errNoPriorityPool)
+	}
+	if _, err := api.Balance(enode.ID{1}); err != errNoPriorityPool {
+		t.Fatalf("balance error mismatch: have %v, want %v", err, errNoPriorityPool)
+	}
+	pool := newPriorityClientPool(ethdb.NewMemDatabase(), 1, mclock.System{})
+	api = NewPrivateLightServerAPI(&LesServer{priorityPool: pool})
+
+	// Top up through RPC, the way les_addBalance is called by the operator
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("les", api); err != nil {
+		t.Fatalf("failed to register API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	var balance hexutil.Uint64
+	if err := client.Call(&balance, "les_addBalance", enode.ID{1}, hexutil.Uint64(100)); err != nil || balance != 100 {
+		t.Fatalf("top up mismatch: have %d, %v, want 100", balance, err)
+	}
+	if balance, err := api.AddBalance(enode.ID{1}, 50); err != nil || balance != 150 {
+		t.Fatalf("top up mismatch: have %d, %v, want 150", balance, err)
+	}
+	if balance, err := api.Balance(enode.ID{1}); err != nil || balance != 150 {
+		t.Fatalf("balance mismatch: have %d, %v, want 150", balance, err)
+	}
+	// A topped up client should be admitted with priority
+	if !pool.connect(enode.ID{1}, func() {}) {
+		t.Fatalf("client with balance rejected")
+	}
+	if count, err := api.PriorityClients(); err != nil || count != 1 {
+		t.Fatalf("priority client count mismatch: have %d, %v, want 1", count, err)
+	}
+	if balance, err := api.AddBalance(enode.ID{1}, 50); err != nil || balance != 200 {
+		t.Fatalf("top up mismatch: have %d, %v, want 200", balance, err)
+	}
+}
@@ -59,6 +59,7 @@ type LightEthereum struct {
 	txPool     *light.TxPool
 	blockchain *light.LightChain
 	serverPool *serverPool
+	ulc        *ulc
 	reqDist    *requestDistributor
 	retriever  *retrieveManager
 
@@ -123,10 +124,20 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 	if leth.blockchain, err = light.NewLightChain(leth.odr, leth.chainConfig, leth.engine); err != nil {
 		return nil, err
 	}
+	if len(config.ULCServers) > 0 {
+		if leth.ulc, err = newULC(config.ULCServers, config.ULCFraction); err != nil {
+			return nil, err
+		}
+		log.Warn("Ultra light client mode enabled", "servers", len(leth.ulc.trusted), "fraction", config.ULCFraction)
+	}
+
 	// Note: AddChildIndexer starts the update process for the child
 	leth.bloomIndexer.AddChildIndexer(leth.bloomTrieIndexer)
-	leth.chtIndexer.Start(leth.blockchain)
-	leth.bloomIndexer.Start(leth.blockchain)
+	if leth.ulc == nil {
+		// ultra light clients have no header chain to index
+		leth.chtIndexer.Start(leth.blockchain)
+		leth.bloomIndexer.Start(leth.blockchain)
+	}
 
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
@@ -136,7 +147,18 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 	}
 
 	leth.txPool = light.NewTxPool(leth.chainConfig, leth.blockchain, leth.relay)
-	if leth.protocolManager, err = NewProtocolManager(leth.chainConfig, light.DefaultClientIndexerConfig, true, config.NetworkId, leth.eventMux, leth.engine, leth.peers, leth.blockchain, nil, chainDb, leth.odr, leth.relay, leth.serverPool, quitSync, &leth.wg); err != nil {
+	leth.relay.setReporter(leth.txPool)
+
+	checkpoint := config.SyncCheckpoint
+	if checkpoint == nil {
+		checkpoint = params.SyncCheckpoints[genesisHash]
+	}
+	if checkpoint != nil {
+		if err := checkpoint.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if leth.protocolManager, err = NewProtocolManager(leth.chainConfig, light.DefaultClientIndexerConfig, true, config.NetworkId, leth.eventMux, leth.engine, leth.peers, leth.blockchain, nil, chainDb, leth.odr, leth.relay, leth.serverPool, leth.ulc, checkpoint, quitSync, &leth.wg); err != nil {
 		return nil, err
 	}
 	leth.ApiBackend = &LesApiBackend{leth, nil}
@@ -237,6 +259,13 @@ func (s *LightEthereum) Start(srvr *p2p.Server) error {
 	// clients are searching for the first advertised protocol in the list
 	protocolVersion := AdvertiseProtocolVersions[0]
 	s.serverPool.start(srvr, lesTopic(s.blockchain.Genesis().Hash(), protocolVersion))
+	if s.ulc != nil {
+		// keep connected to the trusted servers regardless of the peer limits
+		for _, node := range s.ulc.trusted {
+			srvr.AddTrustedPeer(node)
+			srvr.AddPeer(node)
+		}
+	}
 	s.protocolManager.Start(s.config.LightPeers)
 	return nil
 }
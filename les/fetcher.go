@@ -384,9 +384,46 @@ func (f *lightFetcher) peerHasBlock(p *peer, hash common.Hash, number uint64, ha
 	return rawdb.ReadCanonicalHash(f.pm.chainDb, fp.root.number) == fp.root.hash && rawdb.ReadCanonicalHash(f.pm.chainDb, number) == hash
 }
 
+// trustedTd returns the total difficulty of a head if it has been announced with
+// the same total difficulty by enough trusted servers to be accepted by an ultra
+// light client, nil otherwise.
+func (f *lightFetcher) trustedTd(hash common.Hash) *big.Int {
+	var (
+		tds   []*big.Int
+		votes []int
+	)
+	for p, fp := range f.peers {
+		if !p.isTrusted {
+			continue
+		}
+		n := fp.nodeByHash[hash]
+		if n == nil || n.td == nil {
+			continue
+		}
+		i := 0
+		for i < len(tds) && tds[i].Cmp(n.td) != 0 {
+			i++
+		}
+		if i == len(tds) {
+			tds, votes = append(tds, n.td), append(votes, 0)
+		}
+		votes[i]++
+	}
+	for i, td := range tds {
+		if f.pm.ulc.enough(votes[i]) {
+			return td
+		}
+	}
+	return nil
+}
+
 // requestAmount calculates the amount of headers to be downloaded starting
 // from a certain head backwards
 func (f *lightFetcher) requestAmount(p *peer, n *fetcherTreeNode) uint64 {
+	if f.pm.ulc != nil {
+		// ultra light clients skip the header chain, only the head is needed
+		return 1
+	}
 	amount := uint64(0)
 	nn := n
 	for nn != nil && !f.checkKnownNode(p, nn) {
@@ -419,13 +456,17 @@ func (f *lightFetcher) nextRequest() (*distReq, uint64, bool) {
 
 	for p, fp := range f.peers {
 		for hash, n := range fp.nodeByHash {
+			if f.pm.ulc != nil && f.trustedTd(hash) == nil {
+				// ultra light clients only fetch heads announced by enough trusted servers
+				continue
+			}
 			if !f.checkKnownNode(p, n) && !n.requested && (bestTd == nil || n.td.Cmp(bestTd) >= 0) {
 				amount := f.requestAmount(p, n)
 				if bestTd == nil || n.td.Cmp(bestTd) > 0 || amount < bestAmount {
 					bestHash = hash
 					bestAmount = amount
 					bestTd = n.td
-					bestSyncing = fp.bestConfirmed == nil || fp.root == nil || !f.checkKnownNode(p, fp.root)
+					bestSyncing = f.pm.ulc == nil && (fp.bestConfirmed == nil || fp.root == nil || !f.checkKnownNode(p, fp.root))
 				}
 			}
 		}
@@ -516,6 +557,17 @@ func (f *lightFetcher) processResponse(req fetchRequest, resp fetchResponse) boo
 		req.peer.Log().Debug("Response content mismatch", "requested", len(resp.headers), "reqfrom", resp.headers[0], "delivered", req.amount, "delfrom", req.hash)
 		return false
 	}
+	if f.pm.ulc != nil {
+		// the head has been vouched for by the trusted servers, accept it as is
+		td := f.trustedTd(req.hash)
+		if td == nil {
+			// trusted servers announcing it have dropped meanwhile, not the peer's fault
+			return true
+		}
+		f.chain.InsertTrustedHeader(resp.headers[0], td)
+		f.newHeaders(resp.headers, []*big.Int{td})
+		return true
+	}
 	headers := make([]*types.Header, req.amount)
 	for i, header := range resp.headers {
 		headers[int(req.amount)-1-i] = header
@@ -582,6 +634,10 @@ func (f *lightFetcher) checkAnnouncedHeaders(fp *fetcherPeerInfo, headers []*typ
 			td = f.chain.GetTd(hash, number)
 			header = f.chain.GetHeader(hash, number)
 			if header == nil || td == nil {
+				if f.pm.ulc != nil {
+					// ultra light clients don't have the ancestors of trusted heads
+					return true
+				}
 				log.Error("Missing parent of validated header", "hash", hash, "number", number)
 				return false
 			}
@@ -40,6 +40,7 @@ import (
 	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/p2p"
 	"github.com/etherzero/go-etherzero/p2p/discv5"
+	"github.com/etherzero/go-etherzero/p2p/protocols"
 	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/rlp"
 	"github.com/etherzero/go-etherzero/trie"
@@ -63,8 +64,19 @@ const (
 	disableClientRemovePeer = false
 )
 
+// protocolError is an error caused by a remote peer violating the protocol, as
+// opposed to a failure of the connection itself.
+type protocolError struct {
+	code errCode
+	text string
+}
+
+func (e *protocolError) Error() string {
+	return fmt.Sprintf("%v - %v", e.code, e.text)
+}
+
 func errResp(code errCode, format string, v ...interface{}) error {
-	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
+	return &protocolError{code: code, text: fmt.Sprintf(format, v...)}
 }
 
 type BlockChain interface {
@@ -86,6 +98,7 @@ type BlockChain interface {
 type txPool interface {
 	AddRemotes(txs []*types.Transaction) []error
 	Status(hashes []common.Hash) []core.TxStatus
+	Lifecycle(hash common.Hash) *core.TxLifecycleRecord
 }
 
 type ProtocolManager struct {
@@ -101,6 +114,7 @@ type ProtocolManager struct {
 	server      *LesServer
 	serverPool  *serverPool
 	clientPool  *freeClientPool
+	ulc         *ulc // ultra light client configuration, nil if not in ultra light mode
 	lesTopic    discv5.Topic
 	reqDist     *requestDistributor
 	retriever   *retrieveManager
@@ -124,7 +138,7 @@ type ProtocolManager struct {
 
 // NewProtocolManager returns a new ethereum sub protocol manager. The Ethereum sub protocol manages peers capable
 // with the ethereum network.
-func NewProtocolManager(chainConfig *params.ChainConfig, indexerConfig *light.IndexerConfig, lightSync bool, networkId uint64, mux *event.TypeMux, engine consensus.Engine, peers *peerSet, blockchain BlockChain, txpool txPool, chainDb ethdb.Database, odr *LesOdr, txrelay *LesTxRelay, serverPool *serverPool, quitSync chan struct{}, wg *sync.WaitGroup) (*ProtocolManager, error) {
+func NewProtocolManager(chainConfig *params.ChainConfig, indexerConfig *light.IndexerConfig, lightSync bool, networkId uint64, mux *event.TypeMux, engine consensus.Engine, peers *peerSet, blockchain BlockChain, txpool txPool, chainDb ethdb.Database, odr *LesOdr, txrelay *LesTxRelay, serverPool *serverPool, ulc *ulc, syncCheckpoint *params.SyncCheckpoint, quitSync chan struct{}, wg *sync.WaitGroup) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
 		lightSync:   lightSync,
@@ -138,6 +152,7 @@ func NewProtocolManager(chainConfig *params.ChainConfig, indexerConfig *light.In
 		txpool:      txpool,
 		txrelay:     txrelay,
 		serverPool:  serverPool,
+		ulc:         ulc,
 		peers:       peers,
 		newPeerCh:   make(chan *peer),
 		quitSync:    quitSync,
@@ -158,7 +173,7 @@ func NewProtocolManager(chainConfig *params.ChainConfig, indexerConfig *light.In
 		if cht, ok := params.TrustedCheckpoints[blockchain.Genesis().Hash()]; ok {
 			checkpoint = (cht.SectionIndex+1)*params.CHTFrequencyClient - 1
 		}
-		manager.downloader = downloader.New(downloader.LightSync, checkpoint, chainDb, manager.eventMux, nil, blockchain, removePeer)
+		manager.downloader = downloader.New(downloader.LightSync, checkpoint, syncCheckpoint, engine, chainDb, manager.eventMux, nil, blockchain, removePeer)
 		manager.peers.notify((*downloaderPeerNotify)(manager))
 		manager.fetcher = newLightFetcher(manager)
 	}
@@ -251,6 +266,25 @@ func (pm *ProtocolManager) handle(p *peer) error {
 
 	p.Log().Debug("Light Ethereum peer connected", "name", p.Name())
 
+	// Clients with a service balance are admitted with priority and higher capacity
+	var priority bool
+	if !pm.lightSync && pm.server != nil && pm.server.priorityPool != nil {
+		if priority = pm.server.priorityPool.connect(p.ID(), func() { go pm.removePeer(p.id) }); priority {
+			defer func() {
+				pm.server.priorityPool.disconnect(p.ID())
+				pm.clientPool.setConnectedLimit(pm.maxPeers - pm.server.priorityPool.count())
+			}()
+			pm.clientPool.setConnectedLimit(pm.maxPeers - pm.server.priorityPool.count())
+			p.fcParams = pm.server.priorityParams
+			p.accounting = protocols.NewPeer(p.Peer, p.rw, nil)
+		}
+	}
+
+	// Ultra light clients only accept heads from signed announcements of trusted servers
+	if pm.lightSync && pm.ulc != nil {
+		p.isTrusted = pm.ulc.isTrusted(p.ID())
+	}
+
 	// Execute the LES handshake
 	var (
 		genesis = pm.blockchain.Genesis()
@@ -264,7 +298,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		return err
 	}
 
-	if !pm.lightSync && !p.Peer.Info().Network.Trusted {
+	if !pm.lightSync && !priority && !p.Peer.Info().Network.Trusted {
 		addr, ok := p.RemoteAddr().(*net.TCPAddr)
 		// test peer address is not a tcp address, don't use client pool if can not typecast
 		if ok {
@@ -322,6 +356,9 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	for {
 		if err := pm.handleMsg(p); err != nil {
 			p.Log().Debug("Light Ethereum message handling failed", "err", err)
+			if _, ok := err.(*protocolError); ok {
+				p.Peer.AdjustScore(p2p.ScoreInvalid, err.Error())
+			}
 			return err
 		}
 	}
@@ -350,14 +387,18 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		bufValue, _ := p.fcClient.AcceptRequest()
 		cost := costs.baseCost + reqCnt*costs.reqCost
-		if cost > pm.server.defParams.BufLimit {
-			cost = pm.server.defParams.BufLimit
+		if cost > p.fcParams.BufLimit {
+			cost = p.fcParams.BufLimit
 		}
 		if cost > bufValue {
-			recharge := time.Duration((cost - bufValue) * 1000000 / pm.server.defParams.MinRecharge)
+			recharge := time.Duration((cost - bufValue) * 1000000 / p.fcParams.MinRecharge)
 			p.Log().Error("Request came too early", "recharge", common.PrettyDuration(recharge))
 			return true
 		}
+		// Charge priority clients, kicking them out if their balance runs out
+		if p.accounting != nil {
+			pm.server.accounting.Receive(p.accounting, msg.Size, &servedRequest{cost: cost})
+		}
 		return false
 	}
 
@@ -1106,6 +1147,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 
 		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		if pm.txrelay != nil {
+			pm.txrelay.deliverStatus(p, resp.ReqID, resp.Status)
+		}
 
 	default:
 		p.Log().Trace("Received unknown message", "code", msg.Code)
@@ -1176,6 +1220,9 @@ func (pm *ProtocolManager) txStatus(hashes []common.Hash) []txStatus {
 			if block, number, index := rawdb.ReadTxLookupEntry(pm.chainDb, hashes[i]); block != (common.Hash{}) {
 				stats[i].Status = core.TxStatusIncluded
 				stats[i].Lookup = &rawdb.TxLookupEntry{BlockHash: block, BlockIndex: number, Index: index}
+			} else if record := pm.txpool.Lifecycle(hashes[i]); record != nil && record.Status == core.TxLifecycleDropped {
+				// Let the client know why the pool dropped the transaction
+				stats[i].Error = record.Reason
 			}
 		}
 	}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/core/rawdb"
+	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/ethdb"
@@ -62,6 +63,8 @@ func LesRequest(req light.OdrRequest) LesOdrRequest {
 		return (*ReceiptsRequest)(r)
 	case *light.TrieRequest:
 		return (*TrieRequest)(r)
+	case *light.AccountRequest:
+		return (*AccountRequest)(r)
 	case *light.CodeRequest:
 		return (*CodeRequest)(r)
 	case *light.ChtRequest:
@@ -256,6 +259,108 @@ func (r *TrieRequest) Validate(db ethdb.Database, msg *Msg) error {
 	}
 }
 
+// AccountRequest is the ODR request type for an account and a set of its
+// storage slots, retrieved in a single batch of merkle proofs
+type AccountRequest light.AccountRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *AccountRequest) GetCost(peer *peer) uint64 {
+	switch peer.version {
+	case lpv1:
+		return peer.GetRequestCost(GetProofsV1Msg, 1+len(r.Slots))
+	case lpv2:
+		return peer.GetRequestCost(GetProofsV2Msg, 1+len(r.Slots))
+	default:
+		panic(nil)
+	}
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *AccountRequest) CanSend(peer *peer) bool {
+	return peer.HasBlock(r.Id.BlockHash, r.Id.BlockNumber, true)
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *AccountRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting account proof", "root", r.Id.Root, "address", r.Address, "slots", len(r.Slots))
+
+	accKey := crypto.Keccak256(r.Address[:])
+	reqs := []ProofReq{{BHash: r.Id.BlockHash, Key: accKey}}
+	for _, slot := range r.Slots {
+		reqs = append(reqs, ProofReq{
+			BHash:  r.Id.BlockHash,
+			AccKey: accKey,
+			Key:    crypto.Keccak256(slot[:]),
+		})
+	}
+	return peer.RequestProofs(reqID, r.GetCost(peer), reqs)
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *AccountRequest) Validate(db ethdb.Database, msg *Msg) error {
+	log.Debug("Validating account proof", "root", r.Id.Root, "address", r.Address)
+
+	// Collect all the proofs into a single node set
+	var nodeSet *light.NodeSet
+	switch msg.MsgType {
+	case MsgProofsV1:
+		// Storage proofs of missing accounts are omitted by the server
+		proofs := msg.Obj.([]light.NodeList)
+		if len(proofs) == 0 || len(proofs) > 1+len(r.Slots) {
+			return errInvalidEntryCount
+		}
+		nodeSet = light.NewNodeSet()
+		for _, proof := range proofs {
+			proof.Store(nodeSet)
+		}
+	case MsgProofsV2:
+		nodeSet = msg.Obj.(light.NodeList).NodeSet()
+	default:
+		return errInvalidMessageType
+	}
+	reads := &readTraceDB{db: nodeSet}
+
+	// Verify the account against the state root
+	blob, _, err := trie.VerifyProof(r.Id.Root, crypto.Keccak256(r.Address[:]), reads)
+	if err != nil {
+		return fmt.Errorf("merkle proof verification failed: %v", err)
+	}
+	storage := make([]common.Hash, len(r.Slots))
+	if blob == nil {
+		// Missing accounts have empty storage, no further proofs expected
+		r.Account = nil
+	} else {
+		account := new(state.Account)
+		if err := rlp.DecodeBytes(blob, account); err != nil {
+			return err
+		}
+		// Verify the requested slots against the account's storage root
+		for i, slot := range r.Slots {
+			value, _, err := trie.VerifyProof(account.Root, crypto.Keccak256(slot[:]), reads)
+			if err != nil {
+				return fmt.Errorf("merkle proof verification failed: %v", err)
+			}
+			if value != nil {
+				_, content, _, err := rlp.Split(value)
+				if err != nil {
+					return err
+				}
+				storage[i] = common.BytesToHash(content)
+			}
+		}
+		r.Account = account
+	}
+	// check if all nodes have been read by VerifyProof
+	if len(reads.reads) != nodeSet.KeyCount() {
+		return errUselessNodes
+	}
+	r.Storage, r.Proof = storage, nodeSet
+	return nil
+}
+
 type CodeReq struct {
 	BHash  common.Hash
 	AccKey []byte
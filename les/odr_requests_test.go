@@ -0,0 +1,126 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/light"
+)
+
+// accountProofs proves an account and the given storage slots of it from the
+// state, the way a server answers an account request.
+func accountProofs(t *testing.T, sdb state.Database, root common.Hash, addr common.Address, slots []common.Hash) []light.NodeList {
+	tr, err := sdb.OpenTrie(root)
+	if err != nil {
+		t.Fatalf("failed to open state trie: %v", err)
+	}
+	proof := light.NewNodeSet()
+	tr.Prove(crypto.Keccak256(addr[:]), 0, proof)
+	proofs := []light.NodeList{proof.NodeList()}
+
+	st, _ := state.New(root, sdb)
+	if !st.Exist(addr) {
+		return proofs
+	}
+	str, err := sdb.OpenStorageTrie(crypto.Keccak256Hash(addr[:]), st.StorageTrie(addr).Hash())
+	if err != nil {
+		t.Fatalf("failed to open storage trie: %v", err)
+	}
+	for _, slot := range slots {
+		proof := light.NewNodeSet()
+		str.Prove(crypto.Keccak256(slot[:]), 0, proof)
+		proofs = append(proofs, proof.NodeList())
+	}
+	return proofs
+}
+
+// mergeProofs collects a batch of proofs into a single node list, the way they
+// are served to lpv2 clients.
+func mergeProofs(proofs []light.NodeList) light.NodeList {
+	nodes := light.NewNodeSet()
+	for _, proof := range proofs {
+		proof.Store(nodes)
+	}
+	return nodes.NodeList()
+}
+
+// Tests that account requests are validated against the state root, both for
+// existing accounts with storage and for missing ones.
+func TestAccountRequestValidate(t *testing.T) {
+	var (
+		sdb     = state.NewDatabase(ethdb.NewMemDatabase())
+		st, _   = state.New(common.Hash{}, sdb)
+		addr    = common.Address{1}
+		missing = common.Address{2}
+		slots   = []common.Hash{{1}, {2}}
+	)
+	st.SetBalance(addr, big.NewInt(1000), big.NewInt(1))
+	st.SetState(addr, slots[0], common.Hash{0xff})
+	root, _ := st.Commit(false)
+	sdb.TrieDB().Commit(root, false)
+
+	id := light.StateTrieID(&types.Header{Root: root, Number: big.NewInt(1)})
+
+	// Existing accounts should be retrieved along with their storage, in both formats
+	proofs := accountProofs(t, sdb, root, addr, slots)
+	for _, msg := range []*Msg{{MsgType: MsgProofsV1, Obj: proofs}, {MsgType: MsgProofsV2, Obj: mergeProofs(proofs)}} {
+		req := &AccountRequest{Id: id, Address: addr, Slots: slots}
+		if err := req.Validate(nil, msg); err != nil {
+			t.Fatalf("type %d: failed to validate account: %v", msg.MsgType, err)
+		}
+		if req.Account == nil || req.Account.Balance.Cmp(big.NewInt(1000)) != 0 {
+			t.Errorf("type %d: account mismatch: have %v, want balance 1000", msg.MsgType, req.Account)
+		}
+		if req.Storage[0] != (common.Hash{0xff}) || req.Storage[1] != (common.Hash{}) {
+			t.Errorf("type %d: storage mismatch: have %x, want [ff.. 00..]", msg.MsgType, req.Storage)
+		}
+	}
+	// Missing accounts should be proven absent, with empty storage
+	proofs = accountProofs(t, sdb, root, missing, slots)
+	req := &AccountRequest{Id: id, Address: missing, Slots: slots}
+	if err := req.Validate(nil, &Msg{MsgType: MsgProofsV1, Obj: proofs}); err != nil {
+		t.Fatalf("failed to validate missing account: %v", err)
+	}
+	if req.Account != nil || len(req.Storage) != len(slots) || req.Storage[0] != (common.Hash{}) {
+		t.Errorf("missing account mismatch: have %v, %x", req.Account, req.Storage)
+	}
+	// Proofs of a different account, missing storage or with extra nodes should be rejected
+	proofs = accountProofs(t, sdb, root, addr, slots)
+	req = &AccountRequest{Id: id, Address: missing, Slots: slots}
+	if err := req.Validate(nil, &Msg{MsgType: MsgProofsV2, Obj: mergeProofs(proofs)}); err == nil {
+		t.Errorf("proof of another account accepted")
+	}
+	req = &AccountRequest{Id: id, Address: addr, Slots: slots}
+	if err := req.Validate(nil, &Msg{MsgType: MsgProofsV1, Obj: proofs[:1]}); err == nil {
+		t.Errorf("proof without storage accepted")
+	}
+	req = &AccountRequest{Id: id, Address: addr, Slots: slots}
+	if err := req.Validate(nil, &Msg{MsgType: MsgProofsV2, Obj: append(mergeProofs(proofs), []byte{0xde, 0xad})}); err != errUselessNodes {
+		t.Errorf("proof with extra nodes: have %v, want %v", err, errUselessNodes)
+	}
+	req = &AccountRequest{Id: id, Address: addr, Slots: slots}
+	if err := req.Validate(nil, &Msg{MsgType: MsgProofsV1, Obj: append(proofs, proofs[0])}); err != errInvalidEntryCount {
+		t.Errorf("proof with extra entries: have %v, want %v", err, errInvalidEntryCount)
+	}
+}
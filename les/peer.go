@@ -30,6 +30,7 @@ import (
 	"github.com/etherzero/go-etherzero/les/flowcontrol"
 	"github.com/etherzero/go-etherzero/light"
 	"github.com/etherzero/go-etherzero/p2p"
+	"github.com/etherzero/go-etherzero/p2p/protocols"
 	"github.com/etherzero/go-etherzero/rlp"
 )
 
@@ -62,6 +63,7 @@ type peer struct {
 	network uint64 // Network ID being on
 
 	announceType, requestAnnounceType uint64
+	isTrusted                         bool // trusted server of an ultra light client
 
 	id string
 
@@ -78,7 +80,10 @@ type peer struct {
 	fcClient       *flowcontrol.ClientNode // nil if the peer is server only
 	fcServer       *flowcontrol.ServerNode // nil if the peer is client only
 	fcServerParams *flowcontrol.ServerParams
+	fcParams       *flowcontrol.ServerParams // flow control parameters granted to the peer if it is a client
 	fcCosts        requestCostTable
+
+	accounting *protocols.Peer // accounting handle of priority clients, nil otherwise
 }
 
 func newPeer(version int, network uint64, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
@@ -444,13 +449,19 @@ func (p *peer) Handshake(td *big.Int, head common.Hash, headNum uint64, genesis
 		send = send.add("serveChainSince", uint64(0))
 		send = send.add("serveStateSince", uint64(0))
 		send = send.add("txRelay", nil)
-		send = send.add("flowControl/BL", server.defParams.BufLimit)
-		send = send.add("flowControl/MRR", server.defParams.MinRecharge)
+		if p.fcParams == nil {
+			p.fcParams = server.defParams
+		}
+		send = send.add("flowControl/BL", p.fcParams.BufLimit)
+		send = send.add("flowControl/MRR", p.fcParams.MinRecharge)
 		list := server.fcCostStats.getCurrentList()
 		send = send.add("flowControl/MRC", list)
 		p.fcCosts = list.decode()
 	} else {
-		p.requestAnnounceType = announceTypeSimple // set to default until "very light" client mode is implemented
+		p.requestAnnounceType = announceTypeSimple
+		if p.isTrusted {
+			p.requestAnnounceType = announceTypeSigned
+		}
 		send = send.add("announceType", p.requestAnnounceType)
 	}
 	recvList, err := p.sendReceiveHandshake(send)
@@ -499,7 +510,7 @@ func (p *peer) Handshake(td *big.Int, head common.Hash, headNum uint64, genesis
 		if recv.get("announceType", &p.announceType) != nil {
 			p.announceType = announceTypeSimple
 		}
-		p.fcClient = flowcontrol.NewClientNode(server.fcManager, server.defParams)
+		p.fcClient = flowcontrol.NewClientNode(server.fcManager, p.fcParams)
 	} else {
 		if recv.get("serveChainSince", nil) != nil {
 			return errResp(ErrUselessPeer, "peer cannot serve chain")
@@ -0,0 +1,331 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common/mclock"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/p2p/protocols"
+)
+
+const (
+	// priorityCapacityFactor is the multiplier applied to the default flow control
+	// parameters for clients with a positive service balance.
+	priorityCapacityFactor = 10
+
+	// balanceStoreInterval is the maximum time the charged balances of connected
+	// clients are kept in memory only, bounding what a crash can lose.
+	balanceStoreInterval = time.Minute
+)
+
+var (
+	balancePrefix = []byte("lesBalance-") // balancePrefix + node id -> service balance
+
+	errInsufficientBalance = errors.New("insufficient service balance")
+	errNoPriorityPool      = errors.New("paid light service is not enabled")
+)
+
+// priorityClientPool keeps the prepaid service balances of LES clients and
+// admits the ones with a positive balance as priority clients, identified by
+// their node keys. It implements protocols.Balance, so the requests served to
+// priority clients are charged through the generic p2p accounting. A client is
+// kicked out once its balance runs out, after which it can only reconnect as
+// a free client.
+type priorityClientPool struct {
+	db     ethdb.Database
+	lock   sync.Mutex
+	clock  mclock.Clock
+	stored mclock.AbsTime // last time the balances of the connected clients were stored
+	closed bool
+
+	connectedLimit int
+	balances       map[enode.ID]uint64 // cached balances of the connected clients
+	connected      map[enode.ID]func() // disconnect callbacks of the connected clients
+}
+
+// newPriorityClientPool creates a new priority client pool
+func newPriorityClientPool(db ethdb.Database, connectedLimit int, clock mclock.Clock) *priorityClientPool {
+	return &priorityClientPool{
+		db:             db,
+		clock:          clock,
+		stored:         clock.Now(),
+		connectedLimit: connectedLimit,
+		balances:       make(map[enode.ID]uint64),
+		connected:      make(map[enode.ID]func()),
+	}
+}
+
+func (pool *priorityClientPool) stop() {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	for id := range pool.connected {
+		pool.store(id)
+	}
+	pool.closed = true
+}
+
+// connect admits a client as a priority one if it has a positive balance and
+// the pool isn't full. If the connection was rejected, there is no need to call
+// disconnect.
+//
+// Note: the disconnectFn callback should not block.
+func (pool *priorityClientPool) connect(id enode.ID, disconnectFn func()) bool {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if pool.closed {
+		return false
+	}
+	if _, ok := pool.connected[id]; ok {
+		log.Debug("Priority client already connected", "id", id)
+		return false
+	}
+	if len(pool.connected) >= pool.connectedLimit {
+		return false
+	}
+	balance := pool.load(id)
+	if balance == 0 {
+		return false
+	}
+	pool.balances[id] = balance
+	pool.connected[id] = disconnectFn
+	log.Debug("Priority client accepted", "id", id, "balance", balance)
+	return true
+}
+
+// disconnect should be called when the connection of a priority client is
+// terminated, storing its remaining balance.
+func (pool *priorityClientPool) disconnect(id enode.ID) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if _, ok := pool.connected[id]; !ok {
+		return
+	}
+	pool.store(id)
+	delete(pool.connected, id)
+	delete(pool.balances, id)
+	log.Debug("Priority client disconnected", "id", id)
+}
+
+// count returns the number of connected priority clients.
+func (pool *priorityClientPool) count() int {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	return len(pool.connected)
+}
+
+// Add charges a priority client for a served request (implementation of
+// protocols.Balance). A positive amount credits the local node, so it is
+// deducted from the balance of the client. If the balance runs out, the
+// client is kicked out.
+func (pool *priorityClientPool) Add(amount int64, peer *protocols.Peer) error {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	id := peer.ID()
+	disconnectFn, ok := pool.connected[id]
+	if !ok {
+		return nil
+	}
+	balance := pool.balances[id]
+	if amount < 0 {
+		pool.balances[id] = balance + uint64(-amount)
+		pool.storeDue()
+		return nil
+	}
+	if uint64(amount) < balance {
+		pool.balances[id] = balance - uint64(amount)
+		pool.storeDue()
+		return nil
+	}
+	pool.balances[id] = 0
+	pool.store(id)
+	delete(pool.connected, id)
+	delete(pool.balances, id)
+	log.Debug("Priority client kicked out", "id", id)
+	disconnectFn()
+	return errInsufficientBalance
+}
+
+// balance returns the current service balance of a client.
+func (pool *priorityClientPool) balance(id enode.ID) uint64 {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if _, ok := pool.connected[id]; ok {
+		return pool.balances[id]
+	}
+	return pool.load(id)
+}
+
+// deposit adds service units to the balance of a client and returns the new
+// balance. The client gets priority service on its next connection.
+func (pool *priorityClientPool) deposit(id enode.ID, amount uint64) uint64 {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if _, ok := pool.connected[id]; ok {
+		pool.balances[id] += amount
+		pool.store(id)
+		return pool.balances[id]
+	}
+	balance := pool.load(id) + amount
+	pool.write(id, balance)
+	return balance
+}
+
+// load retrieves the stored balance of a client.
+func (pool *priorityClientPool) load(id enode.ID) uint64 {
+	enc, err := pool.db.Get(append(balancePrefix, id[:]...))
+	if err != nil || len(enc) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(enc)
+}
+
+// storeDue saves the cached balances of all connected clients if they weren't
+// stored for balanceStoreInterval.
+func (pool *priorityClientPool) storeDue() {
+	now := pool.clock.Now()
+	if time.Duration(now-pool.stored) < balanceStoreInterval {
+		return
+	}
+	for id := range pool.connected {
+		pool.store(id)
+	}
+	pool.stored = now
+}
+
+// store saves the cached balance of a connected client.
+func (pool *priorityClientPool) store(id enode.ID) {
+	pool.write(id, pool.balances[id])
+}
+
+func (pool *priorityClientPool) write(id enode.ID, balance uint64) {
+	key := append(balancePrefix, id[:]...)
+	if balance == 0 {
+		pool.db.Delete(key)
+		return
+	}
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], balance)
+	pool.db.Put(key, enc[:])
+}
+
+// servedRequest is the accounting record of a request served to a priority
+// client, priced at its flow control cost.
+type servedRequest struct {
+	cost uint64
+}
+
+// lesPrices prices served requests for the p2p accounting (implementation of
+// protocols.Prices). Clients pay for the requests they send.
+type lesPrices struct{}
+
+func (lesPrices) Price(msg interface{}) *protocols.Price {
+	if req, ok := msg.(*servedRequest); ok {
+		return &protocols.Price{Value: req.cost, Payer: protocols.Sender}
+	}
+	return nil
+}
+
+// depositConfirmations is the number of blocks a deposit transaction needs to
+// be buried under before the client's balance is credited.
+const depositConfirmations = 12
+
+var lastDepositKey = []byte("lesLastDeposit") // number of the last block scanned for deposits
+
+// depositLoop credits the on-chain deposits of clients, sent as transfers to
+// the deposit address carrying the node id of the client as data, once they
+// are confirmed. Each wei paid buys 1/LightPrice units of service.
+func (s *LesServer) depositLoop() {
+	headCh := make(chan core.ChainHeadEvent, 10)
+	headSub := s.blockchain.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+
+	var (
+		price = new(big.Int).SetUint64(s.config.LightPrice)
+		next  uint64
+	)
+	if enc, err := s.chainDb.Get(lastDepositKey); err == nil && len(enc) == 8 {
+		next = binary.BigEndian.Uint64(enc) + 1
+	}
+	for {
+		select {
+		case ev := <-headCh:
+			head := ev.Block.NumberU64()
+			if head < depositConfirmations {
+				continue
+			}
+			last := head - depositConfirmations
+			if next == 0 {
+				next = last
+			}
+			for ; next <= last; next++ {
+				block := s.blockchain.GetBlockByNumber(next)
+				if block == nil {
+					break
+				}
+				s.creditDeposits(block, price)
+			}
+			var enc [8]byte
+			binary.BigEndian.PutUint64(enc[:], next-1)
+			s.chainDb.Put(lastDepositKey, enc[:])
+
+		case <-s.quitSync:
+			return
+		}
+	}
+}
+
+// creditDeposits credits the successful deposits of a block.
+func (s *LesServer) creditDeposits(block *types.Block, price *big.Int) {
+	var receipts types.Receipts
+	for i, tx := range block.Transactions() {
+		if to := tx.To(); to == nil || *to != *s.depositAddress || len(tx.Data()) != len(enode.ID{}) {
+			continue
+		}
+		if receipts == nil {
+			receipts = s.blockchain.GetReceiptsByHash(block.Hash())
+		}
+		if i >= len(receipts) || receipts[i].Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+		amount := new(big.Int).Div(tx.Value(), price)
+		if amount.Sign() == 0 || !amount.IsUint64() {
+			continue
+		}
+		var id enode.ID
+		copy(id[:], tx.Data())
+
+		balance := s.priorityPool.deposit(id, amount.Uint64())
+		log.Info("Credited light service deposit", "id", id, "amount", amount, "balance", balance, "tx", tx.Hash())
+	}
+}
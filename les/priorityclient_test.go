@@ -0,0 +1,257 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/mclock"
+	"github.com/etherzero/go-etherzero/consensus/ethash"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/eth"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/p2p"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/p2p/protocols"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that clients are admitted with priority only while they have a balance,
+// are charged for served requests and kicked out once the balance runs out.
+func TestPriorityClientPool(t *testing.T) {
+	var (
+		db         = ethdb.NewMemDatabase()
+		pool       = newPriorityClientPool(db, 1, mclock.System{})
+		accounting = protocols.NewAccounting(pool, lesPrices{})
+
+		paid, other = enode.ID{1}, enode.ID{2}
+		kicked      = make(chan enode.ID, 2)
+	)
+	disconnFn := func(id enode.ID) func() {
+		return func() { kicked <- id }
+	}
+	if pool.connect(paid, disconnFn(paid)) {
+		t.Fatalf("client without balance accepted")
+	}
+	if balance := pool.deposit(paid, 100); balance != 100 {
+		t.Fatalf("balance mismatch: have %d, want 100", balance)
+	}
+	pool.deposit(other, 100)
+	if !pool.connect(paid, disconnFn(paid)) {
+		t.Fatalf("client with balance rejected")
+	}
+	if pool.connect(other, disconnFn(other)) {
+		t.Fatalf("client accepted over connected limit")
+	}
+	// Charge the client through the accounting, draining its balance
+	peer := protocols.NewPeer(p2p.NewPeer(paid, "paid", nil), nil, nil)
+	if err := accounting.Receive(peer, 0, &servedRequest{cost: 60}); err != nil {
+		t.Fatalf("failed to charge client: %v", err)
+	}
+	if balance := pool.balance(paid); balance != 40 {
+		t.Fatalf("balance mismatch: have %d, want 40", balance)
+	}
+	if err := accounting.Receive(peer, 0, &servedRequest{cost: 60}); err != errInsufficientBalance {
+		t.Fatalf("overdraft error mismatch: have %v, want %v", err, errInsufficientBalance)
+	}
+	select {
+	case id := <-kicked:
+		if id != paid {
+			t.Fatalf("kicked client mismatch: have %x, want %x", id, paid)
+		}
+	default:
+		t.Fatalf("client with exhausted balance not kicked")
+	}
+	if balance := pool.balance(paid); balance != 0 {
+		t.Fatalf("balance mismatch: have %d, want 0", balance)
+	}
+	// Balances are persisted across restarts
+	if !pool.connect(other, disconnFn(other)) {
+		t.Fatalf("client with balance rejected")
+	}
+	pool.stop()
+
+	pool = newPriorityClientPool(db, 1, mclock.System{})
+	if balance := pool.balance(other); balance != 100 {
+		t.Fatalf("persisted balance mismatch: have %d, want 100", balance)
+	}
+}
+
+// Tests that the charged balances of connected clients are stored periodically,
+// not only when they disconnect.
+func TestPriorityClientPoolStore(t *testing.T) {
+	var (
+		clock      mclock.Simulated
+		db         = ethdb.NewMemDatabase()
+		pool       = newPriorityClientPool(db, 1, &clock)
+		accounting = protocols.NewAccounting(pool, lesPrices{})
+
+		id   = enode.ID{1}
+		peer = protocols.NewPeer(p2p.NewPeer(id, "paid", nil), nil, nil)
+	)
+	pool.deposit(id, 100)
+	if !pool.connect(id, func() {}) {
+		t.Fatalf("client with balance rejected")
+	}
+	// Charges within the store interval are only kept in memory
+	if err := accounting.Receive(peer, 0, &servedRequest{cost: 10}); err != nil {
+		t.Fatalf("failed to charge client: %v", err)
+	}
+	if balance := newPriorityClientPool(db, 1, &clock).balance(id); balance != 100 {
+		t.Fatalf("stored balance mismatch: have %d, want 100", balance)
+	}
+	// The first charge after the interval stores the balance
+	clock.Run(balanceStoreInterval)
+	if err := accounting.Receive(peer, 0, &servedRequest{cost: 10}); err != nil {
+		t.Fatalf("failed to charge client: %v", err)
+	}
+	if balance := newPriorityClientPool(db, 1, &clock).balance(id); balance != 80 {
+		t.Fatalf("stored balance mismatch: have %d, want 80", balance)
+	}
+}
+
+// Tests that lowering the connected limit of the free client pool kicks out
+// clients, and a zero limit rejects all of them.
+func TestFreeClientPoolLimitChange(t *testing.T) {
+	var (
+		clock  mclock.Simulated
+		pool   = newFreeClientPool(ethdb.NewMemDatabase(), 3, 10000, &clock)
+		kicked = make(chan int, 3)
+	)
+	for i := 0; i < 3; i++ {
+		i := i
+		if !pool.connect(fmt.Sprintf("test peer #%d", i), func() { kicked <- i }) {
+			t.Fatalf("Test peer #%d rejected", i)
+		}
+	}
+	pool.setConnectedLimit(1)
+	if len(kicked) != 2 {
+		t.Fatalf("kicked peer count mismatch: have %d, want 2", len(kicked))
+	}
+	pool.setConnectedLimit(0)
+	if len(kicked) != 3 {
+		t.Fatalf("kicked peer count mismatch: have %d, want 3", len(kicked))
+	}
+	if pool.connect("test peer #3", func() {}) {
+		t.Fatalf("Peer accepted with zero connected limit")
+	}
+}
+
+// newDepositTestServer creates a light server crediting the deposits sent to its
+// address on a fresh chain.
+func newDepositTestServer(t *testing.T) (*LesServer, *core.Genesis, *ethdb.MemDatabase) {
+	var (
+		db    = ethdb.NewMemDatabase()
+		gspec = &core.Genesis{
+			Config: params.TestChainConfig,
+			Number: params.GenesisBlockNumber,
+			Alloc:  core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}},
+		}
+	)
+	gspec.MustCommit(db)
+	blockchain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	server := &LesServer{
+		lesCommons:     lesCommons{config: &eth.Config{LightPrice: 10}, chainDb: db},
+		quitSync:       make(chan struct{}),
+		priorityPool:   newPriorityClientPool(db, 1, mclock.System{}),
+		blockchain:     blockchain,
+		depositAddress: &common.Address{0xde},
+	}
+	return server, gspec, db
+}
+
+// addDeposit adds a transfer of the given value to the deposit address of the
+// server to the block, carrying the given data.
+func addDeposit(server *LesServer, block *core.BlockGen, value int64, data []byte) {
+	tx := types.NewTransaction(block.TxNonce(testBankAddress), *server.depositAddress, big.NewInt(value), 100000, nil, data)
+	tx, _ = types.SignTx(tx, types.HomesteadSigner{}, testBankKey)
+	block.AddTx(tx)
+}
+
+// Tests that only well formed deposits are credited to the clients they name.
+func TestCreditDeposits(t *testing.T) {
+	server, gspec, db := newDepositTestServer(t)
+
+	var (
+		paid, other = enode.ID{1}, enode.ID{2}
+		genesis     = server.blockchain.Genesis()
+	)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 1, func(i int, block *core.BlockGen) {
+		addDeposit(server, block, 1000, paid[:])    // credited 100 units
+		addDeposit(server, block, 255, paid[:])     // credited 25 units, the rest is lost
+		addDeposit(server, block, 5, other[:])      // below the price of a unit
+		addDeposit(server, block, 1000, other[:31]) // malformed node id
+	})
+	if _, err := server.blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	server.creditDeposits(blocks[0], new(big.Int).SetUint64(server.config.LightPrice))
+
+	if balance := server.priorityPool.balance(paid); balance != 125 {
+		t.Errorf("paid balance mismatch: have %d, want 125", balance)
+	}
+	if balance := server.priorityPool.balance(other); balance != 0 {
+		t.Errorf("other balance mismatch: have %d, want 0", balance)
+	}
+}
+
+// Tests that the deposit loop credits deposits once they are confirmed, resuming
+// from the last block scanned.
+func TestDepositLoop(t *testing.T) {
+	server, gspec, db := newDepositTestServer(t)
+
+	// Mark the genesis as scanned and start crediting deposits
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], params.GenesisBlockNumber)
+	db.Put(lastDepositKey, enc[:])
+
+	go server.depositLoop()
+	defer close(server.quitSync)
+
+	// Deposit in the first block and the last one, which stays unconfirmed
+	id := enode.ID{1}
+	blocks, _ := core.GenerateChain(gspec.Config, server.blockchain.Genesis(), ethash.NewFaker(), db, depositConfirmations+2, func(i int, block *core.BlockGen) {
+		if i == 0 || i == depositConfirmations+1 {
+			addDeposit(server, block, 1000, id[:])
+		}
+	})
+	if _, err := server.blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	want := params.GenesisBlockNumber + 2
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if enc, err := db.Get(lastDepositKey); err == nil && binary.BigEndian.Uint64(enc) == want {
+			break
+		}
+		if time.Since(start) > time.Second {
+			t.Fatalf("deposits not scanned up to block %d", want)
+		}
+	}
+	if balance := server.priorityPool.balance(id); balance != 100 {
+		t.Errorf("balance mismatch: have %d, want 100", balance)
+	}
+}
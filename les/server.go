@@ -24,6 +24,7 @@ import (
 	"sync"
 
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/mclock"
 	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/rawdb"
 	"github.com/etherzero/go-etherzero/core/types"
@@ -34,8 +35,10 @@ import (
 	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/p2p"
 	"github.com/etherzero/go-etherzero/p2p/discv5"
+	"github.com/etherzero/go-etherzero/p2p/protocols"
 	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/rlp"
+	"github.com/etherzero/go-etherzero/rpc"
 )
 
 type LesServer struct {
@@ -47,11 +50,17 @@ type LesServer struct {
 	lesTopics   []discv5.Topic
 	privateKey  *ecdsa.PrivateKey
 	quitSync    chan struct{}
+
+	priorityPool   *priorityClientPool
+	priorityParams *flowcontrol.ServerParams
+	accounting     *protocols.Accounting
+	blockchain     *core.BlockChain
+	depositAddress *common.Address // nil if on-chain deposits are disabled
 }
 
 func NewLesServer(eth *eth.Ethereum, config *eth.Config) (*LesServer, error) {
 	quitSync := make(chan struct{})
-	pm, err := NewProtocolManager(eth.BlockChain().Config(), light.DefaultServerIndexerConfig, false, config.NetworkId, eth.EventMux(), eth.Engine(), newPeerSet(), eth.BlockChain(), eth.TxPool(), eth.ChainDb(), nil, nil, nil, quitSync, new(sync.WaitGroup))
+	pm, err := NewProtocolManager(eth.BlockChain().Config(), light.DefaultServerIndexerConfig, false, config.NetworkId, eth.EventMux(), eth.Engine(), newPeerSet(), eth.BlockChain(), eth.TxPool(), eth.ChainDb(), nil, nil, nil, nil, nil, quitSync, new(sync.WaitGroup))
 	if err != nil {
 		return nil, err
 	}
@@ -104,9 +113,36 @@ func NewLesServer(eth *eth.Ethereum, config *eth.Config) (*LesServer, error) {
 	}
 	srv.fcManager = flowcontrol.NewClientManager(uint64(config.LightServ), 10, 1000000000)
 	srv.fcCostStats = newCostStats(eth.ChainDb())
+
+	srv.priorityParams = &flowcontrol.ServerParams{
+		BufLimit:    srv.defParams.BufLimit * priorityCapacityFactor,
+		MinRecharge: srv.defParams.MinRecharge * priorityCapacityFactor,
+	}
+	srv.priorityPool = newPriorityClientPool(eth.ChainDb(), config.LightPeers, mclock.System{})
+	srv.accounting = protocols.NewAccounting(srv.priorityPool, lesPrices{})
+	srv.blockchain = eth.BlockChain()
+	if config.LightPrice > 0 {
+		if etherbase, err := eth.Etherbase(); err == nil {
+			srv.depositAddress = &etherbase
+		} else {
+			logger.Warn("Light service deposits disabled", "err", err)
+		}
+	}
 	return srv, nil
 }
 
+// APIs returns the collection of RPC services the light server offers.
+func (s *LesServer) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPrivateLightServerAPI(s),
+			Public:    false,
+		},
+	}
+}
+
 func (s *LesServer) Protocols() []p2p.Protocol {
 	return s.makeProtocols(ServerProtocolVersions)
 }
@@ -128,6 +164,9 @@ func (s *LesServer) Start(srvr *p2p.Server) {
 	}
 	s.privateKey = srvr.PrivateKey
 	s.protocolManager.blockLoop()
+	if s.depositAddress != nil {
+		go s.depositLoop()
+	}
 }
 
 func (s *LesServer) SetBloomBitsIndexer(bloomIndexer *core.ChainIndexer) {
@@ -144,6 +183,7 @@ func (s *LesServer) Stop() {
 		<-s.protocolManager.noMorePeers
 	}()
 	s.protocolManager.Stop()
+	s.priorityPool.stop()
 }
 
 type requestCosts struct {
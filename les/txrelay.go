@@ -18,34 +18,61 @@ package les
 
 import (
 	"sync"
+	"time"
 
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/mclock"
+	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/rlp"
 )
 
+// txStatusTimeout is the time after which a transaction status request is
+// considered lost and no longer waited for, even if the server is still alive.
+const txStatusTimeout = time.Minute
+
 type ltrInfo struct {
-	tx     *types.Transaction
-	sentTo map[*peer]struct{}
+	tx      *types.Transaction
+	sentTo  map[*peer]struct{}
+	dropped map[*peer]string // drop reasons of the servers that dropped or rejected the tx
+}
+
+// txStatusReq is a sent request answered by a batch of transaction status
+// records, either a transaction relay or a status query.
+type txStatusReq struct {
+	peer   *peer
+	hashes []common.Hash
+	sent   mclock.AbsTime
+}
+
+// txStatusReporter is notified about the status of relayed transactions as
+// reported by the servers (implemented by light.TxPool).
+type txStatusReporter interface {
+	SetTxStatus(hash common.Hash, status core.TxLifecycle, reason string)
 }
 
 type LesTxRelay struct {
 	txSent       map[common.Hash]*ltrInfo
 	txPending    map[common.Hash]struct{}
+	statusReqs   map[uint64]*txStatusReq
 	ps           *peerSet
 	peerList     []*peer
 	peerStartPos int
 	lock         sync.RWMutex
 
-	reqDist *requestDistributor
+	reqDist  *requestDistributor
+	reporter txStatusReporter
+	clock    mclock.Clock
 }
 
 func NewLesTxRelay(ps *peerSet, reqDist *requestDistributor) *LesTxRelay {
 	r := &LesTxRelay{
-		txSent:    make(map[common.Hash]*ltrInfo),
-		txPending: make(map[common.Hash]struct{}),
-		ps:        ps,
-		reqDist:   reqDist,
+		txSent:     make(map[common.Hash]*ltrInfo),
+		txPending:  make(map[common.Hash]struct{}),
+		statusReqs: make(map[uint64]*txStatusReq),
+		ps:         ps,
+		reqDist:    reqDist,
+		clock:      mclock.System{},
 	}
 	ps.notify(r)
 	return r
@@ -63,6 +90,15 @@ func (self *LesTxRelay) unregisterPeer(p *peer) {
 	defer self.lock.Unlock()
 
 	self.peerList = self.ps.AllPeers()
+	for _, ltr := range self.txSent {
+		delete(ltr.sentTo, p)
+		delete(ltr.dropped, p)
+	}
+	for reqID, req := range self.statusReqs {
+		if req.peer == p {
+			delete(self.statusReqs, reqID)
+		}
+	}
 }
 
 // send sends a list of transactions to at most a given number of peers at
@@ -80,8 +116,9 @@ func (self *LesTxRelay) send(txs types.Transactions, count int) {
 		ltr, ok := self.txSent[hash]
 		if !ok {
 			ltr = &ltrInfo{
-				tx:     tx,
-				sentTo: make(map[*peer]struct{}),
+				tx:      tx,
+				sentTo:  make(map[*peer]struct{}),
+				dropped: make(map[*peer]string),
 			}
 			self.txSent[hash] = ltr
 			self.txPending[hash] = struct{}{}
@@ -117,6 +154,14 @@ func (self *LesTxRelay) send(txs types.Transactions, count int) {
 		enc, _ := rlp.EncodeToBytes(ll)
 
 		reqID := genReqID()
+		if pp.version >= lpv2 {
+			// servers reply with the status of the relayed transactions
+			hashes := make([]common.Hash, len(ll))
+			for i, tx := range ll {
+				hashes[i] = tx.Hash()
+			}
+			self.statusReqs[reqID] = &txStatusReq{peer: pp, hashes: hashes, sent: self.clock.Now()}
+		}
 		rq := &distReq{
 			getCost: func(dp distPeer) uint64 {
 				peer := dp.(*peer)
@@ -154,18 +199,130 @@ func (self *LesTxRelay) NewHead(head common.Hash, mined []common.Hash, rollback
 	for _, hash := range rollback {
 		self.txPending[hash] = struct{}{}
 	}
+	self.expireStatus()
 
 	if len(self.txPending) > 0 {
 		txs := make(types.Transactions, len(self.txPending))
 		i := 0
 		for hash := range self.txPending {
-			txs[i] = self.txSent[hash].tx
+			ltr := self.txSent[hash]
+			if len(ltr.dropped) > 0 && len(ltr.dropped) == len(ltr.sentTo) && len(ltr.sentTo) >= len(self.peerList) {
+				// Every server dropped the transaction, retry all of them since
+				// the power of the sender might have regenerated meanwhile
+				ltr.sentTo = make(map[*peer]struct{})
+				ltr.dropped = make(map[*peer]string)
+			}
+			txs[i] = ltr.tx
 			i++
 		}
+		self.requestStatus()
 		self.send(txs, 1)
 	}
 }
 
+// expireStatus drops the status requests not answered within txStatusTimeout.
+func (self *LesTxRelay) expireStatus() {
+	now := self.clock.Now()
+	for reqID, req := range self.statusReqs {
+		if time.Duration(now-req.sent) >= txStatusTimeout {
+			delete(self.statusReqs, reqID)
+		}
+	}
+}
+
+// requestStatus asks the servers about the status of the pending transactions
+// they have been sent.
+func (self *LesTxRelay) requestStatus() {
+	query := make(map[*peer][]common.Hash)
+	for hash := range self.txPending {
+		for p := range self.txSent[hash].sentTo {
+			query[p] = append(query[p], hash)
+		}
+	}
+	for p, hashes := range query {
+		for len(hashes) > 0 {
+			batch := hashes
+			if len(batch) > MaxTxStatus {
+				batch = batch[:MaxTxStatus]
+			}
+			hashes = hashes[len(batch):]
+
+			pp := p
+			reqID := genReqID()
+			self.statusReqs[reqID] = &txStatusReq{peer: pp, hashes: batch, sent: self.clock.Now()}
+			rq := &distReq{
+				getCost: func(dp distPeer) uint64 {
+					return dp.(*peer).GetRequestCost(GetTxStatusMsg, len(batch))
+				},
+				canSend: func(dp distPeer) bool {
+					return dp.(*peer) == pp
+				},
+				request: func(dp distPeer) func() {
+					peer := dp.(*peer)
+					cost := peer.GetRequestCost(GetTxStatusMsg, len(batch))
+					peer.fcServer.QueueRequest(reqID, cost)
+					return func() { peer.RequestTxStatus(reqID, cost, batch) }
+				},
+			}
+			self.reqDist.queue(rq)
+		}
+	}
+}
+
+// deliverStatus processes the transaction status records sent by a server in
+// response to a transaction relay or status request. Transactions dropped by a
+// server are retried with other ones at the next head, and the light pool is
+// notified once every server it was sent to dropped a transaction, or when one
+// of them accepted it again.
+func (self *LesTxRelay) deliverStatus(p *peer, reqID uint64, stats []txStatus) {
+	type report struct {
+		hash   common.Hash
+		status core.TxLifecycle
+		reason string
+	}
+	var reports []report
+
+	self.lock.Lock()
+	req, ok := self.statusReqs[reqID]
+	if !ok || req.peer != p || len(req.hashes) != len(stats) {
+		self.lock.Unlock()
+		return
+	}
+	delete(self.statusReqs, reqID)
+
+	for i, hash := range req.hashes {
+		ltr, ok := self.txSent[hash]
+		if _, pending := self.txPending[hash]; !ok || !pending {
+			continue
+		}
+		if _, sent := ltr.sentTo[p]; !sent {
+			continue
+		}
+		switch stat := stats[i]; {
+		case stat.Error != "":
+			ltr.dropped[p] = stat.Error
+			if len(ltr.dropped) == len(ltr.sentTo) {
+				reports = append(reports, report{hash, core.TxLifecycleDropped, stat.Error})
+			}
+		case stat.Status == core.TxStatusPending:
+			delete(ltr.dropped, p)
+			reports = append(reports, report{hash, core.TxLifecyclePending, ""})
+		case stat.Status == core.TxStatusQueued:
+			delete(ltr.dropped, p)
+			reports = append(reports, report{hash, core.TxLifecycleQueued, ""})
+		}
+	}
+	reporter := self.reporter
+	self.lock.Unlock()
+
+	// Notify the pool without holding the lock, the pool calls into the relay
+	if reporter != nil {
+		for _, r := range reports {
+			reporter.SetTxStatus(r.hash, r.status, r.reason)
+		}
+	}
+}
+
 func (self *LesTxRelay) Discard(hashes []common.Hash) {
 	self.lock.Lock()
 	defer self.lock.Unlock()
@@ -175,3 +332,11 @@ func (self *LesTxRelay) Discard(hashes []common.Hash) {
 		delete(self.txPending, hash)
 	}
 }
+
+// setReporter sets the receiver of the status changes of relayed transactions.
+func (self *LesTxRelay) setReporter(reporter txStatusReporter) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.reporter = reporter
+}
@@ -0,0 +1,134 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/mclock"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+)
+
+type testTxStatus struct {
+	hash   common.Hash
+	status core.TxLifecycle
+	reason string
+}
+
+type testTxStatusReporter []testTxStatus
+
+func (r *testTxStatusReporter) SetTxStatus(hash common.Hash, status core.TxLifecycle, reason string) {
+	*r = append(*r, testTxStatus{hash, status, reason})
+}
+
+// Tests that transactions dropped by the servers are reported to the pool once
+// all of them dropped it, and are retried at the next head.
+func TestTxRelayDroppedStatus(t *testing.T) {
+	quit := make(chan struct{})
+	defer close(quit)
+
+	var (
+		relay    = NewLesTxRelay(newPeerSet(), newRequestDistributor(nil, quit))
+		reporter = new(testTxStatusReporter)
+		p1, p2   = &peer{id: "p1", version: lpv2}, &peer{id: "p2", version: lpv2}
+		tx       = types.NewTransaction(0, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		hash     = tx.Hash()
+		reason   = core.ErrInsufficientPower.Error()
+	)
+	relay.setReporter(reporter)
+	relay.peerList = []*peer{p1, p2}
+	relay.send(types.Transactions{tx}, 2)
+
+	// Collect the relay requests, the servers reply with the status of the txs
+	reqs := make(map[*peer]uint64)
+	for reqID, req := range relay.statusReqs {
+		reqs[req.peer] = reqID
+	}
+	if len(reqs) != 2 {
+		t.Fatalf("relay request count mismatch: have %d, want 2", len(reqs))
+	}
+	relay.deliverStatus(p1, reqs[p1], []txStatus{{Error: reason}})
+	if len(*reporter) != 0 {
+		t.Fatalf("tx reported dropped while still held by a server: %v", *reporter)
+	}
+	relay.deliverStatus(p2, reqs[p2], []txStatus{{Error: reason}})
+	if want := (testTxStatus{hash, core.TxLifecycleDropped, reason}); len(*reporter) != 1 || (*reporter)[0] != want {
+		t.Fatalf("dropped report mismatch: have %v, want %v", *reporter, want)
+	}
+	// Replies to unknown requests are ignored
+	relay.deliverStatus(p1, reqs[p1], []txStatus{{Status: core.TxStatusPending}})
+	if len(*reporter) != 1 {
+		t.Fatalf("reply to unknown request processed")
+	}
+	// The dropped tx is resent at the next head and accepted again
+	relay.NewHead(common.Hash{}, nil, nil)
+	if sent := len(relay.txSent[hash].sentTo); sent != 1 {
+		t.Fatalf("resent tx peer count mismatch: have %d, want 1", sent)
+	}
+	for reqID, req := range relay.statusReqs {
+		relay.deliverStatus(req.peer, reqID, []txStatus{{Status: core.TxStatusPending}})
+	}
+	if want := (testTxStatus{hash, core.TxLifecyclePending, ""}); len(*reporter) != 2 || (*reporter)[1] != want {
+		t.Fatalf("pending report mismatch: have %v, want %v", *reporter, want)
+	}
+}
+
+// Tests that status requests left unanswered by live servers are dropped after
+// a while, and late replies to them are ignored.
+func TestTxRelayStatusExpiry(t *testing.T) {
+	quit := make(chan struct{})
+	defer close(quit)
+
+	var (
+		relay    = NewLesTxRelay(newPeerSet(), newRequestDistributor(nil, quit))
+		clock    = &mclock.Simulated{}
+		reporter = new(testTxStatusReporter)
+		p        = &peer{id: "p", version: lpv2}
+		tx       = types.NewTransaction(0, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	)
+	relay.clock = clock
+	relay.setReporter(reporter)
+	relay.peerList = []*peer{p}
+	relay.send(types.Transactions{tx}, 1)
+
+	if len(relay.statusReqs) != 1 {
+		t.Fatalf("status request count mismatch: have %d, want 1", len(relay.statusReqs))
+	}
+	var reqID uint64
+	for id := range relay.statusReqs {
+		reqID = id
+	}
+	// Requests still within the timeout are retained
+	clock.Run(txStatusTimeout / 2)
+	relay.NewHead(common.Hash{}, nil, nil)
+	if _, ok := relay.statusReqs[reqID]; !ok {
+		t.Fatalf("status request expired early")
+	}
+	// Requests past the timeout are dropped and late replies ignored
+	clock.Run(txStatusTimeout)
+	relay.NewHead(common.Hash{}, nil, nil)
+	if _, ok := relay.statusReqs[reqID]; ok {
+		t.Fatalf("status request not expired")
+	}
+	relay.deliverStatus(p, reqID, []txStatus{{Error: core.ErrInsufficientPower.Error()}})
+	if len(*reporter) != 0 {
+		t.Fatalf("late reply to expired request processed: %v", *reporter)
+	}
+}
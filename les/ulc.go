@@ -0,0 +1,68 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/etherzero/go-etherzero/p2p/enode"
+)
+
+var errNoTrustedServers = errors.New("no trusted servers configured")
+
+// ulc holds the configuration of the ultra light client mode. An ultra light
+// client doesn't download and verify the header chain, it accepts a new head
+// once a large enough fraction of its trusted servers has announced it with a
+// signed announcement. State, receipts and other data retrieved on demand are
+// still verified against the accepted headers.
+type ulc struct {
+	trusted  map[enode.ID]*enode.Node
+	fraction int // minimum percentage of trusted servers announcing a head
+}
+
+// newULC parses the enode URLs of the trusted servers and creates the ultra
+// light client configuration.
+func newULC(servers []string, fraction int) (*ulc, error) {
+	trusted := make(map[enode.ID]*enode.Node)
+	for _, url := range servers {
+		node, err := enode.ParseV4(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted server %q: %v", url, err)
+		}
+		trusted[node.ID()] = node
+	}
+	if len(trusted) == 0 {
+		return nil, errNoTrustedServers
+	}
+	if fraction <= 0 || fraction > 100 {
+		return nil, fmt.Errorf("invalid trusted server fraction %d%%", fraction)
+	}
+	return &ulc{trusted: trusted, fraction: fraction}, nil
+}
+
+// isTrusted returns whether a server is one of the trusted ones.
+func (u *ulc) isTrusted(id enode.ID) bool {
+	_, ok := u.trusted[id]
+	return ok
+}
+
+// enough returns whether the given number of trusted servers reaches the
+// required fraction of all trusted servers.
+func (u *ulc) enough(count int) bool {
+	return count*100 >= u.fraction*len(u.trusted)
+}
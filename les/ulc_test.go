@@ -0,0 +1,107 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+)
+
+// Tests that the trusted servers of an ultra light client are parsed and the
+// announcement threshold is computed from the configured fraction.
+func TestULCConfig(t *testing.T) {
+	var (
+		servers []string
+		ids     []enode.ID
+	)
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		node := enode.NewV4(&key.PublicKey, nil, 30303, 30303)
+		servers = append(servers, node.String())
+		ids = append(ids, node.ID())
+	}
+	if _, err := newULC(nil, 75); err != errNoTrustedServers {
+		t.Errorf("empty server list error mismatch: have %v, want %v", err, errNoTrustedServers)
+	}
+	if _, err := newULC([]string{"invalid"}, 75); err == nil {
+		t.Errorf("invalid server accepted")
+	}
+	if _, err := newULC(servers, 101); err == nil {
+		t.Errorf("invalid fraction accepted")
+	}
+	u, err := newULC(servers[:3], 75)
+	if err != nil {
+		t.Fatalf("failed to create ultra light config: %v", err)
+	}
+	for i, id := range ids {
+		if trusted := u.isTrusted(id); trusted != (i < 3) {
+			t.Errorf("server #%d trust mismatch: have %v, want %v", i, trusted, i < 3)
+		}
+	}
+	for count, want := range []bool{false, false, false, true} {
+		if have := u.enough(count); have != want {
+			t.Errorf("threshold mismatch for %d servers: have %v, want %v", count, have, want)
+		}
+	}
+}
+
+// Tests that an ultra light client only accepts the total difficulty of a head
+// announced consistently by enough trusted servers.
+func TestTrustedTd(t *testing.T) {
+	var servers []string
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		servers = append(servers, enode.NewV4(&key.PublicKey, nil, 30303, 30303).String())
+	}
+	u, err := newULC(servers, 50)
+	if err != nil {
+		t.Fatalf("failed to create ultra light config: %v", err)
+	}
+	var (
+		hash = common.Hash{1}
+		f    = &lightFetcher{pm: &ProtocolManager{ulc: u}, peers: make(map[*peer]*fetcherPeerInfo)}
+	)
+	announce := func(trusted bool, td int64) {
+		f.peers[&peer{isTrusted: trusted}] = &fetcherPeerInfo{
+			nodeByHash: map[common.Hash]*fetcherTreeNode{hash: {hash: hash, td: big.NewInt(td)}},
+		}
+	}
+	// A single trusted server and any number of untrusted ones are not enough
+	announce(true, 10)
+	announce(false, 10)
+	announce(false, 10)
+	if td := f.trustedTd(hash); td != nil {
+		t.Fatalf("head accepted from a single trusted server: td %v", td)
+	}
+	// A trusted server announcing a different total difficulty doesn't count
+	announce(true, 11)
+	if td := f.trustedTd(hash); td != nil {
+		t.Fatalf("head accepted with inconsistent total difficulties: td %v", td)
+	}
+	// A second trusted server agreeing reaches the threshold
+	announce(true, 10)
+	if td := f.trustedTd(hash); td == nil || td.Int64() != 10 {
+		t.Fatalf("total difficulty mismatch: have %v, want 10", td)
+	}
+	if td := f.trustedTd(common.Hash{2}); td != nil {
+		t.Fatalf("unannounced head accepted: td %v", td)
+	}
+}
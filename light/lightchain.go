@@ -96,7 +96,7 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 	if err != nil {
 		return nil, err
 	}
-	bc.genesisBlock, _ = bc.GetBlockByNumber(NoOdr, 0)
+	bc.genesisBlock, _ = bc.GetBlockByNumber(NoOdr, params.GenesisBlockNumber)
 	if bc.genesisBlock == nil {
 		return nil, core.ErrNoGenesis
 	}
@@ -393,6 +393,50 @@ func (self *LightChain) InsertHeaderChain(chain []*types.Header, checkFreq int)
 	return i, err
 }
 
+// InsertTrustedHeader sets a header vouched for by trusted servers as the head
+// of the chain without validating it or requiring its ancestors to be present,
+// as done by ultra light clients. The total difficulty of the header is taken
+// from the announcements. Headers not heavier than the current head are ignored.
+func (self *LightChain) InsertTrustedHeader(header *types.Header, td *big.Int) {
+	self.chainmu.Lock()
+	defer self.chainmu.Unlock()
+
+	self.wg.Add(1)
+	defer self.wg.Done()
+
+	var (
+		hash   = header.Hash()
+		number = header.Number.Uint64()
+	)
+	self.mu.Lock()
+	head := self.hc.CurrentHeader()
+	if localTd := self.hc.GetTd(head.Hash(), head.Number.Uint64()); localTd != nil && td.Cmp(localTd) <= 0 {
+		self.mu.Unlock()
+		return
+	}
+	if err := self.hc.WriteTd(hash, number, td); err != nil {
+		log.Crit("Failed to write header total difficulty", "err", err)
+	}
+	rawdb.WriteHeader(self.chainDb, header)
+
+	// Delete any canonical number assignments above the new head
+	batch := self.chainDb.NewBatch()
+	for i := number + 1; ; i++ {
+		if rawdb.ReadCanonicalHash(self.chainDb, i) == (common.Hash{}) {
+			break
+		}
+		rawdb.DeleteCanonicalHash(batch, i)
+	}
+	rawdb.WriteCanonicalHash(batch, hash, number)
+	batch.Write()
+
+	self.hc.SetCurrentHeader(header)
+	self.mu.Unlock()
+
+	log.Debug("Inserted trusted header", "number", number, "hash", hash, "td", td)
+	self.postChainEvents([]interface{}{core.ChainEvent{Block: types.NewBlockWithHeader(header), Hash: hash}})
+}
+
 // CurrentHeader retrieves the current head header of the canonical chain. The
 // header is retrieved from the HeaderChain's internal cache.
 func (self *LightChain) CurrentHeader() *types.Header {
@@ -26,6 +26,7 @@ import (
 	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/rawdb"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/params"
 )
@@ -53,7 +54,7 @@ func makeHeaderChain(parent *types.Header, n int, db ethdb.Database, seed int) [
 // header only chain.
 func newCanonical(n int) (ethdb.Database, *LightChain, error) {
 	db := ethdb.NewMemDatabase()
-	gspec := core.Genesis{Config: params.TestChainConfig}
+	gspec := core.Genesis{Config: params.TestChainConfig, Number: params.GenesisBlockNumber}
 	genesis := gspec.MustCommit(db)
 	blockchain, _ := NewLightChain(&dummyOdr{db: db, indexerConfig: TestClientIndexerConfig}, gspec.Config, ethash.NewFaker())
 
@@ -71,6 +72,7 @@ func newCanonical(n int) (ethdb.Database, *LightChain, error) {
 func newTestLightChain() *LightChain {
 	db := ethdb.NewMemDatabase()
 	gspec := &core.Genesis{
+		Number:     params.GenesisBlockNumber,
 		Difficulty: big.NewInt(1),
 		Config:     params.TestChainConfig,
 	}
@@ -247,11 +249,12 @@ func makeHeaderChainWithDiff(genesis *types.Block, d []int, seed byte) []*types.
 	for i, difficulty := range d {
 		header := &types.Header{
 			Coinbase:    common.Address{seed},
-			Number:      big.NewInt(int64(i + 1)),
+			Number:      new(big.Int).Add(genesis.Number(), big.NewInt(int64(i+1))),
 			Difficulty:  big.NewInt(int64(difficulty)),
 			UncleHash:   types.EmptyUncleHash,
 			TxHash:      types.EmptyRootHash,
 			ReceiptHash: types.EmptyRootHash,
+			Protocol:    new(devotedb.DevoteProtocol),
 		}
 		if i == 0 {
 			header.ParentHash = genesis.Hash()
@@ -301,7 +304,7 @@ func testReorg(t *testing.T, first, second []int, td int64) {
 	bc.InsertHeaderChain(makeHeaderChainWithDiff(bc.genesisBlock, second, 22), 1)
 	// Check that the chain is valid number and link wise
 	prev := bc.CurrentHeader()
-	for header := bc.GetHeaderByNumber(bc.CurrentHeader().Number.Uint64() - 1); header.Number.Uint64() != 0; prev, header = header, bc.GetHeaderByNumber(header.Number.Uint64()-1) {
+	for header := bc.GetHeaderByNumber(bc.CurrentHeader().Number.Uint64() - 1); header.Number.Uint64() != bc.genesisBlock.NumberU64(); prev, header = header, bc.GetHeaderByNumber(header.Number.Uint64()-1) {
 		if prev.ParentHash != header.Hash() {
 			t.Errorf("parent header hash mismatch: have %x, want %x", prev.ParentHash, header.Hash())
 		}
@@ -352,3 +355,30 @@ func TestReorgBadHeaderHashes(t *testing.T) {
 		t.Errorf("last header hash mismatch: have: %x, want %x", ncm.CurrentHeader().Hash(), headers[2].Hash())
 	}
 }
+
+// Tests that trusted headers are accepted as the head without their ancestors,
+// unless they are lighter than the current head.
+func TestInsertTrustedHeader(t *testing.T) {
+	bc := newTestLightChain()
+
+	headers := makeHeaderChainWithDiff(bc.genesisBlock, []int{1, 2, 3, 4}, 10)
+	head := headers[3]
+	bc.InsertTrustedHeader(head, big.NewInt(100))
+	if bc.CurrentHeader().Hash() != head.Hash() {
+		t.Fatalf("head hash mismatch: have %x, want %x", bc.CurrentHeader().Hash(), head.Hash())
+	}
+	if td := bc.GetTd(head.Hash(), head.Number.Uint64()); td == nil || td.Int64() != 100 {
+		t.Errorf("head td mismatch: have %v, want 100", td)
+	}
+	if hash := rawdb.ReadCanonicalHash(bc.chainDb, head.Number.Uint64()); hash != head.Hash() {
+		t.Errorf("canonical hash mismatch: have %x, want %x", hash, head.Hash())
+	}
+	if bc.HasHeader(headers[2].Hash(), headers[2].Number.Uint64()) {
+		t.Errorf("ancestor of trusted header present")
+	}
+	// Lighter heads are ignored
+	bc.InsertTrustedHeader(headers[2], big.NewInt(99))
+	if bc.CurrentHeader().Hash() != head.Hash() {
+		t.Errorf("head replaced by lighter header")
+	}
+}
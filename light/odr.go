@@ -26,6 +26,7 @@ import (
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/rawdb"
+	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/ethdb"
 )
@@ -95,6 +96,23 @@ func (req *TrieRequest) StoreResult(db ethdb.Database) {
 	req.Proof.Store(db)
 }
 
+// AccountRequest is the ODR request type for retrieving an account together with
+// a set of its storage slots, proven in a single round trip
+type AccountRequest struct {
+	OdrRequest
+	Id      *TrieID // references the state trie the account is retrieved from
+	Address common.Address
+	Slots   []common.Hash
+	Account *state.Account // nil if the account doesn't exist
+	Storage []common.Hash  // values of the requested slots, in request order
+	Proof   *NodeSet
+}
+
+// StoreResult stores the retrieved data in local database
+func (req *AccountRequest) StoreResult(db ethdb.Database) {
+	req.Proof.Store(db)
+}
+
 // CodeRequest is the ODR request type for retrieving contract code
 type CodeRequest struct {
 	OdrRequest
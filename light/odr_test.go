@@ -86,6 +86,27 @@ func (odr *testOdr) Retrieve(ctx context.Context, req OdrRequest) error {
 		nodes := NewNodeSet()
 		t.Prove(req.Key, 0, nodes)
 		req.Proof = nodes
+	case *AccountRequest:
+		nodes := NewNodeSet()
+		accKey := crypto.Keccak256(req.Address[:])
+		t, _ := trie.New(req.Id.Root, trie.NewDatabase(odr.sdb))
+		t.Prove(accKey, 0, nodes)
+		req.Storage = make([]common.Hash, len(req.Slots))
+		if blob, _ := t.TryGet(accKey); blob != nil {
+			req.Account = new(state.Account)
+			rlp.DecodeBytes(blob, req.Account)
+
+			st, _ := trie.New(req.Account.Root, trie.NewDatabase(odr.sdb))
+			for i, slot := range req.Slots {
+				key := crypto.Keccak256(slot[:])
+				st.Prove(key, 0, nodes)
+				if enc, _ := st.TryGet(key); enc != nil {
+					_, content, _, _ := rlp.Split(enc)
+					req.Storage[i] = common.BytesToHash(content)
+				}
+			}
+		}
+		req.Proof = nodes
 	case *CodeRequest:
 		req.Data, _ = odr.sdb.Get(req.Hash[:])
 	}
@@ -193,7 +214,7 @@ func odrContractCall(ctx context.Context, db ethdb.Database, bc *core.BlockChain
 		}
 
 		// Perform read-only call.
-		st.SetBalance(testBankAddress, math.MaxBig256)
+		st.SetBalance(testBankAddress, math.MaxBig256, header.Number)
 		msg := callmsg{types.NewMessage(testBankAddress, &testContractAddr, 0, new(big.Int), 1000000, new(big.Int), data, false)}
 		context := core.NewEVMContext(msg, header, chain, nil)
 		vmenv := vm.NewEVM(context, st, config, vm.Config{})
@@ -252,7 +273,7 @@ func testChainOdr(t *testing.T, protocol int, fn odrTestFn) {
 	var (
 		sdb     = ethdb.NewMemDatabase()
 		ldb     = ethdb.NewMemDatabase()
-		gspec   = core.Genesis{Alloc: core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}}}
+		gspec   = core.Genesis{Number: params.GenesisBlockNumber, Alloc: core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}}}
 		genesis = gspec.MustCommit(sdb)
 	)
 	gspec.MustCommit(ldb)
@@ -277,7 +298,7 @@ func testChainOdr(t *testing.T, protocol int, fn odrTestFn) {
 	}
 
 	test := func(expFail int) {
-		for i := uint64(0); i <= blockchain.CurrentHeader().Number.Uint64(); i++ {
+		for i := genesis.NumberU64(); i <= blockchain.CurrentHeader().Number.Uint64(); i++ {
 			bhash := rawdb.ReadCanonicalHash(sdb, i)
 			b1, err := fn(NoOdr, sdb, blockchain, nil, bhash)
 			if err != nil {
@@ -287,7 +308,7 @@ func testChainOdr(t *testing.T, protocol int, fn odrTestFn) {
 			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
 			defer cancel()
 
-			exp := i < uint64(expFail)
+			exp := i < genesis.NumberU64()+uint64(expFail)
 			b2, err := fn(ctx, ldb, nil, lightchain, bhash)
 			if err != nil && exp {
 				t.Errorf("error in ODR test for block %d: %v", i, err)
@@ -0,0 +1,128 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Storage slots of the top level variables of the masternode contract.
+const (
+	masternodeNodesSlot       = 2 // mapping(bytes8 => node)
+	masternodeIdsSlot         = 3 // mapping(address => bytes8), by owner account
+	masternodeNodeAddressSlot = 4 // mapping(address => bytes8), by node address
+)
+
+// MasternodeInfo is the content of a masternode entry of the masternode contract.
+type MasternodeInfo struct {
+	Id1, Id2       common.Hash
+	PreId, NextId  [8]byte
+	Account        common.Address
+	BlockNumber    *big.Int
+	BlockOnlineAcc *big.Int
+	BlockLastPing  *big.Int
+}
+
+// GetAccount retrieves an account and the given slots of its storage, proven
+// against the state root of the header. The account is nil if it doesn't exist.
+func GetAccount(ctx context.Context, odr OdrBackend, header *types.Header, addr common.Address, slots ...common.Hash) (*state.Account, []common.Hash, error) {
+	r := &AccountRequest{Id: StateTrieID(header), Address: addr, Slots: slots}
+	if err := odr.Retrieve(ctx, r); err != nil {
+		return nil, nil, err
+	}
+	return r.Account, r.Storage, nil
+}
+
+// GetPower computes the power of an account at the given block number from the
+// balance and power snapshot proven in the state of the header.
+func GetPower(ctx context.Context, odr OdrBackend, header *types.Header, addr common.Address, number *big.Int) (*big.Int, error) {
+	account, _, err := GetAccount(ctx, odr, header, addr)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return common.Big0, nil
+	}
+	return state.CalculatePower(account.BlockNumber, number, account.Power, account.Balance), nil
+}
+
+// GetMasternodeId retrieves the id of the masternode registered by an account,
+// or the zero id if the account has none.
+func GetMasternodeId(ctx context.Context, odr OdrBackend, header *types.Header, account common.Address) ([8]byte, error) {
+	return getMasternodeId(ctx, odr, header, account, masternodeIdsSlot)
+}
+
+// GetMasternodeIdByNode retrieves the id of the masternode running with the
+// given node address, or the zero id if there is none.
+func GetMasternodeIdByNode(ctx context.Context, odr OdrBackend, header *types.Header, node common.Address) ([8]byte, error) {
+	return getMasternodeId(ctx, odr, header, node, masternodeNodeAddressSlot)
+}
+
+func getMasternodeId(ctx context.Context, odr OdrBackend, header *types.Header, addr common.Address, slot int64) ([8]byte, error) {
+	var id [8]byte
+
+	_, storage, err := GetAccount(ctx, odr, header, params.MasterndeContractAddress, mappingSlot(common.BytesToHash(addr[:]), slot))
+	if err != nil {
+		return id, err
+	}
+	copy(id[:], storage[0][24:])
+	return id, nil
+}
+
+// GetMasternodeInfo retrieves the entry of a masternode from the proven storage
+// of the masternode contract, or nil if no masternode has the given id.
+func GetMasternodeInfo(ctx context.Context, odr OdrBackend, header *types.Header, id [8]byte) (*MasternodeInfo, error) {
+	var key common.Hash
+	copy(key[:], id[:])
+
+	base := mappingSlot(key, masternodeNodesSlot).Big()
+	slots := make([]common.Hash, 7)
+	for i := range slots {
+		slots[i] = common.BigToHash(new(big.Int).Add(base, big.NewInt(int64(i))))
+	}
+	_, storage, err := GetAccount(ctx, odr, header, params.MasterndeContractAddress, slots...)
+	if err != nil {
+		return nil, err
+	}
+	if storage[0] == (common.Hash{}) {
+		return nil, nil
+	}
+	info := &MasternodeInfo{
+		Id1:            storage[0],
+		Id2:            storage[1],
+		Account:        common.BytesToAddress(storage[3][12:]),
+		BlockNumber:    storage[4].Big(),
+		BlockOnlineAcc: storage[5].Big(),
+		BlockLastPing:  storage[6].Big(),
+	}
+	copy(info.PreId[:], storage[2][24:32])
+	copy(info.NextId[:], storage[2][16:24])
+	return info, nil
+}
+
+// mappingSlot returns the storage slot of a key in a solidity mapping stored
+// at the given top level slot.
+func mappingSlot(key common.Hash, slot int64) common.Hash {
+	return crypto.Keccak256Hash(key[:], common.BigToHash(big.NewInt(slot)).Bytes())
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that power and masternode entries are computed from proven state.
+func TestPowerAndMasternodeOdr(t *testing.T) {
+	var (
+		sdb     = ethdb.NewMemDatabase()
+		statedb *state.StateDB
+		id      = [8]byte{1, 2, 3, 4, 5, 6, 7, 8}
+		preId   = [8]byte{8, 7, 6, 5, 4, 3, 2, 1}
+		node    = common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+	)
+	statedb, _ = state.New(common.Hash{}, state.NewDatabase(sdb))
+	statedb.SetBalance(acc1Addr, big.NewInt(1e18), big.NewInt(10))
+	statedb.SetPower(acc1Addr, big.NewInt(1000))
+
+	// Lay out a masternode entry the way the contract stores it
+	var idsKey, nodeKey, nodeAddressKey [64]byte
+	copy(idsKey[12:32], acc1Addr[:])
+	idsKey[63] = 3
+	copy(nodeAddressKey[12:32], node[:])
+	nodeAddressKey[63] = 4
+	copy(nodeKey[:8], id[:])
+	nodeKey[63] = 2
+
+	contract := params.MasterndeContractAddress
+	statedb.SetNonce(contract, 1)
+	statedb.SetState(contract, crypto.Keccak256Hash(idsKey[:]), common.BytesToHash(id[:]))
+	statedb.SetState(contract, crypto.Keccak256Hash(nodeAddressKey[:]), common.BytesToHash(id[:]))
+
+	var links common.Hash
+	copy(links[24:32], preId[:])
+	fields := []common.Hash{
+		common.HexToHash("0x11"), common.HexToHash("0x22"), links, common.BytesToHash(acc2Addr[:]),
+		common.BigToHash(big.NewInt(5)), common.BigToHash(big.NewInt(6)), common.BigToHash(big.NewInt(7)),
+	}
+	base := new(big.Int).SetBytes(crypto.Keccak256(nodeKey[:]))
+	for i, field := range fields {
+		statedb.SetState(contract, common.BigToHash(new(big.Int).Add(base, big.NewInt(int64(i)))), field)
+	}
+	root, err := statedb.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	statedb.Database().TrieDB().Commit(root, false)
+
+	var (
+		ctx    = context.Background()
+		odr    = &testOdr{sdb: sdb, ldb: ethdb.NewMemDatabase()}
+		header = &types.Header{Number: big.NewInt(20), Root: root}
+		number = big.NewInt(100)
+	)
+	power, err := GetPower(ctx, odr, header, acc1Addr, number)
+	if err != nil {
+		t.Fatalf("failed to retrieve power: %v", err)
+	}
+	if want := statedb.GetPower(acc1Addr, number); power.Cmp(want) != 0 {
+		t.Errorf("power mismatch: have %v, want %v", power, want)
+	}
+	if power, err := GetPower(ctx, odr, header, testBankAddress, number); err != nil || power.Sign() != 0 {
+		t.Errorf("missing account power mismatch: have %v/%v, want 0/nil", power, err)
+	}
+	if have, err := GetMasternodeId(ctx, odr, header, acc1Addr); err != nil || have != id {
+		t.Errorf("masternode id mismatch: have %x/%v, want %x/nil", have, err, id)
+	}
+	if have, err := GetMasternodeIdByNode(ctx, odr, header, node); err != nil || have != id {
+		t.Errorf("masternode id by node mismatch: have %x/%v, want %x/nil", have, err, id)
+	}
+	info, err := GetMasternodeInfo(ctx, odr, header, id)
+	if err != nil || info == nil {
+		t.Fatalf("failed to retrieve masternode: %v", err)
+	}
+	if info.Id1 != fields[0] || info.Id2 != fields[1] || info.PreId != preId || info.NextId != ([8]byte{}) || info.Account != acc2Addr {
+		t.Errorf("masternode mismatch: have %+v", info)
+	}
+	if info.BlockNumber.Int64() != 5 || info.BlockOnlineAcc.Int64() != 6 || info.BlockLastPing.Int64() != 7 {
+		t.Errorf("masternode blocks mismatch: have %v/%v/%v, want 5/6/7", info.BlockNumber, info.BlockOnlineAcc, info.BlockLastPing)
+	}
+	if info, err := GetMasternodeInfo(ctx, odr, header, preId); err != nil || info != nil {
+		t.Errorf("unknown masternode mismatch: have %v/%v, want nil/nil", info, err)
+	}
+}
@@ -67,6 +67,12 @@ type TxPool struct {
 	mined        map[common.Hash][]*types.Transaction // mined transactions by block hash
 	clearIdx     uint64                               // earliest block nr that can contain mined tx info
 
+	lifecycle      map[common.Hash]*core.TxLifecycleRecord // last known lifecycle stage of local transactions
+	lifecycleFeed  event.Feed
+	lifecycleQueue []core.TxLifecycleEvent // lifecycle announcements not yet delivered, oldest first
+	lifecycleLock  sync.Mutex              // protects the lifecycle announcement queue
+	lifecycleWake  chan struct{}           // notification channel of queued lifecycle announcements
+
 	homestead bool
 }
 
@@ -93,6 +99,8 @@ func NewTxPool(config *params.ChainConfig, chain *LightChain, relay TxRelayBacke
 		nonce:       make(map[common.Address]uint64),
 		pending:     make(map[common.Hash]*types.Transaction),
 		mined:       make(map[common.Hash][]*types.Transaction),
+		lifecycle:     make(map[common.Hash]*core.TxLifecycleRecord),
+		lifecycleWake: make(chan struct{}, 1),
 		quit:        make(chan bool),
 		chainHeadCh: make(chan core.ChainHeadEvent, chainHeadChanSize),
 		chain:       chain,
@@ -105,6 +113,7 @@ func NewTxPool(config *params.ChainConfig, chain *LightChain, relay TxRelayBacke
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 	go pool.eventLoop()
+	go pool.lifecycleLoop()
 
 	return pool
 }
@@ -190,6 +199,7 @@ func (pool *TxPool) checkMinedTxs(ctx context.Context, hash common.Hash, number
 		for _, tx := range list {
 			delete(pool.pending, tx.Hash())
 			txc.setState(tx.Hash(), true)
+			pool.setLifecycle(tx.Hash(), core.TxLifecycleIncluded, "")
 		}
 		pool.mined[hash] = list
 	}
@@ -206,6 +216,7 @@ func (pool *TxPool) rollbackTxs(hash common.Hash, txc txStateChanges) {
 			rawdb.DeleteTxLookupEntry(batch, txHash)
 			pool.pending[txHash] = tx
 			txc.setState(txHash, false)
+			pool.setLifecycle(txHash, core.TxLifecyclePending, "")
 		}
 		delete(pool.mined, hash)
 	}
@@ -265,6 +276,7 @@ func (pool *TxPool) reorgOnNewHead(ctx context.Context, newHeader *types.Header)
 					hashes := make([]common.Hash, len(list))
 					for i, tx := range list {
 						hashes[i] = tx.Hash()
+						delete(pool.lifecycle, hashes[i])
 					}
 					pool.relay.Discard(hashes)
 					delete(pool.mined, hash)
@@ -403,6 +415,7 @@ func (self *TxPool) add(ctx context.Context, tx *types.Transaction) error {
 
 	if _, ok := self.pending[hash]; !ok {
 		self.pending[hash] = tx
+		self.setLifecycle(hash, core.TxLifecyclePending, "")
 
 		nonce := tx.Nonce() + 1
 
@@ -512,6 +525,7 @@ func (self *TxPool) RemoveTransactions(txs types.Transactions) {
 	for _, tx := range txs {
 		hash := tx.Hash()
 		delete(self.pending, hash)
+		delete(self.lifecycle, hash)
 		batch.Delete(hash.Bytes())
 		hashes = append(hashes, hash)
 	}
@@ -525,6 +539,82 @@ func (pool *TxPool) RemoveTx(hash common.Hash) {
 	defer pool.mu.Unlock()
 	// delete from pending pool
 	delete(pool.pending, hash)
+	delete(pool.lifecycle, hash)
 	pool.chainDb.Delete(hash[:])
 	pool.relay.Discard([]common.Hash{hash})
 }
+
+// SetTxStatus records the status of a relayed pending transaction as reported
+// by the servers, e.g. that it was dropped for insufficient power and waits for
+// it to regenerate. Inclusion is detected by the pool itself.
+func (pool *TxPool) SetTxStatus(hash common.Hash, status core.TxLifecycle, reason string) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if _, ok := pool.pending[hash]; !ok {
+		return
+	}
+	pool.setLifecycle(hash, status, reason)
+}
+
+// Lifecycle returns the last known lifecycle stage of a local transaction, or
+// nil if it is unknown.
+func (pool *TxPool) Lifecycle(hash common.Hash) *core.TxLifecycleRecord {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	if record := pool.lifecycle[hash]; record != nil {
+		cpy := *record
+		return &cpy
+	}
+	return nil
+}
+
+// SubscribeTxLifecycleEvent registers a subscription of core.TxLifecycleEvent
+// and starts sending event to the given channel.
+func (pool *TxPool) SubscribeTxLifecycleEvent(ch chan<- core.TxLifecycleEvent) event.Subscription {
+	return pool.scope.Track(pool.lifecycleFeed.Subscribe(ch))
+}
+
+// setLifecycle stores a new lifecycle stage of a local transaction and announces
+// it, unless the transaction is already in the same stage.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) setLifecycle(hash common.Hash, status core.TxLifecycle, reason string) {
+	if old := pool.lifecycle[hash]; old != nil && old.Status == status && old.Reason == reason {
+		return
+	}
+	record := &core.TxLifecycleRecord{Hash: hash, Status: status, Reason: reason, Time: time.Now()}
+	pool.lifecycle[hash] = record
+
+	// Queue the announcement for the lifecycle loop, so slow subscribers don't
+	// block the pool but still receive the changes in order
+	pool.lifecycleLock.Lock()
+	pool.lifecycleQueue = append(pool.lifecycleQueue, core.TxLifecycleEvent{Records: []core.TxLifecycleRecord{*record}})
+	pool.lifecycleLock.Unlock()
+
+	select {
+	case pool.lifecycleWake <- struct{}{}:
+	default:
+	}
+}
+
+// lifecycleLoop delivers the queued lifecycle announcements to the subscribers
+// one after the other, retaining the order in which the changes happened.
+func (pool *TxPool) lifecycleLoop() {
+	for {
+		select {
+		case <-pool.lifecycleWake:
+			pool.lifecycleLock.Lock()
+			events := pool.lifecycleQueue
+			pool.lifecycleQueue = nil
+			pool.lifecycleLock.Unlock()
+
+			for _, ev := range events {
+				pool.lifecycleFeed.Send(ev)
+			}
+		case <-pool.quit:
+			return
+		}
+	}
+}
@@ -83,7 +83,7 @@ func TestTxPool(t *testing.T) {
 	var (
 		sdb     = ethdb.NewMemDatabase()
 		ldb     = ethdb.NewMemDatabase()
-		gspec   = core.Genesis{Alloc: core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}}}
+		gspec   = core.Genesis{Number: params.GenesisBlockNumber, Alloc: core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}}}
 		genesis = gspec.MustCommit(sdb)
 	)
 	gspec.MustCommit(ldb)
@@ -141,3 +141,36 @@ func TestTxPool(t *testing.T) {
 		}
 	}
 }
+
+// Tests that lifecycle changes of local transactions are announced in the order
+// they happened, even if the subscriber lags behind.
+func TestTxPoolLifecycleOrder(t *testing.T) {
+	pool := &TxPool{
+		lifecycle:     make(map[common.Hash]*core.TxLifecycleRecord),
+		lifecycleWake: make(chan struct{}, 1),
+		quit:          make(chan bool),
+	}
+	defer close(pool.quit)
+
+	events := make(chan core.TxLifecycleEvent)
+	sub := pool.SubscribeTxLifecycleEvent(events)
+	defer sub.Unsubscribe()
+	go pool.lifecycleLoop()
+
+	hash := common.Hash{1}
+	want := []core.TxLifecycle{core.TxLifecyclePending, core.TxLifecycleIncluded, core.TxLifecyclePending, core.TxLifecycleDropped}
+	for _, status := range want {
+		pool.setLifecycle(hash, status, "")
+	}
+	timeout := time.After(time.Second)
+	for i, status := range want {
+		select {
+		case ev := <-events:
+			if len(ev.Records) != 1 || ev.Records[0].Status != status {
+				t.Fatalf("announcement %d mismatch: have %v, want %v", i, ev.Records, status)
+			}
+		case <-timeout:
+			t.Fatalf("announcement %d timed out", i)
+		}
+	}
+}
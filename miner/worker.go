@@ -18,6 +18,7 @@ package miner
 
 import (
 	"fmt"
+	"math/big"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -354,7 +355,7 @@ func (self *worker) update() {
 					acc, _ := types.Sender(self.current.signer, tx)
 					txs[acc] = append(txs[acc], tx)
 				}
-				txset := types.NewTransactionsByPriceAndNonce(self.current.signer, txs)
+				txset := types.NewTransactionsByPriorityAndNonce(self.current.signer, txs, self.current.powers(txs))
 				self.current.commitTransactions(self.mux, txset, self.chain, self.coinbase)
 				self.updateSnapshot()
 				self.currentMu.Unlock()
@@ -517,7 +518,7 @@ func (self *worker) commitNewWork() (*Work, error) {
 		return nil, fmt.Errorf("got error when fetch pending transactions, err: %s", err)
 	}
 
-	txs := types.NewTransactionsByPriceAndNonce(self.current.signer, pending)
+	txs := types.NewTransactionsByPriorityAndNonce(self.current.signer, pending, work.powers(pending))
 	work.commitTransactions(self.mux, txs, self.chain, self.coinbase)
 
 	// compute uncles for the new block.
@@ -571,6 +572,16 @@ func (self *worker) updateSnapshot() {
 	self.snapshotState = self.current.state.Copy()
 }
 
+// powers retrieves the power available to each of the transaction senders at
+// the block being mined, used to prioritise equally priced transactions.
+func (env *Work) powers(txs map[common.Address]types.Transactions) map[common.Address]*big.Int {
+	powers := make(map[common.Address]*big.Int, len(txs))
+	for addr := range txs {
+		powers[addr] = env.state.GetPower(addr, env.header.Number)
+	}
+	return powers
+}
+
 func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address) {
 	if env.gasPool == nil {
 		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
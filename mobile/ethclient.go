@@ -161,6 +161,17 @@ func (ec *EthereumClient) GetBalanceAt(ctx *Context, account *Address, number in
 	return &BigInt{rawBalance}, err
 }
 
+// GetPowerAt returns the power of the given account, available to pay for transactions.
+// The block number can be <0, in which case the power is taken from the latest known block.
+func (ec *EthereumClient) GetPowerAt(ctx *Context, account *Address, number int64) (power *BigInt, _ error) {
+	if number < 0 {
+		rawPower, err := ec.client.PowerAt(ctx.context, account.address, nil)
+		return &BigInt{rawPower}, err
+	}
+	rawPower, err := ec.client.PowerAt(ctx.context, account.address, big.NewInt(number))
+	return &BigInt{rawPower}, err
+}
+
 // GetStorageAt returns the value of key in the contract storage of the given account.
 // The block number can be <0, in which case the value is taken from the latest known block.
 func (ec *EthereumClient) GetStorageAt(ctx *Context, account *Address, key *Hash, number int64) (storage []byte, _ error) {
@@ -151,6 +151,13 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// RPCLimits configures the rate limits, quotas and authentication enforced
+	// on the requests of the HTTP and websocket RPC interfaces, as well as of the
+	// handlers served on the HTTP endpoint (limited as the namespace named after
+	// their path). If nil, requests are only subject to the default request size
+	// limit.
+	RPCLimits *rpc.Limits `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 
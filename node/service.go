@@ -17,6 +17,7 @@
 package node
 
 import (
+	"net/http"
 	"reflect"
 
 	"github.com/etherzero/go-etherzero/accounts"
@@ -96,3 +97,11 @@ type Service interface {
 	// are all terminated.
 	Stop() error
 }
+
+// HTTPService is implemented by services which, besides their RPC APIs, serve
+// plain HTTP handlers on the node's HTTP endpoint.
+type HTTPService interface {
+	// HTTPHandlers retrieves the handlers the service wishes to serve, keyed by
+	// the URL path they are served on.
+	HTTPHandlers() map[string]http.Handler
+}
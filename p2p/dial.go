@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/etherzero/go-etherzero/log"
@@ -79,6 +80,7 @@ type dialstate struct {
 	randomNodes   []*enode.Node // filled from Table
 	static        map[enode.ID]*dialTask
 	hist          *dialHistory
+	reputation    *reputation // Scores of known nodes, nil if not tracked
 
 	start     time.Time     // time when the dialer was first used
 	bootnodes []*enode.Node // default dials when there are no peers
@@ -87,6 +89,7 @@ type dialstate struct {
 type discoverTable interface {
 	Close()
 	Resolve(*enode.Node) *enode.Node
+	RequestENR(*enode.Node) (*enode.Node, error)
 	LookupRandom() []*enode.Node
 	ReadRandomNodes([]*enode.Node) int
 }
@@ -109,6 +112,7 @@ type task interface {
 type dialTask struct {
 	flags        connFlag
 	dest         *enode.Node
+	checked      bool // whether dest was already checked against the dial filters
 	lastResolved time.Time
 	resolveDelay time.Duration
 }
@@ -165,13 +169,13 @@ func (s *dialstate) newTasks(nRunning int, peers map[enode.ID]*Peer, now time.Ti
 	}
 
 	var newtasks []task
-	addDial := func(flag connFlag, n *enode.Node) bool {
+	addDial := func(flag connFlag, n *enode.Node, checked bool) bool {
 		if err := s.checkDial(n, peers); err != nil {
 			log.Trace("Skipping dial candidate", "id", n.ID(), "addr", &net.TCPAddr{IP: n.IP(), Port: n.TCP()}, "err", err)
 			return false
 		}
 		s.dialing[n.ID()] = flag
-		newtasks = append(newtasks, &dialTask{flags: flag, dest: n})
+		newtasks = append(newtasks, &dialTask{flags: flag, dest: n, checked: checked})
 		return true
 	}
 
@@ -211,7 +215,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[enode.ID]*Peer, now time.Ti
 		s.bootnodes = append(s.bootnodes[:0], s.bootnodes[1:]...)
 		s.bootnodes = append(s.bootnodes, bootnode)
 
-		if addDial(dynDialedConn, bootnode) {
+		if addDial(dynDialedConn, bootnode, false) {
 			needDynDials--
 		}
 	}
@@ -221,16 +225,16 @@ func (s *dialstate) newTasks(nRunning int, peers map[enode.ID]*Peer, now time.Ti
 	if randomCandidates > 0 {
 		n := s.ntab.ReadRandomNodes(s.randomNodes)
 		for i := 0; i < randomCandidates && i < n; i++ {
-			if addDial(dynDialedConn, s.randomNodes[i]) {
+			if addDial(dynDialedConn, s.randomNodes[i], false) {
 				needDynDials--
 			}
 		}
 	}
 	// Create dynamic dials from random lookup results, removing tried
-	// items from the result buffer.
+	// items from the result buffer. The lookup already checked their records.
 	i := 0
 	for ; i < len(s.lookupBuf) && needDynDials > 0; i++ {
-		if addDial(dynDialedConn, s.lookupBuf[i]) {
+		if addDial(dynDialedConn, s.lookupBuf[i], true) {
 			needDynDials--
 		}
 	}
@@ -258,6 +262,7 @@ var (
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNotWhitelisted   = errors.New("not contained in netrestrict whitelist")
+	errBanned           = errors.New("is banned")
 )
 
 func (s *dialstate) checkDial(n *enode.Node, peers map[enode.ID]*Peer) error {
@@ -273,6 +278,8 @@ func (s *dialstate) checkDial(n *enode.Node, peers map[enode.ID]*Peer) error {
 		return errNotWhitelisted
 	case s.hist.contains(n.ID()):
 		return errRecentlyDialed
+	case s.reputation != nil && s.reputation.banned(n.ID()):
+		return errBanned
 	}
 	return nil
 }
@@ -285,6 +292,9 @@ func (s *dialstate) taskDone(t task, now time.Time) {
 	case *discoverTask:
 		s.lookupRunning = false
 		s.lookupBuf = append(s.lookupBuf, t.results...)
+		if s.reputation != nil {
+			s.reputation.sort(s.lookupBuf)
+		}
 	}
 }
 
@@ -294,6 +304,14 @@ func (t *dialTask) Do(srv *Server) {
 			return
 		}
 	}
+	if t.flags&dynDialedConn != 0 && !t.checked && srv.hasDialFilters() {
+		dest, accept, _ := srv.checkCandidate(t.dest)
+		if !accept {
+			log.Trace("Skipping filtered dial candidate", "id", dest.ID(), "addr", &net.TCPAddr{IP: dest.IP(), Port: dest.TCP()})
+			return
+		}
+		t.dest = dest
+	}
 	err := t.dial(srv, t.dest)
 	if err != nil {
 		log.Trace("Dial error", "task", t, "err", err)
@@ -340,6 +358,76 @@ func (t *dialTask) resolve(srv *Server) bool {
 	return true
 }
 
+// hasDialFilters reports whether any protocol wants to check the records of the
+// nodes found through discovery before they are dialed.
+func (srv *Server) hasDialFilters() bool {
+	if srv.ntab == nil {
+		return false
+	}
+	for _, p := range srv.Protocols {
+		if p.DialFilter != nil || p.DialPriority != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCandidate retrieves the record of a dial candidate unless it's already
+// known, and checks it against the dial filters of all protocols. It returns
+// the updated node, whether it may be dialed and whether it's preferred.
+func (srv *Server) checkCandidate(n *enode.Node) (*enode.Node, bool, bool) {
+	// Records learnt from neighbor lists are unsigned placeholders
+	if n.Seq() == 0 {
+		if record, err := srv.ntab.RequestENR(n); err == nil {
+			n = record
+		} else {
+			log.Trace("Failed to retrieve node record", "id", n.ID(), "err", err)
+		}
+	}
+	preferred := false
+	for _, p := range srv.Protocols {
+		if p.DialFilter != nil && !p.DialFilter(n) {
+			return n, false, false
+		}
+		if p.DialPriority != nil && p.DialPriority(n) {
+			preferred = true
+		}
+	}
+	return n, true, preferred
+}
+
+// filterCandidates checks the nodes found by a discovery lookup concurrently,
+// dropping the rejected ones and moving the preferred ones to the front.
+func (srv *Server) filterCandidates(nodes []*enode.Node) []*enode.Node {
+	var (
+		checked   = make([]*enode.Node, len(nodes))
+		accepted  = make([]bool, len(nodes))
+		preferred = make([]bool, len(nodes))
+		wg        sync.WaitGroup
+	)
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n *enode.Node) {
+			defer wg.Done()
+			checked[i], accepted[i], preferred[i] = srv.checkCandidate(n)
+		}(i, n)
+	}
+	wg.Wait()
+
+	var front, back []*enode.Node
+	for i, n := range checked {
+		switch {
+		case !accepted[i]:
+			log.Trace("Dropping filtered dial candidate", "id", n.ID())
+		case preferred[i]:
+			front = append(front, n)
+		default:
+			back = append(back, n)
+		}
+	}
+	return append(front, back...)
+}
+
 type dialError struct {
 	error
 }
@@ -369,6 +457,9 @@ func (t *discoverTask) Do(srv *Server) {
 	}
 	srv.lastLookup = time.Now()
 	t.results = srv.ntab.LookupRandom()
+	if srv.hasDialFilters() {
+		t.results = srv.filterCandidates(t.results)
+	}
 }
 
 func (t *discoverTask) String() string {
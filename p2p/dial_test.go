@@ -18,6 +18,7 @@ package p2p
 
 import (
 	"encoding/binary"
+	"errors"
 	"net"
 	"reflect"
 	"testing"
@@ -86,6 +87,10 @@ func (t fakeTable) LookupRandom() []*enode.Node           { return nil }
 func (t fakeTable) Resolve(*enode.Node) *enode.Node       { return nil }
 func (t fakeTable) ReadRandomNodes(buf []*enode.Node) int { return copy(buf, t) }
 
+func (t fakeTable) RequestENR(n *enode.Node) (*enode.Node, error) {
+	return nil, errors.New("not supported")
+}
+
 // This test checks that dynamic dials are launched from discovery results.
 func TestDialStateDynDial(t *testing.T) {
 	runDialTest(t, dialtest{
@@ -118,9 +123,9 @@ func TestDialStateDynDial(t *testing.T) {
 					}},
 				},
 				new: []task{
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(3), nil)},
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(4), nil)},
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(5), nil)},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(3), nil), checked: true},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(4), nil), checked: true},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(5), nil), checked: true},
 				},
 			},
 			// Some of the dials complete but no new ones are launched yet because
@@ -134,8 +139,8 @@ func TestDialStateDynDial(t *testing.T) {
 					{rw: &conn{flags: dynDialedConn, node: newNode(uintID(4), nil)}},
 				},
 				done: []task{
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(3), nil)},
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(4), nil)},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(3), nil), checked: true},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(4), nil), checked: true},
 				},
 			},
 			// No new dial tasks are launched in the this round because
@@ -150,7 +155,7 @@ func TestDialStateDynDial(t *testing.T) {
 					{rw: &conn{flags: dynDialedConn, node: newNode(uintID(5), nil)}},
 				},
 				done: []task{
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(5), nil)},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(5), nil), checked: true},
 				},
 				new: []task{
 					&waitExpireTask{Duration: 14 * time.Second},
@@ -167,7 +172,7 @@ func TestDialStateDynDial(t *testing.T) {
 					{rw: &conn{flags: dynDialedConn, node: newNode(uintID(5), nil)}},
 				},
 				new: []task{
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(6), nil)},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(6), nil), checked: true},
 				},
 			},
 			// More peers (3,4) drop off and dial for ID 6 completes.
@@ -180,10 +185,10 @@ func TestDialStateDynDial(t *testing.T) {
 					{rw: &conn{flags: dynDialedConn, node: newNode(uintID(5), nil)}},
 				},
 				done: []task{
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(6), nil)},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(6), nil), checked: true},
 				},
 				new: []task{
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(7), nil)},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(7), nil), checked: true},
 					&discoverTask{},
 				},
 			},
@@ -198,7 +203,7 @@ func TestDialStateDynDial(t *testing.T) {
 					{rw: &conn{flags: dynDialedConn, node: newNode(uintID(7), nil)}},
 				},
 				done: []task{
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(7), nil)},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(7), nil), checked: true},
 				},
 			},
 			// Finish the running node discovery with an empty set. A new lookup
@@ -353,9 +358,9 @@ func TestDialStateDynDialFromTable(t *testing.T) {
 					}},
 				},
 				new: []task{
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(10), nil)},
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(11), nil)},
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(12), nil)},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(10), nil), checked: true},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(11), nil), checked: true},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(12), nil), checked: true},
 					&discoverTask{},
 				},
 			},
@@ -372,9 +377,9 @@ func TestDialStateDynDialFromTable(t *testing.T) {
 					&dialTask{flags: dynDialedConn, dest: newNode(uintID(3), nil)},
 					&dialTask{flags: dynDialedConn, dest: newNode(uintID(4), nil)},
 					&dialTask{flags: dynDialedConn, dest: newNode(uintID(5), nil)},
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(10), nil)},
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(11), nil)},
-					&dialTask{flags: dynDialedConn, dest: newNode(uintID(12), nil)},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(10), nil), checked: true},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(11), nil), checked: true},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(12), nil), checked: true},
 				},
 			},
 			// Waiting for expiry. No waitExpireTask is launched because the
@@ -404,6 +409,46 @@ func TestDialStateDynDialFromTable(t *testing.T) {
 	})
 }
 
+// This test checks that the results of discovery lookups are dialed in order of
+// the reputation of the nodes.
+func TestDialStateReputation(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	rep := newReputation(db)
+	rep.adjust(uintID(1), nil, ScoreUseful)
+	rep.adjust(uintID(2), nil, ScoreTimeout)
+	rep.adjust(uintID(3), nil, 5*ScoreUseful)
+
+	state := newDialState(enode.ID{}, nil, nil, fakeTable{}, 2, nil)
+	state.reputation = rep
+
+	runDialTest(t, dialtest{
+		init: state,
+		rounds: []round{
+			// A discovery query is launched.
+			{
+				new: []task{&discoverTask{}},
+			},
+			// The best scored nodes are dialed first, the penalized one is left
+			// for later.
+			{
+				done: []task{
+					&discoverTask{results: []*enode.Node{
+						newNode(uintID(1), nil),
+						newNode(uintID(2), nil),
+						newNode(uintID(3), nil),
+					}},
+				},
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(3), nil), checked: true},
+					&dialTask{flags: dynDialedConn, dest: newNode(uintID(1), nil), checked: true},
+				},
+			},
+		},
+	})
+}
+
 func newNode(id enode.ID, ip net.IP) *enode.Node {
 	var r enr.Record
 	if ip != nil {
@@ -703,3 +748,7 @@ func (t *resolveMock) Self() *enode.Node                     { return new(enode.
 func (t *resolveMock) Close()                                {}
 func (t *resolveMock) LookupRandom() []*enode.Node           { return nil }
 func (t *resolveMock) ReadRandomNodes(buf []*enode.Node) int { return 0 }
+
+func (t *resolveMock) RequestENR(n *enode.Node) (*enode.Node, error) {
+	return nil, errors.New("not supported")
+}
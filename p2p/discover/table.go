@@ -90,6 +90,7 @@ type transport interface {
 	self() *enode.Node
 	ping(enode.ID, *net.UDPAddr) error
 	findnode(toid enode.ID, addr *net.UDPAddr, target encPubkey) ([]*node, error)
+	requestENR(n *enode.Node) (*enode.Node, error)
 	close()
 }
 
@@ -237,6 +238,12 @@ func (tab *Table) Resolve(n *enode.Node) *enode.Node {
 	return nil
 }
 
+// RequestENR retrieves the most recent record of the given node from the node
+// itself.
+func (tab *Table) RequestENR(n *enode.Node) (*enode.Node, error) {
+	return tab.net.requestENR(n)
+}
+
 // LookupRandom finds random nodes in the network.
 func (tab *Table) LookupRandom() []*enode.Node {
 	var target encPubkey
@@ -129,6 +129,10 @@ func (t *pingRecorder) ping(toid enode.ID, toaddr *net.UDPAddr) error {
 	}
 }
 
+func (t *pingRecorder) requestENR(n *enode.Node) (*enode.Node, error) {
+	return nil, errTimeout
+}
+
 func (t *pingRecorder) close() {}
 
 func hasDuplicates(slice []*node) bool {
@@ -29,6 +29,7 @@ import (
 	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/p2p/enr"
 	"github.com/etherzero/go-etherzero/p2p/netutil"
 	"github.com/etherzero/go-etherzero/rlp"
 )
@@ -62,6 +63,8 @@ const (
 	pongPacket
 	findnodePacket
 	neighborsPacket
+	enrRequestPacket
+	enrResponsePacket
 )
 
 // RPC request structures
@@ -105,6 +108,21 @@ type (
 		Rest []rlp.RawValue `rlp:"tail"`
 	}
 
+	// enrRequest queries for the remote node's record.
+	enrRequest struct {
+		Expiration uint64
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
+	// enrResponse is the reply to enrRequest.
+	enrResponse struct {
+		ReplyTok []byte // Hash of the enrRequest packet.
+		Record   enr.Record
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
 	rpcNode struct {
 		IP  net.IP // len 4 for IPv4 or 16 for IPv6
 		UDP uint16 // for discovery protocol
@@ -366,6 +384,53 @@ func (t *udp) findnode(toid enode.ID, toaddr *net.UDPAddr, target encPubkey) ([]
 	return nodes, <-errc
 }
 
+// requestENR sends an enrRequest to the given node and waits for the record it
+// replies with.
+func (t *udp) requestENR(n *enode.Node) (*enode.Node, error) {
+	addr := &net.UDPAddr{IP: n.IP(), Port: n.UDP()}
+
+	// Like findnode, the request is only answered if the destination node holds
+	// a recent endpoint proof of ours.
+	if time.Since(t.db.LastPingReceived(n.ID(), addr.IP)) > bondExpiration {
+		t.ping(n.ID(), addr)
+		time.Sleep(respTimeout)
+	}
+	req := &enrRequest{
+		Expiration: uint64(time.Now().Add(expiration).Unix()),
+	}
+	packet, hash, err := encodePacket(t.priv, enrRequestPacket, req)
+	if err != nil {
+		return nil, err
+	}
+	// Responses are matched if they reference the request we're about to send.
+	var record *enr.Record
+	errc := t.pending(n.ID(), addr.IP, enrResponsePacket, func(r interface{}) (matched bool, requestDone bool) {
+		if matched = bytes.Equal(r.(*enrResponse).ReplyTok, hash); matched {
+			record = &r.(*enrResponse).Record
+		}
+		return matched, matched
+	})
+	t.write(addr, n.ID(), req.name(), packet)
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	// Verify the response record.
+	resp, err := enode.New(enode.ValidSchemes, record)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ID() != n.ID() {
+		return nil, errors.New("invalid ID in response record")
+	}
+	if resp.Seq() < n.Seq() {
+		return n, nil // response record is older
+	}
+	if err := netutil.CheckRelayIP(addr.IP, resp.IP()); err != nil {
+		return nil, fmt.Errorf("invalid IP in response record: %v", err)
+	}
+	return resp, nil
+}
+
 // pending adds a reply matcher to the pending reply queue.
 // see the documentation of type replyMatcher for a detailed explanation.
 func (t *udp) pending(id enode.ID, ip net.IP, ptype byte, callback replyMatchFunc) <-chan error {
@@ -627,6 +692,10 @@ func decodePacket(buf []byte) (packet, encPubkey, []byte, error) {
 		req = new(findnode)
 	case neighborsPacket:
 		req = new(neighbors)
+	case enrRequestPacket:
+		req = new(enrRequest)
+	case enrResponsePacket:
+		req = new(enrResponse)
 	default:
 		return nil, fromKey, hash, fmt.Errorf("unknown type: %d", ptype)
 	}
@@ -750,6 +819,37 @@ func (req *neighbors) handle(t *udp, from *net.UDPAddr, fromID enode.ID, mac []b
 
 func (req *neighbors) name() string { return "NEIGHBORS/v4" }
 
+func (req *enrRequest) preverify(t *udp, from *net.UDPAddr, fromID enode.ID, fromKey encPubkey) error {
+	if expired(req.Expiration) {
+		return errExpired
+	}
+	if time.Since(t.db.LastPongReceived(fromID, from.IP)) > bondExpiration {
+		return errUnknownNode
+	}
+	return nil
+}
+
+func (req *enrRequest) handle(t *udp, from *net.UDPAddr, fromID enode.ID, mac []byte) {
+	t.send(from, fromID, enrResponsePacket, &enrResponse{
+		ReplyTok: mac,
+		Record:   *t.localNode.Node().Record(),
+	})
+}
+
+func (req *enrRequest) name() string { return "ENRREQUEST/v4" }
+
+func (req *enrResponse) preverify(t *udp, from *net.UDPAddr, fromID enode.ID, fromKey encPubkey) error {
+	if !t.handleReply(fromID, from.IP, enrResponsePacket, req) {
+		return errUnsolicitedReply
+	}
+	return nil
+}
+
+func (req *enrResponse) handle(t *udp, from *net.UDPAddr, fromID enode.ID, mac []byte) {
+}
+
+func (req *enrResponse) name() string { return "ENRRESPONSE/v4" }
+
 func expired(ts uint64) bool {
 	return time.Unix(int64(ts), 0).Before(time.Now())
 }
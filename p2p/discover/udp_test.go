@@ -38,6 +38,7 @@ import (
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/p2p/enr"
 	"github.com/etherzero/go-etherzero/rlp"
 )
 
@@ -451,6 +452,74 @@ func TestUDP_successfulPing(t *testing.T) {
 	}
 }
 
+func TestUDP_ENRRequest(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.close()
+
+	// Requests from unknown nodes are refused.
+	test.packetIn(errUnknownNode, enrRequestPacket, &enrRequest{Expiration: futureExp})
+
+	// Once the remote node is bonded, the local record is sent back.
+	remoteID := encodePubkey(&test.remotekey.PublicKey).id()
+	test.table.db.UpdateLastPongReceived(remoteID, test.remoteaddr.IP, time.Now())
+	test.packetIn(nil, enrRequestPacket, &enrRequest{Expiration: futureExp})
+
+	waitTok := test.sent[len(test.sent)-1][:macSize]
+	test.waitPacketOut(func(p *enrResponse) {
+		if !bytes.Equal(p.ReplyTok, waitTok) {
+			t.Errorf("wrong hash in response packet: got %x, want %x", p.ReplyTok, waitTok)
+		}
+		n, err := enode.New(enode.ValidSchemes, &p.Record)
+		if err != nil {
+			t.Fatalf("invalid record: %v", err)
+		}
+		if local := test.udp.localNode.Node(); n.ID() != local.ID() || n.Seq() != local.Seq() {
+			t.Errorf("wrong record in response: got %v, want %v", n, local)
+		}
+	})
+}
+
+func TestUDP_requestENR(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.close()
+
+	remote := enode.NewV4(&test.remotekey.PublicKey, test.remoteaddr.IP, 0, test.remoteaddr.Port)
+	test.table.db.UpdateLastPingReceived(remote.ID(), test.remoteaddr.IP, time.Now())
+
+	var (
+		resp *enode.Node
+		errc = make(chan error, 1)
+	)
+	go func() {
+		var err error
+		resp, err = test.udp.requestENR(remote)
+		errc <- err
+	}()
+	_, hash, _ := test.waitPacketOut(func(p *enrRequest) {})
+
+	// Reply with a newer record of the remote node.
+	var record enr.Record
+	record.Set(enr.IP(test.remoteaddr.IP))
+	record.Set(enr.UDP(test.remoteaddr.Port))
+	record.Set(enr.WithEntry("test", uint(7)))
+	record.SetSeq(remote.Seq() + 1)
+	if err := enode.SignV4(&record, test.remotekey); err != nil {
+		t.Fatal(err)
+	}
+	test.packetIn(nil, enrResponsePacket, &enrResponse{ReplyTok: hash, Record: record})
+
+	if err := <-errc; err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.ID() != remote.ID() || resp.Seq() != record.Seq() {
+		t.Fatalf("wrong response record: got %v", resp)
+	}
+	var value uint
+	if err := resp.Load(enr.WithEntry("test", &value)); err != nil || value != 7 {
+		t.Fatalf("response record lacks entry: got %d, err %v", value, err)
+	}
+}
+
 var testPackets = []struct {
 	input      string
 	wantPacket interface{}
@@ -40,6 +40,7 @@ const (
 	dbVersionKey   = "version" // Version of the database to flush if changes
 	dbNodePrefix   = "n:"      // Identifier to prefix node entries with
 	dbLocalPrefix  = "local:"
+	dbIPBanPrefix  = "ipban:" // Identifier to prefix banned IP addresses with
 	dbDiscoverRoot = "v4"
 
 	// These fields are stored per ID and IP, the full key is "n:<ID>:v4:<IP>:findfail".
@@ -48,6 +49,7 @@ const (
 	dbNodePing      = "lastping"
 	dbNodePong      = "lastpong"
 	dbNodeSeq       = "seq"
+	dbNodeBan       = "ban"
 
 	// Local information is keyed by ID only, the full key is "local:<ID>:seq".
 	// Use localItemKey to create those keys.
@@ -300,6 +302,7 @@ func (db *DB) expirer() {
 		select {
 		case <-tick.C:
 			db.expireNodes()
+			db.expireIPBans()
 		case <-db.quit:
 			return
 		}
@@ -378,6 +381,45 @@ func (db *DB) UpdateFindFails(id ID, ip net.IP, fails int) error {
 	return db.storeInt64(nodeItemKey(id, ip, dbNodeFindFails), int64(fails))
 }
 
+// BanExpiry retrieves the time until which a node is banned.
+func (db *DB) BanExpiry(id ID) time.Time {
+	return time.Unix(db.fetchInt64(nodeItemKey(id, zeroIP, dbNodeBan)), 0)
+}
+
+// UpdateBanExpiry updates the time until which a node is banned.
+func (db *DB) UpdateBanExpiry(id ID, instance time.Time) error {
+	return db.storeInt64(nodeItemKey(id, zeroIP, dbNodeBan), instance.Unix())
+}
+
+// IPBanExpiry retrieves the time until which an IP address is banned.
+func (db *DB) IPBanExpiry(ip net.IP) time.Time {
+	if ip = ip.To16(); ip == nil {
+		return time.Unix(0, 0)
+	}
+	return time.Unix(db.fetchInt64(append([]byte(dbIPBanPrefix), ip...)), 0)
+}
+
+// UpdateIPBanExpiry updates the time until which an IP address is banned.
+func (db *DB) UpdateIPBanExpiry(ip net.IP, instance time.Time) error {
+	if ip = ip.To16(); ip == nil {
+		return fmt.Errorf("invalid IP address")
+	}
+	return db.storeInt64(append([]byte(dbIPBanPrefix), ip...), instance.Unix())
+}
+
+// expireIPBans deletes all IP address bans which have run out.
+func (db *DB) expireIPBans() {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbIPBanPrefix)), nil)
+	defer it.Release()
+
+	now := time.Now().Unix()
+	for it.Next() {
+		if expiry, _ := binary.Varint(it.Value()); expiry < now {
+			db.lvl.Delete(it.Key(), nil)
+		}
+	}
+}
+
 // LocalSeq retrieves the local record sequence counter.
 func (db *DB) localSeq(id ID) uint64 {
 	return db.fetchUint64(nodeItemKey(id, zeroIP, dbLocalSeq))
@@ -113,6 +113,9 @@ type Peer struct {
 
 	// events receives message send / receive events if set
 	events *event.Feed
+
+	// reputation tracks the score of the peer if set
+	reputation *reputation
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -190,6 +193,29 @@ func newPeer(conn *conn, protocols []Protocol) *Peer {
 	return p
 }
 
+// AdjustScore changes the reputation of the peer by the given amount, positive
+// for useful behaviour and negative for misbehaviour. Peers whose score drops
+// too low are disconnected and banned for a while. Trusted peers are exempt.
+func (p *Peer) AdjustScore(delta int, reason string) {
+	if p.reputation == nil || delta == 0 || p.rw.is(trustedConn) {
+		return
+	}
+	var ip net.IP
+	if tcp, ok := p.RemoteAddr().(*net.TCPAddr); ok {
+		ip = tcp.IP
+	}
+	score, banned := p.reputation.adjust(p.ID(), ip, delta)
+	if delta < 0 {
+		p.log.Debug("Penalized peer", "delta", delta, "score", score, "reason", reason)
+	} else {
+		p.log.Trace("Rewarded peer", "delta", delta, "score", score, "reason", reason)
+	}
+	if banned {
+		p.log.Debug("Banning peer", "duration", banDuration, "reason", reason)
+		p.Disconnect(DiscUselessPeer)
+	}
+}
+
 func (p *Peer) Log() log.Logger {
 	return p.log
 }
@@ -56,6 +56,15 @@ type Protocol struct {
 
 	// Attributes contains protocol specific information for the node record.
 	Attributes []enr.Entry
+
+	// DialFilter is an optional helper method called with the record of each
+	// node found through discovery before it is dialed. Nodes rejected by the
+	// filter of any protocol are skipped without an RLPx handshake.
+	DialFilter func(n *enode.Node) bool
+
+	// DialPriority is an optional helper method reporting whether a node found
+	// through discovery should be dialed ahead of the other candidates.
+	DialPriority func(n *enode.Node) bool
 }
 
 func (p Protocol) cap() Cap {
@@ -0,0 +1,223 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/etherzero/go-etherzero/p2p/enode"
+)
+
+// Score adjustments applied by the protocols for the behaviour of their peers.
+const (
+	ScoreUseful    = 1   // Reward for delivering requested or otherwise useful data
+	ScoreDuplicate = -1  // Penalty for resending data the peer already sent
+	ScoreTimeout   = -10 // Penalty for failing to answer a request in time
+	ScoreInvalid   = -50 // Penalty for sending invalid data or violating the protocol
+)
+
+const (
+	maxScore    = 100         // Highest score a peer can accumulate
+	banScore    = -100        // Score at which a peer is banned
+	scoreDecay  = time.Minute // Time in which a score recovers one point towards zero
+	banDuration = time.Hour   // Time for which the node and IP of a banned peer are refused
+	maxScores   = 10000       // Maximum number of node scores tracked
+)
+
+// PeerScore is the reputation of a node, as reported by the admin API.
+type PeerScore struct {
+	ID          string     `json:"id"`                    // Unique node identifier
+	Score       int        `json:"score"`                 // Current score of the node
+	BannedUntil *time.Time `json:"bannedUntil,omitempty"` // Expiry of the ban on the node, if banned
+}
+
+// reputation tracks the scores of the nodes the server interacted with, banning
+// the ones whose score drops too low. Scores decay towards zero over time, so
+// that occasional misbehaviour is forgiven. Bans are persisted in the node
+// database to survive restarts.
+type reputation struct {
+	db     *enode.DB
+	scores map[enode.ID]*score
+	lock   sync.Mutex
+}
+
+// score is the reputation of a single node at the time of its last update.
+type score struct {
+	value   int
+	updated time.Time
+}
+
+func newReputation(db *enode.DB) *reputation {
+	return &reputation{
+		db:     db,
+		scores: make(map[enode.ID]*score),
+	}
+}
+
+// current returns the score decayed up to the given time.
+func (s *score) current(now time.Time) int {
+	decay := int(now.Sub(s.updated) / scoreDecay)
+	switch {
+	case s.value > 0 && s.value > decay:
+		return s.value - decay
+	case s.value < 0 && -s.value > decay:
+		return s.value + decay
+	default:
+		return 0
+	}
+}
+
+// score returns the current score of a node.
+func (r *reputation) score(id enode.ID) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if s := r.scores[id]; s != nil {
+		return s.current(time.Now())
+	}
+	return 0
+}
+
+// adjust changes the score of a node by the given amount, banning the node and
+// its IP address if the score drops to the ban threshold. The new score and
+// whether the node got banned are returned.
+func (r *reputation) adjust(id enode.ID, ip net.IP, delta int) (int, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	s := r.scores[id]
+	if s == nil {
+		if len(r.scores) >= maxScores {
+			r.prune(now)
+		}
+		s = new(score)
+		r.scores[id] = s
+	}
+	s.value, s.updated = s.current(now)+delta, now
+	if s.value > maxScore {
+		s.value = maxScore
+	}
+	if s.value > banScore {
+		if s.value == 0 {
+			delete(r.scores, id)
+		}
+		return s.value, false
+	}
+	// Score dropped too low, ban the node. The score keeps decaying from the
+	// threshold, so nodes misbehaving again soon after the ban are banned faster.
+	s.value = banScore
+
+	expiry := now.Add(banDuration)
+	r.db.UpdateBanExpiry(id, expiry)
+	if ip != nil && !ip.IsLoopback() {
+		r.db.UpdateIPBanExpiry(ip, expiry)
+	}
+	return banScore, true
+}
+
+// prune drops the scores that decayed to zero. If the tracked scores are still at
+// the cap, the least recently updated one is dropped too, making room for a new
+// score. Bans are persisted separately and aren't affected.
+func (r *reputation) prune(now time.Time) {
+	var (
+		oldest enode.ID
+		first  = true
+	)
+	for id, s := range r.scores {
+		if s.current(now) == 0 {
+			delete(r.scores, id)
+			continue
+		}
+		if first || s.updated.Before(r.scores[oldest].updated) {
+			oldest, first = id, false
+		}
+	}
+	if len(r.scores) >= maxScores {
+		delete(r.scores, oldest)
+	}
+}
+
+// banned reports whether a node is banned.
+func (r *reputation) banned(id enode.ID) bool {
+	return r.db.BanExpiry(id).After(time.Now())
+}
+
+// bannedIP reports whether an IP address is banned.
+func (r *reputation) bannedIP(ip net.IP) bool {
+	return ip != nil && r.db.IPBanExpiry(ip).After(time.Now())
+}
+
+// sort orders the given nodes by descending score, keeping the order of nodes
+// with equal scores.
+func (r *reputation) sort(nodes []*enode.Node) {
+	r.lock.Lock()
+	var (
+		now    = time.Now()
+		scores = make(map[enode.ID]int)
+	)
+	for _, n := range nodes {
+		if s := r.scores[n.ID()]; s != nil {
+			scores[n.ID()] = s.current(now)
+		}
+	}
+	r.lock.Unlock()
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return scores[nodes[i].ID()] > scores[nodes[j].ID()]
+	})
+}
+
+// list returns the scores of all nodes with a non-zero score, along with the
+// given nodes, and whether they are banned.
+func (r *reputation) list(ids []enode.ID) []*PeerScore {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var (
+		now  = time.Now()
+		seen = make(map[enode.ID]bool)
+		list []*PeerScore
+	)
+	add := func(id enode.ID, value int) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+
+		entry := &PeerScore{ID: id.String(), Score: value}
+		if expiry := r.db.BanExpiry(id); expiry.After(now) {
+			entry.BannedUntil = &expiry
+		}
+		list = append(list, entry)
+	}
+	for id, s := range r.scores {
+		if value := s.current(now); value != 0 {
+			add(id, value)
+		} else {
+			delete(r.scores, id)
+		}
+	}
+	for _, id := range ids {
+		add(id, 0)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
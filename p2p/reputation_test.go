@@ -0,0 +1,136 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/p2p/enr"
+)
+
+func TestReputationBan(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		rep = newReputation(db)
+		id  = enode.ID{1}
+		ip  = net.IP{10, 0, 0, 1}
+	)
+	for i := 0; i < 10; i++ {
+		rep.adjust(id, ip, ScoreUseful)
+	}
+	if score := rep.score(id); score != 10 {
+		t.Fatalf("score mismatch after rewards: have %d, want %d", score, 10)
+	}
+	for i := 0; i < 2; i++ {
+		if _, banned := rep.adjust(id, ip, ScoreInvalid); banned {
+			t.Fatal("peer banned above the threshold")
+		}
+	}
+	if rep.banned(id) || rep.bannedIP(ip) {
+		t.Fatal("ban recorded above the threshold")
+	}
+	score, banned := rep.adjust(id, ip, ScoreTimeout)
+	if !banned || score != banScore {
+		t.Fatalf("peer not banned: score %d", score)
+	}
+	if !rep.banned(id) || !rep.bannedIP(ip) {
+		t.Fatal("ban not recorded")
+	}
+	// Bans are persisted, other nodes and addresses are unaffected
+	if !newReputation(db).banned(id) {
+		t.Fatal("ban not persisted")
+	}
+	if rep.banned(enode.ID{2}) || rep.bannedIP(net.IP{10, 0, 0, 2}) {
+		t.Fatal("unrelated node banned")
+	}
+	scores := rep.list([]enode.ID{{2}})
+	if len(scores) != 2 || scores[0].BannedUntil == nil || scores[0].Score != banScore || scores[1].Score != 0 {
+		t.Fatalf("score list mismatch: have %+v %+v", scores[0], scores[1])
+	}
+}
+
+func TestReputationDecay(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	rep := newReputation(db)
+	rep.adjust(enode.ID{1}, nil, ScoreTimeout)
+	rep.adjust(enode.ID{2}, nil, 3*ScoreUseful)
+
+	// Move the updates into the past
+	for _, s := range rep.scores {
+		s.updated = s.updated.Add(-5 * scoreDecay)
+	}
+	if score := rep.score(enode.ID{1}); score != ScoreTimeout+5 {
+		t.Errorf("penalty decay mismatch: have %d, want %d", score, ScoreTimeout+5)
+	}
+	if score := rep.score(enode.ID{2}); score != 0 {
+		t.Errorf("reward decay mismatch: have %d, want %d", score, 0)
+	}
+	// Decayed scores are dropped, nodes are dialed in order of their scores
+	if scores := rep.list(nil); len(scores) != 1 {
+		t.Errorf("score list length mismatch: have %d, want %d", len(scores), 1)
+	}
+	rep.adjust(enode.ID{3}, nil, ScoreUseful)
+
+	nodes := []*enode.Node{
+		enode.SignNull(new(enr.Record), enode.ID{1}),
+		enode.SignNull(new(enr.Record), enode.ID{2}),
+		enode.SignNull(new(enr.Record), enode.ID{3}),
+	}
+	rep.sort(nodes)
+	if nodes[0].ID() != (enode.ID{3}) || nodes[1].ID() != (enode.ID{2}) || nodes[2].ID() != (enode.ID{1}) {
+		t.Errorf("dial order mismatch: have %v %v %v", nodes[0].ID(), nodes[1].ID(), nodes[2].ID())
+	}
+}
+
+func TestReputationPrune(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	rep := newReputation(db)
+	for i := 0; i < maxScores; i++ {
+		rep.adjust(uintID(uint32(i)), nil, ScoreUseful)
+	}
+	// Make room by dropping the least recently updated score
+	rep.scores[uintID(7)].updated = rep.scores[uintID(7)].updated.Add(-time.Second)
+	rep.adjust(uintID(maxScores), nil, ScoreUseful)
+
+	if len(rep.scores) != maxScores {
+		t.Fatalf("score count mismatch: have %d, want %d", len(rep.scores), maxScores)
+	}
+	if _, ok := rep.scores[uintID(7)]; ok {
+		t.Errorf("least recently updated score not dropped")
+	}
+	// Decayed scores are dropped first
+	for i := 0; i < 10; i++ {
+		rep.scores[uintID(uint32(i+100))].updated = rep.scores[uintID(uint32(i+100))].updated.Add(-scoreDecay)
+	}
+	rep.adjust(uintID(maxScores+1), nil, ScoreUseful)
+
+	if len(rep.scores) != maxScores-9 {
+		t.Fatalf("score count mismatch: have %d, want %d", len(rep.scores), maxScores-9)
+	}
+	if score := rep.score(uintID(maxScores)); score != ScoreUseful {
+		t.Errorf("recent score mismatch: have %d, want %d", score, ScoreUseful)
+	}
+}
@@ -167,6 +167,7 @@ type Server struct {
 
 	nodedb       *enode.DB
 	localnode    *enode.LocalNode
+	reputation   *reputation
 	ntab         discoverTable
 	listener     net.Listener
 	ourHandshake *protoHandshake
@@ -363,6 +364,32 @@ func (srv *Server) Self() *enode.Node {
 	return ln.Node()
 }
 
+// LocalNode returns the local node record, allowing protocols to update their
+// entries in it while the server is running.
+func (srv *Server) LocalNode() *enode.LocalNode {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	return srv.localnode
+}
+
+// PeerScores returns the reputation of all connected peers, and of all other
+// nodes whose score hasn't decayed back to zero yet.
+func (srv *Server) PeerScores() []*PeerScore {
+	srv.lock.Lock()
+	rep := srv.reputation
+	srv.lock.Unlock()
+
+	if rep == nil {
+		return nil
+	}
+	var ids []enode.ID
+	for _, p := range srv.Peers() {
+		ids = append(ids, p.ID())
+	}
+	return rep.list(ids)
+}
+
 // Stop terminates the server and all active peer connections.
 // It blocks until all active connections have been closed.
 func (srv *Server) Stop() {
@@ -459,6 +486,7 @@ func (srv *Server) Start() (err error) {
 
 	dynPeers := srv.maxDialedConns()
 	dialer := newDialState(srv.localnode.ID(), srv.StaticNodes, srv.BootstrapNodes, srv.ntab, dynPeers, srv.NetRestrict)
+	dialer.reputation = srv.reputation
 	srv.loopWG.Add(1)
 	go srv.run(dialer)
 	return nil
@@ -479,6 +507,7 @@ func (srv *Server) setupLocalNode() error {
 		return err
 	}
 	srv.nodedb = db
+	srv.reputation = newReputation(db)
 	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey)
 	srv.localnode.SetFallbackIP(net.IP{127, 0, 0, 1})
 	srv.localnode.Set(capsByNameAndVersion(srv.ourHandshake.Caps))
@@ -726,6 +755,7 @@ running:
 			if err == nil {
 				// The handshakes are done and it passed all checks.
 				p := newPeer(c, srv.Protocols)
+				p.reputation = srv.reputation
 				// If message events are enabled, pass the peerFeed
 				// to the peer
 				if srv.EnableMsgEvents {
@@ -801,6 +831,8 @@ func (srv *Server) encHandshakeChecks(peers map[enode.ID]*Peer, inboundCount int
 		return DiscAlreadyConnected
 	case c.node.ID() == srv.localnode.ID():
 		return DiscSelf
+	case !c.is(trustedConn) && srv.reputation != nil && srv.reputation.banned(c.node.ID()):
+		return DiscUselessPeer
 	default:
 		return nil
 	}
@@ -869,6 +901,13 @@ func (srv *Server) listenLoop() {
 		if tcp, ok := fd.RemoteAddr().(*net.TCPAddr); ok {
 			ip = tcp.IP
 		}
+		// Reject connections from the addresses of banned peers.
+		if srv.reputation.bannedIP(ip) {
+			srv.log.Debug("Rejected conn (banned address)", "addr", fd.RemoteAddr())
+			fd.Close()
+			slots <- struct{}{}
+			continue
+		}
 		fd = newMeteredConn(fd, true, ip)
 		srv.log.Trace("Accepted connection", "addr", fd.RemoteAddr())
 		go func() {
@@ -19,6 +19,7 @@ package p2p
 import (
 	"crypto/ecdsa"
 	"errors"
+	"io"
 	"math/rand"
 	"net"
 	"reflect"
@@ -414,6 +415,69 @@ func TestServerAtCap(t *testing.T) {
 	}
 }
 
+// Tests that connections from banned nodes are refused after the encryption
+// handshake, unless the nodes are trusted.
+func TestServerBannedNode(t *testing.T) {
+	remote := newkey()
+	remoteID := enode.PubkeyToIDV4(&remote.PublicKey)
+	srv := &Server{
+		Config: Config{
+			PrivateKey: newkey(),
+			MaxPeers:   10,
+			NoDial:     true,
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	newconn := func(id enode.ID) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(&remote.PublicKey, fd)
+		node := enode.SignNull(new(enr.Record), id)
+		return &conn{fd: fd, transport: tx, flags: inboundConn, node: node, cont: make(chan error)}
+	}
+	srv.reputation.db.UpdateBanExpiry(remoteID, time.Now().Add(time.Hour))
+
+	if err := srv.checkpoint(newconn(remoteID), srv.posthandshake); err != DiscUselessPeer {
+		t.Errorf("wrong error for banned conn: have %v, want %v", err, DiscUselessPeer)
+	}
+	if err := srv.checkpoint(newconn(randomID()), srv.posthandshake); err != nil {
+		t.Errorf("unexpected error for unbanned conn: %v", err)
+	}
+	srv.AddTrustedPeer(newNode(remoteID, nil))
+	if err := srv.checkpoint(newconn(remoteID), srv.posthandshake); err != nil {
+		t.Errorf("unexpected error for trusted banned conn: %v", err)
+	}
+}
+
+// Tests that inbound connections from banned addresses are closed before any
+// handshake is attempted.
+func TestServerBannedAddress(t *testing.T) {
+	connected := make(chan *Peer, 1)
+	srv := startTestServer(t, &newkey().PublicKey, func(p *Peer) { connected <- p })
+	defer srv.Stop()
+
+	srv.reputation.db.UpdateIPBanExpiry(net.IP{127, 0, 0, 1}, time.Now().Add(time.Hour))
+
+	conn, err := net.DialTimeout("tcp", srv.ListenAddr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("connection from banned address not closed: %v", err)
+	}
+	select {
+	case <-connected:
+		t.Error("peer added from banned address")
+	default:
+	}
+}
+
 func TestServerPeerLimits(t *testing.T) {
 	srvkey := newkey()
 	clientkey := newkey()
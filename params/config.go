@@ -56,6 +56,10 @@ var TrustedCheckpoints = map[common.Hash]*TrustedCheckpoint{
 	GoerliGenesisHash:  GoerliTrustedCheckpoint,
 }
 
+// SyncCheckpoints associates each known sync checkpoint with the genesis hash of
+// the chain it belongs to. Every entry must pin the hash of its block.
+var SyncCheckpoints = map[common.Hash]*SyncCheckpoint{}
+
 var (
 
 	DevoteChainConfig = &ChainConfig{
@@ -204,6 +208,26 @@ type TrustedCheckpoint struct {
 	BloomRoot    common.Hash `json:"bloomRoot"`
 }
 
+// SyncCheckpoint is a block that any chain synchronised from the network must
+// contain, along with the set of witnesses expected to be sealing blocks at its
+// height. Devote finality is short-range, so a fresh node relies on it (weak
+// subjectivity) to reject long range rewrites served by malicious peers.
+type SyncCheckpoint struct {
+	Name      string      `json:"-"`
+	Number    uint64      `json:"number"`
+	Hash      common.Hash `json:"hash"`      // Hash of the checkpoint block
+	Witnesses []string    `json:"witnesses"` // Witnesses allowed to seal the checkpoint block (empty if any)
+}
+
+// Validate checks that the checkpoint pins the hash of its block. A checkpoint
+// known only by its height would trust whichever block a peer serves first.
+func (c *SyncCheckpoint) Validate() error {
+	if c.Hash == (common.Hash{}) {
+		return fmt.Errorf("sync checkpoint %d has no block hash", c.Number)
+	}
+	return nil
+}
+
 // ChainConfig is the core config which determines the blockchain settings.
 //
 // ChainConfig is stored in the database on a per block basis. This means
@@ -18,12 +18,23 @@ package rpc
 
 import (
 	"net"
+	"net/http"
+	"strings"
 
 	"github.com/etherzero/go-etherzero/log"
 )
 
 // StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules
 func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, timeouts HTTPTimeouts) (net.Listener, *Server, error) {
+	return StartHTTPEndpointWithHandlers(endpoint, apis, modules, cors, vhosts, timeouts, nil, nil)
+}
+
+// StartHTTPEndpointWithHandlers starts the HTTP RPC endpoint, configured with
+// cors/vhosts/modules and optional request limits, additionally serving the
+// given handlers on their paths. The RPC API is served on all other paths.
+// Requests to a handler are subject to the limits and credentials of the
+// namespace named after its path, e.g. graphql for /graphql.
+func StartHTTPEndpointWithHandlers(endpoint string, apis []API, modules []string, cors []string, vhosts []string, timeouts HTTPTimeouts, handlers map[string]http.Handler, limits *Limits) (net.Listener, *Server, error) {
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
 	for _, module := range modules {
@@ -39,6 +50,9 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 			log.Debug("HTTP registered", "namespace", api.Namespace)
 		}
 	}
+	if limits != nil {
+		handler.SetLimits(*limits)
+	}
 	// All APIs registered, start the HTTP listener
 	var (
 		listener net.Listener
@@ -47,13 +61,29 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return nil, nil, err
 	}
-	go NewHTTPServer(cors, vhosts, timeouts, handler).Serve(listener)
+	if len(handlers) == 0 {
+		go NewHTTPServer(cors, vhosts, timeouts, handler).Serve(listener)
+		return listener, handler, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/", handler)
+	for path, h := range handlers {
+		// Handlers are subject to the limits of the namespace named after their path
+		mux.Handle(path, handler.limits.limitHandler(strings.Trim(path, "/"), h))
+		log.Debug("HTTP handler registered", "path", path)
+	}
+	go newHTTPServer(cors, vhosts, timeouts, mux).Serve(listener)
 	return listener, handler, err
 }
 
 // StartWSEndpoint starts a websocket endpoint
 func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool) (net.Listener, *Server, error) {
+	return StartWSEndpointWithLimits(endpoint, apis, modules, wsOrigins, exposeAll, nil)
+}
 
+// StartWSEndpointWithLimits starts a websocket endpoint enforcing the given
+// request limits.
+func StartWSEndpointWithLimits(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool, limits *Limits) (net.Listener, *Server, error) {
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
 	for _, module := range modules {
@@ -69,6 +99,9 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 			log.Debug("WebSocket registered", "service", api.Service, "namespace", api.Namespace)
 		}
 	}
+	if limits != nil {
+		handler.SetLimits(*limits)
+	}
 	// All APIs registered, start the HTTP listener
 	var (
 		listener net.Listener
@@ -0,0 +1,414 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/metrics"
+)
+
+const (
+	// defaultRuleKey is the key of the rule applying to all methods without a
+	// more specific rule configured.
+	defaultRuleKey = "*"
+
+	// maxTrackedClients is the number of rate limited clients after which the
+	// idle ones are dropped.
+	maxTrackedClients = 4096
+)
+
+var (
+	rateLimitedMeter        = metrics.NewRegisteredMeter("rpc/limited/rate", nil)
+	concurrencyLimitedMeter = metrics.NewRegisteredMeter("rpc/limited/concurrency", nil)
+	requestLimitedMeter     = metrics.NewRegisteredMeter("rpc/limited/request", nil)
+	batchLimitedMeter       = metrics.NewRegisteredMeter("rpc/limited/batch", nil)
+	responseLimitedMeter    = metrics.NewRegisteredMeter("rpc/limited/response", nil)
+	unauthorizedMeter       = metrics.NewRegisteredMeter("rpc/unauthorized", nil)
+)
+
+// MethodLimit configures the admission of requests to a method or namespace.
+type MethodLimit struct {
+	Rate        float64 `toml:",omitempty"` // Requests per second allowed per client (0 = unlimited)
+	Burst       int     `toml:",omitempty"` // Requests a client may issue at once (defaults to the rate)
+	Concurrency int     `toml:",omitempty"` // Requests allowed to execute at the same time (0 = unlimited)
+}
+
+// ModuleAuth configures the credentials required to access an RPC namespace.
+// A request is authorized if it carries either one of the static tokens, or
+// a JWT signed with the secret as its bearer token.
+type ModuleAuth struct {
+	Tokens    []string      `toml:",omitempty"` // Accepted static bearer tokens
+	JWTSecret hexutil.Bytes `toml:",omitempty"` // HMAC-SHA256 secret of accepted JWTs
+}
+
+// Limits configures the request admission policy of a networked RPC server.
+//
+// Method limits are looked up by full method name (eth_call), then by namespace
+// (eth), falling back to the "*" rule. Only the most specific rule applies.
+type Limits struct {
+	Methods map[string]MethodLimit `toml:",omitempty"`
+	Auth    map[string]ModuleAuth  `toml:",omitempty"`
+
+	MaxRequestSize  int64 `toml:",omitempty"` // Maximum size of a request body in bytes
+	MaxBatchSize    int   `toml:",omitempty"` // Maximum number of requests in a batch
+	MaxResponseSize int   `toml:",omitempty"` // Maximum size of a single result in bytes
+}
+
+// limitExceededError is returned if a request is refused by a configured limit.
+type limitExceededError struct{ message string }
+
+func (e *limitExceededError) ErrorCode() int { return -32005 }
+
+func (e *limitExceededError) Error() string { return e.message }
+
+// unauthorizedError is returned if a request lacks valid credentials for the
+// namespace it accesses.
+type unauthorizedError struct{ message string }
+
+func (e *unauthorizedError) ErrorCode() int { return -32001 }
+
+func (e *unauthorizedError) Error() string { return e.message }
+
+// authTokenKey is the context key of the bearer token of a request.
+type authTokenKey struct{}
+
+// tokenBucket is a rate limiter allowing bursts of requests.
+type tokenBucket struct {
+	rate   float64   // Tokens refilled per second
+	burst  float64   // Maximum number of tokens held
+	tokens float64   // Tokens currently available
+	last   time.Time // Time of the last refill
+}
+
+// refill adds the tokens accumulated since the last refill.
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// limiter enforces a set of limits on the requests of a server.
+type limiter struct {
+	config Limits
+
+	lock    sync.Mutex
+	buckets map[string]*tokenBucket // Rate limiters keyed by rule and client
+	running map[string]int          // Requests in execution keyed by rule
+}
+
+// newLimiter creates a limiter enforcing the given limits.
+func newLimiter(config Limits) *limiter {
+	return &limiter{
+		config:  config,
+		buckets: make(map[string]*tokenBucket),
+		running: make(map[string]int),
+	}
+}
+
+// SetLimits configures the limits enforced on the requests of the server. It
+// must be called before the server starts serving requests.
+func (s *Server) SetLimits(limits Limits) {
+	s.limits = newLimiter(limits)
+}
+
+// maxRequestSize returns the maximum size of a request body.
+func (l *limiter) maxRequestSize() int64 {
+	if l == nil || l.config.MaxRequestSize <= 0 {
+		return maxRequestContentLength
+	}
+	return l.config.MaxRequestSize
+}
+
+// checkBatch verifies the number of requests in a batch.
+func (l *limiter) checkBatch(size int) Error {
+	if l == nil || l.config.MaxBatchSize <= 0 || size <= l.config.MaxBatchSize {
+		return nil
+	}
+	batchLimitedMeter.Mark(1)
+	return &limitExceededError{fmt.Sprintf("batch too large (%d>%d)", size, l.config.MaxBatchSize)}
+}
+
+// checkResponse verifies the encoded size of a method result.
+func (l *limiter) checkResponse(result interface{}) Error {
+	if l == nil || l.config.MaxResponseSize <= 0 {
+		return nil
+	}
+	blob, err := json.Marshal(result)
+	if err != nil {
+		return &callbackError{err.Error()}
+	}
+	if len(blob) > l.config.MaxResponseSize {
+		responseLimitedMeter.Mark(1)
+		return &limitExceededError{fmt.Sprintf("response too large (%d>%d)", len(blob), l.config.MaxResponseSize)}
+	}
+	return nil
+}
+
+// admit authorizes a request to the given method and applies the limits of
+// its rule. If admitted, the returned function must be called once the request
+// finished executing.
+func (l *limiter) admit(ctx context.Context, namespace, method string) (func(), Error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if err := l.authorize(ctx, namespace); err != nil {
+		unauthorizedMeter.Mark(1)
+		return nil, err
+	}
+	key, rule, ok := l.rule(namespace, method)
+	if !ok {
+		return func() {}, nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if rule.Rate > 0 && !l.take(key+"/"+clientFromContext(ctx), rule) {
+		rateLimitedMeter.Mark(1)
+		return nil, &limitExceededError{fmt.Sprintf("rate limit of %s exceeded", key)}
+	}
+	if rule.Concurrency > 0 {
+		if l.running[key] >= rule.Concurrency {
+			concurrencyLimitedMeter.Mark(1)
+			return nil, &limitExceededError{fmt.Sprintf("concurrency limit of %s exceeded", key)}
+		}
+		l.running[key]++
+		return func() {
+			l.lock.Lock()
+			defer l.lock.Unlock()
+
+			if l.running[key]--; l.running[key] == 0 {
+				delete(l.running, key)
+			}
+		}, nil
+	}
+	return func() {}, nil
+}
+
+// rule looks up the most specific limit applying to a method. Requests without
+// a method, such as the ones of HTTP handlers, are subject to the namespace rule.
+func (l *limiter) rule(namespace, method string) (string, MethodLimit, bool) {
+	keys := []string{namespace, defaultRuleKey}
+	if method != "" {
+		keys = append([]string{namespace + serviceMethodSeparator + method}, keys...)
+	}
+	for _, key := range keys {
+		if rule, ok := l.config.Methods[key]; ok {
+			return key, rule, true
+		}
+	}
+	return "", MethodLimit{}, false
+}
+
+// take consumes a token from the bucket of a client, refilling it according to
+// the time passed since its last use. The caller must hold the lock.
+func (l *limiter) take(key string, rule MethodLimit) bool {
+	now := time.Now()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxTrackedClients {
+			l.expire(now)
+		}
+		burst := float64(rule.Burst)
+		if burst < 1 {
+			burst = rule.Rate
+			if burst < 1 {
+				burst = 1
+			}
+		}
+		bucket = &tokenBucket{rate: rule.Rate, burst: burst, tokens: burst, last: now}
+		l.buckets[key] = bucket
+	}
+	bucket.refill(now)
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// expire drops the buckets of clients idle long enough to have been refilled
+// completely, as they are equivalent to newly created ones. If all clients are
+// active, the least recently used bucket is dropped to make room for a new one.
+// The caller must hold the lock.
+func (l *limiter) expire(now time.Time) {
+	var (
+		oldest     string
+		oldestUsed time.Time
+	)
+	for key, bucket := range l.buckets {
+		used := bucket.last
+		if bucket.refill(now); bucket.tokens >= bucket.burst {
+			delete(l.buckets, key)
+			continue
+		}
+		if oldest == "" || used.Before(oldestUsed) {
+			oldest, oldestUsed = key, used
+		}
+	}
+	if len(l.buckets) >= maxTrackedClients {
+		delete(l.buckets, oldest)
+	}
+}
+
+// limitHandler wraps an HTTP handler served alongside the RPC API, subjecting
+// its requests to the credentials, limits and maximum request size configured
+// for the given namespace.
+func (l *limiter) limitHandler(namespace string, next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		maxSize := l.maxRequestSize()
+		if r.ContentLength > maxSize {
+			requestLimitedMeter.Mark(1)
+			http.Error(w, fmt.Sprintf("content length too large (%d>%d)", r.ContentLength, maxSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		ctx := context.WithValue(r.Context(), "remote", r.RemoteAddr)
+		if token := bearerToken(r); token != "" {
+			ctx = context.WithValue(ctx, authTokenKey{}, token)
+		}
+		release, err := l.admit(ctx, namespace, "")
+		if err != nil {
+			code := http.StatusTooManyRequests
+			if _, ok := err.(*unauthorizedError); ok {
+				code = http.StatusUnauthorized
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+		defer release()
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorize verifies the credentials of a request to a namespace requiring
+// authentication.
+func (l *limiter) authorize(ctx context.Context, namespace string) Error {
+	auth, ok := l.config.Auth[namespace]
+	if !ok {
+		return nil
+	}
+	token, _ := ctx.Value(authTokenKey{}).(string)
+	if token == "" {
+		return &unauthorizedError{fmt.Sprintf("missing credentials for namespace %s", namespace)}
+	}
+	for _, allowed := range auth.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(allowed)) == 1 {
+			return nil
+		}
+	}
+	if len(auth.JWTSecret) > 0 {
+		if err := verifyJWT(token, auth.JWTSecret, time.Now()); err != nil {
+			return &unauthorizedError{fmt.Sprintf("invalid token: %v", err)}
+		}
+		return nil
+	}
+	return &unauthorizedError{fmt.Sprintf("invalid credentials for namespace %s", namespace)}
+}
+
+// verifyJWT checks the signature and validity period of an HS256 signed JWT.
+func verifyJWT(token string, secret []byte, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return err
+	}
+	if header.Alg != "HS256" {
+		return fmt.Errorf("unsupported signing algorithm %q", header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errors.New("malformed signature")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return errors.New("signature mismatch")
+	}
+	var claims struct {
+		Exp *int64 `json:"exp"`
+		Nbf *int64 `json:"nbf"`
+	}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return err
+	}
+	if claims.Exp != nil && now.Unix() >= *claims.Exp {
+		return errors.New("token expired")
+	}
+	if claims.Nbf != nil && now.Unix() < *claims.Nbf {
+		return errors.New("token not yet valid")
+	}
+	return nil
+}
+
+// decodeJWTSegment decodes a base64url encoded JSON segment of a JWT.
+func decodeJWTSegment(segment string, v interface{}) error {
+	blob, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("malformed token")
+	}
+	if err := json.Unmarshal(blob, v); err != nil {
+		return errors.New("malformed token")
+	}
+	return nil
+}
+
+// bearerToken extracts the bearer token from the Authorization header of a
+// request, if any.
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// clientFromContext returns the host of the remote end of a request, which is
+// used to identify clients for rate limiting.
+func clientFromContext(ctx context.Context) string {
+	remote, _ := ctx.Value("remote").(string)
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		return host
+	}
+	return remote
+}
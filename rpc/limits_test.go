@@ -0,0 +1,225 @@
+// Copyright 2019 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// limitedRequest posts a raw JSON-RPC request to a server with the given
+// limits, returning the decoded error code of the response, if any.
+func limitedRequest(srv *Server, body, token string) int {
+	request := httptest.NewRequest(http.MethodPost, "http://url.com", strings.NewReader(body))
+	request.Header.Set("content-type", contentType)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	srv.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		return recorder.Code
+	}
+	var resp jsonErrResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		// Successful and batch responses don't decode into an error object
+		return 0
+	}
+	return resp.Error.Code
+}
+
+func TestLimitsRate(t *testing.T) {
+	srv := newTestServer("service", new(Service))
+	srv.SetLimits(Limits{Methods: map[string]MethodLimit{
+		"service_noArgsRets": {Rate: 0.001, Burst: 2},
+	}})
+	call := `{"jsonrpc":"2.0","id":1,"method":"service_noArgsRets"}`
+	for i := 0; i < 2; i++ {
+		if code := limitedRequest(srv, call, ""); code != 0 {
+			t.Fatalf("request %d: unexpected error code %d", i, code)
+		}
+	}
+	if code := limitedRequest(srv, call, ""); code != -32005 {
+		t.Fatalf("rate limited request: error code mismatch: have %d, want %d", code, -32005)
+	}
+	// Other methods of the namespace are not affected by the method rule
+	other := `{"jsonrpc":"2.0","id":1,"method":"service_rets"}`
+	if code := limitedRequest(srv, other, ""); code != 0 {
+		t.Fatalf("unlimited request: unexpected error code %d", code)
+	}
+}
+
+func TestLimitsConcurrency(t *testing.T) {
+	l := newLimiter(Limits{Methods: map[string]MethodLimit{"service": {Concurrency: 1}}})
+
+	release, err := l.admit(context.Background(), "service", "sleep")
+	if err != nil {
+		t.Fatalf("first request refused: %v", err)
+	}
+	if _, err := l.admit(context.Background(), "service", "echo"); err == nil {
+		t.Fatal("concurrent request admitted above the namespace limit")
+	}
+	release()
+	if _, err := l.admit(context.Background(), "service", "echo"); err != nil {
+		t.Fatalf("request refused after release: %v", err)
+	}
+}
+
+func TestLimitsAuth(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	srv := newTestServer("service", new(Service))
+	srv.SetLimits(Limits{Auth: map[string]ModuleAuth{
+		"service": {Tokens: []string{"static-token"}, JWTSecret: secret},
+	}})
+	call := `{"jsonrpc":"2.0","id":1,"method":"service_noArgsRets"}`
+
+	tests := []struct {
+		token string
+		code  int
+	}{
+		{"", -32001},
+		{"wrong-token", -32001},
+		{"static-token", 0},
+		{signJWT(secret, time.Now().Add(time.Minute).Unix()), 0},
+		{signJWT(secret, time.Now().Add(-time.Minute).Unix()), -32001},
+		{signJWT([]byte("other secret"), time.Now().Add(time.Minute).Unix()), -32001},
+	}
+	for i, tt := range tests {
+		if code := limitedRequest(srv, call, tt.token); code != tt.code {
+			t.Errorf("test %d: error code mismatch: have %d, want %d", i, code, tt.code)
+		}
+	}
+	// Namespaces without credentials configured remain accessible
+	if code := limitedRequest(srv, `{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`, ""); code != 0 {
+		t.Errorf("unauthenticated namespace: unexpected error code %d", code)
+	}
+}
+
+func TestLimitsSizes(t *testing.T) {
+	srv := newTestServer("service", new(Service))
+	srv.SetLimits(Limits{MaxRequestSize: 256, MaxBatchSize: 2, MaxResponseSize: 32})
+
+	call := `{"jsonrpc":"2.0","id":1,"method":"service_noArgsRets"}`
+	if code := limitedRequest(srv, "["+call+","+call+"]", ""); code != 0 {
+		t.Fatalf("batch within limit: unexpected error code %d", code)
+	}
+	if code := limitedRequest(srv, "["+call+","+call+","+call+"]", ""); code != -32005 {
+		t.Fatalf("batch above limit: error code mismatch: have %d, want %d", code, -32005)
+	}
+	large := `{"jsonrpc":"2.0","id":1,"method":"service_echo","params":["` + strings.Repeat("x", 256) + `",1,null]}`
+	if code := limitedRequest(srv, large, ""); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("request above limit: status mismatch: have %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+	echo := `{"jsonrpc":"2.0","id":1,"method":"service_echo","params":["` + strings.Repeat("x", 64) + `",1,null]}`
+	if code := limitedRequest(srv, echo, ""); code != -32005 {
+		t.Fatalf("response above limit: error code mismatch: have %d, want %d", code, -32005)
+	}
+}
+
+func TestLimitsHandler(t *testing.T) {
+	l := newLimiter(Limits{
+		Methods:        map[string]MethodLimit{"graphql": {Rate: 0.001, Burst: 2}},
+		Auth:           map[string]ModuleAuth{"graphql": {Tokens: []string{"static-token"}}},
+		MaxRequestSize: 64,
+	})
+	handler := l.limitHandler("graphql", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+	}))
+	request := func(body, token string) int {
+		request := httptest.NewRequest(http.MethodPost, "http://url.com/graphql", strings.NewReader(body))
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+	if code := request("{}", ""); code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated request: status mismatch: have %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := request(strings.Repeat("x", 65), "static-token"); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("request above limit: status mismatch: have %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+	for i := 0; i < 2; i++ {
+		if code := request("{}", "static-token"); code != http.StatusOK {
+			t.Fatalf("authenticated request %d: status mismatch: have %d, want %d", i, code, http.StatusOK)
+		}
+	}
+	if code := request("{}", "static-token"); code != http.StatusTooManyRequests {
+		t.Fatalf("rate limited request: status mismatch: have %d, want %d", code, http.StatusTooManyRequests)
+	}
+	// Without limits configured, handlers are served as is
+	var unlimited *limiter
+	handler = unlimited.limitHandler("graphql", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	if code := request("{}", ""); code != http.StatusOK {
+		t.Fatalf("unlimited request: status mismatch: have %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestLimitsClientEviction(t *testing.T) {
+	l := newLimiter(Limits{Methods: map[string]MethodLimit{"service": {Rate: 0.001, Burst: 1}}})
+
+	// Track the maximum number of clients, all of them with drained buckets
+	for i := 0; i < maxTrackedClients; i++ {
+		ctx := context.WithValue(context.Background(), "remote", fmt.Sprintf("10.0.%d.%d:1234", i/256, i%256))
+		if _, err := l.admit(ctx, "service", "echo"); err != nil {
+			t.Fatalf("client %d: request refused: %v", i, err)
+		}
+	}
+	if len(l.buckets) != maxTrackedClients {
+		t.Fatalf("tracked client count mismatch: have %d, want %d", len(l.buckets), maxTrackedClients)
+	}
+	l.buckets["service/10.0.0.0"].last = time.Now().Add(-time.Second)
+	// A new client must evict the least recently used one, not grow the set
+	if _, err := l.admit(context.WithValue(context.Background(), "remote", "10.1.0.0:1234"), "service", "echo"); err != nil {
+		t.Fatalf("new client refused: %v", err)
+	}
+	if len(l.buckets) != maxTrackedClients {
+		t.Fatalf("tracked client count mismatch after eviction: have %d, want %d", len(l.buckets), maxTrackedClients)
+	}
+	if _, ok := l.buckets["service/10.0.0.0"]; ok {
+		t.Fatalf("least recently used client not evicted")
+	}
+	if _, ok := l.buckets["service/10.1.0.0"]; !ok {
+		t.Fatalf("new client not tracked")
+	}
+}
+
+// signJWT creates an HS256 signed JWT expiring at the given time.
+func signJWT(secret []byte, expiry int64) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]int64{"exp": expiry})
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(header + "." + payload))
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
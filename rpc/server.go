@@ -169,6 +169,17 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 			return nil
 		}
 
+		// refuse batches exceeding the configured limit as a whole
+		if batch {
+			if err := s.limits.checkBatch(len(reqs)); err != nil {
+				codec.Write(codec.CreateErrorResponse(nil, err))
+				if singleShot {
+					return nil
+				}
+				continue
+			}
+		}
+
 		// check if server is ordered to shutdown and return an error
 		// telling the client that his request failed.
 		if atomic.LoadInt32(&s.run) != 1 {
@@ -274,6 +285,13 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, &invalidParamsError{"Expected subscription id as first argument"}), nil
 	}
 
+	// authorize the request and apply the configured limits
+	release, limitErr := s.limits.admit(ctx, req.svcname, formatName(req.callb.method.Name))
+	if limitErr != nil {
+		return codec.CreateErrorResponse(&req.id, limitErr), nil
+	}
+	defer release()
+
 	if req.callb.isSubscribe {
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
@@ -317,7 +335,11 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 			return res, nil
 		}
 	}
-	return codec.CreateResponse(req.id, reply[0].Interface()), nil
+	result := reply[0].Interface()
+	if err := s.limits.checkResponse(result); err != nil {
+		return codec.CreateErrorResponse(&req.id, err), nil
+	}
+	return codec.CreateResponse(req.id, result), nil
 }
 
 // exec executes the given request and writes the result back using the codec.
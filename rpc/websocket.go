@@ -61,7 +61,7 @@ func (srv *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 		Handshake: wsHandshakeValidator(allowedOrigins),
 		Handler: func(conn *websocket.Conn) {
 			// Create a custom encode/decode pair to enforce payload size and number encoding
-			conn.MaxPayloadBytes = maxRequestContentLength
+			conn.MaxPayloadBytes = int(srv.limits.maxRequestSize())
 
 			encoder := func(v interface{}) error {
 				return websocketJSONCodec.Send(conn, v)
@@ -69,7 +69,18 @@ func (srv *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			decoder := func(v interface{}) error {
 				return websocketJSONCodec.Receive(conn, v)
 			}
-			srv.ServeCodec(NewCodec(conn, encoder, decoder), OptionMethodInvocation|OptionSubscriptions)
+			// Carry over the identity of the client for the limits of the server
+			ctx := context.Background()
+			if r := conn.Request(); r != nil {
+				ctx = context.WithValue(ctx, "remote", r.RemoteAddr)
+				if token := bearerToken(r); token != "" {
+					ctx = context.WithValue(ctx, authTokenKey{}, token)
+				}
+			}
+			codec := NewCodec(conn, encoder, decoder)
+			defer codec.Close()
+
+			srv.serveRequest(ctx, codec, false, OptionMethodInvocation|OptionSubscriptions)
 		},
 	}
 }
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	crand "crypto/rand"
 	mrand "math/rand"
+	"sort"
 	"testing"
 	"time"
 
@@ -143,6 +144,261 @@ func TestMissingKeyProof(t *testing.T) {
 	}
 }
 
+type entrySlice []*kv
+
+func (p entrySlice) Len() int           { return len(p) }
+func (p entrySlice) Less(i, j int) bool { return bytes.Compare(p[i].k, p[j].k) < 0 }
+func (p entrySlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// Tests that arbitrary sub-ranges of a trie can be proven with the merkle proofs
+// of their edge keys, and that the returned nodes are all genuine trie nodes.
+func TestRangeProof(t *testing.T) {
+	trie, entries := randomSortedTrie(4096)
+	root, _ := trie.Commit(nil)
+
+	for i := 0; i < 200; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+
+		proof := ethdb.NewMemDatabase()
+		if err := trie.Prove(entries[start].k, 0, proof); err != nil {
+			t.Fatalf("Failed to prove the first node %v", err)
+		}
+		if err := trie.Prove(entries[end-1].k, 0, proof); err != nil {
+			t.Fatalf("Failed to prove the last node %v", err)
+		}
+		var keys, vals [][]byte
+		for i := start; i < end; i++ {
+			keys = append(keys, entries[i].k)
+			vals = append(vals, entries[i].v)
+		}
+		nodes, more, err := VerifyRangeProof(root, keys[0], keys[len(keys)-1], keys, vals, proof)
+		if err != nil {
+			t.Fatalf("Case %d(%d->%d) expect no error, got %v", i, start, end-1, err)
+		}
+		if more != (end != len(entries)) {
+			t.Fatalf("Case %d(%d->%d) more mismatch: have %v", i, start, end-1, more)
+		}
+		for _, key := range nodes.Keys() {
+			want, _ := trie.db.Node(common.BytesToHash(key))
+			have, _ := nodes.Get(key)
+			if !bytes.Equal(have, want) {
+				t.Fatalf("Case %d(%d->%d) node %x mismatch: have %x, want %x", i, start, end-1, key, have, want)
+			}
+			if ok, _ := proof.Has(key); ok {
+				t.Fatalf("Case %d(%d->%d) boundary node %x returned", i, start, end-1, key)
+			}
+		}
+	}
+}
+
+// Tests that ranges with non-existent edge keys can also be proven.
+func TestRangeProofWithNonExistentProof(t *testing.T) {
+	trie, entries := randomSortedTrie(4096)
+	root := trie.Hash()
+
+	for i := 0; i < 200; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+
+		first := decreaseKey(common.CopyBytes(entries[start].k))
+		if start != 0 && bytes.Equal(first, entries[start-1].k) {
+			continue
+		}
+		last := increaseKey(common.CopyBytes(entries[end-1].k))
+		if end != len(entries) && bytes.Equal(last, entries[end].k) {
+			continue
+		}
+		proof := ethdb.NewMemDatabase()
+		if err := trie.Prove(first, 0, proof); err != nil {
+			t.Fatalf("Failed to prove the first node %v", err)
+		}
+		if err := trie.Prove(last, 0, proof); err != nil {
+			t.Fatalf("Failed to prove the last node %v", err)
+		}
+		var keys, vals [][]byte
+		for i := start; i < end; i++ {
+			keys = append(keys, entries[i].k)
+			vals = append(vals, entries[i].v)
+		}
+		if _, _, err := VerifyRangeProof(root, first, last, keys, vals, proof); err != nil {
+			t.Fatalf("Case %d(%d->%d) expect no error, got %v", i, start, end-1, err)
+		}
+	}
+}
+
+// Tests that tampered or incomplete ranges are rejected.
+func TestBadRangeProof(t *testing.T) {
+	trie, entries := randomSortedTrie(4096)
+	root := trie.Hash()
+
+	for i := 0; i < 200; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+
+		proof := ethdb.NewMemDatabase()
+		trie.Prove(entries[start].k, 0, proof)
+		trie.Prove(entries[end-1].k, 0, proof)
+
+		var keys, vals [][]byte
+		for i := start; i < end; i++ {
+			keys = append(keys, entries[i].k)
+			vals = append(vals, entries[i].v)
+		}
+		first, last := keys[0], keys[len(keys)-1]
+		switch mrand.Intn(3) {
+		case 0:
+			// Modified value
+			index := mrand.Intn(end - start)
+			vals[index] = randBytes(20)
+		case 1:
+			// Gapped entry slice
+			if end-start < 3 {
+				continue
+			}
+			index := mrand.Intn(end-start-2) + 1
+			keys = append(keys[:index], keys[index+1:]...)
+			vals = append(vals[:index], vals[index+1:]...)
+		case 2:
+			// Swapped entries
+			if end-start < 2 {
+				continue
+			}
+			keys[0], keys[1] = keys[1], keys[0]
+		}
+		if _, _, err := VerifyRangeProof(root, first, last, keys, vals, proof); err == nil {
+			t.Fatalf("Case %d(%d->%d) expected error", i, start, end-1)
+		}
+	}
+}
+
+// Tests that a complete leaf set can be verified without any edge proofs.
+func TestAllElementsProof(t *testing.T) {
+	trie, entries := randomSortedTrie(4096)
+	root, _ := trie.Commit(nil)
+
+	var keys, vals [][]byte
+	for _, entry := range entries {
+		keys = append(keys, entry.k)
+		vals = append(vals, entry.v)
+	}
+	nodes, more, err := VerifyRangeProof(root, nil, nil, keys, vals, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if more {
+		t.Fatalf("Expected no more elements")
+	}
+	if have, want := nodes.Len(), len(trie.db.Nodes()); have != want {
+		t.Fatalf("Node count mismatch: have %d, want %d", have, want)
+	}
+	// Dropping a single element must fail the verification
+	if _, _, err := VerifyRangeProof(root, nil, nil, keys[1:], vals[1:], nil); err == nil {
+		t.Fatalf("Expected error for incomplete leaf set")
+	}
+}
+
+// Tests that a trie assembled from consecutive proven ranges can be completed
+// by a trie sync that only needs to retrieve the range boundary nodes.
+func TestRangeProofHeal(t *testing.T) {
+	trie, entries := randomSortedTrie(4096)
+	root, _ := trie.Commit(nil)
+	trie.db.Commit(root, false)
+
+	var (
+		diskdb = ethdb.NewMemDatabase()
+		step   = 300
+	)
+	for start := 0; start < len(entries); start += step {
+		end := start + step
+		if end > len(entries) {
+			end = len(entries)
+		}
+		proof := ethdb.NewMemDatabase()
+		trie.Prove(entries[start].k, 0, proof)
+		trie.Prove(entries[end-1].k, 0, proof)
+
+		var keys, vals [][]byte
+		for i := start; i < end; i++ {
+			keys = append(keys, entries[i].k)
+			vals = append(vals, entries[i].v)
+		}
+		nodes, _, err := VerifyRangeProof(root, keys[0], keys[len(keys)-1], keys, vals, proof)
+		if err != nil {
+			t.Fatalf("Range %d->%d failed: %v", start, end-1, err)
+		}
+		for _, key := range nodes.Keys() {
+			blob, _ := nodes.Get(key)
+			diskdb.Put(key, blob)
+		}
+	}
+	sched := NewSync(root, diskdb, nil)
+	healed := 0
+	for queue := sched.Missing(100); len(queue) > 0; queue = sched.Missing(100) {
+		results := make([]SyncResult, len(queue))
+		for i, hash := range queue {
+			data, err := trie.db.Node(hash)
+			if err != nil {
+				t.Fatalf("Failed to retrieve node data for %x: %v", hash, err)
+			}
+			results[i] = SyncResult{hash, data}
+		}
+		if _, index, err := sched.Process(results); err != nil {
+			t.Fatalf("Failed to process result #%d: %v", index, err)
+		}
+		if _, err := sched.Commit(diskdb); err != nil {
+			t.Fatalf("Failed to commit data: %v", err)
+		}
+		healed += len(queue)
+	}
+	if healed >= diskdb.Len()/2 {
+		t.Fatalf("Healing retrieved too many nodes: %d of %d", healed, diskdb.Len())
+	}
+	healedTrie, err := New(root, NewDatabase(diskdb))
+	if err != nil {
+		t.Fatalf("Failed to open healed trie: %v", err)
+	}
+	for _, entry := range entries {
+		if have := healedTrie.Get(entry.k); !bytes.Equal(have, entry.v) {
+			t.Fatalf("Healed value mismatch for %x: have %x, want %x", entry.k, have, entry.v)
+		}
+	}
+}
+
+// randomSortedTrie creates a trie of n random 32 byte keys, returning the trie
+// along with its leaves sorted by key.
+func randomSortedTrie(n int) (*Trie, entrySlice) {
+	trie, _ := New(common.Hash{}, NewDatabase(ethdb.NewMemDatabase()))
+	var entries entrySlice
+	for i := 0; i < n; i++ {
+		value := &kv{randBytes(32), randBytes(20), false}
+		trie.Update(value.k, value.v)
+		entries = append(entries, value)
+	}
+	sort.Sort(entries)
+	return trie, entries
+}
+
+func increaseKey(key []byte) []byte {
+	for i := len(key) - 1; i >= 0; i-- {
+		key[i]++
+		if key[i] != 0x0 {
+			break
+		}
+	}
+	return key
+}
+
+func decreaseKey(key []byte) []byte {
+	for i := len(key) - 1; i >= 0; i-- {
+		key[i]--
+		if key[i] != 0xff {
+			break
+		}
+	}
+	return key
+}
+
 // mutateByte changes one byte in b.
 func mutateByte(b []byte) {
 	for r := mrand.Intn(len(b)); ; {